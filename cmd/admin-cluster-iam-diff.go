@@ -0,0 +1,384 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/klauspost/compress/zip"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var adminClusterIAMDiffCmd = cli.Command{
+	Name:            "diff",
+	Usage:           "show the differences between two IAM exports",
+	Action:          mainClusterIAMDiff,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} SOURCE TARGET
+
+  SOURCE and TARGET are each a zip file of 'mc admin cluster iam export' or the
+  alias of a cluster, whose IAM info is exported on the fly.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Policies, users, groups, service accounts and policy mappings are compared by
+  name. Secret keys are masked and update times are ignored.
+
+EXAMPLES:
+  1. Show what changed on a cluster since an export.
+     {{.Prompt}} {{.HelpName}} /tmp/myminio-iam-info.zip myminio
+
+  2. Compare the IAM info of two clusters.
+     {{.Prompt}} {{.HelpName}} staging production
+`,
+}
+
+// iamAssetsDir is the directory of the files in an IAM export.
+const iamAssetsDir = "iam-assets"
+
+// iamExportFiles are the files of an IAM export with the entity they
+// hold and the kind of entities selected by 'import --only'.
+var iamExportFiles = []struct {
+	file, entity, kind string
+}{
+	{"policies.json", "policy", "policies"},
+	{"users.json", "user", "users"},
+	{"user_mappings.json", "user policy mapping", "users"},
+	{"stsuser_mappings.json", "STS user policy mapping", "users"},
+	{"groups.json", "group", "groups"},
+	{"group_mappings.json", "group policy mapping", "groups"},
+	{"svcaccts.json", "service account", "svcaccts"},
+}
+
+// iamExport is the content of an IAM export, the entities of each file
+// by name.
+type iamExport map[string]map[string]json.RawMessage
+
+// readIAMExport parses a zipped IAM export.
+func readIAMExport(data []byte) (iamExport, *probe.Error) {
+	zr, e := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	known := make(map[string]bool, len(iamExportFiles))
+	for _, f := range iamExportFiles {
+		known[f.file] = true
+	}
+	x := make(iamExport)
+	for _, f := range zr.File {
+		dir, name := path.Split(f.Name)
+		if dir != iamAssetsDir+"/" || !known[name] {
+			continue
+		}
+		r, e := f.Open()
+		if e != nil {
+			return nil, probe.NewError(e).Trace(f.Name)
+		}
+		entities := make(map[string]json.RawMessage)
+		e = json.NewDecoder(r).Decode(&entities)
+		r.Close()
+		if e != nil {
+			return nil, probe.NewError(e).Trace(f.Name)
+		}
+		x[name] = entities
+	}
+	return x, nil
+}
+
+// loadIAMExport reads an IAM export from a zip file or from the cluster
+// of an alias.
+func loadIAMExport(arg string) (iamExport, *probe.Error) {
+	if st, e := os.Stat(arg); e == nil && st.Mode().IsRegular() {
+		data, e := os.ReadFile(arg)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(arg)
+		}
+		x, err := readIAMExport(data)
+		return x, err.Trace(arg)
+	}
+	client, err := newAdminClient(arg)
+	if err != nil {
+		return nil, err.Trace(arg)
+	}
+	r, e := client.ExportIAM(globalContext)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(arg)
+	}
+	defer r.Close()
+	data, e := io.ReadAll(r)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(arg)
+	}
+	x, err := readIAMExport(data)
+	return x, err.Trace(arg)
+}
+
+// filter returns the entities of the given kinds and names, all of
+// them if kinds or names are empty.
+func (x iamExport) filter(kinds, names map[string]bool) iamExport {
+	filtered := make(iamExport)
+	for _, f := range iamExportFiles {
+		if x[f.file] == nil || (len(kinds) > 0 && !kinds[f.kind]) {
+			continue
+		}
+		entities := make(map[string]json.RawMessage)
+		for name, entity := range x[f.file] {
+			if len(names) == 0 || names[name] {
+				entities[name] = entity
+			}
+		}
+		if len(entities) > 0 {
+			filtered[f.file] = entities
+		}
+	}
+	return filtered
+}
+
+// zip returns the IAM export zipped for import.
+func (x iamExport) zip() ([]byte, *probe.Error) {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range iamExportFiles {
+		if x[f.file] == nil {
+			continue
+		}
+		w, e := zw.Create(path.Join(iamAssetsDir, f.file))
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		data, e := json.Marshal(x[f.file])
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		if _, e = w.Write(data); e != nil {
+			return nil, probe.NewError(e)
+		}
+	}
+	if e := zw.Close(); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return b.Bytes(), nil
+}
+
+// iamFieldDiff is the difference of a field of an IAM entity.
+type iamFieldDiff struct {
+	Field  string `json:"field"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// iamEntityDiff is the difference of an IAM entity between two exports.
+type iamEntityDiff struct {
+	Entity string         `json:"entity"`
+	Name   string         `json:"name"`
+	Change string         `json:"change"`
+	Fields []iamFieldDiff `json:"fields,omitempty"`
+}
+
+// iamValue formats a field value of an IAM entity, masking secrets.
+func iamValue(field string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if strings.Contains(strings.ToLower(field), "secret") {
+		return "*****"
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// diffIAMEntity returns the fields which differ between two entities,
+// ignoring update times.
+func diffIAMEntity(source, target json.RawMessage) []iamFieldDiff {
+	var src, dst interface{}
+	json.Unmarshal(source, &src)
+	json.Unmarshal(target, &dst)
+	srcFields, srcOK := src.(map[string]interface{})
+	dstFields, dstOK := dst.(map[string]interface{})
+	if !srcOK || !dstOK {
+		if reflect.DeepEqual(src, dst) {
+			return nil
+		}
+		return []iamFieldDiff{{Source: iamValue("", src), Target: iamValue("", dst)}}
+	}
+
+	fields := make([]string, 0, len(srcFields)+len(dstFields))
+	for field := range srcFields {
+		fields = append(fields, field)
+	}
+	for field := range dstFields {
+		if _, ok := srcFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var diffs []iamFieldDiff
+	for _, field := range fields {
+		if field == "updatedAt" || reflect.DeepEqual(srcFields[field], dstFields[field]) {
+			continue
+		}
+		diffs = append(diffs, iamFieldDiff{
+			Field:  field,
+			Source: iamValue(field, srcFields[field]),
+			Target: iamValue(field, dstFields[field]),
+		})
+	}
+	return diffs
+}
+
+// diffIAMExports returns the differences between the source and the
+// target exports by kind of entity and name.
+func diffIAMExports(source, target iamExport) []iamEntityDiff {
+	diffs := []iamEntityDiff{}
+	for _, f := range iamExportFiles {
+		src, dst := source[f.file], target[f.file]
+		names := make([]string, 0, len(src)+len(dst))
+		for name := range src {
+			names = append(names, name)
+		}
+		for name := range dst {
+			if _, ok := src[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			d := iamEntityDiff{Entity: f.entity, Name: name}
+			switch {
+			case src[name] == nil:
+				d.Change = "added"
+			case dst[name] == nil:
+				d.Change = "removed"
+			default:
+				if d.Fields = diffIAMEntity(src[name], dst[name]); len(d.Fields) == 0 {
+					continue
+				}
+				d.Change = "changed"
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// iamDiffMessage lists the differences between two IAM exports, or
+// what an import would change with 'import --dry-run'.
+type iamDiffMessage struct {
+	Status      string          `json:"status"`
+	Source      string          `json:"source"`
+	Target      string          `json:"target"`
+	DryRun      bool            `json:"dryRun,omitempty"`
+	Differences []iamEntityDiff `json:"differences"`
+}
+
+func (m iamDiffMessage) String() string {
+	if len(m.Differences) == 0 {
+		if m.DryRun {
+			return console.Colorize("IAMDiffSame", "Nothing to import from `"+m.Source+"` into `"+m.Target+"`.")
+		}
+		return console.Colorize("IAMDiffSame", "No differences found between `"+m.Source+"` and `"+m.Target+"`.")
+	}
+	var b strings.Builder
+	if m.DryRun {
+		fmt.Fprintln(&b, "Importing `"+m.Source+"` into `"+m.Target+"` would:")
+	}
+	for _, d := range m.Differences {
+		name := d.Entity + " " + d.Name
+		switch d.Change {
+		case "added":
+			if m.DryRun {
+				name = "add " + name
+			}
+			fmt.Fprintln(&b, console.Colorize("IAMDiffAdded", "+ "+name))
+		case "removed":
+			fmt.Fprintln(&b, console.Colorize("IAMDiffRemoved", "- "+name))
+		default:
+			if m.DryRun {
+				name = "update " + name
+			}
+			fmt.Fprintln(&b, console.Colorize("IAMDiffChanged", "~ "+name))
+			for _, f := range d.Fields {
+				if f.Field != "" {
+					fmt.Fprintf(&b, "    %s: %s => %s\n", f.Field, f.Source, f.Target)
+				} else {
+					fmt.Fprintf(&b, "    %s => %s\n", f.Source, f.Target)
+				}
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m iamDiffMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func setIAMDiffColors() {
+	console.SetColor("IAMDiffSame", color.New(color.FgGreen))
+	console.SetColor("IAMDiffAdded", color.New(color.FgGreen))
+	console.SetColor("IAMDiffRemoved", color.New(color.FgRed))
+	console.SetColor("IAMDiffChanged", color.New(color.FgYellow))
+}
+
+// mainClusterIAMDiff - iam info diff command
+func mainClusterIAMDiff(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	setIAMDiffColors()
+
+	source, target := ctx.Args().Get(0), ctx.Args().Get(1)
+	srcIAM, err := loadIAMExport(source)
+	fatalIf(err, "Unable to get the IAM info of `"+source+"`.")
+	dstIAM, err := loadIAMExport(target)
+	fatalIf(err, "Unable to get the IAM info of `"+target+"`.")
+
+	printMsg(iamDiffMessage{
+		Source:      source,
+		Target:      target,
+		Differences: diffIAMExports(srcIAM, dstIAM),
+	})
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	json "github.com/minio/colorjson"
+)
+
+func TestIAMExportDiff(t *testing.T) {
+	source := iamExport{
+		"policies.json": {
+			"finance-ro": json.RawMessage(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"]}]}`),
+			"old":        json.RawMessage(`{"Version":"2012-10-17"}`),
+		},
+		"users.json": {
+			"alice": json.RawMessage(`{"secretKey":"a","policy":"readwrite","status":"enabled"}`),
+		},
+		"group_mappings.json": {
+			"finance": json.RawMessage(`{"policy":"finance-ro","updatedAt":"2024-01-01T00:00:00Z"}`),
+		},
+	}
+	target := iamExport{
+		"policies.json": {
+			"finance-ro": source["policies.json"]["finance-ro"],
+		},
+		"users.json": {
+			"alice": json.RawMessage(`{"secretKey":"b","policy":"readonly","status":"enabled"}`),
+			"bob":   json.RawMessage(`{"secretKey":"c","status":"enabled"}`),
+		},
+		"group_mappings.json": {
+			"finance": json.RawMessage(`{"policy":"finance-ro","updatedAt":"2024-06-01T00:00:00Z"}`),
+		},
+	}
+	want := []iamEntityDiff{
+		{Entity: "policy", Name: "old", Change: "removed"},
+		{Entity: "user", Name: "alice", Change: "changed", Fields: []iamFieldDiff{
+			{Field: "policy", Source: "readwrite", Target: "readonly"},
+			{Field: "secretKey", Source: "*****", Target: "*****"},
+		}},
+		{Entity: "user", Name: "bob", Change: "added"},
+	}
+	if got := diffIAMExports(source, target); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	selected := target.filter(map[string]bool{"users": true}, map[string]bool{"bob": true, "finance": true})
+	if len(selected) != 1 || len(selected["users.json"]) != 1 || selected["users.json"]["bob"] == nil {
+		t.Fatalf("unexpected selection %v", selected)
+	}
+	data, err := selected.zip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := readIAMExport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := diffIAMExports(selected, read); len(d) != 0 {
+		t.Errorf("zipped export differs: %+v", d)
+	}
+}
@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -33,13 +34,28 @@ import (
 	"github.com/minio/pkg/v3/console"
 )
 
+var iamImportFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "only",
+		Usage: "import only these kinds of entities, comma separated. Values: policies, users, groups, svcaccts",
+	},
+	cli.StringFlag{
+		Name:  "select",
+		Usage: "import only the entities of these names, comma separated",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show what would be imported without importing it",
+	},
+}
+
 var adminClusterIAMImportCmd = cli.Command{
 	Name:            "import",
 	Usage:           "imports IAM info from zipped file",
 	Action:          mainClusterIAMImport,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(iamImportFlags, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -54,6 +70,12 @@ EXAMPLES:
   1. Set IAM info from previously exported metadata zip file.
      {{.Prompt}} {{.HelpName}} myminio /tmp/myminio-iam-info.zip
 
+  2. Restore only the policy "finance-ro" from an export.
+     {{.Prompt}} {{.HelpName}} myminio /tmp/myminio-iam-info.zip --only policies --select finance-ro
+
+  3. Show which users and groups would be added or updated by an import.
+     {{.Prompt}} {{.HelpName}} myminio /tmp/myminio-iam-info.zip --only users,groups --dry-run
+
 `,
 }
 
@@ -178,6 +200,42 @@ func checkIAMImportSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 2 {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code
 	}
+	for kind := range splitIAMImportList(ctx.String("only")) {
+		switch kind {
+		case "policies", "users", "groups", "svcaccts":
+		default:
+			fatalIf(errInvalidArgument().Trace(kind), "Unknown kind of IAM entities `"+kind+"`. Values: policies, users, groups, svcaccts.")
+		}
+	}
+}
+
+// splitIAMImportList returns the set of comma separated values of an
+// import flag.
+func splitIAMImportList(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+// selectIAMImport returns the entities of the export selected by the
+// import flags, zipped for import.
+func selectIAMImport(ctx *cli.Context, file string) (iamExport, []byte) {
+	data, e := os.ReadFile(file)
+	fatalIf(probe.NewError(e).Trace(file), "Unable to get IAM info")
+	x, err := readIAMExport(data)
+	fatalIf(err.Trace(file), "Unable to read IAM info")
+
+	x = x.filter(splitIAMImportList(ctx.String("only")), splitIAMImportList(ctx.String("select")))
+	if len(x) == 0 {
+		fatalIf(errInvalidArgument().Trace(file), "No IAM entities selected for import.")
+	}
+	data, err = x.zip()
+	fatalIf(err.Trace(file), "Unable to prepare IAM info")
+	return x, data
 }
 
 // mainClusterIAMImport - iam info import command
@@ -207,6 +265,26 @@ func mainClusterIAMImport(ctx *cli.Context) error {
 
 	f, e = os.Open(args.Get(1))
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to get IAM info")
+	content := io.ReadSeeker(f)
+
+	if ctx.IsSet("only") || ctx.IsSet("select") || ctx.Bool("dry-run") {
+		selected, data := selectIAMImport(ctx, args.Get(1))
+		if ctx.Bool("dry-run") {
+			setIAMDiffColors()
+			live, err := loadIAMExport(aliasedURL)
+			fatalIf(err, "Unable to get the IAM info of `"+aliasedURL+"`.")
+			// An import adds and updates entities, it never removes them.
+			changes := []iamEntityDiff{}
+			for _, d := range diffIAMExports(live, selected) {
+				if d.Change != "removed" {
+					changes = append(changes, d)
+				}
+			}
+			printMsg(iamDiffMessage{Source: args.Get(1), Target: aliasedURL, DryRun: true, Differences: changes})
+			return nil
+		}
+		content = bytes.NewReader(data)
+	}
 
 	// Create a new MinIO Admin Client
 	client, err := newAdminClient(aliasedURL)
@@ -215,10 +293,10 @@ func mainClusterIAMImport(ctx *cli.Context) error {
 		return nil
 	}
 
-	iamr, e := client.ImportIAMV2(context.Background(), f)
+	iamr, e := client.ImportIAMV2(context.Background(), io.NopCloser(content))
 	if e != nil {
-		f.Seek(0, 0)
-		e = client.ImportIAM(context.Background(), f)
+		content.Seek(0, 0)
+		e = client.ImportIAM(context.Background(), io.NopCloser(content))
 		fatalIf(probe.NewError(e).Trace(aliasedURL), "Unable to import IAM info.")
 		if !globalJSON {
 			console.Infof("IAM info imported to %s from %s\n", aliasedURL, args.Get(1))
@@ -0,0 +1,280 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var adminConfigDiffCmd = cli.Command{
+	Name:         "diff",
+	Usage:        "show the differences between two server configurations",
+	Before:       setGlobalsFromContext,
+	Action:       mainAdminConfigDiff,
+	OnUsageError: onUsageError,
+	Flags:        globalFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} SOURCE TARGET
+
+  SOURCE and TARGET are each one of:
+    ALIAS       the current configuration of a cluster
+    ALIAS@[ID]  a local snapshot of the configuration of a cluster, the latest without ID
+    FILE        a configuration saved by 'mc admin config export'
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Keys are compared by subsystem with their effective values, including those set
+  by environment variables on the server. Values of secret keys, such as passwords,
+  tokens and connection strings, and credentials in URLs are masked.
+
+EXAMPLES:
+  1. Show how the configuration of staging differs from production.
+     {{.Prompt}} {{.HelpName}} production staging
+
+  2. Compare the configuration of a cluster with an exported one.
+     {{.Prompt}} {{.HelpName}} myminio config.txt
+
+  3. Show what changed on a cluster since its latest snapshot.
+     {{.Prompt}} {{.HelpName}} myminio@ myminio
+`,
+}
+
+// configKeyDiff is the difference of a configuration key between two
+// configurations.
+type configKeyDiff struct {
+	SubSystem string `json:"subSystem"`
+	Key       string `json:"key"`
+	Change    string `json:"change"`
+	Source    string `json:"source,omitempty"`
+	Target    string `json:"target,omitempty"`
+}
+
+// configDiffMessage lists the differences of two configurations.
+type configDiffMessage struct {
+	Status      string          `json:"status"`
+	Source      string          `json:"source"`
+	Target      string          `json:"target"`
+	Differences []configKeyDiff `json:"differences"`
+}
+
+func (m configDiffMessage) String() string {
+	if len(m.Differences) == 0 {
+		return console.Colorize("ConfigDiffSame", "No differences found between `"+m.Source+"` and `"+m.Target+"`.")
+	}
+	var b strings.Builder
+	var subSys string
+	for _, d := range m.Differences {
+		if d.SubSystem != subSys {
+			if subSys != "" {
+				b.WriteString("\n")
+			}
+			subSys = d.SubSystem
+			fmt.Fprintln(&b, console.Colorize("ConfigDiffSubSys", subSys))
+		}
+		switch d.Change {
+		case "added":
+			fmt.Fprintln(&b, console.Colorize("ConfigDiffAdded", "  + "+d.Key+"="+d.Target))
+		case "removed":
+			fmt.Fprintln(&b, console.Colorize("ConfigDiffRemoved", "  - "+d.Key+"="+d.Source))
+		default:
+			fmt.Fprintln(&b, console.Colorize("ConfigDiffChanged", "  ~ "+d.Key+": "+d.Source+" => "+d.Target))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m configDiffMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// isSecretConfigKey tells if the value of a configuration key must
+// not be shown. The server does not tell which keys are sensitive, so
+// they are recognized by name, including the connection strings of
+// the notification targets which embed a password.
+func isSecretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range []string{"secret", "password", "token", "api_key", "private", "connection_string", "dsn_string"} {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// configURLUserInfo matches the user information of the URLs in a
+// configuration value, e.g. the credentials of an AMQP or Redis URL.
+var configURLUserInfo = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s,]+@`)
+
+// maskConfigValue hides the secrets of a configuration value.
+func maskConfigValue(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	if isSecretConfigKey(key) {
+		return "*****"
+	}
+	return configURLUserInfo.ReplaceAllString(value, "${1}*****@")
+}
+
+// configValues returns the effective values of the keys of each
+// subsystem and target of a configuration.
+func configValues(cfg []madmin.SubsysConfig) map[string]map[string]string {
+	values := make(map[string]map[string]string, len(cfg))
+	for _, sc := range cfg {
+		name := sc.SubSystem
+		if sc.Target != "" {
+			name += ":" + sc.Target
+		}
+		if values[name] == nil {
+			values[name] = make(map[string]string, len(sc.KV))
+		}
+		for _, kv := range sc.KV {
+			if kv.EnvOverride != nil {
+				values[name][kv.Key] = kv.EnvOverride.Value
+			} else {
+				values[name][kv.Key] = kv.Value
+			}
+		}
+	}
+	return values
+}
+
+// diffServerConfigs returns the differences between the source and the
+// target configurations sorted by subsystem and key, secrets masked.
+func diffServerConfigs(source, target []madmin.SubsysConfig) []configKeyDiff {
+	srcValues, dstValues := configValues(source), configValues(target)
+	subSystems := make(map[string]bool)
+	for name := range srcValues {
+		subSystems[name] = true
+	}
+	for name := range dstValues {
+		subSystems[name] = true
+	}
+	names := make([]string, 0, len(subSystems))
+	for name := range subSystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diffs := []configKeyDiff{}
+	for _, name := range names {
+		src, dst := srcValues[name], dstValues[name]
+		keys := make([]string, 0, len(src)+len(dst))
+		for key := range src {
+			keys = append(keys, key)
+		}
+		for key := range dst {
+			if _, ok := src[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			srcValue, inSrc := src[key]
+			dstValue, inDst := dst[key]
+			d := configKeyDiff{SubSystem: name, Key: key, Source: srcValue, Target: dstValue}
+			switch {
+			case !inSrc:
+				d.Change = "added"
+			case !inDst:
+				d.Change = "removed"
+			case srcValue != dstValue:
+				d.Change = "changed"
+			default:
+				continue
+			}
+			d.Source, d.Target = maskConfigValue(key, d.Source), maskConfigValue(key, d.Target)
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// loadServerConfig returns the configuration of a cluster, of one of
+// its local snapshots given as ALIAS@[ID] or of an exported file.
+func loadServerConfig(arg string) ([]madmin.SubsysConfig, *probe.Error) {
+	var buf []byte
+	// An exported file is looked up first, its path may contain '@'.
+	if st, e := os.Stat(arg); e == nil && st.Mode().IsRegular() {
+		if buf, e = os.ReadFile(arg); e != nil {
+			return nil, probe.NewError(e).Trace(arg)
+		}
+	} else if alias, id, ok := strings.Cut(arg, "@"); ok {
+		data, _, err := readConfigSnapshot(cleanAlias(alias), id)
+		if err != nil {
+			return nil, err.Trace(arg)
+		}
+		buf = data
+	} else {
+		client, err := newAdminClient(arg)
+		if err != nil {
+			return nil, err.Trace(arg)
+		}
+		if buf, e = client.GetConfig(globalContext); e != nil {
+			return nil, probe.NewError(e).Trace(arg)
+		}
+	}
+	cfg, e := madmin.ParseServerConfigOutput(string(buf))
+	if e != nil {
+		return nil, probe.NewError(e).Trace(arg)
+	}
+	return cfg, nil
+}
+
+func mainAdminConfigDiff(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("ConfigDiffSame", color.New(color.FgGreen))
+	console.SetColor("ConfigDiffSubSys", color.New(color.FgCyan, color.Bold))
+	console.SetColor("ConfigDiffAdded", color.New(color.FgGreen))
+	console.SetColor("ConfigDiffRemoved", color.New(color.FgRed))
+	console.SetColor("ConfigDiffChanged", color.New(color.FgYellow))
+
+	source, target := ctx.Args().Get(0), ctx.Args().Get(1)
+	srcCfg, err := loadServerConfig(source)
+	fatalIf(err, "Unable to get the configuration of `"+source+"`.")
+	dstCfg, err := loadServerConfig(target)
+	fatalIf(err, "Unable to get the configuration of `"+target+"`.")
+
+	printMsg(configDiffMessage{
+		Source:      source,
+		Target:      target,
+		Differences: diffServerConfigs(srcCfg, dstCfg),
+	})
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/pkg/v3/console"
+)
+
+var adminConfigSnapshotLogCmd = cli.Command{
+	Name:         "log",
+	Usage:        "list the snapshots of the configuration of a cluster",
+	Before:       setGlobalsFromContext,
+	Action:       mainAdminConfigSnapshotLog,
+	OnUsageError: onUsageError,
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "count, n",
+			Usage: "list only the last 'n' snapshots",
+		},
+	}, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Snapshots are listed latest first with the number of keys changed since the
+  previous one. Use 'mc admin config diff ALIAS@ID1 ALIAS@ID2' to see the changes.
+
+EXAMPLES:
+  1. List the last 5 snapshots of "myminio".
+     {{.Prompt}} {{.HelpName}} myminio -n 5
+`,
+}
+
+// summarizeConfigDiff counts the differences by kind of change.
+func summarizeConfigDiff(diffs []configKeyDiff) string {
+	counts := make(map[string]int)
+	for _, d := range diffs {
+		counts[d.Change]++
+	}
+	var parts []string
+	for _, change := range []string{"added", "removed", "changed"} {
+		if counts[change] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[change], change))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func mainAdminConfigSnapshotLog(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("ConfigSnapshotID", color.New(color.FgYellow, color.Bold))
+	console.SetColor("ConfigSnapshotChanges", color.New(color.FgCyan))
+
+	alias := cleanAlias(ctx.Args().Get(0))
+	snapshots, err := loadConfigSnapshots(alias)
+	fatalIf(err, "Unable to list the snapshots of `"+alias+"`.")
+
+	first := 0
+	if n := ctx.Int("count"); n > 0 && n < len(snapshots) {
+		first = len(snapshots) - n
+	}
+	var previous []madmin.SubsysConfig
+	msgs := make([]configSnapshotMessage, 0, len(snapshots)-first)
+	for i, snapshot := range snapshots {
+		// Changes of the oldest listed snapshot need the one before.
+		if i < first-1 {
+			continue
+		}
+		cfg, err := loadServerConfig(alias + "@" + snapshot.ID)
+		fatalIf(err, "Unable to read the snapshot "+snapshot.ID+" of `"+alias+"`.")
+		if i >= first {
+			msg := configSnapshotMessage{op: "log", Alias: alias, ID: snapshot.ID, Time: snapshot.Time, Message: snapshot.Message, Saved: true}
+			if i > 0 {
+				msg.Changes = summarizeConfigDiff(diffServerConfigs(previous, cfg))
+			}
+			msgs = append(msgs, msg)
+		}
+		previous = cfg
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		printMsg(msgs[i])
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var adminConfigSnapshotSaveCmd = cli.Command{
+	Name:         "save",
+	Usage:        "save a snapshot of the configuration of a cluster",
+	Before:       setGlobalsFromContext,
+	Action:       mainAdminConfigSnapshotSave,
+	OnUsageError: onUsageError,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "message, m",
+			Usage: "describe the snapshot",
+		},
+	}, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Snapshots are kept in the mc configuration directory. A configuration identical
+  to the latest snapshot is not saved again, so this can run periodically.
+
+EXAMPLES:
+  1. Save a snapshot of the configuration of "myminio".
+     {{.Prompt}} {{.HelpName}} myminio --message "before enabling OpenID"
+`,
+}
+
+// configSnapshotMessage is a snapshot of the configuration of an alias.
+type configSnapshotMessage struct {
+	op      string
+	Status  string    `json:"status"`
+	Alias   string    `json:"alias"`
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+	Saved   bool      `json:"saved"`
+	Changes string    `json:"changes,omitempty"`
+}
+
+func (m configSnapshotMessage) String() string {
+	if m.op == "log" {
+		s := console.Colorize("ConfigSnapshotID", m.ID) + "  " + m.Time.Local().Format(printDate)
+		if m.Changes != "" {
+			s += "  " + console.Colorize("ConfigSnapshotChanges", "("+m.Changes+")")
+		}
+		if m.Message != "" {
+			s += "\n    " + m.Message
+		}
+		return s
+	}
+	if !m.Saved {
+		return console.Colorize("ConfigSnapshotMessage", "Configuration of `"+m.Alias+"` is unchanged since snapshot "+m.ID+".")
+	}
+	return console.Colorize("ConfigSnapshotMessage", "Saved snapshot "+m.ID+" of the configuration of `"+m.Alias+"`.")
+}
+
+func (m configSnapshotMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func mainAdminConfigSnapshotSave(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("ConfigSnapshotMessage", color.New(color.FgGreen))
+
+	aliasedURL := ctx.Args().Get(0)
+	alias, _ := url2Alias(aliasedURL)
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err, "Unable to initialize admin connection.")
+
+	buf, e := client.GetConfig(globalContext)
+	fatalIf(probe.NewError(e), "Unable to get server config")
+
+	snapshot, saved, err := saveConfigSnapshot(alias, buf, ctx.String("message"))
+	fatalIf(err, "Unable to save the snapshot of `"+alias+"`.")
+
+	printMsg(configSnapshotMessage{
+		op:      "save",
+		Alias:   alias,
+		ID:      snapshot.ID,
+		Time:    snapshot.Time,
+		Message: snapshot.Message,
+		Saved:   saved,
+	})
+	return nil
+}
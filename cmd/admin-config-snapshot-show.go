@@ -0,0 +1,66 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+)
+
+var adminConfigSnapshotShowCmd = cli.Command{
+	Name:         "show",
+	Usage:        "show a snapshot of the configuration of a cluster",
+	Before:       setGlobalsFromContext,
+	Action:       mainAdminConfigSnapshotShow,
+	OnUsageError: onUsageError,
+	Flags:        globalFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS [ID]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  The snapshot is printed like 'mc admin config export' does, so it can be given to
+  'mc admin config import' to restore it. Without ID the latest snapshot is shown.
+  ID may be shortened as long as it is unique.
+
+EXAMPLES:
+  1. Show the latest snapshot of "myminio".
+     {{.Prompt}} {{.HelpName}} myminio
+
+  2. Restore the snapshot 3f2a9c of "myminio".
+     {{.Prompt}} {{.HelpName}} myminio 3f2a9c | mc admin config import myminio
+`,
+}
+
+func mainAdminConfigSnapshotShow(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	alias := cleanAlias(ctx.Args().Get(0))
+	id := ctx.Args().Get(1)
+
+	data, _, err := readConfigSnapshot(alias, id)
+	fatalIf(err, "Unable to read the snapshot of `"+alias+"`.")
+
+	printMsg(configExportMessage{Value: data})
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var adminConfigSnapshotSubcommands = []cli.Command{
+	adminConfigSnapshotSaveCmd,
+	adminConfigSnapshotLogCmd,
+	adminConfigSnapshotShowCmd,
+}
+
+var adminConfigSnapshotCmd = cli.Command{
+	Name:            "snapshot",
+	Usage:           "keep local versioned snapshots of server configurations",
+	Action:          mainAdminConfigSnapshot,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	Subcommands:     adminConfigSnapshotSubcommands,
+	HideHelpCommand: true,
+}
+
+func mainAdminConfigSnapshot(ctx *cli.Context) error {
+	commandNotFound(ctx, adminConfigSnapshotSubcommands)
+	return nil
+}
+
+// configSnapshot is a configuration export saved locally. Exports are
+// stored by content, the ID is a prefix of their SHA-256 checksum.
+type configSnapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+}
+
+// configSnapshotDir is where the snapshots of an alias are kept.
+func configSnapshotDir(alias string) string {
+	return filepath.Join(mustGetMcConfigDir(), "config-snapshots", alias)
+}
+
+// loadConfigSnapshots returns the snapshots of an alias, oldest first.
+func loadConfigSnapshots(alias string) ([]configSnapshot, *probe.Error) {
+	data, e := os.ReadFile(filepath.Join(configSnapshotDir(alias), "index.json"))
+	if errors.Is(e, os.ErrNotExist) {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(alias)
+	}
+	var snapshots []configSnapshot
+	if e = json.Unmarshal(data, &snapshots); e != nil {
+		return nil, probe.NewError(e).Trace(alias)
+	}
+	return snapshots, nil
+}
+
+// saveConfigSnapshot stores an export of the configuration of an alias
+// unless it is the same as the latest one, which is returned instead.
+func saveConfigSnapshot(alias string, data []byte, message string) (configSnapshot, bool, *probe.Error) {
+	snapshots, err := loadConfigSnapshots(alias)
+	if err != nil {
+		return configSnapshot{}, false, err
+	}
+	sum := sha256.Sum256(data)
+	snapshot := configSnapshot{
+		ID:      hex.EncodeToString(sum[:])[:12],
+		Time:    time.Now().UTC(),
+		Message: message,
+	}
+	if n := len(snapshots); n > 0 && snapshots[n-1].ID == snapshot.ID {
+		return snapshots[n-1], false, nil
+	}
+
+	// Exports contain secrets, only the user may read them.
+	dir := configSnapshotDir(alias)
+	if e := os.MkdirAll(dir, 0o700); e != nil {
+		return snapshot, false, probe.NewError(e).Trace(dir)
+	}
+	if e := os.WriteFile(filepath.Join(dir, snapshot.ID+".txt"), data, 0o600); e != nil {
+		return snapshot, false, probe.NewError(e).Trace(dir)
+	}
+	index, e := json.MarshalIndent(append(snapshots, snapshot), "", "\t")
+	if e != nil {
+		return snapshot, false, probe.NewError(e)
+	}
+	if e = os.WriteFile(filepath.Join(dir, "index.json"), index, 0o600); e != nil {
+		return snapshot, false, probe.NewError(e).Trace(dir)
+	}
+	return snapshot, true, nil
+}
+
+// readConfigSnapshot returns the export of the snapshot of an alias
+// whose ID starts with id, the latest one if id is empty.
+func readConfigSnapshot(alias, id string) ([]byte, configSnapshot, *probe.Error) {
+	snapshots, err := loadConfigSnapshots(alias)
+	if err != nil {
+		return nil, configSnapshot{}, err
+	}
+	if len(snapshots) == 0 {
+		return nil, configSnapshot{}, probe.NewError(fmt.Errorf("no snapshots of %s found", alias))
+	}
+
+	snapshot := snapshots[len(snapshots)-1]
+	if id != "" {
+		var found []configSnapshot
+		for _, s := range snapshots {
+			if strings.HasPrefix(s.ID, id) && (len(found) == 0 || found[len(found)-1].ID != s.ID) {
+				found = append(found, s)
+			}
+		}
+		switch len(found) {
+		case 0:
+			return nil, configSnapshot{}, probe.NewError(fmt.Errorf("no snapshot %s of %s found", id, alias))
+		case 1:
+			snapshot = found[0]
+		default:
+			return nil, configSnapshot{}, probe.NewError(fmt.Errorf("snapshot ID %s of %s is ambiguous", id, alias))
+		}
+	}
+	data, e := os.ReadFile(filepath.Join(configSnapshotDir(alias), snapshot.ID+".txt"))
+	if e != nil {
+		return nil, snapshot, probe.NewError(e).Trace(alias, snapshot.ID)
+	}
+	return data, snapshot, nil
+}
@@ -27,6 +27,8 @@ var adminConfigSubcommands = []cli.Command{
 	adminConfigRestoreCmd,
 	adminConfigExportCmd,
 	adminConfigImportCmd,
+	adminConfigDiffCmd,
+	adminConfigSnapshotCmd,
 }
 
 var adminConfigCmd = cli.Command{
@@ -33,7 +33,7 @@ var adminDecommissionStartCmd = cli.Command{
 	Action:       mainAdminDecommissionStart,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        globalFlags,
+	Flags:        append(poolPlanFlags, globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -46,6 +46,9 @@ FLAGS:
 EXAMPLES:
   1. Start decommissioning a pool for removal.
      {{.Prompt}} {{.HelpName}} myminio/ http://server{5...8}/disk{1...4}
+
+  2. Estimate the data to move and the final usage of the remaining pools, without starting.
+     {{.Prompt}} {{.HelpName}} myminio/ http://server{5...8}/disk{1...4} --plan --throughput 2GiB
 `,
 }
 
@@ -91,6 +94,26 @@ func mainAdminDecommissionStart(ctx *cli.Context) error {
 	client, err := newAdminClient(aliasedURL)
 	fatalIf(err, "Unable to initialize admin connection.")
 
+	if ctx.Bool("plan") {
+		pools, err := fetchPoolPlanPools(client)
+		fatalIf(err.Trace(aliasedURL), "Unable to get the usage of the pools.")
+		index := -1
+		for _, pool := range pools {
+			if pool.CmdLine == args.Get(1) {
+				index = pool.Index
+			}
+		}
+		if index < 0 {
+			fatalIf(errInvalidArgument().Trace(args...), "No such pool `"+args.Get(1)+"` found.")
+		}
+		plan := planDecommission(pools, index, poolPlanThroughput(ctx))
+		printMsg(plan)
+		if !plan.Feasible {
+			return exitStatus(globalErrorExitStatus)
+		}
+		return nil
+	}
+
 	e := client.DecommissionPool(globalContext, args.Get(1))
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to start decommission on the specified pool")
 
@@ -52,6 +52,7 @@ var adminCmdSubcommands = []cli.Command{
 	adminScannerCmd,
 	adminTopCmd,
 	adminTraceCmd,
+	adminMonitorCmd,
 	adminConsoleCmd,
 	adminClusterCmd,
 	adminRebalanceCmd,
@@ -0,0 +1,185 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// monitorMetrics are the metrics rules can test, described in the
+// help of the command.
+var monitorMetrics = map[string]bool{
+	"unreachable":         true,
+	"servers-offline":     true,
+	"drives-offline":      true,
+	"drives-healing":      true,
+	"replication-backlog": true,
+	"replication-failed":  true,
+	"scanner-lag":         true,
+	"decom-active":        true,
+	"decom-failed":        true,
+	"quota-used":          true,
+}
+
+// monitorDefaultRules are evaluated when no rule is given.
+var monitorDefaultRules = []string{
+	"unreachable",
+	"servers-offline>0",
+	"drives-offline>0",
+	"drives-healing>0",
+	"decom-failed>0",
+	"quota-used>90",
+}
+
+// monitorRule raises an alert when a metric compares to a threshold.
+type monitorRule struct {
+	Metric    string
+	Op        string
+	Threshold float64
+}
+
+func (r monitorRule) String() string {
+	return r.Metric + r.Op + strconv.FormatFloat(r.Threshold, 'f', -1, 64)
+}
+
+// parseMonitorRule parses METRIC[OP THRESHOLD], a metric alone is
+// the rule METRIC>0.
+func parseMonitorRule(s string) (monitorRule, *probe.Error) {
+	s = strings.Join(strings.Fields(s), "")
+	rule := monitorRule{Metric: s, Op: ">"}
+	if i := strings.IndexAny(s, "<>=!"); i >= 0 {
+		rule.Metric, rule.Op = s[:i], s[i:i+1]
+		if i+1 < len(s) && s[i+1] == '=' {
+			rule.Op = s[i : i+2]
+		}
+		threshold, e := strconv.ParseFloat(s[i+len(rule.Op):], 64)
+		if e != nil {
+			return rule, probe.NewError(fmt.Errorf("invalid threshold in rule %s", s))
+		}
+		rule.Threshold = threshold
+	}
+	switch rule.Op {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return rule, probe.NewError(fmt.Errorf("invalid operator %s in rule %s", rule.Op, s))
+	}
+	if !monitorMetrics[rule.Metric] {
+		return rule, probe.NewError(fmt.Errorf("unknown metric %s in rule %s", rule.Metric, s))
+	}
+	return rule, nil
+}
+
+func (r monitorRule) match(v float64) bool {
+	switch r.Op {
+	case ">":
+		return v > r.Threshold
+	case ">=":
+		return v >= r.Threshold
+	case "<":
+		return v < r.Threshold
+	case "<=":
+		return v <= r.Threshold
+	case "==":
+		return v == r.Threshold
+	default:
+		return v != r.Threshold
+	}
+}
+
+// monitorSample is the value of a metric, of a bucket for bucket
+// metrics.
+type monitorSample struct {
+	Metric  string
+	Subject string
+	Value   float64
+}
+
+// monitorAlert is a rule matching the value of a metric.
+type monitorAlert struct {
+	Rule     string
+	Subject  string
+	Value    float64
+	Since    time.Time
+	Resolved bool
+	notified time.Time
+}
+
+// monitorAlerts tracks the alerts across evaluations to notify only
+// changes: new alerts, resolved alerts and, every repeat period,
+// alerts which are still firing.
+type monitorAlerts struct {
+	repeat time.Duration
+	active map[string]*monitorAlert
+}
+
+func newMonitorAlerts(repeat time.Duration) *monitorAlerts {
+	return &monitorAlerts{repeat: repeat, active: make(map[string]*monitorAlert)}
+}
+
+// evaluate applies the rules to the samples and returns the alerts to
+// notify. Alerts on a metric with no samples, which could not be
+// collected, are left unchanged.
+func (a *monitorAlerts) evaluate(rules []monitorRule, samples []monitorSample, now time.Time) (notify []monitorAlert) {
+	sampled := make(map[string]bool)
+	for _, s := range samples {
+		sampled[s.Metric] = true
+	}
+	firing := make(map[string]bool)
+	for _, rule := range rules {
+		for _, s := range samples {
+			if s.Metric != rule.Metric || !rule.match(s.Value) {
+				continue
+			}
+			key := rule.String() + "\x00" + s.Subject
+			firing[key] = true
+			alert, ok := a.active[key]
+			if !ok {
+				alert = &monitorAlert{Rule: rule.String(), Subject: s.Subject, Since: now}
+				a.active[key] = alert
+			}
+			alert.Value = s.Value
+			if !ok || (a.repeat > 0 && now.Sub(alert.notified) >= a.repeat) {
+				alert.notified = now
+				notify = append(notify, *alert)
+			}
+		}
+	}
+
+	var keys []string
+	for key := range a.active {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		alert := a.active[key]
+		rule, _ := parseMonitorRule(alert.Rule)
+		if firing[key] || !sampled[rule.Metric] {
+			continue
+		}
+		delete(a.active, key)
+		alert.Resolved = true
+		notify = append(notify, *alert)
+	}
+	return notify
+}
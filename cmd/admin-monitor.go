@@ -0,0 +1,407 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var adminMonitorFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name:  "interval",
+		Usage: "time between two checks",
+		Value: time.Minute,
+	},
+	cli.StringSliceFlag{
+		Name:  "rule",
+		Usage: "alert when METRIC[OP THRESHOLD] holds, e.g. 'replication-backlog>1000'. Replaces the default rules",
+	},
+	cli.StringSliceFlag{
+		Name:  "webhook",
+		Usage: "post alerts as JSON to this URL",
+	},
+	cli.StringFlag{
+		Name:  "mail-command",
+		Usage: "run this shell command for each alert with the alert on its standard input, e.g. \"mail -s 'MinIO alert' ops@example.com\"",
+	},
+	cli.DurationFlag{
+		Name:  "repeat",
+		Usage: "notify alerts still firing again after this time, never if 0",
+	},
+	cli.BoolFlag{
+		Name:  "once",
+		Usage: "check once and exit, with an error if an alert fires",
+	},
+}
+
+var adminMonitorCmd = cli.Command{
+	Name:            "monitor",
+	Usage:           "watch the health of a cluster and send alerts",
+	Action:          mainAdminMonitor,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           append(adminMonitorFlags, globalFlags...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+METRICS:
+  unreachable          1 if the cluster does not answer
+  servers-offline      number of offline servers
+  drives-offline       number of drives which are not ok
+  drives-healing       number of drives being healed
+  replication-backlog  number of objects pending replication
+  replication-failed   number of objects which failed replication
+  scanner-lag          minutes since the scanner last updated the usage
+  decom-active         number of pools being decommissioned
+  decom-failed         number of pools whose decommission failed
+  quota-used           percentage of its quota used by a bucket
+
+  Rules compare a metric with >, >=, <, <=, == or != to a threshold, a metric alone
+  is the rule METRIC>0. Without --rule, these rules are evaluated:
+  unreachable, servers-offline>0, drives-offline>0, drives-healing>0, decom-failed>0
+  and quota-used>90.
+
+DESCRIPTION:
+  Alerts are printed, posted to the webhooks and given to the mail command when they
+  start firing and when they are resolved. An alert which keeps firing is notified
+  once, or every --repeat period.
+
+  The mail command also gets the alert in the MC_ALERT_ALIAS, MC_ALERT_STATE,
+  MC_ALERT_RULE and MC_ALERT_SUBJECT environment variables. Its standard output
+  is written to the standard error of mc, so that it does not mix with the
+  --json alerts, and its error output is reported when it fails.
+
+EXAMPLES:
+  1. Print alerts of the default rules for "myminio" as JSON every minute.
+     {{.Prompt}} {{.HelpName}} --json myminio
+
+  2. Post alerts to a webhook when the replication backlog grows or a drive goes offline.
+     {{.Prompt}} {{.HelpName}} myminio --rule 'replication-backlog>1000' --rule 'drives-offline>0' --webhook https://hooks.example.com/minio
+
+  3. Mail alerts, reminding every hour of those still firing.
+     {{.Prompt}} {{.HelpName}} myminio --repeat 1h --mail-command "mail -s 'MinIO alert' ops@example.com"
+
+  4. Check once from cron, failing if an alert fires.
+     {{.Prompt}} {{.HelpName}} myminio --once
+`,
+}
+
+// monitorAlertMessage is an alert starting to fire, still firing or
+// resolved.
+type monitorAlertMessage struct {
+	Status  string    `json:"status"`
+	Alias   string    `json:"alias"`
+	State   string    `json:"state"`
+	Rule    string    `json:"rule"`
+	Subject string    `json:"subject,omitempty"`
+	Value   float64   `json:"value"`
+	Since   time.Time `json:"since"`
+	Time    time.Time `json:"time"`
+}
+
+func newMonitorAlertMessage(alias string, alert monitorAlert, now time.Time) monitorAlertMessage {
+	msg := monitorAlertMessage{
+		Alias:   alias,
+		State:   "firing",
+		Rule:    alert.Rule,
+		Subject: alert.Subject,
+		Value:   alert.Value,
+		Since:   alert.Since,
+		Time:    now,
+	}
+	if alert.Resolved {
+		msg.State = "resolved"
+	}
+	return msg
+}
+
+// text is the alert without colors, as given to the mail command.
+func (m monitorAlertMessage) text() string {
+	rule := m.Rule
+	if m.Subject != "" {
+		rule += " on " + m.Subject
+	}
+	if m.State == "resolved" {
+		return fmt.Sprintf("%s: resolved %s, firing since %s", m.Alias, rule, m.Since.Format(printDate))
+	}
+	return fmt.Sprintf("%s: %s is %v since %s", m.Alias, rule, m.Value, m.Since.Format(printDate))
+}
+
+func (m monitorAlertMessage) String() string {
+	if m.State == "resolved" {
+		return console.Colorize("MonitorResolved", "[RESOLVED] ") + m.text()
+	}
+	return console.Colorize("MonitorFiring", "[FIRING]   ") + m.text()
+}
+
+func (m monitorAlertMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// collectMonitorSamples returns the samples of the metrics of the
+// rules. Metrics which can not be collected have no sample.
+func collectMonitorSamples(ctx context.Context, client *madmin.AdminClient, alias string, rules []monitorRule) []monitorSample {
+	needed := make(map[string]bool)
+	for _, rule := range rules {
+		needed[rule.Metric] = true
+	}
+
+	info, e := client.ServerInfo(ctx)
+	if e != nil {
+		errorIf(probe.NewError(e).Trace(alias), "Unable to get the server information of `%s`.", alias)
+		return []monitorSample{{Metric: "unreachable", Value: 1}}
+	}
+	var serversOffline, drivesOffline, drivesHealing float64
+	for _, server := range info.Servers {
+		if server.State != string(madmin.ItemOnline) {
+			serversOffline++
+		}
+		for _, drive := range server.Disks {
+			if drive.State != madmin.DriveStateOk {
+				drivesOffline++
+			}
+			if drive.Healing {
+				drivesHealing++
+			}
+		}
+	}
+	samples := []monitorSample{
+		{Metric: "unreachable"},
+		{Metric: "servers-offline", Value: serversOffline},
+		{Metric: "drives-offline", Value: drivesOffline},
+		{Metric: "drives-healing", Value: drivesHealing},
+	}
+
+	if needed["replication-backlog"] || needed["replication-failed"] || needed["scanner-lag"] || needed["quota-used"] {
+		usage, e := client.DataUsageInfo(ctx)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(alias), "Unable to get the data usage of `%s`.", alias)
+		} else {
+			samples = append(samples,
+				monitorSample{Metric: "replication-backlog", Value: float64(usage.ReplicationPendingCount)},
+				monitorSample{Metric: "replication-failed", Value: float64(usage.ReplicationFailedCount)},
+			)
+			if !usage.LastUpdate.IsZero() {
+				samples = append(samples, monitorSample{Metric: "scanner-lag", Value: float64(int(time.Since(usage.LastUpdate).Minutes()))})
+			}
+			if needed["quota-used"] {
+				samples = append(samples, collectQuotaSamples(ctx, client, alias, usage)...)
+			}
+		}
+	}
+
+	if needed["decom-active"] || needed["decom-failed"] {
+		pools, e := client.ListPoolsStatus(ctx)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(alias), "Unable to get the pools of `%s`.", alias)
+		} else {
+			var active, failed float64
+			for _, pool := range pools {
+				decom := pool.Decommission
+				switch {
+				case decom == nil:
+				case decom.Failed:
+					failed++
+				case !decom.Complete && !decom.Canceled:
+					active++
+				}
+			}
+			samples = append(samples,
+				monitorSample{Metric: "decom-active", Value: active},
+				monitorSample{Metric: "decom-failed", Value: failed},
+			)
+		}
+	}
+	return samples
+}
+
+// collectQuotaSamples returns the percentage of their quota used by
+// the buckets which have one.
+func collectQuotaSamples(ctx context.Context, client *madmin.AdminClient, alias string, usage madmin.DataUsageInfo) []monitorSample {
+	buckets := make([]string, 0, len(usage.BucketsUsage))
+	for bucket := range usage.BucketsUsage {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	samples := []monitorSample{}
+	for _, bucket := range buckets {
+		quota, e := client.GetBucketQuota(ctx, bucket)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(alias, bucket), "Unable to get the quota of `%s/%s`.", alias, bucket)
+			continue
+		}
+		limit := quota.Size
+		if limit == 0 {
+			limit = quota.Quota
+		}
+		if limit == 0 {
+			continue
+		}
+		used := float64(usage.BucketsUsage[bucket].Size) * 100 / float64(limit)
+		samples = append(samples, monitorSample{Metric: "quota-used", Subject: bucket, Value: float64(int(used*10)) / 10})
+	}
+	// The metric is sampled even without quotas so that alerts of
+	// removed quotas are resolved.
+	if len(samples) == 0 {
+		samples = append(samples, monitorSample{Metric: "quota-used"})
+	}
+	return samples
+}
+
+// newMonitorWebhook returns a function posting alerts to url.
+func newMonitorWebhook(url string, client *http.Client) func(ctx context.Context, msg monitorAlertMessage) error {
+	return func(ctx context.Context, msg monitorAlertMessage) error {
+		req, e := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(msg.JSON()))
+		if e != nil {
+			return e
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, e := client.Do(req)
+		if e != nil {
+			return e
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// newMonitorMailCommand returns a function running the shell command
+// with an alert on its standard input. The alert is also passed in
+// MC_ALERT_* environment variables.
+func newMonitorMailCommand(command string) func(ctx context.Context, msg monitorAlertMessage) error {
+	return func(ctx context.Context, msg monitorAlertMessage) error {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+		}
+		cmd.Env = append(os.Environ(),
+			"MC_ALERT_ALIAS="+msg.Alias,
+			"MC_ALERT_STATE="+msg.State,
+			"MC_ALERT_RULE="+msg.Rule,
+			"MC_ALERT_SUBJECT="+msg.Subject,
+		)
+		cmd.Stdin = strings.NewReader(msg.text() + "\n")
+		var stderr bytes.Buffer
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = &stderr
+		if e := cmd.Run(); e != nil {
+			if out := strings.TrimSpace(stderr.String()); out != "" {
+				return errors.New(out)
+			}
+			return e
+		}
+		return nil
+	}
+}
+
+func mainAdminMonitor(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("MonitorFiring", color.New(color.FgRed, color.Bold))
+	console.SetColor("MonitorResolved", color.New(color.FgGreen, color.Bold))
+
+	aliasedURL := ctx.Args().Get(0)
+	alias, _ := url2Alias(aliasedURL)
+
+	ruleSpecs := ctx.StringSlice("rule")
+	if len(ruleSpecs) == 0 {
+		ruleSpecs = monitorDefaultRules
+	}
+	rules := make([]monitorRule, 0, len(ruleSpecs))
+	for _, spec := range ruleSpecs {
+		rule, err := parseMonitorRule(spec)
+		fatalIf(err.Trace(spec), "Invalid monitoring rule.")
+		rules = append(rules, rule)
+	}
+	interval := ctx.Duration("interval")
+	if interval <= 0 {
+		fatalIf(errInvalidArgument().Trace(interval.String()), "The interval must be positive.")
+	}
+
+	var notifiers []func(ctx context.Context, msg monitorAlertMessage) error
+	for _, url := range ctx.StringSlice("webhook") {
+		notifiers = append(notifiers, newMonitorWebhook(url, httpClient(30*time.Second)))
+	}
+	if command := ctx.String("mail-command"); command != "" {
+		notifiers = append(notifiers, newMonitorMailCommand(command))
+	}
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err, "Unable to initialize admin connection.")
+
+	alerts := newMonitorAlerts(ctx.Duration("repeat"))
+	for {
+		now := time.Now().UTC()
+		samples := collectMonitorSamples(globalContext, client, alias, rules)
+		for _, alert := range alerts.evaluate(rules, samples, now) {
+			msg := newMonitorAlertMessage(alias, alert, now)
+			printMsg(msg)
+			for _, notify := range notifiers {
+				errorIf(probe.NewError(notify(globalContext, msg)).Trace(alias), "Unable to send the alert %s.", msg.Rule)
+			}
+		}
+
+		if ctx.Bool("once") {
+			if len(alerts.active) > 0 {
+				return exitStatus(globalErrorExitStatus)
+			}
+			return nil
+		}
+		select {
+		case <-globalContext.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
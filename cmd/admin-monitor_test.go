@@ -0,0 +1,99 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMonitorRule(t *testing.T) {
+	testCases := []struct {
+		rule string
+		want monitorRule
+		fail bool
+	}{
+		{rule: "drives-offline", want: monitorRule{Metric: "drives-offline", Op: ">"}},
+		{rule: "replication-backlog > 1000", want: monitorRule{Metric: "replication-backlog", Op: ">", Threshold: 1000}},
+		{rule: "quota-used>=90.5", want: monitorRule{Metric: "quota-used", Op: ">=", Threshold: 90.5}},
+		{rule: "servers-offline!=0", want: monitorRule{Metric: "servers-offline", Op: "!=", Threshold: 0}},
+		{rule: "cpu>1", fail: true},
+		{rule: "drives-offline=>1", fail: true},
+		{rule: "drives-offline>many", fail: true},
+	}
+	for _, tc := range testCases {
+		rule, err := parseMonitorRule(tc.rule)
+		if (err != nil) != tc.fail {
+			t.Fatalf("%s: unexpected error %v", tc.rule, err)
+		}
+		if !tc.fail && rule != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.rule, rule, tc.want)
+		}
+	}
+}
+
+func TestMonitorAlerts(t *testing.T) {
+	rules := []monitorRule{
+		{Metric: "drives-offline", Op: ">"},
+		{Metric: "quota-used", Op: ">", Threshold: 90},
+	}
+	alerts := newMonitorAlerts(time.Hour)
+	now := time.Now()
+	states := func(notify []monitorAlert) (s []string) {
+		for _, a := range notify {
+			state := "firing"
+			if a.Resolved {
+				state = "resolved"
+			}
+			s = append(s, state+" "+a.Rule+" "+a.Subject)
+		}
+		return s
+	}
+	check := func(step string, notify []monitorAlert, want ...string) {
+		t.Helper()
+		got := states(notify)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %q, want %q", step, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %q, want %q", step, got, want)
+			}
+		}
+	}
+
+	check("fire", alerts.evaluate(rules, []monitorSample{
+		{Metric: "drives-offline", Value: 2},
+		{Metric: "quota-used", Subject: "logs", Value: 95},
+		{Metric: "quota-used", Subject: "data", Value: 10},
+	}, now), "firing drives-offline>0 ", "firing quota-used>90 logs")
+
+	check("deduplicate", alerts.evaluate(rules, []monitorSample{
+		{Metric: "drives-offline", Value: 3},
+		{Metric: "quota-used", Subject: "logs", Value: 96},
+	}, now.Add(time.Minute)))
+
+	// Drives are unknown while the cluster is unreachable, their
+	// alert is neither repeated nor resolved.
+	check("unreachable", alerts.evaluate(rules, []monitorSample{{Metric: "unreachable", Value: 1}}, now.Add(2*time.Minute)))
+
+	check("resolve and repeat", alerts.evaluate(rules, []monitorSample{
+		{Metric: "drives-offline", Value: 0},
+		{Metric: "quota-used", Subject: "logs", Value: 97},
+	}, now.Add(time.Hour)), "firing quota-used>90 logs", "resolved drives-offline>0 ")
+}
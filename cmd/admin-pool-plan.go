@@ -0,0 +1,303 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var poolPlanFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "plan",
+		Usage: "only estimate the data to move and the final usage of the pools, do not start",
+	},
+	cli.StringFlag{
+		Name:  "throughput",
+		Usage: "data moved per second by the cluster, used to estimate the duration of the plan",
+		Value: "500MiB",
+	},
+}
+
+// poolPlanFullRatio is the usage above which a pool is reported as nearly full.
+const poolPlanFullRatio = 0.9
+
+// poolPlanPool is the usage of a pool before and after a plan. Sizes
+// are usable bytes, i.e. the data drives of the erasure sets.
+type poolPlanPool struct {
+	Index     int    `json:"index"`
+	CmdLine   string `json:"cmdline,omitempty"`
+	Capacity  uint64 `json:"capacity"`
+	Used      uint64 `json:"used"`
+	FinalUsed uint64 `json:"finalUsed"`
+	Received  uint64 `json:"received,omitempty"`
+	Drained   bool   `json:"drained,omitempty"`
+
+	drivesPerSet int
+	parity       int
+}
+
+func (p poolPlanPool) free() uint64 {
+	if p.Used > p.Capacity {
+		return 0
+	}
+	return p.Capacity - p.Used
+}
+
+// rawBytes returns the size of n bytes written to the pool including parity.
+func (p poolPlanPool) rawBytes(n uint64) uint64 {
+	if data := p.drivesPerSet - p.parity; data > 0 {
+		return uint64(float64(n) * float64(p.drivesPerSet) / float64(data))
+	}
+	return n
+}
+
+// poolPlan is the estimated outcome of a decommission or a rebalance.
+type poolPlan struct {
+	Status     string         `json:"status"`
+	Operation  string         `json:"operation"`
+	Pools      []poolPlanPool `json:"pools"`
+	Move       uint64         `json:"move"`
+	RawWrite   uint64         `json:"rawWrite"`
+	Throughput uint64         `json:"throughput"`
+	Duration   time.Duration  `json:"duration"`
+	Feasible   bool           `json:"feasible"`
+	Warnings   []string       `json:"warnings,omitempty"`
+}
+
+// newPoolPlanPools returns the pools of the cluster with their usage. Pools
+// which are decommissioned or being decommissioned are marked drained.
+func newPoolPlanPools(info madmin.InfoMessage, statuses []madmin.PoolStatus) []poolPlanPool {
+	summary := clusterSummaryInfo(info)
+	pools := make([]poolPlanPool, 0, len(summary))
+	for idx := 0; idx < len(summary); idx++ {
+		s := summary[idx]
+		if s == nil {
+			break
+		}
+		pools = append(pools, poolPlanPool{
+			Index:        idx,
+			Capacity:     s.drivesTotalUsableSpace,
+			Used:         s.drivesTotalUsableSpace - s.drivesTotalFreeSpace,
+			drivesPerSet: s.drivesPerSet,
+			parity:       s.driveTolerance,
+		})
+	}
+	for _, status := range statuses {
+		if status.ID < 0 || status.ID >= len(pools) {
+			continue
+		}
+		pools[status.ID].CmdLine = status.CmdLine
+		if d := status.Decommission; d != nil && !d.Failed && !d.Canceled && (d.Complete || !d.StartTime.IsZero()) {
+			pools[status.ID].Drained = true
+		}
+	}
+	return pools
+}
+
+// finish computes the raw data written and the duration of the plan
+// once the data moved to each pool is known.
+func (p *poolPlan) finish() {
+	p.Status = "success"
+	p.Feasible = true
+	for i, pool := range p.Pools {
+		p.Pools[i].FinalUsed = pool.FinalUsed + pool.Received
+		p.RawWrite += pool.rawBytes(pool.Received)
+		if p.Pools[i].FinalUsed > pool.Capacity {
+			p.Feasible = false
+			p.Warnings = append(p.Warnings, fmt.Sprintf("%s pool would need %s more than its capacity", humanize.Ordinal(pool.Index+1), humanize.IBytes(p.Pools[i].FinalUsed-pool.Capacity)))
+		} else if pool.Received > 0 && float64(p.Pools[i].FinalUsed) > poolPlanFullRatio*float64(pool.Capacity) {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("%s pool would be more than %d%% full", humanize.Ordinal(pool.Index+1), int(100*poolPlanFullRatio)))
+		}
+	}
+	if p.Throughput > 0 {
+		p.Duration = time.Duration(float64(p.RawWrite) / float64(p.Throughput) * float64(time.Second)).Round(time.Second)
+	}
+}
+
+// planDecommission estimates the decommission of the pool at index. Its
+// data is spread over the remaining pools in proportion to their free
+// space, which is how new objects are placed.
+func planDecommission(pools []poolPlanPool, index int, throughput uint64) poolPlan {
+	plan := poolPlan{Operation: "decommission", Pools: pools, Throughput: throughput}
+	var free uint64
+	for i := range pools {
+		pools[i].FinalUsed = pools[i].Used
+		if i != index && !pools[i].Drained {
+			free += pools[i].free()
+		}
+	}
+	plan.Move = pools[index].Used
+	pools[index].FinalUsed = 0
+	pools[index].Drained = true
+
+	var remaining []int
+	for i := range pools {
+		if !pools[i].Drained {
+			remaining = append(remaining, i)
+		}
+	}
+	switch {
+	case len(remaining) == 0:
+		plan.finish()
+		plan.Feasible = false
+		plan.Warnings = append(plan.Warnings, "no other pool is left to receive the data")
+		return plan
+	case free == 0:
+		// All the remaining pools are full, spread evenly to report how much is missing.
+		for _, i := range remaining {
+			pools[i].Received = plan.Move / uint64(len(remaining))
+		}
+	default:
+		var received uint64
+		for n, i := range remaining {
+			if n == len(remaining)-1 {
+				pools[i].Received = plan.Move - received
+				break
+			}
+			pools[i].Received = uint64(float64(plan.Move) * float64(pools[i].free()) / float64(free))
+			received += pools[i].Received
+		}
+	}
+	plan.finish()
+	return plan
+}
+
+// planRebalance estimates a rebalance, which moves data from the pools
+// above the average usage to the ones below until they are all equally full.
+func planRebalance(pools []poolPlanPool, throughput uint64) poolPlan {
+	plan := poolPlan{Operation: "rebalance", Pools: pools, Throughput: throughput}
+	var used, capacity uint64
+	for i := range pools {
+		pools[i].FinalUsed = pools[i].Used
+		if !pools[i].Drained {
+			used += pools[i].Used
+			capacity += pools[i].Capacity
+		}
+	}
+	if capacity == 0 {
+		plan.finish()
+		return plan
+	}
+	ratio := float64(used) / float64(capacity)
+	for i := range pools {
+		if pools[i].Drained {
+			continue
+		}
+		target := uint64(ratio * float64(pools[i].Capacity))
+		if pools[i].Used > target {
+			plan.Move += pools[i].Used - target
+			pools[i].FinalUsed = target
+		} else {
+			pools[i].Received = target - pools[i].Used
+		}
+	}
+	plan.finish()
+	if len(pools) < 2 {
+		plan.Warnings = append(plan.Warnings, "a single pool has nothing to rebalance")
+	}
+	return plan
+}
+
+// fetchPoolPlanPools returns the pools of the cluster and their usage.
+func fetchPoolPlanPools(client *madmin.AdminClient) ([]poolPlanPool, *probe.Error) {
+	info, e := client.ServerInfo(globalContext)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if info.BackendType() != madmin.Erasure {
+		return nil, probe.NewError(fmt.Errorf("pools are only available in erasure mode"))
+	}
+	statuses, e := client.ListPoolsStatus(globalContext)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return newPoolPlanPools(info, statuses), nil
+}
+
+// poolPlanThroughput parses the --throughput flag.
+func poolPlanThroughput(ctx *cli.Context) uint64 {
+	throughput, e := humanize.ParseBytes(strings.TrimSuffix(ctx.String("throughput"), "/s"))
+	fatalIf(probe.NewError(e).Trace(ctx.String("throughput")), "Unable to parse --throughput.")
+	return throughput
+}
+
+func (p poolPlan) JSON() string {
+	buf, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (p poolPlan) String() string {
+	console.SetColor("PoolPlanOK", color.New(color.FgGreen, color.Bold))
+	console.SetColor("PoolPlanWarn", color.New(color.FgYellow))
+	console.SetColor("PoolPlanFail", color.New(color.FgRed, color.Bold))
+
+	usage := func(used, capacity uint64) string {
+		if capacity == 0 {
+			return "0% (total: 0B)"
+		}
+		return fmt.Sprintf("%.1f%% (%s)", 100*float64(used)/float64(capacity), humanize.IBytes(used))
+	}
+	cellText := [][]string{{"Pool", "Capacity", "Usage", "Change", "Final usage"}}
+	for _, pool := range p.Pools {
+		name := humanize.Ordinal(pool.Index + 1)
+		if pool.CmdLine != "" {
+			name += " " + pool.CmdLine
+		}
+		change := ""
+		switch {
+		case pool.Received > 0:
+			change = "+" + humanize.IBytes(pool.Received)
+		case pool.FinalUsed < pool.Used:
+			change = "-" + humanize.IBytes(pool.Used-pool.FinalUsed)
+		}
+		cellText = append(cellText, []string{name, humanize.IBytes(pool.Capacity), usage(pool.Used, pool.Capacity), change, usage(pool.FinalUsed, pool.Capacity)})
+	}
+	var printColors []*color.Color
+	printColors = append(printColors, getPrintCol(colGreen))
+	for range p.Pools {
+		printColors = append(printColors, getPrintCol(colGrey))
+	}
+	var b strings.Builder
+	tbl := console.NewTable(printColors, []bool{false, false, false, false, false}, 0)
+	fatalIf(probe.NewError(tbl.PopulateTable(&b, cellText)), "Unable to populate the table.")
+
+	fmt.Fprintf(&b, "\nData to move: %s (%s written including parity)\n", humanize.IBytes(p.Move), humanize.IBytes(p.RawWrite))
+	if p.Throughput > 0 {
+		fmt.Fprintf(&b, "Estimated duration: %s at %s/s\n", p.Duration, humanize.IBytes(p.Throughput))
+	}
+	for _, warning := range p.Warnings {
+		fmt.Fprintln(&b, console.Colorize("PoolPlanWarn", "Warning: "+warning))
+	}
+	if p.Feasible {
+		b.WriteString(console.Colorize("PoolPlanOK", fmt.Sprintf("The %s can complete.", p.Operation)))
+	} else {
+		b.WriteString(console.Colorize("PoolPlanFail", fmt.Sprintf("The %s can not complete, the remaining pools do not have enough free space.", p.Operation)))
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func testPoolPlanPools() []poolPlanPool {
+	const tib = 1 << 40
+	return []poolPlanPool{
+		{Index: 0, Capacity: 100 * tib, Used: 60 * tib, drivesPerSet: 16, parity: 4},
+		{Index: 1, Capacity: 100 * tib, Used: 80 * tib, drivesPerSet: 16, parity: 4},
+		{Index: 2, Capacity: 200 * tib, Used: 20 * tib, drivesPerSet: 8, parity: 4},
+	}
+}
+
+func TestPlanDecommission(t *testing.T) {
+	const tib = 1 << 40
+	plan := planDecommission(testPoolPlanPools(), 0, 1<<30)
+	if !plan.Feasible || plan.Move != 60*tib {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	// 20TiB and 180TiB free, the data is spread 1:9.
+	if got := plan.Pools[1].Received; got != 6*tib {
+		t.Errorf("expected 6TiB moved to the 2nd pool, got %d", got)
+	}
+	if got := plan.Pools[2].FinalUsed; got != 74*tib {
+		t.Errorf("expected 74TiB used on the 3rd pool, got %d", got)
+	}
+	if plan.Pools[0].FinalUsed != 0 {
+		t.Errorf("the decommissioned pool is not empty: %+v", plan.Pools[0])
+	}
+	// 6TiB*16/12 + 54TiB*8/4
+	if want := uint64(8*tib + 108*tib); plan.RawWrite != want {
+		t.Errorf("expected %d bytes written, got %d", want, plan.RawWrite)
+	}
+	if want := time.Duration(116*1024) * time.Second; plan.Duration != want {
+		t.Errorf("expected %s, got %s", want, plan.Duration)
+	}
+
+	pools := testPoolPlanPools()
+	pools[2].Used = 100 * tib
+	plan = planDecommission(pools, 2, 0)
+	if plan.Feasible || len(plan.Warnings) == 0 {
+		t.Errorf("decommissioning 100TiB can not fit in 60TiB free: %+v", plan)
+	}
+
+	pools = testPoolPlanPools()
+	pools[1].Drained = true
+	plan = planDecommission(pools, 0, 0)
+	if plan.Pools[1].Received != 0 || plan.Pools[2].Received != 60*tib {
+		t.Errorf("a drained pool received data: %+v", plan.Pools)
+	}
+}
+
+func TestPlanRebalance(t *testing.T) {
+	const tib = 1 << 40
+	plan := planRebalance(testPoolPlanPools(), 0)
+	// 160TiB used of 400TiB, every pool ends 40% full.
+	for _, pool := range plan.Pools {
+		if pool.FinalUsed*10 != pool.Capacity*4 {
+			t.Errorf("pool %d is not 40%% full: %+v", pool.Index, pool)
+		}
+	}
+	if plan.Move != 60*tib || plan.Pools[2].Received != 60*tib || !plan.Feasible {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+}
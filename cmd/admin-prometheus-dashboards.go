@@ -0,0 +1,351 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var prometheusDashboardsFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "output-dir, o",
+		Usage: "directory to write the generated files to",
+		Value: ".",
+	},
+	cli.StringFlag{
+		Name:  "job",
+		Usage: "prometheus job name of the cluster",
+		Value: defaultJobName,
+	},
+	cli.BoolFlag{
+		Name:  "public",
+		Usage: "disable bearer token generation for scrape_configs",
+	},
+}
+
+var adminPrometheusDashboardsCmd = cli.Command{
+	Name:            "dashboards",
+	Usage:           "generate grafana dashboards and prometheus alert rules for a cluster",
+	Action:          mainAdminPrometheusDashboards,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           append(prometheusDashboardsFlags, globalFlags...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Inspects the pools, nodes, buckets and replication targets of the cluster and
+  writes three files using the v3 metrics:
+    ALIAS-scrape.yml     prometheus scrape config, labelling the series with cluster="ALIAS"
+    ALIAS-alerts.yml     prometheus alerting rules
+    ALIAS-dashboard.json grafana dashboard, asking for its prometheus datasource on import
+
+  Run the command again after adding pools, buckets or replication targets.
+
+EXAMPLES:
+  1. Generate the dashboard and alert rules of the cluster 'myminio' in the current directory.
+     {{.Prompt}} {{.HelpName}} myminio
+
+  2. Generate them in '/etc/prometheus' for a cluster scraped without a bearer token.
+     {{.Prompt}} {{.HelpName}} myminio --output-dir /etc/prometheus --public
+`,
+}
+
+// prometheusClusterTopology is what the generated files know of a cluster.
+type prometheusClusterTopology struct {
+	Alias   string
+	Job     string
+	Nodes   []string
+	Pools   []int
+	Drives  int
+	Buckets []string
+	Quotas  map[string]uint64
+	Targets []madmin.BucketTarget
+}
+
+// replicatedBuckets returns the buckets having a replication target.
+func (t prometheusClusterTopology) replicatedBuckets() []string {
+	var buckets []string
+	for _, target := range t.Targets {
+		if len(buckets) == 0 || buckets[len(buckets)-1] != target.SourceBucket {
+			buckets = append(buckets, target.SourceBucket)
+		}
+	}
+	return buckets
+}
+
+// prometheusRuleFile is a prometheus alerting rules file.
+type prometheusRuleFile struct {
+	Groups []prometheusRuleGroup `yaml:"groups"`
+}
+
+type prometheusRuleGroup struct {
+	Name  string                `yaml:"name"`
+	Rules []prometheusAlertRule `yaml:"rules"`
+}
+
+type prometheusAlertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+func newPrometheusAlertRule(alert, expr, duration, severity, summary string) prometheusAlertRule {
+	return prometheusAlertRule{
+		Alert:       alert,
+		Expr:        expr,
+		For:         duration,
+		Labels:      map[string]string{"severity": severity},
+		Annotations: map[string]string{"summary": summary},
+	}
+}
+
+// generatePrometheusAlertRules returns the alerting rules of the cluster,
+// the thresholds come from its current topology.
+func generatePrometheusAlertRules(t prometheusClusterTopology) prometheusRuleFile {
+	c := fmt.Sprintf("cluster=%q", t.Alias)
+	rules := []prometheusAlertRule{
+		newPrometheusAlertRule("MinIONodesOffline", fmt.Sprintf("max(%s{%s}) > 0", metricV3NodesOffline, c), "5m", "critical",
+			fmt.Sprintf("{{ $value }} node(s) of %s are offline", t.Alias)),
+		newPrometheusAlertRule("MinIONodesMissing", fmt.Sprintf("max(%s{%s}) < %d", metricV3NodesOnline, c, len(t.Nodes)), "5m", "critical",
+			fmt.Sprintf("only {{ $value }} of the %d nodes of %s are online", len(t.Nodes), t.Alias)),
+		newPrometheusAlertRule("MinIODrivesOffline", fmt.Sprintf("max(%s{%s}) > 0", metricV3DrivesOffline, c), "5m", "warning",
+			fmt.Sprintf("{{ $value }} drive(s) of %s are offline", t.Alias)),
+		newPrometheusAlertRule("MinIODrivesMissing", fmt.Sprintf("max(%s{%s}) < %d", metricV3DrivesOnline, c, t.Drives), "5m", "warning",
+			fmt.Sprintf("only {{ $value }} of the %d drives of %s are online", t.Drives, t.Alias)),
+		newPrometheusAlertRule("MinIOCapacityLow",
+			fmt.Sprintf("1 - max(%s{%s}) / max(%s{%s}) > 0.85", metricV3CapacityUsableFree, c, metricV3CapacityUsableTotal, c), "15m", "warning",
+			fmt.Sprintf("%s uses more than 85%% of its usable capacity", t.Alias)),
+		newPrometheusAlertRule("MinIOErasureSetBelowWriteQuorum",
+			fmt.Sprintf("max by (pool_id, set_id) (%s{%s}) < max by (pool_id, set_id) (%s{%s})", metricV3SetOnlineDrives, c, metricV3SetWriteQuorum, c), "1m", "critical",
+			fmt.Sprintf("erasure set {{ $labels.set_id }} of pool {{ $labels.pool_id }} of %s lost its write quorum", t.Alias)),
+		newPrometheusAlertRule("MinIOServerErrors",
+			fmt.Sprintf("sum(rate(%s{%s}[5m])) / sum(rate(%s{%s}[5m])) > 0.05", metricV3APIRequests5xx, c, metricV3APIRequests, c), "10m", "warning",
+			fmt.Sprintf("more than 5%% of the requests to %s fail with a server error", t.Alias)),
+	}
+	for _, pool := range t.Pools {
+		rules = append(rules, newPrometheusAlertRule(fmt.Sprintf("MinIOPool%dHealing", pool+1),
+			fmt.Sprintf("sum(%s{%s,pool_id=\"%d\"}) > 0", metricV3SetHealingDrives, c, pool), "1h", "info",
+			fmt.Sprintf("pool %d of %s has been healing {{ $value }} drive(s) for an hour", pool+1, t.Alias)))
+	}
+	for _, target := range t.Targets {
+		rules = append(rules, newPrometheusAlertRule("MinIOReplicationFailed",
+			fmt.Sprintf("max(%s{%s,bucket=%q,targetArn=%q}) > 0", metricV3ReplFailedLastHour, c, target.SourceBucket, target.Arn), "", "warning",
+			fmt.Sprintf("{{ $value }} object(s) of %s/%s failed to replicate to %s/%s in the last hour", t.Alias, target.SourceBucket, target.Endpoint, target.TargetBucket)))
+	}
+	buckets := make([]string, 0, len(t.Quotas))
+	for bucket := range t.Quotas {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	for _, bucket := range buckets {
+		rules = append(rules, newPrometheusAlertRule("MinIOBucketQuotaNearlyReached",
+			fmt.Sprintf("max(%s{%s,bucket=%q}) / %d > 0.9", metricV3BucketBytes, c, bucket, t.Quotas[bucket]), "15m", "warning",
+			fmt.Sprintf("bucket %s/%s uses more than 90%% of its quota", t.Alias, bucket)))
+	}
+	return prometheusRuleFile{Groups: []prometheusRuleGroup{{Name: "minio-" + t.Alias, Rules: rules}}}
+}
+
+// generatePrometheusScrapeConfig returns the scrape config of the cluster
+// metrics and of the replication metrics of each replicated bucket.
+func generatePrometheusScrapeConfig(t prometheusClusterTopology, u *url.URL, token string) PrometheusConfig {
+	job := func(name, path string) ScrapeConfig {
+		return ScrapeConfig{
+			JobName:     name,
+			BearerToken: token,
+			MetricsPath: path,
+			Scheme:      u.Scheme,
+			StaticConfigs: []StatConfig{{
+				Targets: []string{u.Host},
+				Labels:  map[string]string{"cluster": t.Alias},
+			}},
+		}
+	}
+	config := PrometheusConfig{ScrapeConfigs: []ScrapeConfig{job(t.Job, metricsV3EndPointRoot)}}
+	for _, bucket := range t.replicatedBuckets() {
+		config.ScrapeConfigs = append(config.ScrapeConfigs, job(t.Job+"-replication-"+bucket, getMetricsV3Path("replication", bucket)))
+	}
+	return config
+}
+
+// fetchPrometheusClusterTopology collects the topology of the cluster.
+func fetchPrometheusClusterTopology(ctx context.Context, alias, job string) (prometheusClusterTopology, *probe.Error) {
+	t := prometheusClusterTopology{Alias: alias, Job: job, Quotas: map[string]uint64{}}
+
+	client, err := newAdminClient(alias)
+	if err != nil {
+		return t, err
+	}
+	info, e := client.ServerInfo(ctx)
+	if e != nil {
+		return t, probe.NewError(e)
+	}
+	pools := map[int]bool{}
+	for _, server := range info.Servers {
+		t.Nodes = append(t.Nodes, server.Endpoint)
+		for _, disk := range server.Disks {
+			pools[disk.PoolIndex] = true
+		}
+		t.Drives += len(server.Disks)
+	}
+	sort.Strings(t.Nodes)
+	for pool := range pools {
+		t.Pools = append(t.Pools, pool)
+	}
+	sort.Ints(t.Pools)
+
+	s3Client, err := newClient(alias)
+	if err != nil {
+		return t, err
+	}
+	buckets, err := s3Client.ListBuckets(ctx)
+	if err != nil {
+		return t, err
+	}
+	for _, bucket := range buckets {
+		t.Buckets = append(t.Buckets, bucket.BucketName)
+		// Quotas are not supported by every deployment, ignore errors.
+		if quota, e := client.GetBucketQuota(ctx, bucket.BucketName); e == nil && quota.Size > 0 {
+			t.Quotas[bucket.BucketName] = quota.Size
+		}
+	}
+	sort.Strings(t.Buckets)
+
+	targets, e := client.ListRemoteTargets(ctx, "", string(madmin.ReplicationService))
+	if e != nil {
+		return t, probe.NewError(e)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].SourceBucket != targets[j].SourceBucket {
+			return targets[i].SourceBucket < targets[j].SourceBucket
+		}
+		return targets[i].Arn < targets[j].Arn
+	})
+	t.Targets = targets
+	return t, nil
+}
+
+type prometheusDashboardsMessage struct {
+	Status    string `json:"status"`
+	Alias     string `json:"alias"`
+	Pools     int    `json:"pools"`
+	Nodes     int    `json:"nodes"`
+	Buckets   int    `json:"buckets"`
+	Targets   int    `json:"replicationTargets"`
+	Dashboard string `json:"dashboard"`
+	Alerts    string `json:"alerts"`
+	Scrape    string `json:"scrape"`
+}
+
+func (m prometheusDashboardsMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Generated for %s (%d pools, %d nodes, %d buckets, %d replication targets):\n",
+		console.Colorize("PrometheusAlias", m.Alias), m.Pools, m.Nodes, m.Buckets, m.Targets)
+	for _, file := range []string{m.Scrape, m.Alerts, m.Dashboard} {
+		fmt.Fprintf(&b, "  %s\n", console.Colorize("PrometheusFile", file))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m prometheusDashboardsMessage) JSON() string {
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// mainAdminPrometheusDashboards is the handle for "mc admin prometheus dashboards" sub-command.
+func mainAdminPrometheusDashboards(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("PrometheusAlias", color.New(color.FgCyan, color.Bold))
+	console.SetColor("PrometheusFile", color.New(color.FgGreen))
+
+	alias := cleanAlias(ctx.Args().Get(0))
+	if !isValidAlias(alias) {
+		fatalIf(errInvalidAlias(alias), "Invalid alias.")
+	}
+	hostConfig := mustGetHostConfig(alias)
+	if hostConfig == nil {
+		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
+	}
+	fatalIf(hostConfig.resolveCredentials(alias), "Unable to retrieve the credentials of `"+alias+"`.")
+	u, e := url.Parse(hostConfig.URL)
+	fatalIf(probe.NewError(e), "Unable to parse the URL of `"+alias+"`.")
+
+	var token string
+	if !ctx.Bool("public") {
+		token, e = getPrometheusToken(hostConfig)
+		fatalIf(probe.NewError(e), "Unable to generate the bearer token.")
+	}
+
+	topology, err := fetchPrometheusClusterTopology(globalContext, alias, ctx.String("job"))
+	fatalIf(err, "Unable to fetch the topology of `"+alias+"`.")
+
+	dashboard, e := gojson.MarshalIndent(generateGrafanaDashboard(topology), "", "  ")
+	fatalIf(probe.NewError(e), "Unable to generate the Grafana dashboard.")
+	alerts, e := yaml.Marshal(generatePrometheusAlertRules(topology))
+	fatalIf(probe.NewError(e), "Unable to generate the Prometheus alert rules.")
+	scrape, e := yaml.Marshal(generatePrometheusScrapeConfig(topology, u, token))
+	fatalIf(probe.NewError(e), "Unable to generate the Prometheus config.")
+
+	dir := ctx.String("output-dir")
+	fatalIf(probe.NewError(os.MkdirAll(dir, 0o755)), "Unable to create `"+dir+"`.")
+	msg := prometheusDashboardsMessage{
+		Status:    "success",
+		Alias:     alias,
+		Pools:     len(topology.Pools),
+		Nodes:     len(topology.Nodes),
+		Buckets:   len(topology.Buckets),
+		Targets:   len(topology.Targets),
+		Dashboard: filepath.Join(dir, alias+"-dashboard.json"),
+		Alerts:    filepath.Join(dir, alias+"-alerts.yml"),
+		Scrape:    filepath.Join(dir, alias+"-scrape.yml"),
+	}
+	fatalIf(probe.NewError(os.WriteFile(msg.Dashboard, append(dashboard, '\n'), 0o644)), "Unable to write `"+msg.Dashboard+"`.")
+	fatalIf(probe.NewError(os.WriteFile(msg.Alerts, alerts, 0o644)), "Unable to write `"+msg.Alerts+"`.")
+	// The scrape config holds the bearer token.
+	fatalIf(probe.NewError(os.WriteFile(msg.Scrape, scrape, 0o600)), "Unable to write `"+msg.Scrape+"`.")
+
+	printMsg(msg)
+	return nil
+}
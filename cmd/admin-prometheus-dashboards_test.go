@@ -0,0 +1,90 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/minio/madmin-go/v3"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestPrometheusDashboards(t *testing.T) {
+	topology := prometheusClusterTopology{
+		Alias:   "site1",
+		Job:     defaultJobName,
+		Nodes:   []string{"node1:9000", "node2:9000"},
+		Pools:   []int{0, 1},
+		Drives:  8,
+		Buckets: []string{"logs", "photos"},
+		Quotas:  map[string]uint64{"logs": 1 << 30},
+		Targets: []madmin.BucketTarget{
+			{SourceBucket: "photos", Endpoint: "site2:9000", TargetBucket: "photos", Arn: "arn:minio:replication::1:photos"},
+			{SourceBucket: "photos", Endpoint: "site3:9000", TargetBucket: "photos", Arn: "arn:minio:replication::2:photos"},
+		},
+	}
+
+	dashboard := generateGrafanaDashboard(topology)
+	titles := map[string]bool{}
+	for _, panel := range dashboard.Panels {
+		titles[panel.Title] = true
+		if panel.GridPos.X+panel.GridPos.W > 24 {
+			t.Errorf("panel %q overflows the grid: %+v", panel.Title, panel.GridPos)
+		}
+	}
+	for _, title := range []string{"Pool 1 erasure sets", "Pool 2 erasure sets", "Bucket quota used", "photos to site2:9000/photos", "photos to site3:9000/photos"} {
+		if !titles[title] {
+			t.Errorf("dashboard is missing the %q panel", title)
+		}
+	}
+	if _, e := json.Marshal(dashboard); e != nil {
+		t.Fatal(e)
+	}
+
+	rules := generatePrometheusAlertRules(topology)
+	if _, e := yaml.Marshal(rules); e != nil {
+		t.Fatal(e)
+	}
+	var exprs strings.Builder
+	for _, rule := range rules.Groups[0].Rules {
+		exprs.WriteString(rule.Alert + " " + rule.Expr + "\n")
+	}
+	for _, want := range []string{
+		`minio_cluster_health_nodes_online_count{cluster="site1"}) < 2`,
+		`minio_cluster_health_drives_online_count{cluster="site1"}) < 8`,
+		`targetArn="arn:minio:replication::2:photos"`,
+		`bucket="logs"}) / 1073741824 > 0.9`,
+		"MinIOPool2Healing",
+	} {
+		if !strings.Contains(exprs.String(), want) {
+			t.Errorf("alert rules do not contain %q:\n%s", want, exprs.String())
+		}
+	}
+
+	u, _ := url.Parse("https://site1:9000")
+	config := generatePrometheusScrapeConfig(topology, u, "token")
+	if len(config.ScrapeConfigs) != 2 {
+		t.Fatalf("expected a cluster and a replication job, got %+v", config.ScrapeConfigs)
+	}
+	if job := config.ScrapeConfigs[1]; job.MetricsPath != "/minio/metrics/v3/bucket/replication/photos" || job.StaticConfigs[0].Labels["cluster"] != "site1" {
+		t.Errorf("unexpected replication job %+v", job)
+	}
+}
@@ -143,7 +143,8 @@ func (c PrometheusConfig) JSON() string {
 
 // StatConfig - container to hold the targets config.
 type StatConfig struct {
-	Targets []string `yaml:",flow" json:"targets"`
+	Targets []string          `yaml:",flow" json:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
 }
 
 // String colorized stat config yaml.
@@ -195,6 +196,7 @@ func generatePrometheusConfig(ctx *cli.Context) error {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
 		return nil
 	}
+	fatalIf(hostConfig.resolveCredentials(alias), "Unable to retrieve the credentials of `"+alias+"`.")
 
 	u, e := url.Parse(hostConfig.URL)
 	if e != nil {
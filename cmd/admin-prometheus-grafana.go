@@ -0,0 +1,234 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// grafanaDashboard is a Grafana dashboard ready to import, asking for
+// its Prometheus datasource on import.
+type grafanaDashboard struct {
+	Inputs        []grafanaInput    `json:"__inputs"`
+	UID           string            `json:"uid"`
+	Title         string            `json:"title"`
+	Tags          []string          `json:"tags"`
+	Timezone      string            `json:"timezone"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Version       int               `json:"version"`
+	Refresh       string            `json:"refresh"`
+	Time          grafanaTimeRange  `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaInput struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	PluginID string `json:"pluginId"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+// grafanaVariable is a custom variable listing the values found on
+// the cluster.
+type grafanaVariable struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Type       string `json:"type"`
+	Query      string `json:"query"`
+	Multi      bool   `json:"multi"`
+	IncludeAll bool   `json:"includeAll"`
+	AllValue   string `json:"allValue"`
+}
+
+type grafanaPanel struct {
+	ID          int                 `json:"id"`
+	Type        string              `json:"type"`
+	Title       string              `json:"title"`
+	GridPos     grafanaGridPos      `json:"gridPos"`
+	Datasource  *grafanaDatasource  `json:"datasource,omitempty"`
+	Targets     []grafanaTarget     `json:"targets,omitempty"`
+	FieldConfig *grafanaFieldConfig `json:"fieldConfig,omitempty"`
+	Collapsed   *bool               `json:"collapsed,omitempty"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	RefID        string `json:"refId"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults  grafanaFieldDefaults `json:"defaults"`
+	Overrides []struct{}           `json:"overrides"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// grafanaLayout places panels left to right in rows of 24 columns.
+type grafanaLayout struct {
+	panels []grafanaPanel
+	x, y   int
+	height int
+}
+
+// row starts a new row of panels under a title.
+func (l *grafanaLayout) row(title string) {
+	if l.x > 0 {
+		l.x, l.y = 0, l.y+l.height
+	}
+	collapsed := false
+	l.panels = append(l.panels, grafanaPanel{
+		ID:        len(l.panels) + 1,
+		Type:      "row",
+		Title:     title,
+		GridPos:   grafanaGridPos{H: 1, W: 24, Y: l.y},
+		Collapsed: &collapsed,
+	})
+	l.y++
+	l.height = 0
+}
+
+// add adds a panel of width w showing the PromQL expressions exprs,
+// each given as EXPR or EXPR|LEGEND.
+func (l *grafanaLayout) add(kind, title, unit string, w int, exprs ...string) {
+	h := 8
+	if kind == "stat" || kind == "gauge" {
+		h = 4
+	}
+	if l.x+w > 24 {
+		l.x, l.y, l.height = 0, l.y+l.height, 0
+	}
+	panel := grafanaPanel{
+		ID:          len(l.panels) + 1,
+		Type:        kind,
+		Title:       title,
+		GridPos:     grafanaGridPos{H: h, W: w, X: l.x, Y: l.y},
+		Datasource:  &grafanaDatasource{Type: "prometheus", UID: "${DS_PROMETHEUS}"},
+		FieldConfig: &grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: unit}, Overrides: []struct{}{}},
+	}
+	for i, expr := range exprs {
+		expr, legend, _ := strings.Cut(expr, "|")
+		panel.Targets = append(panel.Targets, grafanaTarget{Expr: expr, LegendFormat: legend, RefID: string(rune('A' + i))})
+	}
+	l.panels = append(l.panels, panel)
+	l.x += w
+	l.height = max(l.height, h)
+}
+
+// generateGrafanaDashboard returns a dashboard of the cluster with a
+// row for each of its pools and replication targets.
+func generateGrafanaDashboard(t prometheusClusterTopology) grafanaDashboard {
+	c := fmt.Sprintf("cluster=%q", t.Alias)
+	node := c + `,server=~"$server"`
+	bucket := c + `,bucket=~"$bucket"`
+
+	var l grafanaLayout
+	l.row("Overview")
+	l.add("stat", "Nodes online", "none", 4, fmt.Sprintf("max(%s{%s})", metricV3NodesOnline, c))
+	l.add("stat", "Nodes offline", "none", 4, fmt.Sprintf("max(%s{%s})", metricV3NodesOffline, c))
+	l.add("stat", "Drives online", "none", 4, fmt.Sprintf("max(%s{%s})", metricV3DrivesOnline, c))
+	l.add("stat", "Drives offline", "none", 4, fmt.Sprintf("max(%s{%s})", metricV3DrivesOffline, c))
+	l.add("gauge", "Usable capacity used", "percentunit", 4,
+		fmt.Sprintf("1 - max(%s{%s}) / max(%s{%s})", metricV3CapacityUsableFree, c, metricV3CapacityUsableTotal, c))
+	l.add("stat", "Objects", "short", 4, fmt.Sprintf("max(%s{%s})", metricV3ObjectsCount, c))
+
+	l.row("Pools")
+	for _, pool := range t.Pools {
+		p := fmt.Sprintf("%s,pool_id=\"%d\"", c, pool)
+		l.add("timeseries", fmt.Sprintf("Pool %d erasure sets", pool+1), "none", 12,
+			fmt.Sprintf("max by (set_id) (%s{%s})|set {{set_id}} online drives", metricV3SetOnlineDrives, p),
+			fmt.Sprintf("max by (set_id) (%s{%s})|set {{set_id}} healing drives", metricV3SetHealingDrives, p),
+			fmt.Sprintf("max by (set_id) (%s{%s})|set {{set_id}} write quorum", metricV3SetWriteQuorum, p))
+	}
+
+	l.row("Nodes")
+	l.add("timeseries", "Requests by node", "reqps", 12, fmt.Sprintf("sum by (server) (rate(%s{%s}[5m]))|{{server}}", metricV3APIRequests, node))
+	l.add("timeseries", "CPU by node", "percentunit", 12, fmt.Sprintf("rate(%s{%s}[5m])|{{server}}", metricV3ProcessCPU, node))
+	l.add("timeseries", "Memory by node", "bytes", 12, fmt.Sprintf("%s{%s}|{{server}}", metricV3ProcessMemory, node))
+	l.add("timeseries", "Drive usage", "percentunit", 12,
+		fmt.Sprintf("%s{%s} / %s{%s}|{{server}} {{drive}}", metricV3DriveUsed, node, metricV3DriveTotal, node))
+
+	l.row("API")
+	l.add("timeseries", "Requests by API", "reqps", 12, fmt.Sprintf("sum by (name) (rate(%s{%s}[5m]))|{{name}}", metricV3APIRequests, c))
+	l.add("timeseries", "Errors", "reqps", 12,
+		fmt.Sprintf("sum(rate(%s{%s}[5m]))|4xx", metricV3APIRequests4xx, c),
+		fmt.Sprintf("sum(rate(%s{%s}[5m]))|5xx", metricV3APIRequests5xx, c))
+	l.add("timeseries", "Requests in flight", "short", 12, fmt.Sprintf("sum by (server) (%s{%s})|{{server}}", metricV3APIRequestsInflight, node))
+
+	if len(t.Buckets) > 0 {
+		l.row("Buckets")
+		l.add("timeseries", "Bucket size", "bytes", 12, fmt.Sprintf("max by (bucket) (%s{%s})|{{bucket}}", metricV3BucketBytes, bucket))
+		l.add("timeseries", "Bucket objects", "short", 12, fmt.Sprintf("max by (bucket) (%s{%s})|{{bucket}}", metricV3BucketObjects, bucket))
+		if len(t.Quotas) > 0 {
+			l.add("timeseries", "Bucket quota used", "percentunit", 12,
+				fmt.Sprintf("max by (bucket) (%s{%s}) / max by (bucket) (%s{%s} > 0)|{{bucket}}", metricV3BucketBytes, bucket, metricV3BucketQuotaBytes, bucket))
+		}
+	}
+
+	if len(t.Targets) > 0 {
+		l.row("Replication")
+		for _, target := range t.Targets {
+			r := fmt.Sprintf("%s,bucket=%q,targetArn=%q", c, target.SourceBucket, target.Arn)
+			l.add("timeseries", fmt.Sprintf("%s to %s/%s", target.SourceBucket, target.Endpoint, target.TargetBucket), "short", 12,
+				fmt.Sprintf("max(%s{%s})|failed last hour", metricV3ReplFailedLastHour, r),
+				fmt.Sprintf("max by (operation) (%s{%s})|{{operation}} latency ms", metricV3ReplLatency, r))
+		}
+	}
+
+	return grafanaDashboard{
+		Inputs:        []grafanaInput{{Name: "DS_PROMETHEUS", Label: "Prometheus", Type: "datasource", PluginID: "prometheus"}},
+		UID:           "minio-" + t.Alias,
+		Title:         "MinIO " + t.Alias,
+		Tags:          []string{"minio"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Version:       1,
+		Refresh:       "1m",
+		Time:          grafanaTimeRange{From: "now-6h", To: "now"},
+		Templating: grafanaTemplating{List: []grafanaVariable{
+			{Name: "server", Label: "Node", Type: "custom", Query: strings.Join(t.Nodes, ","), Multi: true, IncludeAll: true, AllValue: ".*"},
+			{Name: "bucket", Label: "Bucket", Type: "custom", Query: strings.Join(t.Buckets, ","), Multi: true, IncludeAll: true, AllValue: ".*"},
+		}},
+		Panels: l.panels,
+	}
+}
@@ -108,3 +108,41 @@ func printPrometheusMetricsV3(ctx *cli.Context, req prometheusMetricsReq) error
 
 	return errors.New(resp.Status)
 }
+
+// Metrics v3 families used by the generated dashboards and alert rules.
+const (
+	// cluster/health
+	metricV3NodesOnline         = "minio_cluster_health_nodes_online_count"
+	metricV3NodesOffline        = "minio_cluster_health_nodes_offline_count"
+	metricV3DrivesOnline        = "minio_cluster_health_drives_online_count"
+	metricV3DrivesOffline       = "minio_cluster_health_drives_offline_count"
+	metricV3CapacityUsableTotal = "minio_cluster_health_capacity_usable_total_bytes"
+	metricV3CapacityUsableFree  = "minio_cluster_health_capacity_usable_free_bytes"
+
+	// cluster/erasure-set
+	metricV3SetOnlineDrives  = "minio_cluster_erasure_set_online_drives_count"
+	metricV3SetHealingDrives = "minio_cluster_erasure_set_healing_drives_count"
+	metricV3SetWriteQuorum   = "minio_cluster_erasure_set_write_quorum"
+
+	// cluster/usage
+	metricV3ObjectsCount     = "minio_cluster_usage_objects_count"
+	metricV3BucketBytes      = "minio_cluster_usage_buckets_total_bytes"
+	metricV3BucketObjects    = "minio_cluster_usage_buckets_objects_count"
+	metricV3BucketQuotaBytes = "minio_cluster_usage_buckets_quota_total_bytes"
+
+	// api/requests
+	metricV3APIRequests         = "minio_api_requests_total"
+	metricV3APIRequests4xx      = "minio_api_requests_4xx_errors_total"
+	metricV3APIRequests5xx      = "minio_api_requests_5xx_errors_total"
+	metricV3APIRequestsInflight = "minio_api_requests_inflight_total"
+
+	// system
+	metricV3DriveUsed     = "minio_system_drive_used_bytes"
+	metricV3DriveTotal    = "minio_system_drive_total_bytes"
+	metricV3ProcessMemory = "minio_system_process_resident_memory_bytes"
+	metricV3ProcessCPU    = "minio_system_process_cpu_total_seconds"
+
+	// bucket/replication
+	metricV3ReplFailedLastHour = "minio_bucket_replication_last_hour_failed_count"
+	metricV3ReplLatency        = "minio_bucket_replication_latency_ms"
+)
@@ -218,6 +218,7 @@ func mainSupportMetrics(ctx *cli.Context) error {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
 		return nil
 	}
+	fatalIf(hostConfig.resolveCredentials(alias), "Unable to retrieve the credentials of `"+alias+"`.")
 
 	token, e := getPrometheusToken(hostConfig)
 	if e != nil {
@@ -33,7 +33,7 @@ var adminRebalanceStartCmd = cli.Command{
 	Action:       mainAdminRebalanceStart,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        globalFlags,
+	Flags:        append(poolPlanFlags, globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -46,6 +46,9 @@ FLAGS:
 xEXAMPLES:
   1. Start rebalance on a MinIO deployment with alias myminio
      {{.Prompt}} {{.HelpName}} myminio
+
+  2. Estimate the data to move and the final usage of the pools, without starting.
+     {{.Prompt}} {{.HelpName}} myminio --plan
 `,
 }
 
@@ -80,6 +83,17 @@ func mainAdminRebalanceStart(ctx *cli.Context) error {
 	client, err := newAdminClient(aliasedURL)
 	fatalIf(err.Trace(aliasedURL), "Unable to initialize admin client")
 
+	if ctx.Bool("plan") {
+		pools, err := fetchPoolPlanPools(client)
+		fatalIf(err.Trace(aliasedURL), "Unable to get the usage of the pools.")
+		plan := planRebalance(pools, poolPlanThroughput(ctx))
+		printMsg(plan)
+		if !plan.Feasible {
+			return exitStatus(globalErrorExitStatus)
+		}
+		return nil
+	}
+
 	id, e := client.RebalanceStart(globalContext)
 	fatalIf(probe.NewError(e), "Unable to start rebalance")
 
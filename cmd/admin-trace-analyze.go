@@ -0,0 +1,416 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	gojson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+	"github.com/olekukonko/tablewriter"
+)
+
+// traceRecorder persists the raw trace stream, one JSON encoded
+// madmin.TraceInfo per line. Every record is written straight to
+// the file, so a capture survives mc being interrupted.
+type traceRecorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *gojson.Encoder
+}
+
+func newTraceRecorder(fileName string) (*traceRecorder, *probe.Error) {
+	f, e := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	enc := gojson.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return &traceRecorder{f: f, enc: enc}, nil
+}
+
+func (r *traceRecorder) record(t madmin.TraceInfo) *probe.Error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return probe.NewError(r.enc.Encode(t))
+}
+
+func (r *traceRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// readTraceRecording calls fn for every trace found in a recording
+// made with --record. Gzip compressed recordings are detected and
+// decompressed transparently.
+func readTraceRecording(fileName string, fn func(t madmin.TraceInfo)) *probe.Error {
+	f, e := os.Open(fileName)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, e := gzip.NewReader(br)
+		if e != nil {
+			return probe.NewError(e)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dec := gojson.NewDecoder(r)
+	for {
+		var t madmin.TraceInfo
+		e := dec.Decode(&t)
+		if errors.Is(e, io.EOF) {
+			return nil
+		}
+		if errors.Is(e, io.ErrUnexpectedEOF) {
+			// The last record of a capture that was
+			// interrupted may be incomplete, ignore it.
+			return nil
+		}
+		if e != nil {
+			return probe.NewError(e)
+		}
+		fn(t)
+	}
+}
+
+// traceFilter applies the server side trace filters on a recording.
+type traceFilter struct {
+	types      madmin.TraceType
+	onlyErrors bool
+	threshold  time.Duration
+}
+
+func newTraceFilter(opts madmin.ServiceTraceOpts) traceFilter {
+	return traceFilter{
+		types:      opts.TraceTypes(),
+		onlyErrors: opts.OnlyErrors,
+		threshold:  opts.Threshold,
+	}
+}
+
+func (f traceFilter) matches(t madmin.TraceInfo) bool {
+	if !f.types.Overlaps(t.TraceType) {
+		return false
+	}
+	if f.threshold > 0 && t.Duration < f.threshold {
+		return false
+	}
+	if f.onlyErrors {
+		failed := t.Error != ""
+		if t.HTTP != nil && t.HTTP.RespInfo.StatusCode >= http.StatusBadRequest {
+			failed = true
+		}
+		if !failed {
+			return false
+		}
+	}
+	return true
+}
+
+// traceLatencies collects call durations to compute percentiles.
+type traceLatencies map[string][]time.Duration
+
+func (l traceLatencies) add(t madmin.TraceInfo) {
+	l[t.FuncName] = append(l[t.FuncName], t.Duration)
+}
+
+type traceLatency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+func (l traceLatencies) percentiles() map[string]traceLatency {
+	res := make(map[string]traceLatency, len(l))
+	for name, durs := range l {
+		sort.Slice(durs, func(i, j int) bool { return durs[i] < durs[j] })
+		res[name] = traceLatency{
+			P50: durationPercentile(durs, 50),
+			P90: durationPercentile(durs, 90),
+			P99: durationPercentile(durs, 99),
+			Max: durs[len(durs)-1],
+		}
+	}
+	return res
+}
+
+// durationPercentile returns the nearest rank percentile of sorted durations.
+func durationPercentile(sorted []time.Duration, pct int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*pct + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// traceFolded aggregates call durations into folded stacks,
+// 'type;api;bucket' weighted by the total duration in microseconds.
+// The output can be fed directly to flamegraph tools.
+type traceFolded map[string]int64
+
+func (f traceFolded) add(t madmin.TraceInfo) {
+	bucket := strings.SplitN(strings.TrimPrefix(t.Path, "/"), "/", 2)[0]
+	if bucket == "" {
+		bucket = "-"
+	}
+	stack := strings.Join([]string{t.TraceType.String(), t.FuncName, bucket}, ";")
+	f[stack] += t.Duration.Microseconds()
+}
+
+type traceAnalyzeMessage struct {
+	Status    string                  `json:"status"`
+	Source    string                  `json:"source"`
+	Total     int                     `json:"total"`
+	Matched   int                     `json:"matched"`
+	Stats     *statTrace              `json:"stats"`
+	Latencies map[string]traceLatency `json:"latencies"`
+
+	maxEntries int
+	allFlag    bool
+}
+
+func (m traceAnalyzeMessage) JSON() string {
+	m.Status = "success"
+	m.Stats.mu.Lock()
+	defer m.Stats.mu.Unlock()
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", " ")
+	enc.SetEscapeHTML(false)
+	fatalIf(probe.NewError(enc.Encode(m)), "Unable to marshal into JSON.")
+
+	// strip off extra newline added by json encoder
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (m traceAnalyzeMessage) String() string {
+	var s strings.Builder
+	s.WriteString(console.Colorize("metrics-top-title", fmt.Sprintf("Recording: %s (%d of %d traces matched)\n", m.Source, m.Matched, m.Total)))
+	if m.Matched == 0 {
+		return s.String()
+	}
+	ui := &traceStatsUI{
+		current:    m.Stats,
+		maxEntries: m.maxEntries,
+		allFlag:    m.allFlag,
+		offline:    true,
+	}
+	s.WriteString(ui.View())
+	s.WriteString("\n")
+
+	var names []string
+	for name := range m.Latencies {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return m.Latencies[names[i]].P99 > m.Latencies[names[j]].P99
+	})
+	if m.maxEntries > 0 && len(names) > m.maxEntries {
+		names = names[:m.maxEntries]
+	}
+
+	table := tablewriter.NewWriter(&s)
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("  ")
+	table.SetNoWhiteSpace(true)
+	table.Append([]string{
+		console.Colorize("metrics-top-title", "Call"),
+		console.Colorize("metrics-top-title", "P50"),
+		console.Colorize("metrics-top-title", "P90"),
+		console.Colorize("metrics-top-title", "P99"),
+		console.Colorize("metrics-top-title", "Max"),
+	})
+	for _, name := range names {
+		l := m.Latencies[name]
+		table.Append([]string{
+			console.Colorize("metrics-title", metricsTitle(name)),
+			console.Colorize("metrics-dur", roundDur(l.P50).String()),
+			console.Colorize("metrics-dur", roundDur(l.P90).String()),
+			console.Colorize("metrics-dur-med", roundDur(l.P99).String()),
+			console.Colorize("metrics-dur-high", roundDur(l.Max).String()),
+		})
+	}
+	table.Render()
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// writeTraceCSV writes a per call summary of the analyzed traces as CSV.
+func writeTraceCSV(w io.Writer, m traceAnalyzeMessage) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"call", "count", "errors", "avg_ns", "min_ns", "max_ns", "p50_ns", "p90_ns", "p99_ns",
+		"avg_ttfb_ns", "max_ttfb_ns", "rx_bytes", "tx_bytes", "size_bytes",
+	})
+	var entries []statItem
+	for _, v := range m.Stats.Calls {
+		entries = append(entries, v)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count == entries[j].Count {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Count > entries[j].Count
+	})
+	itoa := func(d time.Duration) string { return strconv.FormatInt(int64(d), 10) }
+	for _, v := range entries {
+		if v.Count <= 0 {
+			continue
+		}
+		l := m.Latencies[v.Name]
+		cw.Write([]string{
+			v.Name,
+			strconv.Itoa(v.Count),
+			strconv.Itoa(v.Errors),
+			itoa(v.Duration / time.Duration(v.Count)),
+			itoa(v.MinDur),
+			itoa(v.MaxDur),
+			itoa(l.P50),
+			itoa(l.P90),
+			itoa(l.P99),
+			itoa(v.TTFB / time.Duration(v.Count)),
+			itoa(v.MaxTTFB),
+			strconv.Itoa(v.CallStats.Rx),
+			strconv.Itoa(v.CallStats.Tx),
+			strconv.FormatInt(v.Size, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTraceFolded writes folded stacks sorted by stack name.
+func writeTraceFolded(w io.Writer, folded traceFolded) error {
+	stacks := make([]string, 0, len(folded))
+	for stack := range folded {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	bw := bufio.NewWriter(w)
+	for _, stack := range stacks {
+		fmt.Fprintf(bw, "%s %d\n", stack, folded[stack])
+	}
+	return bw.Flush()
+}
+
+func checkAdminTraceAnalyzeSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	filterFlag := ctx.Bool("filter-request") || ctx.Bool("filter-response")
+	if filterFlag && ctx.String("filter-size") == "" {
+		// filter must use with filter-size flags
+		showCommandHelpAndExit(ctx, 1)
+	}
+	if ctx.String("record") != "" {
+		fatalIf(errDummy().Trace(), "You cannot use --record when analyzing a recording.")
+	}
+	if ctx.Bool("csv") && ctx.Bool("folded") {
+		fatalIf(errDummy().Trace(), "You cannot specify both --csv and --folded flags at the same time.")
+	}
+	if ctx.Bool("all") && len(ctx.StringSlice("call")) > 0 {
+		fatalIf(errDummy().Trace(), "You cannot specify both --all and --call flags at the same time.")
+	}
+}
+
+// mainAdminTraceAnalyze - analyzes a trace recording made with --record.
+func mainAdminTraceAnalyze(ctx *cli.Context) error {
+	checkAdminTraceAnalyzeSyntax(ctx)
+
+	fileName := ctx.Args().Get(1)
+
+	opts, e := tracingOpts(ctx, ctx.StringSlice("call"))
+	fatalIf(probe.NewError(e), "Unable to analyze trace recording")
+
+	filter := newTraceFilter(opts)
+	mopts := matchingOpts(ctx)
+
+	stats := &statTrace{Calls: make(map[string]statItem, 20)}
+	latencies := make(traceLatencies)
+	folded := make(traceFolded)
+	msg := traceAnalyzeMessage{
+		Source:     fileName,
+		Stats:      stats,
+		maxEntries: ctx.Int("stats-n"),
+		allFlag:    ctx.Bool("all"),
+	}
+	err := readTraceRecording(fileName, func(t madmin.TraceInfo) {
+		msg.Total++
+		if !filter.matches(t) || !mopts.matches(madmin.ServiceTraceInfo{Trace: t}) {
+			return
+		}
+		msg.Matched++
+		if stats.Started.IsZero() || t.Time.Before(stats.Started) {
+			stats.Started = t.Time
+		}
+		if end := t.Time.Add(t.Duration); end.After(stats.Ended) {
+			stats.Ended = end
+		}
+		stats.add(madmin.ServiceTraceInfo{Trace: t})
+		latencies.add(t)
+		folded.add(t)
+	})
+	fatalIf(err.Trace(fileName), "Unable to read trace recording.")
+	msg.Latencies = latencies.percentiles()
+
+	switch {
+	case ctx.Bool("csv"):
+		fatalIf(probe.NewError(writeTraceCSV(os.Stdout, msg)), "Unable to write CSV summary.")
+	case ctx.Bool("folded"):
+		fatalIf(probe.NewError(writeTraceFolded(os.Stdout, folded)), "Unable to write folded stacks.")
+	default:
+		initTraceStatsUIColors()
+		printMsg(msg)
+	}
+	return nil
+}
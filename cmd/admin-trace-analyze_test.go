@@ -0,0 +1,102 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+func TestTraceRecording(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.trace")
+	recorder, err := newTraceRecorder(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	traces := []madmin.TraceInfo{
+		{TraceType: madmin.TraceS3, FuncName: "s3.GetObject", Time: now, Path: "/bucket/object", Duration: time.Millisecond},
+		{TraceType: madmin.TraceStorage, FuncName: "storage.ReadAll", Time: now, Path: "/disk/bucket", Duration: time.Second, Error: "disk not found"},
+	}
+	for _, trc := range traces {
+		if err := recorder.record(trc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e := recorder.Close(); e != nil {
+		t.Fatal(e)
+	}
+
+	// Simulate an interrupted capture.
+	f, e := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		t.Fatal(e)
+	}
+	f.WriteString(`{"type":4,"nodename":`)
+	f.Close()
+
+	var got []madmin.TraceInfo
+	if err := readTraceRecording(fileName, func(trc madmin.TraceInfo) { got = append(got, trc) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(traces) {
+		t.Fatalf("expected %d traces, got %d", len(traces), len(got))
+	}
+	for i := range traces {
+		if got[i].FuncName != traces[i].FuncName || !got[i].Time.Equal(traces[i].Time) || got[i].Duration != traces[i].Duration || got[i].Error != traces[i].Error {
+			t.Errorf("trace %d: expected %+v, got %+v", i, traces[i], got[i])
+		}
+	}
+
+	filter := newTraceFilter(madmin.ServiceTraceOpts{Storage: true, OnlyErrors: true})
+	if filter.matches(got[0]) {
+		t.Errorf("expected S3 call to be filtered")
+	}
+	if !filter.matches(got[1]) {
+		t.Errorf("expected failed storage call to match")
+	}
+}
+
+func TestDurationPercentile(t *testing.T) {
+	var durs []time.Duration
+	for i := 1; i <= 100; i++ {
+		durs = append(durs, time.Duration(i)*time.Millisecond)
+	}
+	testCases := []struct {
+		pct  int
+		want time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+		{0, time.Millisecond},
+	}
+	for _, tc := range testCases {
+		if got := durationPercentile(durs, tc.pct); got != tc.want {
+			t.Errorf("p%d: expected %v, got %v", tc.pct, tc.want, got)
+		}
+	}
+	if got := durationPercentile(durs[:1], 99); got != time.Millisecond {
+		t.Errorf("expected single sample, got %v", got)
+	}
+}
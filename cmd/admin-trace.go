@@ -111,6 +111,18 @@ var adminTraceFlags = []cli.Flag{
 		Name:  "filter-size",
 		Usage: "filter size, use with filter (see UNITS)",
 	},
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "record the raw trace stream to a file for offline analysis",
+	},
+	cli.BoolFlag{
+		Name:  "csv",
+		Usage: "print a per call summary in CSV format, use with analyze",
+	},
+	cli.BoolFlag{
+		Name:  "folded",
+		Usage: "print folded stacks for flamegraph tools, use with analyze",
+	},
 }
 
 // traceCallTypes contains all call types and flags to apply when selected.
@@ -193,6 +205,7 @@ var adminTraceCmd = cli.Command{
 
 USAGE:
   {{.HelpName}} [FLAGS] TARGET
+  {{.HelpName}} analyze [FLAGS] FILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -231,6 +244,15 @@ EXAMPLES:
   
   8. Show trace only for requests operations duration greater than 5ms
      {{.Prompt}} {{.HelpName}} --response-duration 5ms myminio
+
+  9. Record all calls to a file while showing statistics
+     {{.Prompt}} {{.HelpName}} -a --stats --record myminio.trace myminio
+
+  10. Show statistics and latency percentiles of recorded calls with '503' status code
+     {{.Prompt}} {{.HelpName}} analyze --status-code 503 myminio.trace
+
+  11. Export recorded S3 calls as folded stacks for a flamegraph
+     {{.Prompt}} {{.HelpName}} analyze --folded myminio.trace | flamegraph.pl > myminio.svg
 `,
 }
 
@@ -251,6 +273,10 @@ func checkAdminTraceSyntax(ctx *cli.Context) {
 	if ctx.Bool("all") && len(ctx.StringSlice("call")) > 0 {
 		fatalIf(errDummy().Trace(), "You cannot specify both --all and --call flags at the same time.")
 	}
+
+	if ctx.Bool("csv") || ctx.Bool("folded") {
+		fatalIf(errDummy().Trace(), "--csv and --folded flags can only be used with analyze.")
+	}
 }
 
 func printTrace(verbose bool, traceInfo madmin.ServiceTraceInfo) {
@@ -484,6 +510,10 @@ func tracingOpts(ctx *cli.Context, apis []string) (opts madmin.ServiceTraceOpts,
 
 // mainAdminTrace - the entry function of trace command
 func mainAdminTrace(ctx *cli.Context) error {
+	if ctx.Args().First() == "analyze" && len(ctx.Args()) > 1 {
+		return mainAdminTraceAnalyze(ctx)
+	}
+
 	// Check for command syntax
 	checkAdminTraceSyntax(ctx)
 
@@ -525,6 +555,13 @@ func mainAdminTrace(ctx *cli.Context) error {
 
 	mopts := matchingOpts(ctx)
 
+	var recorder *traceRecorder
+	if recordFile := ctx.String("record"); recordFile != "" {
+		recorder, err = newTraceRecorder(recordFile)
+		fatalIf(err.Trace(recordFile), "Unable to create trace recording.")
+		defer recorder.Close()
+	}
+
 	// Start listening on all trace activity.
 	traceCh := client.ServiceTrace(ctxt, opts)
 	if stats {
@@ -538,6 +575,13 @@ func mainAdminTrace(ctx *cli.Context) error {
 					ui.Kill()
 					return
 				}
+				if recorder != nil {
+					if err := recorder.record(t.Trace); err != nil {
+						te = err.ToGoError()
+						ui.Kill()
+						return
+					}
+				}
 				if mopts.matches(t) {
 					filteredTraces <- t
 				}
@@ -556,6 +600,9 @@ func mainAdminTrace(ctx *cli.Context) error {
 		if traceInfo.Err != nil {
 			fatalIf(probe.NewError(traceInfo.Err), "Unable to listen to http trace")
 		}
+		if recorder != nil {
+			fatalIf(recorder.record(traceInfo.Trace), "Unable to record http trace")
+		}
 		if mopts.matches(traceInfo) {
 			printTrace(verbose, traceInfo)
 		}
@@ -917,9 +964,19 @@ type statItem struct {
 type statTrace struct {
 	Calls   map[string]statItem `json:"calls"`
 	Started time.Time
+	Ended   time.Time
 	mu      sync.Mutex
 }
 
+// duration returns the time span covered by the statistics.
+// Live statistics are still running, so they end now.
+func (s *statTrace) duration() time.Duration {
+	if s.Ended.IsZero() {
+		return time.Since(s.Started)
+	}
+	return s.Ended.Sub(s.Started)
+}
+
 func (s *statTrace) JSON() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
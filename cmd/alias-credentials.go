@@ -0,0 +1,515 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ini/ini"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/pkg/v3/env"
+	"golang.org/x/term"
+)
+
+// Types of credential sources of an alias.
+const (
+	credentialSourceProcess    = "process"
+	credentialSourceKeyring    = "keyring"
+	credentialSourceFile       = "file"
+	credentialSourceAWSProfile = "aws-profile"
+	credentialSourceSTS        = "sts"
+)
+
+const (
+	// Passphrase of encrypted credential files.
+	mcEnvCredentialsPassphrase = "MC_CREDENTIALS_PASSPHRASE"
+	// Service attribute of the keyring items written by mc.
+	keyringService = "mc"
+	// Credentials expiring within this window are retrieved again.
+	credentialExpiryWindow = time.Minute
+)
+
+// awsSSOPortalURL is the endpoint of the AWS SSO portal of a region.
+var awsSSOPortalURL = "https://portal.sso.%s.amazonaws.com"
+
+// processCredentials is the output of a credential process, the same
+// as the credential_process of the AWS CLI. The keyring items and the
+// credential files use the same format.
+type processCredentials struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string    `json:",omitempty"`
+	Expiration      time.Time `json:",omitempty"`
+}
+
+func (p processCredentials) value() credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     p.AccessKeyID,
+		SecretAccessKey: p.SecretAccessKey,
+		SessionToken:    p.SessionToken,
+	}
+}
+
+func parseProcessCredentials(data []byte) (processCredentials, error) {
+	var creds processCredentials
+	if e := json.Unmarshal(data, &creds); e != nil {
+		return creds, fmt.Errorf("unable to parse credentials: %w", e)
+	}
+	if creds.Version != 1 {
+		return creds, fmt.Errorf("unsupported credentials version %d", creds.Version)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, errors.New("credentials have no access key or secret key")
+	}
+	return creds, nil
+}
+
+func (s *credentialSourceV10) String() string {
+	switch s.Type {
+	case credentialSourceProcess:
+		return s.Type + ": " + s.Command
+	case credentialSourceKeyring:
+		return s.Type + ": " + s.Item
+	case credentialSourceFile:
+		return s.Type + ": " + s.File
+	case credentialSourceAWSProfile:
+		return s.Type + ": " + s.Profile
+	case credentialSourceSTS:
+		if s.STS != nil {
+			return s.Type + ": " + s.STS.String()
+		}
+	}
+	return s.Type
+}
+
+// validate checks that all the fields needed by the type of source
+// are set.
+func (s *credentialSourceV10) validate() *probe.Error {
+	var missing string
+	switch s.Type {
+	case credentialSourceProcess:
+		if s.Command == "" {
+			missing = "command"
+		}
+	case credentialSourceKeyring:
+		if s.Item == "" {
+			missing = "item"
+		}
+	case credentialSourceFile:
+		if s.File == "" {
+			missing = "file"
+		}
+	case credentialSourceAWSProfile:
+	case credentialSourceSTS:
+		if s.STS == nil {
+			missing = "identity"
+		} else if e := s.STS.validate(); e != nil {
+			return probe.NewError(e)
+		}
+	default:
+		return probe.NewError(fmt.Errorf("unknown credential source type `%s`", s.Type))
+	}
+	if missing != "" {
+		return probe.NewError(fmt.Errorf("credential source `%s` has no %s", s.Type, missing))
+	}
+	return nil
+}
+
+// retrieve returns the credentials held by the source for alias.
+func (s *credentialSourceV10) retrieve(alias string) (processCredentials, *probe.Error) {
+	if err := s.validate(); err != nil {
+		return processCredentials{}, err
+	}
+	var (
+		data []byte
+		e    error
+	)
+	switch s.Type {
+	case credentialSourceProcess:
+		data, e = runCredentialCommand(shellCommand(s.Command))
+	case credentialSourceKeyring:
+		data, e = runCredentialCommand(exec.Command("secret-tool", "lookup", "service", keyringService, "alias", s.Item))
+		if e == nil && len(bytes.TrimSpace(data)) == 0 {
+			e = fmt.Errorf("no keyring item found for `%s`", s.Item)
+		}
+	case credentialSourceFile:
+		data, e = readCredentialFile(s.File, s.Identity)
+	case credentialSourceAWSProfile:
+		var creds processCredentials
+		creds, e = awsProfileCredentials(s.File, s.Profile)
+		if e != nil {
+			return creds, probe.NewError(e).Trace(s.Profile)
+		}
+		return creds, nil
+	case credentialSourceSTS:
+		creds, e := s.STS.retrieve(alias)
+		if e != nil {
+			return creds, probe.NewError(e).Trace(alias)
+		}
+		return creds, nil
+	}
+	if e != nil {
+		return processCredentials{}, probe.NewError(e).Trace(s.String())
+	}
+	creds, e := parseProcessCredentials(data)
+	if e != nil {
+		return creds, probe.NewError(e).Trace(s.String())
+	}
+	return creds, nil
+}
+
+// shellCommand runs command the way the shell of the platform would.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// runCredentialCommand returns the standard output of cmd. The command
+// shares the terminal of mc, so it can prompt for MFA codes or open a
+// browser.
+func runCredentialCommand(cmd *exec.Cmd) ([]byte, error) {
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, e := cmd.Output()
+	if e != nil {
+		return nil, fmt.Errorf("`%s` failed: %w", strings.Join(cmd.Args, " "), e)
+	}
+	return out, nil
+}
+
+// readCredentialFile decrypts a credential file, with the age identity
+// file if one is given and with a passphrase otherwise.
+func readCredentialFile(file, identity string) ([]byte, error) {
+	if identity != "" {
+		return runCredentialCommand(exec.Command("age", "--decrypt", "--identity", identity, file))
+	}
+	data, e := os.ReadFile(file)
+	if e != nil {
+		return nil, e
+	}
+	passphrase, e := credentialsPassphrase(file, false)
+	if e != nil {
+		return nil, e
+	}
+	return madmin.DecryptData(passphrase, bytes.NewReader(data))
+}
+
+// writeCredentialFile encrypts creds into file with a passphrase.
+func writeCredentialFile(file string, creds processCredentials) *probe.Error {
+	passphrase, e := credentialsPassphrase(file, true)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	creds.Version = 1
+	data, e := json.Marshal(creds)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if data, e = madmin.EncryptData(passphrase, data); e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.WriteFile(file, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(file)
+	}
+	return nil
+}
+
+// credentialsPassphrase returns the passphrase of a credential file
+// from the environment or prompts for it on the terminal.
+func credentialsPassphrase(file string, confirm bool) (string, error) {
+	if passphrase := env.Get(mcEnvCredentialsPassphrase, ""); passphrase != "" {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase for `%s`, set %s", file, mcEnvCredentialsPassphrase)
+	}
+	fmt.Fprintf(os.Stderr, "Enter passphrase for `%s`: ", file)
+	passphrase, e := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if e != nil {
+		return "", e
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, e := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if e != nil {
+			return "", e
+		}
+		if !bytes.Equal(passphrase, again) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	return string(passphrase), nil
+}
+
+// storeKeyringCredentials writes creds to the Secret Service keyring
+// as the item of alias.
+func storeKeyringCredentials(item string, creds processCredentials) *probe.Error {
+	creds.Version = 1
+	data, e := json.Marshal(creds)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	cmd := exec.Command("secret-tool", "store", "--label", "mc alias "+item, "service", keyringService, "alias", item)
+	cmd.Stdin = bytes.NewReader(data)
+	if out, e := cmd.CombinedOutput(); e != nil {
+		return probe.NewError(fmt.Errorf("unable to store credentials in the keyring: %w: %s", e, bytes.TrimSpace(out)))
+	}
+	return nil
+}
+
+// awsProfileCredentials returns the credentials of an AWS profile. SSO
+// profiles use the token cached by `aws sso login`, all others are read
+// from the shared credentials file.
+func awsProfileCredentials(file, profile string) (processCredentials, error) {
+	if profile == "" {
+		profile = env.Get("AWS_PROFILE", "default")
+	}
+	sso, e := awsSSOProfile(profile)
+	if e != nil {
+		return processCredentials{}, e
+	}
+	if sso != nil {
+		return sso.credentials()
+	}
+	value, e := (&credentials.FileAWSCredentials{Filename: file, Profile: profile}).Retrieve()
+	if e != nil {
+		return processCredentials{}, e
+	}
+	if value.AccessKeyID == "" || value.SecretAccessKey == "" {
+		return processCredentials{}, fmt.Errorf("AWS profile `%s` has no credentials", profile)
+	}
+	return processCredentials{
+		Version:         1,
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+		Expiration:      value.Expiration,
+	}, nil
+}
+
+// awsSSO is the SSO configuration of an AWS profile.
+type awsSSO struct {
+	startURL  string
+	region    string
+	accountID string
+	roleName  string
+	cacheKey  string
+}
+
+// awsSSOProfile reads the SSO configuration of profile from the AWS
+// config file, it returns nil if profile does not use SSO.
+func awsSSOProfile(profile string) (*awsSSO, error) {
+	configFile := env.Get("AWS_CONFIG_FILE", "")
+	if configFile == "" {
+		homeDir, e := os.UserHomeDir()
+		if e != nil {
+			return nil, e
+		}
+		configFile = filepath.Join(homeDir, ".aws", "config")
+	}
+	cfg, e := ini.Load(configFile)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil, nil
+		}
+		return nil, e
+	}
+	name := "profile " + profile
+	if profile == "default" && !cfg.HasSection(name) {
+		name = profile
+	}
+	section, e := cfg.GetSection(name)
+	if e != nil {
+		return nil, nil
+	}
+	sso := &awsSSO{
+		startURL:  section.Key("sso_start_url").String(),
+		region:    section.Key("sso_region").String(),
+		accountID: section.Key("sso_account_id").String(),
+		roleName:  section.Key("sso_role_name").String(),
+	}
+	sso.cacheKey = sso.startURL
+	if session := section.Key("sso_session").String(); session != "" {
+		s, e := cfg.GetSection("sso-session " + session)
+		if e != nil {
+			return nil, fmt.Errorf("AWS profile `%s` references unknown sso-session `%s`", profile, session)
+		}
+		sso.startURL = s.Key("sso_start_url").String()
+		sso.region = s.Key("sso_region").String()
+		sso.cacheKey = session
+	}
+	if sso.accountID == "" && sso.roleName == "" && sso.startURL == "" {
+		return nil, nil
+	}
+	if sso.accountID == "" || sso.roleName == "" || sso.region == "" || sso.cacheKey == "" {
+		return nil, fmt.Errorf("AWS profile `%s` has an incomplete SSO configuration", profile)
+	}
+	return sso, nil
+}
+
+// credentials exchanges the cached SSO token for role credentials.
+func (s *awsSSO) credentials() (processCredentials, error) {
+	homeDir, e := os.UserHomeDir()
+	if e != nil {
+		return processCredentials{}, e
+	}
+	sum := sha1.Sum([]byte(s.cacheKey))
+	data, e := os.ReadFile(filepath.Join(homeDir, ".aws", "sso", "cache", hex.EncodeToString(sum[:])+".json"))
+	if e != nil {
+		return processCredentials{}, fmt.Errorf("no cached SSO token, run `aws sso login`: %w", e)
+	}
+	var token struct {
+		AccessToken string    `json:"accessToken"`
+		ExpiresAt   time.Time `json:"expiresAt"`
+	}
+	if e = json.Unmarshal(data, &token); e != nil {
+		return processCredentials{}, e
+	}
+	if token.AccessToken == "" || time.Now().After(token.ExpiresAt) {
+		return processCredentials{}, errors.New("the cached SSO token expired, run `aws sso login`")
+	}
+
+	endpoint := fmt.Sprintf(awsSSOPortalURL, s.region) + "/federation/credentials?" + url.Values{
+		"account_id": {s.accountID},
+		"role_name":  {s.roleName},
+	}.Encode()
+	req, e := http.NewRequestWithContext(globalContext, http.MethodGet, endpoint, nil)
+	if e != nil {
+		return processCredentials{}, e
+	}
+	req.Header.Set("x-amz-sso_bearer_token", token.AccessToken)
+	resp, e := http.DefaultClient.Do(req)
+	if e != nil {
+		return processCredentials{}, e
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return processCredentials{}, fmt.Errorf("SSO portal returned %s", resp.Status)
+	}
+	var out struct {
+		RoleCredentials struct {
+			AccessKeyID     string `json:"accessKeyId"`
+			SecretAccessKey string `json:"secretAccessKey"`
+			SessionToken    string `json:"sessionToken"`
+			Expiration      int64  `json:"expiration"`
+		} `json:"roleCredentials"`
+	}
+	if e = json.NewDecoder(resp.Body).Decode(&out); e != nil {
+		return processCredentials{}, e
+	}
+	rc := out.RoleCredentials
+	return processCredentials{
+		Version:         1,
+		AccessKeyID:     rc.AccessKeyID,
+		SecretAccessKey: rc.SecretAccessKey,
+		SessionToken:    rc.SessionToken,
+		Expiration:      time.UnixMilli(rc.Expiration),
+	}, nil
+}
+
+// Credentials retrieved from the sources of the aliases, so each
+// source is asked once until its credentials expire.
+var (
+	sourcedCredentialsMu sync.Mutex
+	sourcedCredentials   = map[string]processCredentials{}
+)
+
+// retrieveSourceCredentials returns the cached credentials of alias or
+// retrieves them from source.
+func retrieveSourceCredentials(alias string, source *credentialSourceV10) (processCredentials, *probe.Error) {
+	sourcedCredentialsMu.Lock()
+	defer sourcedCredentialsMu.Unlock()
+	if creds, ok := sourcedCredentials[alias]; ok && !credentialsExpired(creds) {
+		return creds, nil
+	}
+	creds, err := source.retrieve(alias)
+	if err != nil {
+		return creds, err.Trace(alias)
+	}
+	sourcedCredentials[alias] = creds
+	return creds, nil
+}
+
+func credentialsExpired(creds processCredentials) bool {
+	return !creds.Expiration.IsZero() && time.Now().Add(credentialExpiryWindow).After(creds.Expiration)
+}
+
+// resolveCredentials fills the keys of an alias with a credential
+// source.
+func (cfg *aliasConfigV10) resolveCredentials(alias string) *probe.Error {
+	if cfg == nil || cfg.Credentials == nil {
+		return nil
+	}
+	creds, err := retrieveSourceCredentials(alias, cfg.Credentials)
+	if err != nil {
+		return err
+	}
+	cfg.AccessKey = creds.AccessKeyID
+	cfg.SecretKey = creds.SecretAccessKey
+	cfg.SessionToken = creds.SessionToken
+	return nil
+}
+
+// credentialSourceProvider is a credentials.Provider retrieving the
+// keys of an alias from its credential source again when they expire.
+type credentialSourceProvider struct {
+	alias      string
+	source     *credentialSourceV10
+	signType   credentials.SignatureType
+	expiration time.Time
+}
+
+func (p *credentialSourceProvider) Retrieve() (credentials.Value, error) {
+	creds, err := retrieveSourceCredentials(p.alias, p.source)
+	if err != nil {
+		return credentials.Value{}, err.ToGoError()
+	}
+	p.expiration = creds.Expiration
+	value := creds.value()
+	value.SignerType = p.signType
+	return value, nil
+}
+
+func (p *credentialSourceProvider) IsExpired() bool {
+	return credentialsExpired(processCredentials{Expiration: p.expiration})
+}
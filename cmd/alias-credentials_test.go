@@ -0,0 +1,105 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCredentialSources(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential process test uses sh")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "aws-config"))
+	t.Setenv(mcEnvCredentialsPassphrase, "correct horse battery staple")
+
+	// The process counts its runs, it must run again once its
+	// credentials expire.
+	counter := filepath.Join(dir, "runs")
+	expired := time.Now().Add(30 * time.Second).UTC().Format(time.RFC3339)
+	process := &credentialSourceV10{
+		Type:    credentialSourceProcess,
+		Command: fmt.Sprintf(`echo run >> %s; echo '{"Version":1,"AccessKeyId":"proc","SecretAccessKey":"proc-secret","Expiration":"%s"}'`, counter, expired),
+	}
+	for i := 0; i < 2; i++ {
+		cfg := &aliasConfigV10{Credentials: process}
+		if err := cfg.resolveCredentials("proc"); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.AccessKey != "proc" || cfg.SecretKey != "proc-secret" {
+			t.Fatalf("unexpected process credentials %s:%s", cfg.AccessKey, cfg.SecretKey)
+		}
+	}
+	if runs, _ := os.ReadFile(counter); string(runs) != "run\nrun\n" {
+		t.Errorf("expiring credentials were not retrieved again: %q", runs)
+	}
+
+	file := filepath.Join(dir, "creds.enc")
+	if err := writeCredentialFile(file, processCredentials{AccessKeyID: "file", SecretAccessKey: "file-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := (&credentialSourceV10{Type: credentialSourceFile, File: file}).retrieve("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "file" || creds.SecretAccessKey != "file-secret" {
+		t.Errorf("unexpected file credentials %+v", creds)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-amz-sso_bearer_token") != "token" || r.URL.Query().Get("role_name") != "dev" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"roleCredentials":{"accessKeyId":"sso","secretAccessKey":"sso-secret","sessionToken":"sso-token","expiration":4102444800000}}`)
+	}))
+	defer server.Close()
+	portalURL := awsSSOPortalURL
+	t.Cleanup(func() { awsSSOPortalURL = portalURL })
+	awsSSOPortalURL = server.URL + "/%s"
+	config := "[profile dev]\nsso_session = corp\nsso_account_id = 123456789012\nsso_role_name = dev\n\n" +
+		"[sso-session corp]\nsso_start_url = https://corp.awsapps.com/start\nsso_region = eu-west-1\n"
+	if e := os.WriteFile(filepath.Join(dir, "aws-config"), []byte(config), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	sum := sha1.Sum([]byte("corp"))
+	cacheDir := filepath.Join(dir, ".aws", "sso", "cache")
+	os.MkdirAll(cacheDir, 0o700)
+	token := `{"accessToken":"token","expiresAt":"2100-01-01T00:00:00Z"}`
+	if e := os.WriteFile(filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json"), []byte(token), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	creds, err = (&credentialSourceV10{Type: credentialSourceAWSProfile, Profile: "dev"}).retrieve("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "sso" || creds.SessionToken != "sso-token" || creds.Expiration.Year() != 2100 {
+		t.Errorf("unexpected SSO credentials %+v", creds)
+	}
+}
@@ -0,0 +1,548 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/v3/console"
+)
+
+// aliasDefaultsFlags set the default options of an alias or a profile.
+// The global flags of the same names are left out of these commands so
+// that the environment variables of the global flags are not saved.
+var aliasDefaultsFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "disable SSL certificate verification",
+	},
+	cli.StringSliceFlag{
+		Name:  "resolve",
+		Usage: "resolve HOST[:PORT] to an IP address. Example: minio.local:9000=10.10.75.1",
+	},
+	cli.StringFlag{
+		Name:  "ca-certs",
+		Usage: "PEM bundle of certificate authorities trusted in addition to the system ones",
+	},
+	cli.StringFlag{
+		Name:  "limit-upload",
+		Usage: "limit uploads to a maximum rate in KiB/s, MiB/s, GiB/s",
+	},
+	cli.StringFlag{
+		Name:  "limit-download",
+		Usage: "limit downloads to a maximum rate in KiB/s, MiB/s, GiB/s",
+	},
+	cli.StringFlag{
+		Name:  "conn-read-deadline",
+		Usage: "connection READ deadline, e.g. '5m'",
+	},
+	cli.StringFlag{
+		Name:  "conn-write-deadline",
+		Usage: "connection WRITE deadline, e.g. '5m'",
+	},
+	cli.StringFlag{
+		Name:  "enc-kms",
+		Usage: "encrypt uploads with this SSE-KMS key",
+	},
+	cli.BoolFlag{
+		Name:  "enc-s3",
+		Usage: "encrypt uploads with SSE-S3",
+	},
+	cli.StringFlag{
+		Name:  "checksum",
+		Usage: "add this checksum to uploads. Values: MD5, CRC32, CRC32C, SHA1 or SHA256",
+	},
+	cli.StringFlag{
+		Name:  "storage-class, sc",
+		Usage: "storage class of uploads",
+	},
+}
+
+var aliasDefaultsCmd = cli.Command{
+	Name:         "defaults",
+	Usage:        "show or set the default options of an alias",
+	Action:       mainAliasDefaults,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags: append(append(aliasDefaultsFlags,
+		cli.StringFlag{
+			Name:  "profile",
+			Usage: "use the default options of this profile, overridden by those of the alias",
+		},
+		cli.BoolFlag{
+			Name:  "clear",
+			Usage: "clear the default options and the profile of the alias before setting the given ones",
+		},
+	), aliasDefaultsGlobalFlags()...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS [FLAGS]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Every command using the alias applies its default options, those of its profile
+  overridden by its own. Flags given on the command line take precedence over them.
+  Checksum and storage class defaults apply to uploads to the alias by cp, mv,
+  mirror, pipe and put. Without flags, the effective default options are shown.
+
+  An alias turns off the --insecure or --enc-s3 option of its profile with
+  --insecure=false or --enc-s3=false. Its SSE-KMS or SSE-S3 default replaces
+  the encryption of the profile.
+
+EXAMPLES:
+  1. Always resolve the host of "mylab" to a fixed address and trust a private CA.
+     {{.Prompt}} {{.HelpName}} mylab --resolve minio.lab:9000=10.0.0.5 --ca-certs ~/lab-ca.pem
+
+  2. Encrypt the uploads to "myminio" with a KMS key and limit their rate.
+     {{.Prompt}} {{.HelpName}} myminio --enc-kms my-key --limit-upload 50MiB
+
+  3. Use the "lab" profile for "mylab", dropping its own default options.
+     {{.Prompt}} {{.HelpName}} mylab --clear --profile lab
+
+  4. Show the effective default options of "mylab".
+     {{.Prompt}} {{.HelpName}} mylab
+`,
+}
+
+func mainAliasDefaults(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("Alias", color.New(color.FgCyan, color.Bold))
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	alias := cleanAlias(ctx.Args().First())
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	aliasCfg, ok := conf.Aliases[alias]
+	if !ok {
+		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
+	}
+
+	update := ctx.IsSet("profile") || ctx.Bool("clear")
+	for _, flag := range aliasDefaultsFlags {
+		name, _, _ := strings.Cut(flag.GetName(), ",")
+		update = update || ctx.IsSet(name)
+	}
+	if !update {
+		printMsg(aliasDefaultsMessage{op: "list", Alias: alias, Profile: aliasCfg.Profile, Defaults: resolveAliasOptions(conf, aliasCfg)})
+		return nil
+	}
+
+	if ctx.Bool("clear") {
+		aliasCfg.Profile, aliasCfg.Defaults = "", nil
+	}
+	if ctx.IsSet("profile") {
+		aliasCfg.Profile = ctx.String("profile")
+		if _, ok := conf.Profiles[aliasCfg.Profile]; !ok && aliasCfg.Profile != "" {
+			fatalIf(errInvalidArgument().Trace(aliasCfg.Profile), "No such profile `"+aliasCfg.Profile+"` found.")
+		}
+	}
+	var defaults aliasDefaultsV10
+	if aliasCfg.Defaults != nil {
+		defaults = *aliasCfg.Defaults
+	}
+	defaults = aliasDefaultsFromContext(ctx, defaults)
+	fatalIf(defaults.validate().Trace(alias), "Invalid default options for `"+alias+"`.")
+	aliasCfg.Defaults = nil
+	if !reflect.DeepEqual(defaults, aliasDefaultsV10{}) {
+		aliasCfg.Defaults = &defaults
+	}
+
+	conf.Aliases[alias] = aliasCfg
+	fatalIf(saveMcConfig(conf).Trace(alias), "Unable to update hosts in config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasDefaultsMessage{op: "set", Alias: alias, Profile: aliasCfg.Profile, Defaults: resolveAliasOptions(conf, aliasCfg)})
+	return nil
+}
+
+// aliasDefaultsGlobalFlags are the global flags of the commands taking
+// aliasDefaultsFlags.
+func aliasDefaultsGlobalFlags() []cli.Flag {
+	var flags []cli.Flag
+	for _, flag := range globalFlags {
+		switch flag.GetName() {
+		case "insecure", "resolve", "limit-upload", "limit-download", "conn-read-deadline", "conn-write-deadline":
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// aliasDefaultsFromContext overrides the options of d given on the
+// command line.
+func aliasDefaultsFromContext(ctx *cli.Context, d aliasDefaultsV10) aliasDefaultsV10 {
+	if ctx.IsSet("insecure") {
+		insecure := ctx.Bool("insecure")
+		d.Insecure = &insecure
+	}
+	if ctx.IsSet("resolve") {
+		d.Resolve = ctx.StringSlice("resolve")
+	}
+	if ctx.IsSet("ca-certs") {
+		d.CACerts = ctx.String("ca-certs")
+		if d.CACerts != "" {
+			abs, e := filepath.Abs(d.CACerts)
+			fatalIf(probe.NewError(e), "Unable to resolve `"+d.CACerts+"`.")
+			d.CACerts = abs
+		}
+	}
+	for name, field := range map[string]*string{
+		"limit-upload":        &d.LimitUpload,
+		"limit-download":      &d.LimitDownload,
+		"conn-read-deadline":  &d.ConnReadDeadline,
+		"conn-write-deadline": &d.ConnWriteDeadline,
+		"enc-kms":             &d.EncKMS,
+		"checksum":            &d.Checksum,
+		"storage-class":       &d.StorageClass,
+	} {
+		if ctx.IsSet(name) {
+			*field = ctx.String(name)
+		}
+	}
+	if ctx.IsSet("enc-s3") {
+		encS3 := ctx.Bool("enc-s3")
+		d.EncS3 = &encS3
+	}
+	return d
+}
+
+// merge returns d with the options set in o overriding its own.
+func (d aliasDefaultsV10) merge(o *aliasDefaultsV10) aliasDefaultsV10 {
+	if o == nil {
+		return d
+	}
+	if o.Insecure != nil {
+		d.Insecure = o.Insecure
+	}
+	// The encryption of the alias replaces the one of the profile.
+	if o.EncKMS != "" || o.EncS3 != nil {
+		d.EncKMS, d.EncS3 = "", nil
+	}
+	if o.EncS3 != nil {
+		d.EncS3 = o.EncS3
+	}
+	if len(o.Resolve) > 0 {
+		d.Resolve = o.Resolve
+	}
+	for dst, src := range map[*string]string{
+		&d.CACerts:           o.CACerts,
+		&d.LimitUpload:       o.LimitUpload,
+		&d.LimitDownload:     o.LimitDownload,
+		&d.ConnReadDeadline:  o.ConnReadDeadline,
+		&d.ConnWriteDeadline: o.ConnWriteDeadline,
+		&d.EncKMS:            o.EncKMS,
+		&d.Checksum:          o.Checksum,
+		&d.StorageClass:      o.StorageClass,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+	return d
+}
+
+// isTrue returns true if the optional boolean b is set and true.
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// fmtOptionalBool formats an optional boolean, the empty string when unset.
+func fmtOptionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprint(*b)
+}
+
+// resolveAliasOptions returns the default options of an alias, from
+// its profile overridden by its own defaults.
+func resolveAliasOptions(conf *configV10, aliasCfg aliasConfigV10) *aliasDefaultsV10 {
+	if aliasCfg.Profile == "" && aliasCfg.Defaults == nil {
+		return nil
+	}
+	options := conf.Profiles[aliasCfg.Profile].merge(aliasCfg.Defaults)
+	return &options
+}
+
+// parseResolveEntry parses a HOST[:PORT]=IP pair, very similar to
+// cURL's syntax.
+func parseResolveEntry(entry string) (string, netip.Addr, error) {
+	i := strings.IndexByte(entry, '=')
+	if i < 0 {
+		return "", netip.Addr{}, fmt.Errorf("invalid DNS resolve entry %s", entry)
+	}
+	if strings.ContainsRune(entry[:i], ':') {
+		if _, _, err := net.SplitHostPort(entry[:i]); err != nil {
+			return "", netip.Addr{}, fmt.Errorf("invalid DNS resolve entry %s: %v", entry, err)
+		}
+	}
+	addr, err := netip.ParseAddr(entry[i+1:])
+	if err != nil {
+		return "", netip.Addr{}, fmt.Errorf("invalid DNS resolve entry %s: %v", entry, err)
+	}
+	return entry[:i], addr, nil
+}
+
+// validate checks that all the options can be used.
+func (d *aliasDefaultsV10) validate() *probe.Error {
+	for _, entry := range d.Resolve {
+		if _, _, e := parseResolveEntry(entry); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	if d.CACerts != "" {
+		if _, err := aliasRootCAs(d.CACerts); err != nil {
+			return err
+		}
+	}
+	for _, limit := range []string{d.LimitUpload, d.LimitDownload} {
+		if limit != "" {
+			if _, e := humanize.ParseBytes(limit); e != nil {
+				return probe.NewError(e).Trace(limit)
+			}
+		}
+	}
+	for _, deadline := range []string{d.ConnReadDeadline, d.ConnWriteDeadline} {
+		if deadline != "" {
+			if _, e := time.ParseDuration(deadline); e != nil {
+				return probe.NewError(e).Trace(deadline)
+			}
+		}
+	}
+	switch strings.ToUpper(d.Checksum) {
+	case "", "MD5", "CRC32", "CRC32C", "SHA1", "SHA256":
+	default:
+		return probe.NewError(fmt.Errorf("unknown checksum type: %s. Should be one of MD5, CRC32, CRC32C, SHA1 or SHA256", d.Checksum))
+	}
+	if d.EncKMS != "" && isTrue(d.EncS3) {
+		return probe.NewError(fmt.Errorf("only one of SSE-KMS and SSE-S3 can be the default"))
+	}
+	return nil
+}
+
+// Certificate pools of the CA bundles of the aliases.
+var aliasRootCAsCache sync.Map
+
+// aliasRootCAs returns the root CAs of mc with the certificates of the
+// PEM bundle file added.
+func aliasRootCAs(file string) (*x509.CertPool, *probe.Error) {
+	if pool, ok := aliasRootCAsCache.Load(file); ok {
+		return pool.(*x509.CertPool), nil
+	}
+	data, e := os.ReadFile(file)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(file)
+	}
+	var pool *x509.CertPool
+	if globalRootCAs != nil {
+		pool = globalRootCAs.Clone()
+	} else if pool, e = x509.SystemCertPool(); e != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, probe.NewError(fmt.Errorf("no PEM certificates found in `%s`", file))
+	}
+	aliasRootCAsCache.Store(file, pool)
+	return pool, nil
+}
+
+// apply sets the connection options of the defaults which are not
+// given on the command line in config.
+func (d *aliasDefaultsV10) apply(config *Config) {
+	if d == nil {
+		return
+	}
+	if !globalInsecureSet && d.Insecure != nil {
+		config.Insecure = *d.Insecure
+	}
+	for _, entry := range d.Resolve {
+		host, addr, e := parseResolveEntry(entry)
+		if e != nil {
+			continue
+		}
+		if config.Resolvers == nil {
+			config.Resolvers = make(map[string]netip.Addr)
+		}
+		config.Resolvers[host] = addr
+	}
+	if d.CACerts != "" {
+		pool, err := aliasRootCAs(d.CACerts)
+		fatalIf(err.Trace(config.Alias), "Unable to load the CA certificates of `"+config.Alias+"`.")
+		config.RootCAs = pool
+	}
+	if config.UploadLimit == 0 && d.LimitUpload != "" {
+		limit, _ := humanize.ParseBytes(d.LimitUpload)
+		config.UploadLimit = int64(limit)
+	}
+	if config.DownloadLimit == 0 && d.LimitDownload != "" {
+		limit, _ := humanize.ParseBytes(d.LimitDownload)
+		config.DownloadLimit = int64(limit)
+	}
+	if !globalConnReadDeadlineSet && d.ConnReadDeadline != "" {
+		config.ConnReadDeadline, _ = time.ParseDuration(d.ConnReadDeadline)
+	}
+	if !globalConnWriteDeadlineSet && d.ConnWriteDeadline != "" {
+		config.ConnWriteDeadline, _ = time.ParseDuration(d.ConnWriteDeadline)
+	}
+}
+
+// addAliasSSEDefaults adds the default server-side encryption of the
+// aliases given in args to encMap. Keys given on the command line take
+// precedence since getSSE returns the first matching prefix.
+func addAliasSSEDefaults(args []string, encMap map[string][]prefixSSEPair) *probe.Error {
+	for _, arg := range args {
+		alias, _ := url2Alias(arg)
+		aliasCfg := mustGetHostConfig(alias)
+		if aliasCfg == nil || aliasCfg.options == nil {
+			continue
+		}
+		prefix := alias + "/"
+		if pairs := encMap[alias]; len(pairs) > 0 && pairs[len(pairs)-1].Prefix == prefix {
+			continue
+		}
+		var sse encrypt.ServerSide
+		switch {
+		case aliasCfg.options.EncKMS != "":
+			var e error
+			if sse, e = encrypt.NewSSEKMS(aliasCfg.options.EncKMS, nil); e != nil {
+				return probe.NewError(e).Trace(alias)
+			}
+		case isTrue(aliasCfg.options.EncS3):
+			sse = encrypt.NewSSE()
+		default:
+			continue
+		}
+		encMap[alias] = append(encMap[alias], prefixSSEPair{Prefix: prefix, SSE: sse})
+	}
+	return nil
+}
+
+// applyAliasFlagDefaults sets the checksum and storage class flags of
+// an upload command, unless they are given, to the defaults of the
+// alias of the target, the last argument.
+func applyAliasFlagDefaults(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) == 0 {
+		return
+	}
+	checksum, storageClass := aliasUploadDefaults(ctx, args[len(args)-1])
+	if checksum != "" {
+		ctx.Set("checksum", checksum)
+	}
+	if storageClass != "" {
+		ctx.Set("storage-class", storageClass)
+	}
+}
+
+// aliasUploadDefaults returns the checksum and storage class defaults
+// of the alias of target which are not overridden on the command line.
+func aliasUploadDefaults(ctx *cli.Context, target string) (checksum, storageClass string) {
+	alias, _ := url2Alias(target)
+	aliasCfg := mustGetHostConfig(alias)
+	if aliasCfg == nil || aliasCfg.options == nil {
+		return "", ""
+	}
+	if !ctx.IsSet("checksum") && !ctx.Bool("md5") {
+		checksum = aliasCfg.options.Checksum
+	}
+	if !ctx.IsSet("storage-class") {
+		storageClass = aliasCfg.options.StorageClass
+	}
+	return checksum, storageClass
+}
+
+// aliasDefaultsMessage shows the default options of an alias or a
+// profile.
+type aliasDefaultsMessage struct {
+	op       string
+	Status   string            `json:"status"`
+	Alias    string            `json:"alias,omitempty"`
+	Profile  string            `json:"profile,omitempty"`
+	Defaults *aliasDefaultsV10 `json:"defaults,omitempty"`
+}
+
+func (m aliasDefaultsMessage) String() string {
+	switch m.op {
+	case "set":
+		if m.Alias != "" {
+			return console.Colorize("AliasMessage", "Updated the defaults of `"+m.Alias+"` successfully.")
+		}
+		return console.Colorize("AliasMessage", "Set profile `"+m.Profile+"` successfully.")
+	case "remove":
+		return console.Colorize("AliasMessage", "Removed profile `"+m.Profile+"` successfully.")
+	}
+
+	var b strings.Builder
+	if m.Alias != "" {
+		fmt.Fprintln(&b, console.Colorize("Alias", m.Alias))
+		if m.Profile != "" {
+			fmt.Fprintf(&b, "  %-20s: %s\n", "Profile", m.Profile)
+		}
+	} else {
+		fmt.Fprintln(&b, console.Colorize("Alias", m.Profile))
+	}
+	if d := m.Defaults; d != nil {
+		for _, row := range [][2]string{
+			{"Insecure", fmtOptionalBool(d.Insecure)},
+			{"Resolve", strings.Join(d.Resolve, ", ")},
+			{"CA certificates", d.CACerts},
+			{"Upload limit", d.LimitUpload},
+			{"Download limit", d.LimitDownload},
+			{"Read deadline", d.ConnReadDeadline},
+			{"Write deadline", d.ConnWriteDeadline},
+			{"SSE-KMS key", d.EncKMS},
+			{"SSE-S3", fmtOptionalBool(d.EncS3)},
+			{"Checksum", d.Checksum},
+			{"Storage class", d.StorageClass},
+		} {
+			if row[1] != "" {
+				fmt.Fprintf(&b, "  %-20s: %s\n", row[0], row[1])
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m aliasDefaultsMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAliasDefaults(t *testing.T) {
+	yes, no := true, false
+	profile := aliasDefaultsV10{
+		EncS3:        &yes,
+		Resolve:      []string{"minio.lab:9000=10.0.0.5"},
+		LimitUpload:  "1MiB",
+		Checksum:     "CRC32C",
+		StorageClass: "STANDARD",
+	}
+	defaults := &aliasDefaultsV10{
+		Insecure:         &yes,
+		EncKMS:           "key",
+		ConnReadDeadline: "30s",
+		StorageClass:     "REDUCED_REDUNDANCY",
+	}
+	options := profile.merge(defaults)
+	want := aliasDefaultsV10{
+		Insecure:         &yes,
+		EncKMS:           "key",
+		Resolve:          []string{"minio.lab:9000=10.0.0.5"},
+		LimitUpload:      "1MiB",
+		ConnReadDeadline: "30s",
+		Checksum:         "CRC32C",
+		StorageClass:     "REDUCED_REDUNDANCY",
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Fatalf("got %+v, want %+v", options, want)
+	}
+	if err := options.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{UploadLimit: 2048}
+	options.apply(config)
+	if !config.Insecure || config.UploadLimit != 2048 || config.ConnReadDeadline != 30*time.Second {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if addr := config.Resolvers["minio.lab:9000"]; addr != netip.MustParseAddr("10.0.0.5") {
+		t.Errorf("got resolver %v", addr)
+	}
+
+	// --insecure=false on the command line takes precedence.
+	defer func(set bool) { globalInsecureSet = set }(globalInsecureSet)
+	globalInsecureSet = true
+	config = &Config{}
+	options.apply(config)
+	if config.Insecure {
+		t.Errorf("expected --insecure=false to override the alias default")
+	}
+
+	// An alias turns off the options of its profile.
+	profile.Insecure = &yes
+	options = profile.merge(&aliasDefaultsV10{Insecure: &no, EncS3: &no})
+	if isTrue(options.Insecure) || isTrue(options.EncS3) {
+		t.Errorf("expected insecure and SSE-S3 turned off, got %+v", options)
+	}
+
+	for _, invalid := range []aliasDefaultsV10{
+		{Resolve: []string{"minio.lab"}},
+		{LimitUpload: "fast"},
+		{ConnWriteDeadline: "soon"},
+		{Checksum: "CRC64"},
+		{EncKMS: "key", EncS3: &yes},
+		{CACerts: "/nonexistent/ca.pem"},
+	} {
+		if invalid.validate() == nil {
+			t.Errorf("%+v: expected an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasGroupListCmd = cli.Command{
+	Name:            "list",
+	ShortName:       "ls",
+	Usage:           "list groups of aliases",
+	Action:          mainAliasGroupList,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [GROUP]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all groups.
+     {{.Prompt}} {{.HelpName}}
+
+  2. List the aliases of the "prod" group.
+     {{.Prompt}} {{.HelpName}} prod
+`,
+}
+
+func mainAliasGroupList(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Incorrect number of arguments for alias group list command.")
+	}
+	console.SetColor("Alias", color.New(color.FgCyan, color.Bold))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+
+	if group := ctx.Args().First(); group != "" {
+		aliases, ok := conf.Groups[group]
+		if !ok {
+			fatalIf(errInvalidArgument().Trace(group), "No such group `"+group+"` found.")
+		}
+		printMsg(aliasGroupMessage{op: "list", Group: group, Aliases: aliases})
+		return nil
+	}
+
+	groups := make([]string, 0, len(conf.Groups))
+	for group := range conf.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		printMsg(aliasGroupMessage{op: "list", Group: group, Aliases: conf.Groups[group]})
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasGroupSubcommands = []cli.Command{
+	aliasGroupSetCmd,
+	aliasGroupListCmd,
+	aliasGroupRemoveCmd,
+}
+
+var aliasGroupCmd = cli.Command{
+	Name:            "group",
+	Usage:           "manage groups of aliases, used as @GROUP by any command",
+	Action:          mainAliasGroup,
+	Before:          setGlobalsFromContext,
+	HideHelpCommand: true,
+	Flags:           globalFlags,
+	Subcommands:     aliasGroupSubcommands,
+}
+
+func mainAliasGroup(ctx *cli.Context) error {
+	commandNotFound(ctx, aliasGroupSubcommands)
+	return nil
+}
+
+// aliasGroupMessage is a group of aliases.
+type aliasGroupMessage struct {
+	op      string
+	Status  string   `json:"status"`
+	Group   string   `json:"group"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+func (g aliasGroupMessage) String() string {
+	switch g.op {
+	case "list":
+		return console.Colorize("Alias", g.Group) + ": " + strings.Join(g.Aliases, ", ")
+	case "remove":
+		return console.Colorize("AliasMessage", "Removed group `"+g.Group+"` successfully.")
+	default:
+		return console.Colorize("AliasMessage", "Set group `"+g.Group+"` to "+strings.Join(g.Aliases, ", ")+" successfully.")
+	}
+}
+
+func (g aliasGroupMessage) JSON() string {
+	g.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(g, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// checkAliasGroupName fails if name is not a valid group name.
+func checkAliasGroupName(name string) {
+	if !isValidAlias(name) {
+		fatalIf(errInvalidArgument().Trace(name), "Invalid group name `"+name+"`.")
+	}
+}
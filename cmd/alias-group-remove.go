@@ -0,0 +1,67 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasGroupRemoveCmd = cli.Command{
+	Name:            "remove",
+	ShortName:       "rm",
+	Usage:           "remove a group of aliases",
+	Action:          mainAliasGroupRemove,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} GROUP
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Remove the "prod" group, its aliases are kept.
+     {{.Prompt}} {{.HelpName}} prod
+`,
+}
+
+func mainAliasGroupRemove(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Incorrect number of arguments for alias group remove command.")
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	group := ctx.Args().First()
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	if _, ok := conf.Groups[group]; !ok {
+		fatalIf(errInvalidArgument().Trace(group), "No such group `"+group+"` found.")
+	}
+	delete(conf.Groups, group)
+	fatalIf(saveMcConfig(conf).Trace(group), "Unable to update groups in config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasGroupMessage{op: "remove", Group: group})
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+// aliasGroupRun is the run of a command against one alias of a group.
+type aliasGroupRun struct {
+	Alias string
+	Args  []string
+}
+
+// expandAliasGroupArgs returns one run per alias of the group given as
+// @GROUP in args. It returns no runs if args reference no group.
+// Arguments of 'alias' commands are never expanded.
+func expandAliasGroupArgs(args []string, groups map[string][]string) ([]aliasGroupRun, *probe.Error) {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			if arg == "alias" {
+				return nil, nil
+			}
+			break
+		}
+	}
+
+	var group string
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "@"), "/")
+		if !strings.HasPrefix(arg, "@") || groups[name] == nil {
+			continue
+		}
+		if group != "" && group != name {
+			return nil, errInvalidArgument().Trace(group, name)
+		}
+		group = name
+	}
+	if group == "" {
+		return nil, nil
+	}
+
+	runs := make([]aliasGroupRun, 0, len(groups[group]))
+	for _, alias := range groups[group] {
+		run := aliasGroupRun{Alias: alias, Args: make([]string, len(args))}
+		for i, arg := range args {
+			run.Args[i] = arg
+			if arg == "@"+group || strings.HasPrefix(arg, "@"+group+"/") {
+				run.Args[i] = alias + strings.TrimPrefix(arg, "@"+group)
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// aliasGroupRunMessage is the outcome of the run against one alias.
+type aliasGroupRunMessage struct {
+	Status string `json:"status"`
+	Alias  string `json:"alias"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (m aliasGroupRunMessage) String() string {
+	if m.Error != "" {
+		return console.Colorize("AliasGroupFailed", fmt.Sprintf("%s: failed, %s", m.Alias, m.Error))
+	}
+	return console.Colorize("AliasGroupSuccess", m.Alias+": succeeded")
+}
+
+func (m aliasGroupRunMessage) JSON() string {
+	m.Status = "success"
+	if m.Error != "" {
+		m.Status = "error"
+	}
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// aliasGroupOutput serializes the labeled output of concurrent runs.
+type aliasGroupOutput struct {
+	mu    sync.Mutex
+	width int
+	json  bool
+}
+
+func (o *aliasGroupOutput) label(alias string) string {
+	return console.Colorize("Alias", fmt.Sprintf("%-*s", o.width, alias)) + " | "
+}
+
+// copyLines prefixes each line of r with the label of alias.
+func (o *aliasGroupOutput) copyLines(w io.Writer, r io.Reader, alias string) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		o.mu.Lock()
+		fmt.Fprintln(w, o.label(alias)+scanner.Text())
+		o.mu.Unlock()
+	}
+}
+
+// copyJSON adds the alias to each JSON object of r which has none.
+// Anything which is not JSON is labeled like text.
+func (o *aliasGroupOutput) copyJSON(w io.Writer, r io.Reader, alias string) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	aliasField, _ := json.Marshal(alias)
+	for {
+		var raw json.RawMessage
+		if e := dec.Decode(&raw); e != nil {
+			if e != io.EOF {
+				rest, _ := io.ReadAll(dec.Buffered())
+				o.copyLines(w, io.MultiReader(bytes.NewReader(bytes.TrimLeft(rest, " \t\r\n")), br), alias)
+			}
+			return
+		}
+		raw = bytes.TrimSpace(raw)
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) == nil && fields["alias"] == nil {
+			labeled := append([]byte(`{"alias":`), aliasField...)
+			if !bytes.Equal(bytes.TrimSpace(raw[1:]), []byte("}")) {
+				labeled = append(labeled, ',')
+			}
+			raw = append(labeled, raw[1:]...)
+		}
+		var out bytes.Buffer
+		if json.Indent(&out, raw, "", " ") != nil {
+			out.Reset()
+			out.Write(raw)
+		}
+		o.mu.Lock()
+		fmt.Fprintln(w, out.String())
+		o.mu.Unlock()
+	}
+}
+
+// runAliasGroup runs this command once per alias concurrently, prints
+// their labeled output followed by a summary and returns the exit code.
+func runAliasGroup(runs []aliasGroupRun, jsonOutput bool) int {
+	console.SetColor("Alias", color.New(color.FgCyan, color.Bold))
+	console.SetColor("AliasGroupSuccess", color.New(color.FgGreen))
+	console.SetColor("AliasGroupFailed", color.New(color.FgRed))
+
+	exe, e := os.Executable()
+	fatalIf(probe.NewError(e), "Unable to find the mc executable.")
+
+	out := &aliasGroupOutput{json: jsonOutput}
+	for _, run := range runs {
+		out.width = max(out.width, len(run.Alias))
+	}
+
+	msgs := make([]aliasGroupRunMessage, len(runs))
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		msgs[i].Alias = run.Alias
+		wg.Add(1)
+		go func(i int, run aliasGroupRun) {
+			defer wg.Done()
+			if e := out.run(exe, run); e != nil {
+				msgs[i].Error = e.Error()
+			}
+		}(i, run)
+	}
+	wg.Wait()
+
+	code := 0
+	for _, msg := range msgs {
+		if msg.Error != "" {
+			code = globalErrorExitStatus
+		}
+		printMsg(msg)
+	}
+	return code
+}
+
+func (o *aliasGroupOutput) run(exe string, run aliasGroupRun) error {
+	cmd := exec.CommandContext(globalContext, exe, run.Args...)
+	stdout, e := cmd.StdoutPipe()
+	if e != nil {
+		return e
+	}
+	stderr, e := cmd.StderrPipe()
+	if e != nil {
+		return e
+	}
+	if e = cmd.Start(); e != nil {
+		return e
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if o.json {
+			o.copyJSON(os.Stdout, stdout, run.Alias)
+		} else {
+			o.copyLines(os.Stdout, stdout, run.Alias)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		o.copyLines(os.Stderr, stderr, run.Alias)
+	}()
+	wg.Wait()
+	return cmd.Wait()
+}
+
+// mainAliasGroupRun runs the command against each alias of the group
+// referenced in args and exits, if there is one.
+func mainAliasGroupRun(args []string) {
+	conf, err := loadMcConfig()
+	if err != nil || len(conf.Groups) == 0 {
+		return
+	}
+	runs, err := expandAliasGroupArgs(args, conf.Groups)
+	fatalIf(err, "Only one group can be used by a command.")
+	if len(runs) == 0 {
+		return
+	}
+	jsonOutput := globalJSON
+	for _, arg := range args {
+		if arg == "--json" {
+			jsonOutput = true
+		}
+	}
+	os.Exit(runAliasGroup(runs, jsonOutput))
+}
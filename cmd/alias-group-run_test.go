@@ -0,0 +1,76 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandAliasGroupArgs(t *testing.T) {
+	groups := map[string][]string{
+		"prod": {"us-east", "eu-west"},
+		"dev":  {"play"},
+	}
+	testCases := []struct {
+		args []string
+		runs []aliasGroupRun
+		fail bool
+	}{
+		{args: []string{"ls", "myminio/bucket"}},
+		{args: []string{"ls", "@unknown/bucket"}},
+		{args: []string{"alias", "group", "set", "@prod"}},
+		{
+			args: []string{"--json", "mirror", "@prod/src", "@prod/dst"},
+			runs: []aliasGroupRun{
+				{Alias: "us-east", Args: []string{"--json", "mirror", "us-east/src", "us-east/dst"}},
+				{Alias: "eu-west", Args: []string{"--json", "mirror", "eu-west/src", "eu-west/dst"}},
+			},
+		},
+		{
+			args: []string{"admin", "info", "@dev", "@products"},
+			runs: []aliasGroupRun{{Alias: "play", Args: []string{"admin", "info", "play", "@products"}}},
+		},
+		{args: []string{"cp", "@prod/a", "@dev/b"}, fail: true},
+	}
+	for i, tc := range testCases {
+		runs, err := expandAliasGroupArgs(tc.args, groups)
+		if (err != nil) != tc.fail {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(runs, tc.runs) {
+			t.Errorf("case %d: got %+v, want %+v", i, runs, tc.runs)
+		}
+	}
+}
+
+func TestAliasGroupOutputJSON(t *testing.T) {
+	var b bytes.Buffer
+	o := &aliasGroupOutput{json: true}
+	input := "{\n \"status\": \"success\",\n \"size\": 1\n}\n{\"alias\":\"other\"}\n{}\nnot json\n"
+	o.copyJSON(&b, strings.NewReader(input), "us-east")
+	want := "{\n \"alias\": \"us-east\",\n \"status\": \"success\",\n \"size\": 1\n}\n" +
+		"{\n \"alias\": \"other\"\n}\n" +
+		"{\n \"alias\": \"us-east\"\n}\n" +
+		"us-east | not json\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasGroupSetCmd = cli.Command{
+	Name:            "set",
+	ShortName:       "s",
+	Usage:           "create or replace a group of aliases",
+	Action:          mainAliasGroupSet,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} GROUP ALIAS [ALIAS...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Any command given @GROUP in place of an alias runs once for each alias of the
+  group, concurrently. The output of each run is labeled with its alias and a
+  summary of which aliases succeeded is printed at the end.
+
+EXAMPLES:
+  1. Group three clusters as "prod".
+     {{.Prompt}} {{.HelpName}} prod us-east eu-west ap-south
+
+  2. Show the server information of all the clusters of "prod".
+     {{.Prompt}} mc admin info @prod
+
+  3. Create the same bucket on all the clusters of "prod".
+     {{.Prompt}} mc mb @prod/reports
+`,
+}
+
+func checkAliasGroupSetSyntax(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	checkAliasGroupName(args.First())
+	seen := make(map[string]bool)
+	for _, alias := range args.Tail() {
+		alias = cleanAlias(alias)
+		if mustGetHostConfig(alias) == nil {
+			fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
+		}
+		if seen[alias] {
+			fatalIf(errInvalidArgument().Trace(alias), "Alias `"+alias+"` is given more than once.")
+		}
+		seen[alias] = true
+	}
+}
+
+func mainAliasGroupSet(ctx *cli.Context) error {
+	checkAliasGroupSetSyntax(ctx)
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	group := ctx.Args().First()
+	var aliases []string
+	for _, alias := range ctx.Args().Tail() {
+		aliases = append(aliases, cleanAlias(alias))
+	}
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	if conf.Groups == nil {
+		conf.Groups = make(map[string][]string)
+	}
+	conf.Groups[group] = aliases
+	fatalIf(saveMcConfig(conf).Trace(group), "Unable to update groups in config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasGroupMessage{op: "set", Group: group, Aliases: aliases})
+	return nil
+}
@@ -88,14 +88,18 @@ func checkCredentialsSyntax(credentials aliasConfigV10) {
 		fatalIf(errInvalidURL(credentials.URL), "Invalid URL.")
 	}
 
-	if !isValidAccessKey(credentials.AccessKey) {
-		fatalIf(errInvalidArgument().Trace(credentials.AccessKey),
-			"Invalid access key `"+credentials.AccessKey+"`.")
-	}
-
-	if !isValidSecretKey(credentials.SecretKey) {
-		fatalIf(errInvalidArgument().Trace(),
-			"Invalid secret key.")
+	if credentials.Credentials != nil {
+		fatalIf(credentials.Credentials.validate(), "Invalid credential source.")
+	} else {
+		if !isValidAccessKey(credentials.AccessKey) {
+			fatalIf(errInvalidArgument().Trace(credentials.AccessKey),
+				"Invalid access key `"+credentials.AccessKey+"`.")
+		}
+
+		if !isValidSecretKey(credentials.SecretKey) {
+			fatalIf(errInvalidArgument().Trace(),
+				"Invalid secret key.")
+		}
 	}
 
 	if credentials.API != "" && !isValidAPI(credentials.API) { // Empty value set to default "S3v4".
@@ -79,6 +79,7 @@ func mainAliasList(ctx *cli.Context, deprecated bool) error {
 	console.SetColor("API", color.New(color.FgBlue))
 	console.SetColor("Path", color.New(color.FgCyan))
 	console.SetColor("Src", color.New(color.FgCyan))
+	console.SetColor("Credentials", color.New(color.FgCyan))
 
 	alias := cleanAlias(ctx.Args().Get(0))
 
@@ -103,7 +104,7 @@ func printAliases(aliases ...aliasMessage) {
 			// Format properly for alignment based on alias length only in non json mode.
 			alias.Alias = fmt.Sprintf("%-*.*s", maxAlias, maxAlias, alias.Alias)
 		}
-		if alias.AccessKey == "" || alias.SecretKey == "" {
+		if alias.Credentials == "" && (alias.AccessKey == "" || alias.SecretKey == "") {
 			alias.AccessKey = ""
 			alias.SecretKey = ""
 			alias.API = ""
@@ -129,6 +130,9 @@ func buildAliasMessage(alias string, deprecated bool, aliasCfg *aliasConfigV10)
 		API:         aliasCfg.API,
 		Src:         aliasCfg.Src,
 	}
+	if aliasCfg.Credentials != nil {
+		aliasMsg.Credentials = aliasCfg.Credentials.String()
+	}
 
 	if deprecated {
 		aliasMsg.Lookup = aliasCfg.Path
@@ -43,6 +43,9 @@ var aliasSubcommands = []cli.Command{
 	aliasRemoveCmd,
 	aliasImportCmd,
 	aliasExportCmd,
+	aliasGroupCmd,
+	aliasProfileCmd,
+	aliasDefaultsCmd,
 }
 
 var aliasCmd = cli.Command{
@@ -74,6 +77,7 @@ type aliasMessage struct {
 	API         string `json:"api,omitempty"`
 	Path        string `json:"path,omitempty"`
 	Src         string `json:"src,omitempty"`
+	Credentials string `json:"credentials,omitempty"`
 	// Deprecated field, replaced by Path
 	Lookup string `json:"lookup,omitempty"`
 }
@@ -84,6 +88,22 @@ type aliasMessage struct {
 func (h aliasMessage) String() string {
 	switch h.op {
 	case "list":
+		// Handle deprecated lookup
+		path := h.Path
+		if path == "" {
+			path = h.Lookup
+		}
+		if h.Credentials != "" {
+			t := newPrettyRecord(2,
+				Row{"Alias", "Alias"},
+				Row{"URL", "URL"},
+				Row{"Credentials", "Credentials"},
+				Row{"API", "API"},
+				Row{"Path", "Path"},
+				Row{"Src", "Src"},
+			)
+			return t.buildRecord(h.Alias, h.URL, h.Credentials, h.API, path, h.Src)
+		}
 		// Create a new pretty table with cols configuration
 		t := newPrettyRecord(2,
 			Row{"Alias", "Alias"},
@@ -94,11 +114,6 @@ func (h aliasMessage) String() string {
 			Row{"Path", "Path"},
 			Row{"Src", "Src"},
 		)
-		// Handle deprecated lookup
-		path := h.Path
-		if path == "" {
-			path = h.Lookup
-		}
 		return t.buildRecord(h.Alias, h.URL, h.AccessKey, h.SecretKey, h.API, path, h.Src)
 	case "remove":
 		return console.Colorize("AliasMessage", "Removed `"+h.Alias+"` successfully.")
@@ -0,0 +1,81 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// # This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasProfileListCmd = cli.Command{
+	Name:            "list",
+	ShortName:       "ls",
+	Usage:           "list profiles of default options",
+	Action:          mainAliasProfileList,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [PROFILE]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all profiles.
+     {{.Prompt}} {{.HelpName}}
+
+  2. Show the options of the "lab" profile.
+     {{.Prompt}} {{.HelpName}} lab
+`,
+}
+
+func mainAliasProfileList(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Incorrect number of arguments for alias profile list command.")
+	}
+	console.SetColor("Alias", color.New(color.FgCyan, color.Bold))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+
+	profiles := make([]string, 0, len(conf.Profiles))
+	if profile := ctx.Args().First(); profile != "" {
+		if _, ok := conf.Profiles[profile]; !ok {
+			fatalIf(errInvalidArgument().Trace(profile), "No such profile `"+profile+"` found.")
+		}
+		profiles = append(profiles, profile)
+	} else {
+		for profile := range conf.Profiles {
+			profiles = append(profiles, profile)
+		}
+		sort.Strings(profiles)
+	}
+	for _, profile := range profiles {
+		defaults := conf.Profiles[profile]
+		printMsg(aliasDefaultsMessage{op: "list", Profile: profile, Defaults: &defaults})
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// # This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+)
+
+var aliasProfileSubcommands = []cli.Command{
+	aliasProfileSetCmd,
+	aliasProfileListCmd,
+	aliasProfileRemoveCmd,
+}
+
+var aliasProfileCmd = cli.Command{
+	Name:            "profile",
+	Usage:           "manage named profiles of default options shared by aliases",
+	Action:          mainAliasProfile,
+	Before:          setGlobalsFromContext,
+	HideHelpCommand: true,
+	Flags:           globalFlags,
+	Subcommands:     aliasProfileSubcommands,
+}
+
+func mainAliasProfile(ctx *cli.Context) error {
+	commandNotFound(ctx, aliasProfileSubcommands)
+	return nil
+}
+
+// checkAliasProfileName fails if name is not a valid profile name.
+func checkAliasProfileName(name string) {
+	if !isValidAlias(name) {
+		fatalIf(errInvalidArgument().Trace(name), "Invalid profile name `"+name+"`.")
+	}
+}
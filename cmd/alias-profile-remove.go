@@ -0,0 +1,82 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// # This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasProfileRemoveCmd = cli.Command{
+	Name:            "remove",
+	ShortName:       "rm",
+	Usage:           "remove a profile of default options",
+	Action:          mainAliasProfileRemove,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} PROFILE
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Remove the "lab" profile, which no alias may use.
+     {{.Prompt}} {{.HelpName}} lab
+`,
+}
+
+func mainAliasProfileRemove(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Incorrect number of arguments for alias profile remove command.")
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	profile := ctx.Args().First()
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+	if _, ok := conf.Profiles[profile]; !ok {
+		fatalIf(errInvalidArgument().Trace(profile), "No such profile `"+profile+"` found.")
+	}
+
+	var users []string
+	for alias, aliasCfg := range conf.Aliases {
+		if aliasCfg.Profile == profile {
+			users = append(users, alias)
+		}
+	}
+	if len(users) > 0 {
+		sort.Strings(users)
+		fatalIf(errInvalidArgument().Trace(profile), "Profile `"+profile+"` is used by "+strings.Join(users, ", ")+".")
+	}
+
+	delete(conf.Profiles, profile)
+	fatalIf(saveMcConfig(conf).Trace(profile), "Unable to update profiles in config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasDefaultsMessage{op: "remove", Profile: profile})
+	return nil
+}
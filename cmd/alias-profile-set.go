@@ -0,0 +1,84 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// # This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v3/console"
+)
+
+var aliasProfileSetCmd = cli.Command{
+	Name:            "set",
+	ShortName:       "s",
+	Usage:           "create or update a profile of default options",
+	Action:          mainAliasProfileSet,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           append(aliasDefaultsFlags, aliasDefaultsGlobalFlags()...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} PROFILE [FLAGS]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  A profile bundles default options which aliases using it apply to every
+  command. Options given to an existing profile replace only those options.
+  Flags given on the command line always take precedence over the defaults.
+
+EXAMPLES:
+  1. Create the "lab" profile resolving a host and trusting a private CA.
+     {{.Prompt}} {{.HelpName}} lab --resolve minio.lab:9000=10.0.0.5 --ca-certs ~/lab-ca.pem
+
+  2. Encrypt the uploads of the aliases of the "secure" profile with a KMS key and add a checksum.
+     {{.Prompt}} {{.HelpName}} secure --enc-kms my-key --checksum CRC32C
+
+  3. Use the "lab" profile for the alias "mylab".
+     {{.Prompt}} mc alias defaults mylab --profile lab
+`,
+}
+
+func mainAliasProfileSet(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	profile := ctx.Args().First()
+	checkAliasProfileName(profile)
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
+
+	defaults := aliasDefaultsFromContext(ctx, conf.Profiles[profile])
+	fatalIf(defaults.validate().Trace(profile), "Invalid options for profile `"+profile+"`.")
+
+	if conf.Profiles == nil {
+		conf.Profiles = make(map[string]aliasDefaultsV10)
+	}
+	conf.Profiles[profile] = defaults
+	fatalIf(saveMcConfig(conf).Trace(profile), "Unable to update profiles in config `"+mustGetMcConfigPath()+"`.")
+
+	printMsg(aliasDefaultsMessage{op: "set", Profile: profile, Defaults: &defaults})
+	return nil
+}
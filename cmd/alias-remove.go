@@ -99,6 +99,7 @@ func removeAlias(alias string) aliasMessage {
 
 	// Remove the alias from the config.
 	delete(conf.Aliases, alias)
+	removeSTSCache(alias)
 
 	err = saveMcConfig(conf)
 	fatalIf(err.Trace(alias), "Unable to save the delete alias in config version `"+globalMCConfigVersion+"`.")
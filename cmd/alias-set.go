@@ -26,6 +26,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -49,6 +50,62 @@ var aliasSetFlags = []cli.Flag{
 		Name:  "api",
 		Usage: "API signature. Valid options are '[S3v4, S3v2]'",
 	},
+	cli.StringFlag{
+		Name:  "credential-process",
+		Usage: "retrieve the keys by running a command printing them in the AWS credential_process format",
+	},
+	cli.BoolFlag{
+		Name:  "keyring",
+		Usage: "store the keys in the Secret Service keyring instead of the config file",
+	},
+	cli.StringFlag{
+		Name:  "credentials-file",
+		Usage: "store the keys in a file encrypted with a passphrase instead of the config file",
+	},
+	cli.StringFlag{
+		Name:  "age-identity",
+		Usage: "decrypt the --credentials-file with this age identity file instead of a passphrase",
+	},
+	cli.StringFlag{
+		Name:  "aws-profile",
+		Usage: "retrieve the keys from an AWS CLI profile, including SSO profiles",
+	},
+	cli.StringFlag{
+		Name:  "sts",
+		Usage: "log in with an identity for temporary keys. Valid options are '[ldap, oidc, certificate, web-identity]'",
+	},
+	cli.StringFlag{
+		Name:  "ldap-username",
+		Usage: "LDAP username of --sts ldap, prompted for if not set",
+	},
+	cli.StringFlag{
+		Name:  "oidc-issuer",
+		Usage: "OpenID provider URL of --sts oidc",
+	},
+	cli.StringFlag{
+		Name:  "oidc-client-id",
+		Usage: "client ID of mc at the OpenID provider of --sts oidc",
+	},
+	cli.StringFlag{
+		Name:  "client-cert",
+		Usage: "TLS client certificate of --sts certificate",
+	},
+	cli.StringFlag{
+		Name:  "client-key",
+		Usage: "TLS client private key of --sts certificate",
+	},
+	cli.StringFlag{
+		Name:  "web-identity-token-file",
+		Usage: "file holding the JWT of --sts web-identity, read again at each refresh",
+	},
+	cli.StringFlag{
+		Name:  "role-arn",
+		Usage: "role ARN to assume with --sts oidc and web-identity",
+	},
+	cli.StringFlag{
+		Name:  "sts-duration",
+		Usage: "requested lifetime of the temporary keys, e.g. '12h'",
+	},
 }
 
 var aliasSetCmd = cli.Command{
@@ -71,6 +128,25 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+CREDENTIAL SOURCES:
+  With --credential-process, --keyring, --credentials-file or --aws-profile the keys
+  are not written to the config file. The alias records where to retrieve them from
+  each time mc runs:
+    --credential-process  the command prints {"Version": 1, "AccessKeyId": ..., "SecretAccessKey": ...,
+                          "SessionToken": ..., "Expiration": ...} and runs again once the keys expire.
+    --keyring             the keys are stored with 'secret-tool' under 'service mc alias ALIAS'.
+    --credentials-file    the keys are encrypted with a passphrase, read from MC_CREDENTIALS_PASSPHRASE
+                          or prompted for. With --age-identity the file must already hold the keys in
+                          the credential_process format, encrypted with 'age'.
+    --aws-profile         the keys of a profile of ~/.aws/credentials, or of an SSO profile of
+                          ~/.aws/config after 'aws sso login'.
+    --sts                 temporary keys from the STS API of the server, cached in the mc config
+                          directory and renewed before they expire, also within long-running
+                          commands such as 'mirror --watch'. The LDAP password is read from
+                          MC_LDAP_PASSWORD_<ALIAS> or prompted for. OpenID logins use the device
+                          flow: mc prints a URL to open in any browser. The refresh token is
+                          not saved, the device flow runs again once the cached keys expired.
+
 EXAMPLES:
   1. Add MinIO service under "myminio" alias. For security reasons turn off bash history momentarily.
      {{.DisableHistory}}
@@ -95,6 +171,22 @@ EXAMPLES:
      {{.Prompt}} echo -e "BKIKJAA5BMMU2RHO6IBB\nV8f1CwQqAcwo80UEIJEjc5gVQUSSx5ohQ9GSrr12" | \
                  {{.HelpName}} mys3 https://s3.amazonaws.com --api "s3v4" --path "off"
      {{.EnableHistory}}
+  6. Add MinIO service under "myminio" alias, retrieving the keys from a password manager.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --credential-process "vault-creds minio"
+  7. Add MinIO service under "myminio" alias, keeping the prompted keys in the desktop keyring.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --keyring
+  8. Add MinIO service under "myminio" alias, keeping the prompted keys in an encrypted file.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --credentials-file ~/.mc/myminio.enc
+  9. Add Amazon S3 storage service under "mys3" alias using the "dev" AWS SSO profile.
+     {{.Prompt}} {{.HelpName}} mys3 https://s3.amazonaws.com --aws-profile dev
+  10. Add MinIO service under "myminio" alias, logging in with an LDAP account.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --sts ldap --ldap-username alice
+  11. Add MinIO service under "myminio" alias, logging in with Keycloak.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --sts oidc \
+                 --oidc-issuer https://keycloak.example.com/realms/corp --oidc-client-id mc
+  12. Add MinIO service under "myminio" alias, logging in with a client certificate.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --sts certificate \
+                 --client-cert ~/.mc/certs/client.crt --client-key ~/.mc/certs/client.key
 `,
 }
 
@@ -159,13 +251,19 @@ func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 	mcCfgV10, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
+	// Keep the default options of an alias being replaced.
+	if old, ok := mcCfgV10.Aliases[alias]; ok {
+		aliasCfgV10.Profile = old.Profile
+		aliasCfgV10.Defaults = old.Defaults
+	}
+
 	// Add new host.
 	mcCfgV10.Aliases[alias] = aliasCfgV10
 
 	err = saveMcConfig(mcCfgV10)
 	fatalIf(err.Trace(alias), "Unable to update hosts in config version `"+mustGetMcConfigPath()+"`.")
 
-	return aliasMessage{
+	msg := aliasMessage{
 		Alias:     alias,
 		URL:       aliasCfgV10.URL,
 		AccessKey: aliasCfgV10.AccessKey,
@@ -173,11 +271,15 @@ func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 		API:       aliasCfgV10.API,
 		Path:      aliasCfgV10.Path,
 	}
+	if aliasCfgV10.Credentials != nil {
+		msg.Credentials = aliasCfgV10.Credentials.String()
+	}
+	return msg
 }
 
 // probeS3Signature - auto probe S3 server signature: issue a Stat call
 // using v4 signature then v2 in case of failure.
-func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, peerCert *x509.Certificate) (string, *probe.Error) {
+func probeS3Signature(ctx context.Context, accessKey, secretKey, sessionToken, url string, peerCert *x509.Certificate) (string, *probe.Error) {
 	probeBucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "probe-bsign-")
 	// Test s3 connection for API auto probe
 	s3Config := &Config{
@@ -185,6 +287,7 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 		Insecure:          globalInsecure,
 		AccessKey:         accessKey,
 		SecretKey:         secretKey,
+		SessionToken:      sessionToken,
 		HostURL:           urlJoinPath(url, probeBucketName),
 		Debug:             globalDebug,
 		ConnReadDeadline:  globalConnReadDeadline,
@@ -234,12 +337,13 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 
 // BuildS3Config constructs an S3 Config and does
 // signature auto-probe when needed.
-func BuildS3Config(ctx context.Context, alias, url, accessKey, secretKey, api, path string, peerCert *x509.Certificate) (*Config, *probe.Error) {
+func BuildS3Config(ctx context.Context, alias, url, accessKey, secretKey, sessionToken, api, path string, peerCert *x509.Certificate) (*Config, *probe.Error) {
 	s3Config := NewS3Config(alias, url, &aliasConfigV10{
-		AccessKey: accessKey,
-		SecretKey: secretKey,
-		URL:       url,
-		Path:      path,
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		SessionToken: sessionToken,
+		URL:          url,
+		Path:         path,
 	})
 
 	if peerCert != nil {
@@ -253,7 +357,7 @@ func BuildS3Config(ctx context.Context, alias, url, accessKey, secretKey, api, p
 		return s3Config, nil
 	}
 	// Probe S3 signature version
-	api, err := probeS3Signature(ctx, accessKey, secretKey, url, peerCert)
+	api, err := probeS3Signature(ctx, accessKey, secretKey, sessionToken, url, peerCert)
 	if err != nil {
 		return nil, err.Trace(url, accessKey, api, path)
 	}
@@ -300,6 +404,81 @@ func fetchAliasKeys(args cli.Args) (string, string) {
 	return accessKey, secretKey
 }
 
+// aliasSetCredentialSource returns the credential source requested on
+// the command line, nil if the keys go to the config file.
+func aliasSetCredentialSource(ctx *cli.Context, alias, url string) *credentialSourceV10 {
+	var sources []*credentialSourceV10
+	if sts := ctx.String("sts"); sts != "" {
+		sources = append(sources, &credentialSourceV10{Type: credentialSourceSTS, STS: aliasSetSTSIdentity(ctx, sts, url)})
+	}
+	if command := ctx.String("credential-process"); command != "" {
+		sources = append(sources, &credentialSourceV10{Type: credentialSourceProcess, Command: command})
+	}
+	if ctx.Bool("keyring") {
+		sources = append(sources, &credentialSourceV10{Type: credentialSourceKeyring, Item: alias})
+	}
+	if file := ctx.String("credentials-file"); file != "" {
+		file, e := filepath.Abs(file)
+		fatalIf(probe.NewError(e), "Unable to resolve the credentials file path.")
+		sources = append(sources, &credentialSourceV10{Type: credentialSourceFile, File: file, Identity: ctx.String("age-identity")})
+	} else if ctx.String("age-identity") != "" {
+		fatalIf(errInvalidArgument(), "--age-identity requires --credentials-file.")
+	}
+	if profile := ctx.String("aws-profile"); profile != "" {
+		sources = append(sources, &credentialSourceV10{Type: credentialSourceAWSProfile, Profile: profile})
+	}
+	switch len(sources) {
+	case 0:
+		return nil
+	case 1:
+		return sources[0]
+	}
+	fatalIf(errInvalidArgument(), "Only one of --credential-process, --keyring, --credentials-file, --aws-profile and --sts can be used.")
+	return nil
+}
+
+// aliasSetSTSIdentity returns the STS identity requested on the command
+// line.
+func aliasSetSTSIdentity(ctx *cli.Context, sts, url string) *stsCredentialSourceV10 {
+	identity := &stsCredentialSourceV10{
+		Type:      strings.ToLower(sts),
+		Endpoint:  url,
+		Username:  ctx.String("ldap-username"),
+		Issuer:    ctx.String("oidc-issuer"),
+		ClientID:  ctx.String("oidc-client-id"),
+		TokenFile: ctx.String("web-identity-token-file"),
+		RoleARN:   ctx.String("role-arn"),
+	}
+	if file := identity.TokenFile; file != "" {
+		var e error
+		identity.TokenFile, e = filepath.Abs(file)
+		fatalIf(probe.NewError(e), "Unable to resolve `"+file+"`.")
+	}
+	if cert, key := ctx.String("client-cert"), ctx.String("client-key"); cert != "" || key != "" {
+		var e error
+		identity.Certificate, e = filepath.Abs(cert)
+		fatalIf(probe.NewError(e), "Unable to resolve `"+cert+"`.")
+		identity.Key, e = filepath.Abs(key)
+		fatalIf(probe.NewError(e), "Unable to resolve `"+key+"`.")
+	}
+	if d := ctx.String("sts-duration"); d != "" {
+		duration, e := time.ParseDuration(d)
+		fatalIf(probe.NewError(e).Trace(d), "Invalid --sts-duration.")
+		identity.DurationSeconds = int(duration.Seconds())
+	}
+	if identity.Type == stsLDAP && identity.Username == "" {
+		identity.Username = promptLDAPUsername()
+	}
+	fatalIf(probe.NewError(identity.validate()), "Invalid --sts identity.")
+	return identity
+}
+
+// storesKeys returns true if the keys of the alias are written to the
+// credential source by 'alias set'.
+func (s *credentialSourceV10) storesKeys() bool {
+	return s.Type == credentialSourceKeyring || (s.Type == credentialSourceFile && s.Identity == "")
+}
+
 func mainAliasSet(cli *cli.Context, deprecated bool) error {
 	console.SetColor("AliasMessage", color.New(color.FgGreen))
 	var (
@@ -327,7 +506,21 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 		}
 	}
 
-	accessKey, secretKey := fetchAliasKeys(args)
+	var accessKey, secretKey, sessionToken string
+	source := aliasSetCredentialSource(cli, alias, url)
+	if source == nil || source.storesKeys() {
+		accessKey, secretKey = fetchAliasKeys(args)
+	} else {
+		if len(args) > 2 {
+			fatalIf(errInvalidArgument().Trace(args.Tail()...),
+				"Keys cannot be given with a credential source of type `"+source.Type+"`.")
+		}
+		// Log in again instead of using the keys of an earlier alias.
+		removeSTSCache(alias)
+		creds, err := source.retrieve(alias)
+		fatalIf(err.Trace(alias), "Unable to retrieve the credentials of `"+alias+"`.")
+		accessKey, secretKey, sessionToken = creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken
+	}
 	checkAliasSetSyntax(cli, accessKey, secretKey, deprecated)
 
 	ctx, cancelAliasAdd := context.WithCancel(globalContext)
@@ -338,16 +531,29 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 		fatalIf(err.Trace(alias, url, accessKey), "Unable to initialize new alias from the provided credentials.")
 	}
 
-	s3Config, err := BuildS3Config(ctx, alias, url, accessKey, secretKey, api, path, peerCert)
+	s3Config, err := BuildS3Config(ctx, alias, url, accessKey, secretKey, sessionToken, api, path, peerCert)
 	fatalIf(err.Trace(alias, url, accessKey), "Unable to initialize new alias from the provided credentials.")
 
-	msg := setAlias(alias, aliasConfigV10{
+	aliasCfg := aliasConfigV10{
 		URL:       s3Config.HostURL,
 		AccessKey: s3Config.AccessKey,
 		SecretKey: s3Config.SecretKey,
 		API:       s3Config.Signature,
 		Path:      path,
-	}) // Add an alias with specified credentials.
+	}
+	if source != nil {
+		// Keep the keys out of the config file.
+		creds := processCredentials{AccessKeyID: accessKey, SecretAccessKey: secretKey}
+		switch {
+		case source.Type == credentialSourceKeyring:
+			fatalIf(storeKeyringCredentials(source.Item, creds).Trace(alias), "Unable to store the credentials of `"+alias+"`.")
+		case source.storesKeys():
+			fatalIf(writeCredentialFile(source.File, creds).Trace(alias), "Unable to store the credentials of `"+alias+"`.")
+		}
+		aliasCfg.AccessKey, aliasCfg.SecretKey = "", ""
+		aliasCfg.Credentials = source
+	}
+	msg := setAlias(alias, aliasCfg) // Add an alias with specified credentials.
 
 	msg.op = "set"
 	if deprecated {
@@ -0,0 +1,413 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/pkg/v3/env"
+	"golang.org/x/term"
+)
+
+// Identities of STS credential sources.
+const (
+	stsLDAP        = "ldap"
+	stsOIDC        = "oidc"
+	stsCertificate = "certificate"
+	stsWebIdentity = "web-identity"
+)
+
+// Prefix of the environment variables holding the LDAP password of
+// an alias, prompted for otherwise.
+const mcEnvLDAPPasswordPrefix = "MC_LDAP_PASSWORD_"
+
+// ldapPasswords keeps the LDAP passwords entered during this run, so
+// long-running commands refresh their credentials without prompting.
+// It is guarded by sourcedCredentialsMu.
+var ldapPasswords = map[string]string{}
+
+// oidcRefreshTokens keeps the OpenID refresh tokens of this run. They
+// are long-lived secrets and never written to disk, a new run logs in
+// with the device flow again once the cached credentials expired. It is
+// guarded by sourcedCredentialsMu.
+var oidcRefreshTokens = map[string]string{}
+
+// stsCache is the last temporary credentials of an alias, kept on disk
+// so each run of mc does not log in again.
+type stsCache struct {
+	Credentials processCredentials `json:"credentials"`
+}
+
+func stsCachePath(alias string) string {
+	return filepath.Join(mustGetMcConfigDir(), "sts", alias+".json")
+}
+
+func loadSTSCache(alias string) stsCache {
+	var cache stsCache
+	if data, e := os.ReadFile(stsCachePath(alias)); e == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+func saveSTSCache(alias string, cache stsCache) error {
+	file := stsCachePath(alias)
+	if e := os.MkdirAll(filepath.Dir(file), 0o700); e != nil {
+		return e
+	}
+	data, e := json.Marshal(cache)
+	if e != nil {
+		return e
+	}
+	return os.WriteFile(file, data, 0o600)
+}
+
+// removeSTSCache drops the cached credentials of an alias.
+func removeSTSCache(alias string) {
+	os.Remove(stsCachePath(alias))
+}
+
+func (s *stsCredentialSourceV10) String() string {
+	switch s.Type {
+	case stsLDAP:
+		return s.Type + " " + s.Username
+	case stsOIDC:
+		return s.Type + " " + s.Issuer
+	case stsCertificate:
+		return s.Type + " " + s.Certificate
+	case stsWebIdentity:
+		return s.Type + " " + s.TokenFile
+	}
+	return s.Type
+}
+
+func (s *stsCredentialSourceV10) validate() error {
+	var missing string
+	switch s.Type {
+	case stsLDAP:
+		if s.Username == "" {
+			missing = "username"
+		}
+	case stsOIDC:
+		switch {
+		case s.Issuer == "":
+			missing = "issuer"
+		case s.ClientID == "":
+			missing = "client ID"
+		}
+	case stsCertificate:
+		switch {
+		case s.Certificate == "":
+			missing = "certificate"
+		case s.Key == "":
+			missing = "key"
+		}
+	case stsWebIdentity:
+		if s.TokenFile == "" {
+			missing = "token file"
+		}
+	default:
+		return fmt.Errorf("unknown STS identity `%s`, valid options are `[ldap, oidc, certificate, web-identity]`", s.Type)
+	}
+	if missing == "" && s.Endpoint == "" {
+		missing = "endpoint"
+	}
+	if missing != "" {
+		return fmt.Errorf("STS %s identity has no %s", s.Type, missing)
+	}
+	return nil
+}
+
+// retrieve returns the cached temporary credentials of alias, or logs
+// in with the identity when they expired.
+func (s *stsCredentialSourceV10) retrieve(alias string) (processCredentials, error) {
+	cache := loadSTSCache(alias)
+	if cache.Credentials.AccessKeyID != "" && !credentialsExpired(cache.Credentials) {
+		return cache.Credentials, nil
+	}
+
+	var (
+		value credentials.Value
+		e     error
+	)
+	client := &http.Client{Transport: stsTransport(nil)}
+	switch s.Type {
+	case stsLDAP:
+		var password string
+		if password, e = ldapPassword(alias, s.Username); e != nil {
+			return processCredentials{}, e
+		}
+		value, e = (&credentials.LDAPIdentity{
+			Client:          client,
+			STSEndpoint:     s.Endpoint,
+			LDAPUsername:    s.Username,
+			LDAPPassword:    password,
+			RequestedExpiry: time.Duration(s.DurationSeconds) * time.Second,
+		}).Retrieve()
+		if e != nil {
+			// Ask for the password again next time.
+			delete(ldapPasswords, alias)
+		}
+	case stsCertificate:
+		var cert tls.Certificate
+		if cert, e = tls.LoadX509KeyPair(s.Certificate, s.Key); e != nil {
+			return processCredentials{}, e
+		}
+		value, e = (&credentials.STSCertificateIdentity{
+			STSEndpoint:          s.Endpoint,
+			S3CredentialLivetime: time.Duration(s.DurationSeconds) * time.Second,
+			Client:               http.Client{Transport: stsTransport(&cert)},
+		}).Retrieve()
+	case stsWebIdentity:
+		value, e = s.assumeRoleWithWebIdentity(client, func() (string, error) {
+			token, e := os.ReadFile(s.TokenFile)
+			return strings.TrimSpace(string(token)), e
+		})
+	case stsOIDC:
+		value, e = s.assumeRoleWithWebIdentity(client, func() (string, error) {
+			token, refreshToken, e := oidcIDToken(s.Issuer, s.ClientID, oidcRefreshTokens[alias])
+			if e != nil {
+				delete(oidcRefreshTokens, alias)
+				return "", e
+			}
+			oidcRefreshTokens[alias] = refreshToken
+			return token, nil
+		})
+	}
+	if e != nil {
+		return processCredentials{}, fmt.Errorf("unable to log in with the %s identity: %w", s.Type, e)
+	}
+	cache.Credentials = processCredentials{
+		Version:         1,
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+		Expiration:      value.Expiration,
+	}
+	if e = saveSTSCache(alias, cache); e != nil {
+		return processCredentials{}, e
+	}
+	return cache.Credentials, nil
+}
+
+func (s *stsCredentialSourceV10) assumeRoleWithWebIdentity(client *http.Client, token func() (string, error)) (credentials.Value, error) {
+	return (&credentials.STSWebIdentity{
+		Client:      client,
+		STSEndpoint: s.Endpoint,
+		RoleARN:     s.RoleARN,
+		GetWebIDTokenExpiry: func() (*credentials.WebIdentityToken, error) {
+			t, e := token()
+			if e != nil {
+				return nil, e
+			}
+			return &credentials.WebIdentityToken{Token: t, Expiry: s.DurationSeconds}, nil
+		},
+	}).Retrieve()
+}
+
+// stsTransport returns the transport of STS calls, presenting cert to
+// the server if it is set.
+func stsTransport(cert *tls.Certificate) *http.Transport {
+	tlsConfig := &tls.Config{
+		RootCAs:            globalRootCAs,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: globalInsecure,
+	}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// ldapPassword returns the LDAP password of alias from the environment,
+// from an earlier prompt or prompts for it on the terminal.
+func ldapPassword(alias, username string) (string, error) {
+	if password := env.Get(mcEnvLDAPPasswordPrefix+alias, ""); password != "" {
+		return password, nil
+	}
+	if password, ok := ldapPasswords[alias]; ok {
+		return password, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no LDAP password for `%s`, set %s%s", alias, mcEnvLDAPPasswordPrefix, alias)
+	}
+	fmt.Fprintf(os.Stderr, "Enter LDAP Password of `%s`: ", username)
+	password, e := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if e != nil {
+		return "", e
+	}
+	ldapPasswords[alias] = string(password)
+	return string(password), nil
+}
+
+// promptLDAPUsername asks for the LDAP username of a new alias.
+func promptLDAPUsername() string {
+	fmt.Fprint(os.Stderr, "Enter LDAP Username: ")
+	value, _, _ := bufio.NewReader(os.Stdin).ReadLine()
+	return strings.TrimSpace(string(value))
+}
+
+// oidcIDToken returns an ID token of the OpenID provider issuer, using
+// the refresh token when there is one and the device authorization
+// flow otherwise. The user completes the flow in a browser on any
+// machine, which suits hosts without one. It returns the new refresh
+// token along with the ID token.
+func oidcIDToken(issuer, clientID, refreshToken string) (string, string, error) {
+	var provider struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint               string `json:"token_endpoint"`
+	}
+	if e := oidcCall(http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil, &provider); e != nil {
+		return "", "", e
+	}
+
+	if refreshToken != "" {
+		token, e := oidcToken(provider.TokenEndpoint, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+			"client_id":     {clientID},
+		})
+		if e == nil && token.IDToken != "" {
+			if token.RefreshToken == "" {
+				token.RefreshToken = refreshToken
+			}
+			return token.IDToken, token.RefreshToken, nil
+		}
+		// Fall back to a new login when the refresh token expired.
+	}
+
+	if provider.DeviceAuthorizationEndpoint == "" {
+		return "", "", fmt.Errorf("`%s` does not support the device authorization flow", issuer)
+	}
+	var device struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	e := oidcCall(http.MethodPost, provider.DeviceAuthorizationEndpoint, url.Values{
+		"client_id": {clientID},
+		"scope":     {"openid offline_access"},
+	}, &device)
+	if e != nil {
+		return "", "", e
+	}
+	if device.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "To log in, open %s\n", device.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(os.Stderr, "To log in, open %s and enter the code %s\n", device.VerificationURI, device.UserCode)
+	}
+
+	interval := time.Duration(max(device.Interval, 1)) * time.Second
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-globalContext.Done():
+			return "", "", globalContext.Err()
+		case <-time.After(interval):
+		}
+		token, e := oidcToken(provider.TokenEndpoint, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {device.DeviceCode},
+			"client_id":   {clientID},
+		})
+		var oe *oidcError
+		switch {
+		case errors.As(e, &oe) && oe.Code == "authorization_pending":
+			continue
+		case errors.As(e, &oe) && oe.Code == "slow_down":
+			interval += 5 * time.Second
+			continue
+		case e != nil:
+			return "", "", e
+		case token.IDToken == "":
+			return "", "", errors.New("the OpenID provider returned no ID token")
+		}
+		return token.IDToken, token.RefreshToken, nil
+	}
+	return "", "", errors.New("the device login expired")
+}
+
+type oidcTokenResponse struct {
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// oidcError is an OAuth 2.0 error response.
+type oidcError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oidcError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+func oidcToken(endpoint string, form url.Values) (oidcTokenResponse, error) {
+	var token oidcTokenResponse
+	return token, oidcCall(http.MethodPost, endpoint, form, &token)
+}
+
+// oidcCall sends form to an endpoint of the OpenID provider and
+// decodes its JSON response into out.
+func oidcCall(method, endpoint string, form url.Values, out interface{}) error {
+	req, e := http.NewRequestWithContext(globalContext, method, endpoint, strings.NewReader(form.Encode()))
+	if e != nil {
+		return e
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, e := (&http.Client{Transport: stsTransport(nil)}).Do(req)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		oe := &oidcError{}
+		if json.NewDecoder(resp.Body).Decode(oe) == nil && oe.Code != "" {
+			return oe
+		}
+		return fmt.Errorf("`%s` returned %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
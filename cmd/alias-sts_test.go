@@ -0,0 +1,133 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSTSCredentialSource(t *testing.T) {
+	mcCustomConfigDir = t.TempDir()
+	defer func() { mcCustomConfigDir = "" }()
+	oidcRefreshTokens = map[string]string{}
+	defer func() { oidcRefreshTokens = map[string]string{} }()
+
+	var pending, logins int
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"device_authorization_endpoint":"%[1]s/device","token_endpoint":"%[1]s/token"}`, server.URL)
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"device_code":"dc","user_code":"ABCD","verification_uri":"https://idp/device","expires_in":60,"interval":1}`)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case r.Form.Get("device_code") == "dc" && pending > 0:
+			pending--
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"authorization_pending"}`)
+		case r.Form.Get("device_code") == "dc":
+			fmt.Fprint(w, `{"id_token":"device-token","refresh_token":"refresh"}`)
+		case r.Form.Get("refresh_token") == "refresh":
+			fmt.Fprint(w, `{"id_token":"refreshed-token"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		logins++
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleWithWebIdentityResult><Credentials><AccessKeyId>%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken><Expiration>%s</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`,
+			r.Form.Get("WebIdentityToken"), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	})
+
+	oidc := &stsCredentialSourceV10{Type: stsOIDC, Endpoint: server.URL, Issuer: server.URL, ClientID: "mc"}
+	pending = 1
+	creds, e := oidc.retrieve("oidc")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if creds.AccessKeyID != "device-token" || creds.SessionToken != "session" {
+		t.Fatalf("unexpected device flow credentials %+v", creds)
+	}
+	// The second run uses the cached credentials.
+	if creds, e = oidc.retrieve("oidc"); e != nil || logins != 1 {
+		t.Fatalf("cached credentials were not used: %d logins, %v", logins, e)
+	}
+	// The refresh token is not written to disk.
+	if data, e := os.ReadFile(stsCachePath("oidc")); e != nil || strings.Contains(string(data), "refresh") {
+		t.Fatalf("unexpected cache %s: %v", data, e)
+	}
+	// Expired credentials are renewed with the refresh token of this run.
+	expire := func() {
+		cache := loadSTSCache("oidc")
+		cache.Credentials.Expiration = time.Now().Add(-time.Minute)
+		if e := saveSTSCache("oidc", cache); e != nil {
+			t.Fatal(e)
+		}
+	}
+	expire()
+	if creds, e = oidc.retrieve("oidc"); e != nil {
+		t.Fatal(e)
+	}
+	if creds.AccessKeyID != "refreshed-token" {
+		t.Fatalf("credentials were not refreshed: %+v", creds)
+	}
+	// A new run logs in with the device flow again.
+	expire()
+	oidcRefreshTokens = map[string]string{}
+	if creds, e = oidc.retrieve("oidc"); e != nil {
+		t.Fatal(e)
+	}
+	if creds.AccessKeyID != "device-token" {
+		t.Fatalf("expected a new device flow login: %+v", creds)
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("file-token\n"), 0o600)
+	source := &credentialSourceV10{
+		Type: credentialSourceSTS,
+		STS:  &stsCredentialSourceV10{Type: stsWebIdentity, Endpoint: server.URL, TokenFile: tokenFile},
+	}
+	cfg := &aliasConfigV10{Credentials: source}
+	if err := cfg.resolveCredentials("web"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessKey != "file-token" || cfg.SessionToken != "session" {
+		t.Fatalf("unexpected web identity credentials %s:%s", cfg.AccessKey, cfg.SessionToken)
+	}
+	removeSTSCache("web")
+	if _, e = os.Stat(stsCachePath("web")); !os.IsNotExist(e) {
+		t.Fatalf("cache was not removed: %v", e)
+	}
+}
@@ -230,22 +230,24 @@ var (
 // with their bash completer function
 var completeCmds = map[string]complete.Predictor{
 	// S3 API level commands
-	"/ls":        complete.PredictOr(s3Completer, fsCompleter),
-	"/cp":        complete.PredictOr(s3Completer, fsCompleter),
-	"/mv":        complete.PredictOr(s3Completer, fsCompleter),
-	"/rm":        complete.PredictOr(s3Completer, fsCompleter),
-	"/rb":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
-	"/cat":       complete.PredictOr(s3Completer, fsCompleter),
-	"/head":      complete.PredictOr(s3Completer, fsCompleter),
-	"/diff":      complete.PredictOr(s3Completer, fsCompleter),
-	"/find":      complete.PredictOr(s3Completer, fsCompleter),
-	"/mirror":    complete.PredictOr(s3Completer, fsCompleter),
-	"/pipe":      complete.PredictOr(s3Completer, fsCompleter),
-	"/stat":      complete.PredictOr(s3Completer, fsCompleter),
-	"/watch":     complete.PredictOr(s3Completer, fsCompleter),
-	"/anonymous": complete.PredictOr(s3Completer, fsCompleter),
-	"/tree":      complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
-	"/du":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/ls":           complete.PredictOr(s3Completer, fsCompleter),
+	"/cp":           complete.PredictOr(s3Completer, fsCompleter),
+	"/mv":           complete.PredictOr(s3Completer, fsCompleter),
+	"/rm":           complete.PredictOr(s3Completer, fsCompleter),
+	"/rb":           complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/bench":        s3Completer,
+	"/cat":          complete.PredictOr(s3Completer, fsCompleter),
+	"/head":         complete.PredictOr(s3Completer, fsCompleter),
+	"/diff":         complete.PredictOr(s3Completer, fsCompleter),
+	"/find":         complete.PredictOr(s3Completer, fsCompleter),
+	"/mirror":       complete.PredictOr(s3Completer, fsCompleter),
+	"/migrate/plan": s3Completer,
+	"/pipe":         complete.PredictOr(s3Completer, fsCompleter),
+	"/stat":         complete.PredictOr(s3Completer, fsCompleter),
+	"/watch":        complete.PredictOr(s3Completer, fsCompleter),
+	"/anonymous":    complete.PredictOr(s3Completer, fsCompleter),
+	"/tree":         complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/du":           complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 
 	"/retention/set":   s3Completer,
 	"/retention/clear": s3Completer,
@@ -272,6 +274,7 @@ var completeCmds = map[string]complete.Predictor{
 	"/replicate/list":    s3Complete{deepLevel: 2},
 	"/replicate/remove":  s3Complete{deepLevel: 2},
 	"/replicate/backlog": s3Complete{deepLevel: 2},
+	"/replicate/verify":  s3Complete{deepLevel: 2},
 
 	"/replicate/export":        s3Complete{deepLevel: 2},
 	"/replicate/import":        s3Complete{deepLevel: 2},
@@ -327,6 +330,11 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/config/export":  aliasCompleter,
 	"/admin/config/history": aliasCompleter,
 	"/admin/config/restore": aliasCompleter,
+	"/admin/config/diff":    aliasCompleter,
+
+	"/admin/config/snapshot/save": aliasCompleter,
+	"/admin/config/snapshot/log":  aliasCompleter,
+	"/admin/config/snapshot/show": aliasCompleter,
 
 	"/admin/decom/start":         aliasCompleter,
 	"/admin/decom/status":        aliasCompleter,
@@ -340,6 +348,7 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/rebalance/stop":   aliasCompleter,
 
 	"/admin/trace":     aliasCompleter,
+	"/admin/monitor":   aliasCompleter,
 	"/admin/speedtest": aliasCompleter,
 	"/admin/console":   aliasCompleter,
 	"/admin/update":    aliasCompleter,
@@ -355,8 +364,9 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/service/freeze":   aliasCompleter,
 	"/admin/service/unfreeze": aliasCompleter,
 
-	"/admin/prometheus/generate": aliasCompleter,
-	"/admin/prometheus/metrics":  aliasCompleter,
+	"/admin/prometheus/generate":   aliasCompleter,
+	"/admin/prometheus/metrics":    aliasCompleter,
+	"/admin/prometheus/dashboards": aliasCompleter,
 
 	"/admin/profile/start": aliasCompleter,
 	"/admin/profile/stop":  aliasCompleter,
@@ -479,6 +489,7 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/cluster/bucket/import": aliasCompleter,
 	"/admin/cluster/iam/export":    aliasCompleter,
 	"/admin/cluster/iam/import":    aliasCompleter,
+	"/admin/cluster/iam/diff":      aliasCompleter,
 
 	"/alias/set":    nil,
 	"/alias/list":   aliasCompleter,
@@ -486,6 +497,15 @@ var completeCmds = map[string]complete.Predictor{
 	"/alias/import": nil,
 	"/alias/export": aliasCompleter,
 
+	"/alias/group/set":    aliasCompleter,
+	"/alias/group/list":   nil,
+	"/alias/group/remove": nil,
+
+	"/alias/profile/set":    nil,
+	"/alias/profile/list":   nil,
+	"/alias/profile/remove": nil,
+	"/alias/defaults":       aliasCompleter,
+
 	"/support/callhome":     aliasCompleter,
 	"/support/register":     aliasCompleter,
 	"/support/diag":         aliasCompleter,
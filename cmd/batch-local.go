@@ -0,0 +1,727 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/wildcard"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Batch jobs run locally by 'mc batch start --local' use the same
+// YAML definitions as the jobs run by a MinIO server. The endpoints
+// of a job without 'endpoint' are the alias or directory passed on
+// the command line, the role the 'local' deployment has on a server.
+
+type localBatchCredentials struct {
+	AccessKey    string `yaml:"accessKey"`
+	SecretKey    string `yaml:"secretKey"`
+	SessionToken string `yaml:"sessionToken"`
+}
+
+type localBatchEndpoint struct {
+	Type        string                `yaml:"type"`
+	Bucket      string                `yaml:"bucket"`
+	Prefix      string                `yaml:"prefix"`
+	Endpoint    string                `yaml:"endpoint"`
+	Path        string                `yaml:"path"`
+	Credentials localBatchCredentials `yaml:"credentials"`
+}
+
+type localBatchKV struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// match reports whether m has the key with a value matching
+// the wildcard pattern, keys are compared case insensitively.
+func (kv localBatchKV) match(m map[string]string) bool {
+	for k, v := range m {
+		if strings.EqualFold(k, kv.Key) && wildcard.Match(kv.Value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+type localBatchNotify struct {
+	Endpoint string `yaml:"endpoint"`
+	Token    string `yaml:"token"`
+}
+
+type localBatchRetry struct {
+	Attempts int    `yaml:"attempts"`
+	Delay    string `yaml:"delay"`
+}
+
+type localBatchReplicate struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Source     localBatchEndpoint `yaml:"source"`
+	Target     localBatchEndpoint `yaml:"target"`
+	Flags      struct {
+		Filter struct {
+			NewerThan     string         `yaml:"newerThan"`
+			OlderThan     string         `yaml:"olderThan"`
+			CreatedAfter  string         `yaml:"createdAfter"`
+			CreatedBefore string         `yaml:"createdBefore"`
+			Tags          []localBatchKV `yaml:"tags"`
+			Metadata      []localBatchKV `yaml:"metadata"`
+		} `yaml:"filter"`
+		Notify localBatchNotify `yaml:"notify"`
+		Retry  localBatchRetry  `yaml:"retry"`
+	} `yaml:"flags"`
+}
+
+type localBatchExpireRule struct {
+	Type          string         `yaml:"type"`
+	Name          string         `yaml:"name"`
+	OlderThan     string         `yaml:"olderThan"`
+	CreatedBefore string         `yaml:"createdBefore"`
+	Tags          []localBatchKV `yaml:"tags"`
+	Metadata      []localBatchKV `yaml:"metadata"`
+	Size          struct {
+		LessThan    string `yaml:"lessThan"`
+		GreaterThan string `yaml:"greaterThan"`
+	} `yaml:"size"`
+	Purge struct {
+		RetainVersions int `yaml:"retainVersions"`
+	} `yaml:"purge"`
+}
+
+type localBatchExpire struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Bucket     string                 `yaml:"bucket"`
+	Prefix     string                 `yaml:"prefix"`
+	Rules      []localBatchExpireRule `yaml:"rules"`
+	Notify     localBatchNotify       `yaml:"notify"`
+	Retry      localBatchRetry        `yaml:"retry"`
+}
+
+type localBatchJob struct {
+	Replicate *localBatchReplicate `yaml:"replicate"`
+	Expire    *localBatchExpire    `yaml:"expire"`
+	KeyRotate *struct{}            `yaml:"keyrotate"`
+}
+
+// parseLocalBatchJob parses a batch job definition and validates that
+// it can be run by mc.
+func parseLocalBatchJob(buf []byte) (*localBatchJob, *probe.Error) {
+	job := &localBatchJob{}
+	if e := yaml.Unmarshal(buf, job); e != nil {
+		return nil, probe.NewError(e)
+	}
+	switch {
+	case job.KeyRotate != nil:
+		return nil, probe.NewError(errors.New("keyrotate jobs need the KMS of a MinIO server and cannot run locally"))
+	case job.Replicate != nil:
+		r := job.Replicate
+		if r.Source.Bucket == "" || r.Target.Bucket == "" {
+			return nil, probe.NewError(errors.New("replicate jobs need a source and a target bucket"))
+		}
+		for _, d := range []string{r.Flags.Filter.NewerThan, r.Flags.Filter.OlderThan} {
+			if _, e := parseLocalBatchDuration(d); e != nil {
+				return nil, probe.NewError(e)
+			}
+		}
+		for _, t := range []string{r.Flags.Filter.CreatedAfter, r.Flags.Filter.CreatedBefore} {
+			if _, e := parseLocalBatchTime(t); e != nil {
+				return nil, probe.NewError(e)
+			}
+		}
+	case job.Expire != nil:
+		x := job.Expire
+		if x.Bucket == "" {
+			return nil, probe.NewError(errors.New("expire jobs need a bucket"))
+		}
+		for _, rule := range x.Rules {
+			if rule.Type != "object" && rule.Type != "deleted" {
+				return nil, probe.NewError(errors.New("expire rule type must be 'object' or 'deleted'"))
+			}
+			if _, e := parseLocalBatchDuration(rule.OlderThan); e != nil {
+				return nil, probe.NewError(e)
+			}
+			if _, e := parseLocalBatchTime(rule.CreatedBefore); e != nil {
+				return nil, probe.NewError(e)
+			}
+			for _, s := range []string{rule.Size.LessThan, rule.Size.GreaterThan} {
+				if s == "" {
+					continue
+				}
+				if _, e := humanize.ParseBytes(s); e != nil {
+					return nil, probe.NewError(e)
+				}
+			}
+		}
+	default:
+		return nil, probe.NewError(errors.New("no replicate or expire job found"))
+	}
+	return job, nil
+}
+
+func parseLocalBatchDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, e := ParseDuration(s)
+	return time.Duration(d), e
+}
+
+func parseLocalBatchTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// localBatchAliasURL returns the aliased URL of bucket/prefix on the
+// endpoint. Endpoints with an URL are registered as alias 'name' for
+// the lifetime of the command, others use localAlias.
+func localBatchAliasURL(e localBatchEndpoint, name, localAlias string) string {
+	alias := localAlias
+	if e.Endpoint != "" {
+		lookup := e.Path
+		if lookup == "" {
+			lookup = "auto"
+		}
+		aliasToConfigMap[name] = &aliasConfigV10{
+			URL:          e.Endpoint,
+			AccessKey:    e.Credentials.AccessKey,
+			SecretKey:    e.Credentials.SecretKey,
+			SessionToken: e.Credentials.SessionToken,
+			API:          "S3v4",
+			Path:         lookup,
+			Src:          "batch job",
+		}
+		alias = name
+	}
+	return urlJoinPath(alias, path.Join(e.Bucket, e.Prefix))
+}
+
+// localBatchRunner runs a job and tracks its progress in the
+// same metric as jobs run by a MinIO server.
+type localBatchRunner struct {
+	mu         sync.Mutex
+	metric     madmin.JobMetric
+	jobErr     string
+	statusFile string
+	retry      localBatchRetry
+}
+
+// localBatchStatus is the content of the status file of a job run in
+// mc, with the result of the job once it is done.
+type localBatchStatus struct {
+	Status string           `json:"status"`
+	Error  string           `json:"error,omitempty"`
+	Metric madmin.JobMetric `json:"metric"`
+}
+
+// localBatchResult returns the status of a job: in-progress, success
+// or failed.
+func localBatchResult(m madmin.JobMetric) string {
+	switch {
+	case m.Failed:
+		return "failed"
+	case m.Complete:
+		return "success"
+	}
+	return "in-progress"
+}
+
+// localBatchStatusRetention is how long the status of a finished job
+// is kept.
+const localBatchStatusRetention = 7 * 24 * time.Hour
+
+func newLocalBatchRunner(jobType madmin.BatchJobType) *localBatchRunner {
+	r := &localBatchRunner{
+		metric: madmin.JobMetric{
+			JobID:     uuid.NewString(),
+			JobType:   string(jobType),
+			StartTime: time.Now().UTC(),
+		},
+	}
+	r.metric.LastUpdate = r.metric.StartTime
+	switch jobType {
+	case madmin.BatchJobReplicate:
+		r.metric.Replicate = &madmin.ReplicateInfo{}
+	case madmin.BatchJobExpire:
+		r.metric.Expired = &madmin.ExpirationInfo{}
+	}
+	r.statusFile = localBatchStatusFile(r.metric.JobID)
+	return r
+}
+
+// localBatchStatusFile returns the file where the status of
+// the locally run job is saved for 'mc batch status --local'.
+func localBatchStatusFile(jobID string) string {
+	return filepath.Join(localBatchStatusDir(), jobID+".json")
+}
+
+func localBatchStatusDir() string {
+	return filepath.Join(mustGetMcConfigDir(), "batch")
+}
+
+func readLocalBatchStatus(jobID string) (st localBatchStatus, err *probe.Error) {
+	buf, e := os.ReadFile(localBatchStatusFile(jobID))
+	if e != nil {
+		return st, probe.NewError(e)
+	}
+	return st, probe.NewError(gojson.Unmarshal(buf, &st))
+}
+
+// writeLocalBatchStatus replaces the status file of a job.
+func writeLocalBatchStatus(file string, st localBatchStatus) *probe.Error {
+	buf, e := gojson.Marshal(st)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.MkdirAll(filepath.Dir(file), 0o700); e != nil {
+		return probe.NewError(e)
+	}
+	tmp := file + ".tmp"
+	if e = os.WriteFile(tmp, buf, 0o600); e != nil {
+		return probe.NewError(e)
+	}
+	return probe.NewError(os.Rename(tmp, file))
+}
+
+// pruneLocalBatchStatus removes the status files of the jobs which
+// ended more than localBatchStatusRetention ago. A job which stopped
+// updating its status without a result was interrupted.
+func pruneLocalBatchStatus(now time.Time) *probe.Error {
+	entries, e := os.ReadDir(localBatchStatusDir())
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil
+		}
+		return probe.NewError(e)
+	}
+	for _, entry := range entries {
+		jobID, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		st, err := readLocalBatchStatus(jobID)
+		if err != nil || now.Sub(st.Metric.LastUpdate) < localBatchStatusRetention {
+			continue
+		}
+		if e = os.Remove(localBatchStatusFile(jobID)); e != nil && !os.IsNotExist(e) {
+			return probe.NewError(e)
+		}
+	}
+	return nil
+}
+
+func (r *localBatchRunner) update(fn func(m *madmin.JobMetric)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.metric)
+	r.metric.LastUpdate = time.Now().UTC()
+}
+
+func (r *localBatchRunner) snapshot() madmin.JobMetric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.metric
+	if m.Replicate != nil {
+		ri := *m.Replicate
+		m.Replicate = &ri
+	}
+	if m.Expired != nil {
+		ei := *m.Expired
+		m.Expired = &ei
+	}
+	return m
+}
+
+// save writes the current metric and the result of the job to the
+// status file.
+func (r *localBatchRunner) save() *probe.Error {
+	m := r.snapshot()
+	if !m.Complete && !m.Failed {
+		m.LastUpdate = time.Now().UTC()
+	}
+	r.mu.Lock()
+	jobErr := r.jobErr
+	r.mu.Unlock()
+	return writeLocalBatchStatus(r.statusFile, localBatchStatus{Status: localBatchResult(m), Error: jobErr, Metric: m})
+}
+
+// finish marks the job as complete or failed, saves its status
+// and sends it to the notification endpoint, if any. A job which
+// returned jobErr failed.
+func (r *localBatchRunner) finish(ctx context.Context, notify localBatchNotify, jobErr *probe.Error) *probe.Error {
+	r.update(func(m *madmin.JobMetric) {
+		failed := (m.Replicate != nil && m.Replicate.ObjectsFailed > 0) ||
+			(m.Expired != nil && m.Expired.ObjectsFailed > 0) || ctx.Err() != nil || jobErr != nil
+		m.Failed, m.Complete = failed, !failed
+		if jobErr != nil {
+			r.jobErr = jobErr.ToGoError().Error()
+		}
+	})
+	if err := r.save(); err != nil {
+		return err
+	}
+	if notify.Endpoint == "" {
+		return nil
+	}
+	buf, e := gojson.Marshal(r.snapshot())
+	if e != nil {
+		return probe.NewError(e)
+	}
+	req, e := http.NewRequestWithContext(globalContext, http.MethodPost, notify.Endpoint, bytes.NewReader(buf))
+	if e != nil {
+		return probe.NewError(e)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if notify.Token != "" {
+		req.Header.Set("Authorization", notify.Token)
+	}
+	resp, e := http.DefaultClient.Do(req)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return probe.NewError(errors.New("notification endpoint returned " + resp.Status))
+	}
+	return nil
+}
+
+// withRetry runs fn until it succeeds or the attempts of the job
+// are exhausted.
+func (r *localBatchRunner) withRetry(ctx context.Context, fn func() *probe.Error) *probe.Error {
+	delay, _ := time.ParseDuration(r.retry.Delay)
+	var err *probe.Error
+	for attempt := 0; attempt <= r.retry.Attempts; attempt++ {
+		if attempt > 0 {
+			clientRetries.Inc()
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// run executes the job and calls progress with the current metric
+// every second and once more when the job is done.
+func (r *localBatchRunner) run(ctx context.Context, job *localBatchJob, localAlias string, progress func(madmin.JobMetric)) *probe.Error {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				errorIf(r.save(), "Unable to save the batch job status.")
+				progress(r.snapshot())
+			}
+		}
+	}()
+	errorIf(pruneLocalBatchStatus(time.Now()), "Unable to remove the status of old batch jobs.")
+	if err := r.save(); err != nil {
+		close(done)
+		wg.Wait()
+		return err
+	}
+
+	var (
+		err    *probe.Error
+		notify localBatchNotify
+	)
+	switch {
+	case job.Replicate != nil:
+		r.retry, notify = job.Replicate.Flags.Retry, job.Replicate.Flags.Notify
+		err = r.replicate(ctx, job.Replicate, localAlias)
+	case job.Expire != nil:
+		r.retry, notify = job.Expire.Retry, job.Expire.Notify
+		err = r.expire(ctx, job.Expire, localAlias)
+	}
+	close(done)
+	wg.Wait()
+
+	if e := r.finish(ctx, notify, err); e != nil && err == nil {
+		err = e
+	}
+	progress(r.snapshot())
+	return err
+}
+
+func (r *localBatchRunner) replicate(ctx context.Context, job *localBatchReplicate, localAlias string) *probe.Error {
+	sourceURL := localBatchAliasURL(job.Source, "mc-batch-source", localAlias)
+	targetURL := localBatchAliasURL(job.Target, "mc-batch-target", localAlias)
+	sourceAlias, _, _, err := expandAlias(sourceURL)
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	targetAlias, targetURLFull, _, err := expandAlias(targetURL)
+	if err != nil {
+		return err.Trace(targetURL)
+	}
+	srcClnt, err := newClient(sourceURL)
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	// Object names are relative to the bucket and are
+	// replicated below the prefix of the target.
+	source := job.Source
+	source.Prefix = ""
+	bucketClnt, err := newClient(localBatchAliasURL(source, "mc-batch-source", localAlias))
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	bucketURL := strings.TrimSuffix(filepath.ToSlash(bucketClnt.GetURL().String()), "/") + "/"
+
+	filter := job.Flags.Filter
+	newerThan, _ := parseLocalBatchDuration(filter.NewerThan)
+	olderThan, _ := parseLocalBatchDuration(filter.OlderThan)
+	createdAfter, _ := parseLocalBatchTime(filter.CreatedAfter)
+	createdBefore, _ := parseLocalBatchTime(filter.CreatedBefore)
+
+	resultCh := make(chan URLs, 10000)
+	pm := newParallelManager(resultCh)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for urls := range resultCh {
+			object := strings.TrimPrefix(filepath.ToSlash(urls.SourceContent.URL.String()), bucketURL)
+			if urls.Error != nil {
+				errorIf(urls.Error.Trace(urls.SourceContent.URL.String()), "Unable to replicate `%s`.", object)
+			}
+			r.update(func(m *madmin.JobMetric) {
+				m.Replicate.Bucket, m.Replicate.Object = job.Source.Bucket, object
+				if urls.Error != nil {
+					m.Replicate.ObjectsFailed++
+					m.Replicate.BytesFailed += urls.SourceContent.Size
+					return
+				}
+				m.Replicate.Objects++
+				m.Replicate.BytesTransferred += urls.SourceContent.Size
+			})
+		}
+	}()
+
+	for content := range srcClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: len(filter.Metadata) > 0 || len(filter.Tags) > 0, ShowDir: DirNone}) {
+		if content.Err != nil {
+			err = content.Err.Trace(sourceURL)
+			break
+		}
+		age := time.Since(content.Time)
+		switch {
+		case newerThan > 0 && age >= newerThan,
+			olderThan > 0 && age < olderThan,
+			!createdAfter.IsZero() && !content.Time.After(createdAfter),
+			!createdBefore.IsZero() && !content.Time.Before(createdBefore):
+			continue
+		}
+		if !localBatchMatchMeta(ctx, sourceAlias, content, filter.Tags, filter.Metadata) {
+			continue
+		}
+
+		object := strings.TrimPrefix(filepath.ToSlash(content.URL.String()), bucketURL)
+		urls := URLs{
+			SourceAlias:   sourceAlias,
+			SourceContent: content,
+			TargetAlias:   targetAlias,
+			TargetContent: &ClientContent{URL: *newClientURL(urlJoinPath(targetURLFull, object))},
+		}
+		pm.queueTask(func() URLs {
+			var result URLs
+			r.withRetry(ctx, func() *probe.Error {
+				result = uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{
+					urls:     urls,
+					progress: pm,
+					preserve: true,
+				})
+				return result.Error
+			})
+			return result
+		}, content.Size)
+	}
+	pm.stopAndWait()
+	close(resultCh)
+	wg.Wait()
+	return err
+}
+
+// localBatchMatchMeta reports whether the object has all tags and
+// metadata, which are fetched when they are not part of the listing.
+func localBatchMatchMeta(ctx context.Context, alias string, content *ClientContent, tags, metadata []localBatchKV) bool {
+	if len(tags) == 0 && len(metadata) == 0 {
+		return true
+	}
+	clnt, err := newClientFromAlias(alias, content.URL.String())
+	if err != nil {
+		return false
+	}
+	if len(tags) > 0 && content.Tags == nil {
+		if content.Tags, err = clnt.GetTags(ctx, content.VersionID); err != nil {
+			return false
+		}
+	}
+	if len(metadata) > 0 && len(content.Metadata) == 0 && len(content.UserMetadata) == 0 {
+		st, err := clnt.Stat(ctx, StatOptions{versionID: content.VersionID})
+		if err != nil {
+			return false
+		}
+		content.Metadata, content.UserMetadata = st.Metadata, st.UserMetadata
+	}
+	for _, kv := range tags {
+		if !kv.match(content.Tags) {
+			return false
+		}
+	}
+	for _, kv := range metadata {
+		if !kv.match(content.Metadata) && !kv.match(content.UserMetadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *localBatchRunner) expire(ctx context.Context, job *localBatchExpire, localAlias string) *probe.Error {
+	bucketURL := localBatchAliasURL(localBatchEndpoint{Bucket: job.Bucket}, "", localAlias)
+	alias, _, _, err := expandAlias(bucketURL)
+	if err != nil {
+		return err.Trace(bucketURL)
+	}
+	clnt, err := newClient(bucketURL)
+	if err != nil {
+		return err.Trace(bucketURL)
+	}
+	prefixURL := urlJoinPath(bucketURL, job.Prefix)
+	listClnt, err := newClient(prefixURL)
+	if err != nil {
+		return err.Trace(prefixURL)
+	}
+	root := strings.TrimSuffix(filepath.ToSlash(clnt.GetURL().String()), "/") + "/"
+
+	// Versions of an object are listed one after another,
+	// the latest first.
+	var versions []*ClientContent
+	expire := func() {
+		if len(versions) == 0 {
+			return
+		}
+		object := strings.TrimPrefix(filepath.ToSlash(versions[0].URL.String()), root)
+		for _, rule := range job.Rules {
+			if !localBatchExpireMatch(ctx, alias, rule, object, versions[0]) {
+				continue
+			}
+			if rule.Purge.RetainVersions >= len(versions) {
+				break
+			}
+			for _, v := range versions[rule.Purge.RetainVersions:] {
+				rmErr := r.withRetry(ctx, func() *probe.Error {
+					contentCh := make(chan *ClientContent, 1)
+					contentCh <- &ClientContent{URL: v.URL, VersionID: v.VersionID}
+					close(contentCh)
+					for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+						if result.Err != nil {
+							return result.Err
+						}
+					}
+					return nil
+				})
+				errorIf(rmErr.Trace(v.URL.String()), "Unable to expire `%s`.", object)
+				r.update(func(m *madmin.JobMetric) {
+					m.Expired.Bucket, m.Expired.Object = job.Bucket, object
+					if rmErr != nil {
+						m.Expired.ObjectsFailed++
+						return
+					}
+					m.Expired.Objects++
+				})
+			}
+			break
+		}
+		versions = versions[:0]
+	}
+
+	for content := range listClnt.List(ctx, ListOptions{Recursive: true, WithOlderVersions: true, WithDeleteMarkers: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			err = content.Err.Trace(prefixURL)
+			break
+		}
+		if len(versions) > 0 && versions[0].URL.Path != content.URL.Path {
+			expire()
+		}
+		versions = append(versions, content)
+	}
+	if err == nil {
+		expire()
+	}
+	return err
+}
+
+// localBatchExpireMatch reports whether the latest version of an
+// object matches an expire rule.
+func localBatchExpireMatch(ctx context.Context, alias string, rule localBatchExpireRule, object string, latest *ClientContent) bool {
+	if latest.IsDeleteMarker != (rule.Type == "deleted") {
+		return false
+	}
+	if rule.Name != "" && !wildcard.Match(rule.Name, object) {
+		return false
+	}
+	if olderThan, _ := parseLocalBatchDuration(rule.OlderThan); olderThan > 0 && time.Since(latest.Time) < olderThan {
+		return false
+	}
+	if before, _ := parseLocalBatchTime(rule.CreatedBefore); !before.IsZero() && !latest.Time.Before(before) {
+		return false
+	}
+	if latest.IsDeleteMarker {
+		return true
+	}
+	if rule.Size.LessThan != "" {
+		if lt, _ := humanize.ParseBytes(rule.Size.LessThan); latest.Size >= int64(lt) {
+			return false
+		}
+	}
+	if rule.Size.GreaterThan != "" {
+		if gt, _ := humanize.ParseBytes(rule.Size.GreaterThan); latest.Size <= int64(gt) {
+			return false
+		}
+	}
+	return localBatchMatchMeta(ctx, alias, latest, rule.Tags, rule.Metadata)
+}
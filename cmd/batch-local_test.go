@@ -0,0 +1,99 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestParseLocalBatchJob(t *testing.T) {
+	testCases := []struct {
+		job     string
+		success bool
+	}{
+		{madmin.BatchJobReplicateTemplate, false},
+		{"replicate:\n  source:\n    bucket: a\n  target:\n    bucket: b\n  flags:\n    filter:\n      olderThan: 7d\n", true},
+		{"replicate:\n  source:\n    bucket: a\n", false},
+		{"expire:\n  bucket: a\n  rules:\n    - type: deleted\n      olderThan: 10h\n", true},
+		{"expire:\n  bucket: a\n  rules:\n    - type: other\n", false},
+		{"keyrotate:\n  bucket: a\n", false},
+		{"unknown: {}\n", false},
+	}
+	for i, tc := range testCases {
+		_, err := parseLocalBatchJob([]byte(tc.job))
+		if (err == nil) != tc.success {
+			t.Errorf("Test %d: expected success %t, got %v", i+1, tc.success, err)
+		}
+	}
+}
+
+func TestLocalBatchKVMatch(t *testing.T) {
+	kv := localBatchKV{Key: "content-type", Value: "image/*"}
+	if !kv.match(map[string]string{"Content-Type": "image/png"}) {
+		t.Error("expected match")
+	}
+	if kv.match(map[string]string{"Content-Type": "text/plain"}) || kv.match(nil) {
+		t.Error("unexpected match")
+	}
+}
+
+func TestLocalBatchStatus(t *testing.T) {
+	mcCustomConfigDir = t.TempDir()
+	defer func() { mcCustomConfigDir = "" }()
+
+	// A job which returned an error failed, whatever its objects.
+	r := newLocalBatchRunner(madmin.BatchJobReplicate)
+	if err := r.finish(context.Background(), localBatchNotify{}, probe.NewError(errors.New("listing failed"))); err != nil {
+		t.Fatal(err)
+	}
+	st, err := readLocalBatchStatus(r.metric.JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Status != "failed" || st.Error != "listing failed" || !st.Metric.Failed {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	ok := newLocalBatchRunner(madmin.BatchJobExpire)
+	if err = ok.finish(context.Background(), localBatchNotify{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ = readLocalBatchStatus(ok.metric.JobID); st.Status != "success" {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	// Only the jobs which ended before the retention are removed.
+	if err = pruneLocalBatchStatus(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readLocalBatchStatus(ok.metric.JobID); err != nil {
+		t.Errorf("expected the recent status to be kept: %v", err)
+	}
+	if err = pruneLocalBatchStatus(time.Now().Add(localBatchStatusRetention + time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readLocalBatchStatus(ok.metric.JobID); err == nil {
+		t.Error("expected the old status to be removed")
+	}
+}
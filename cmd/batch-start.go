@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
 	json "github.com/minio/colorjson"
@@ -30,18 +31,25 @@ import (
 	"github.com/minio/pkg/v3/console"
 )
 
+var batchStartFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "local",
+		Usage: "run the job in mc instead of MinIO, TARGET is the alias or the directory used for endpoints without 'endpoint'",
+	},
+}
+
 var batchStartCmd = cli.Command{
 	Name:         "start",
 	Usage:        "start a new batch job",
 	Action:       mainBatchStart,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        globalFlags,
+	Flags:        append(batchStartFlags, globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
-  {{.HelpName}} TARGET JOBFILE
+  {{.HelpName}} [--local] TARGET JOBFILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -49,6 +57,12 @@ FLAGS:
 EXAMPLES:
   1. Start a new batch 'replication' job:
      {{.Prompt}} {{.HelpName}} myminio ./replication.yaml
+
+  2. Run a 'replication' job from AWS S3 into 'myminio' in mc, the job source has an 'endpoint':
+     {{.Prompt}} {{.HelpName}} --local myminio ./replication.yaml
+
+  3. Run an 'expire' job on a local directory in mc:
+     {{.Prompt}} {{.HelpName}} --local /mnt/data ./expire.yaml
 `,
 }
 
@@ -85,6 +99,10 @@ func mainBatchStart(ctx *cli.Context) error {
 
 	console.SetColor("BatchStart", color.New(color.FgGreen, color.Bold))
 
+	if ctx.Bool("local") {
+		return mainBatchStartLocal(ctx)
+	}
+
 	// Get the alias parameter from cli
 	args := ctx.Args()
 	aliasedURL := args.Get(0)
@@ -108,3 +126,50 @@ func mainBatchStart(ctx *cli.Context) error {
 	})
 	return nil
 }
+
+// mainBatchStartLocal runs the job in mc until it is done.
+func mainBatchStartLocal(ctx *cli.Context) error {
+	args := ctx.Args()
+	localAlias := args.Get(0)
+
+	buf, e := os.ReadFile(args.Get(1))
+	fatalIf(probe.NewError(e), "Unable to read %s", args.Get(1))
+	job, err := parseLocalBatchJob(buf)
+	fatalIf(err.Trace(args.Get(1)), "Unable to parse the job definition")
+
+	jobType := madmin.BatchJobReplicate
+	if job.Expire != nil {
+		jobType = madmin.BatchJobExpire
+	}
+	runner := newLocalBatchRunner(jobType)
+	m := runner.snapshot()
+	printMsg(batchStartMessage{
+		Status: "success",
+		Result: madmin.BatchJobResult{ID: m.JobID, Type: jobType, Started: m.StartTime},
+	})
+
+	ctxt, cancel := context.WithCancel(globalContext)
+	defer cancel()
+
+	if globalJSON {
+		err = runner.run(ctxt, job, localAlias, func(madmin.JobMetric) {})
+		metric := runner.snapshot()
+		printMsg(batchJobStatusMessage{Status: localBatchResult(metric), Metric: metric})
+		fatalIf(err, "Unable to run job")
+		return nil
+	}
+
+	ui := tea.NewProgram(initBatchJobMetricsUI(m.JobID))
+	errCh := make(chan *probe.Error, 1)
+	go func() {
+		errCh <- runner.run(ctxt, job, localAlias, func(m madmin.JobMetric) { ui.Send(m) })
+	}()
+	if _, e := ui.Run(); e != nil {
+		cancel()
+		fatalIf(probe.NewError(e), "Unable to display the job status")
+	}
+	// The job is canceled when the user quits the UI.
+	cancel()
+	fatalIf(<-errCh, "Unable to run job")
+	return nil
+}
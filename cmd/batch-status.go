@@ -19,19 +19,27 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
+var batchStatusFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "local",
+		Usage: "summarize a job run in mc by 'mc batch start --local', its status is kept for 7 days after it ended",
+	},
+}
+
 var batchStatusCmd = cli.Command{
 	Name:            "status",
 	Usage:           "summarize job events on MinIO server in real-time",
 	Action:          mainBatchStatus,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(batchStatusFlags, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
   {{.HelpName}} TARGET JOBID
+  {{.HelpName}} --local JOBID
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -39,12 +47,16 @@ FLAGS:
 EXAMPLES:
    1. Display current in-progress JOB events.
       {{.Prompt}} {{.HelpName}} myminio/ KwSysDpxcBU9FNhGkn2dCf
+
+   2. Display the events of a JOB run in mc.
+      {{.Prompt}} {{.HelpName}} --local 8c3e1b4e-2f57-4bd2-9d8e-2b9e4f1b6a0d
 `,
 }
 
 // batchJobStatusMessage container for batch job status messages
 type batchJobStatusMessage struct {
 	Status string           `json:"status"`
+	Error  string           `json:"error,omitempty"`
 	Metric madmin.JobMetric `json:"metric"`
 }
 
@@ -62,6 +74,9 @@ func (c batchJobStatusMessage) String() string {
 
 // checkBatchStatusSyntax - validate all the passed arguments
 func checkBatchStatusSyntax(ctx *cli.Context) {
+	if ctx.Bool("local") && len(ctx.Args()) == 1 {
+		return
+	}
 	if len(ctx.Args()) != 2 {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code
 	}
@@ -69,6 +84,9 @@ func checkBatchStatusSyntax(ctx *cli.Context) {
 
 func mainBatchStatus(ctx *cli.Context) error {
 	checkBatchStatusSyntax(ctx)
+	if ctx.Bool("local") {
+		return mainBatchStatusLocal(ctx.Args().Get(0))
+	}
 
 	aliasedURL := ctx.Args().Get(0)
 	jobID := ctx.Args().Get(1)
@@ -158,6 +176,71 @@ func mainBatchStatus(ctx *cli.Context) error {
 	return nil
 }
 
+// localBatchStatusStale is the time after which a job that did
+// not update its status is considered not running anymore.
+const localBatchStatusStale = 10 * time.Second
+
+// mainBatchStatusLocal follows the status saved by a job run in mc.
+func mainBatchStatusLocal(jobID string) error {
+	ctxt, cancel := context.WithCancel(globalContext)
+	defer cancel()
+
+	st, err := readLocalBatchStatus(jobID)
+	fatalIf(err.Trace(jobID), "Unable to lookup job status")
+
+	ui := tea.NewProgram(initBatchJobMetricsUI(jobID))
+	final := make(chan localBatchStatus, 1)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			if st.Status == "in-progress" && time.Since(st.Metric.LastUpdate) > localBatchStatusStale {
+				// The job was interrupted, record it as failed.
+				st.Metric.Failed = true
+				st.Status, st.Error = localBatchResult(st.Metric), "the job was interrupted"
+				errorIf(writeLocalBatchStatus(localBatchStatusFile(jobID), st), "Unable to save the batch job status.")
+			}
+			if st.Status != "in-progress" {
+				final <- st
+			}
+			if globalJSON {
+				printMsg(batchJobStatusMessage{Status: st.Status, Error: st.Error, Metric: st.Metric})
+			} else {
+				ui.Send(st.Metric)
+			}
+			if st.Status != "in-progress" {
+				cancel()
+				return
+			}
+			select {
+			case <-ctxt.Done():
+				return
+			case <-ticker.C:
+			}
+			if next, err := readLocalBatchStatus(jobID); err == nil {
+				st = next
+			}
+		}
+	}()
+
+	if !globalJSON {
+		if _, e := ui.Run(); e != nil {
+			cancel()
+			fatalIf(probe.NewError(e).Trace(jobID), "Unable to get current batch status")
+		}
+		select {
+		case st := <-final:
+			if st.Error != "" {
+				errorIf(errDummy().Trace(jobID), "The batch job failed: %s", st.Error)
+			}
+		default:
+		}
+	} else {
+		<-ctxt.Done()
+	}
+	return nil
+}
+
 func initBatchJobMetricsUI(jobID string) *batchJobMetricsUI {
 	s := spinner.New()
 	s.Spinner = spinner.Points
@@ -0,0 +1,826 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	mrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var benchFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "duration",
+		Usage: "duration of the benchmark",
+		Value: "1m",
+	},
+	cli.IntFlag{
+		Name:  "concurrent, c",
+		Usage: "number of concurrent operations",
+		Value: 16,
+	},
+	cli.StringFlag{
+		Name:  "mix",
+		Usage: "weighted mix of 'get', 'put', 'delete', 'list' and 'stat' operations",
+		Value: "get=45,put=30,stat=15,list=5,delete=5",
+	},
+	cli.StringFlag{
+		Name:  "size",
+		Usage: "weighted distribution of the object sizes",
+		Value: "1MiB",
+	},
+	cli.IntFlag{
+		Name:  "objects",
+		Usage: "number of distinct object names used by the benchmark",
+		Value: 1000,
+	},
+	cli.StringFlag{
+		Name:  "key-dist",
+		Usage: "distribution of the accessed object names, one of 'uniform', 'zipf' or 'sequential'",
+		Value: "uniform",
+	},
+	cli.BoolFlag{
+		Name:  "no-prefill",
+		Usage: "do not upload the objects before the benchmark starts",
+	},
+	cli.BoolFlag{
+		Name:  "keep",
+		Usage: "do not remove the objects created by the benchmark",
+	},
+	cli.Int64Flag{
+		Name:  "seed",
+		Usage: "seed of the random operations, object sizes and names",
+	},
+}
+
+var benchCmd = cli.Command{
+	Name:         "bench",
+	Usage:        "run an S3 workload and measure its latency and throughput",
+	Action:       mainBench,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(benchFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Runs a mix of S3 operations from this host against TARGET, which can be any
+  alias and bucket, including non MinIO S3 services. All objects are created
+  under a new 'mc-bench-*' prefix in TARGET and removed at the end unless
+  --keep is set.
+
+  --mix and --size take comma separated NAME=WEIGHT pairs, the weight is 1
+  when omitted. Before the benchmark starts, --objects objects are uploaded
+  so that GET, STAT and DELETE find existing objects. GET, STAT and DELETE
+  only address objects which exist, PUT (re)creates them and LIST lists the
+  first 1000 objects of the prefix. When no existing object is found, the
+  operation is counted as skipped and the worker waits briefly before the
+  next one. 'delete' requires 'put' in --mix to recreate the objects.
+
+  For each operation the number of operations and errors, the operations and
+  bytes per second and the latency percentiles are reported.
+
+EXAMPLES:
+  1. Run the default mix of operations for one minute on the bucket 'bench'.
+     {{.Prompt}} {{.HelpName}} myminio/bench
+
+  2. Upload 4KiB, 1MiB and 64MiB objects with 64 concurrent operations for 5 minutes.
+     {{.Prompt}} {{.HelpName}} --mix put --size 4KiB=70,1MiB=25,64MiB=5 --concurrent 64 --duration 5m s3/bench
+
+  3. Read hot objects following a zipf distribution, with a fixed seed to compare services.
+     {{.Prompt}} {{.HelpName}} --mix get=9,stat=1 --key-dist zipf --seed 42 --json gcs/bench
+`,
+}
+
+// benchOps lists the supported operations in their display order.
+var benchOps = []string{"get", "put", "delete", "list", "stat"}
+
+// benchLatency holds the latency statistics of an operation.
+type benchLatency struct {
+	Min  time.Duration `json:"min"`
+	Avg  time.Duration `json:"avg"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	P999 time.Duration `json:"p999"`
+	Max  time.Duration `json:"max"`
+}
+
+// benchOpResult holds the results of one operation.
+type benchOpResult struct {
+	Op          string       `json:"op"`
+	Count       uint64       `json:"count"`
+	Errors      uint64       `json:"errors"`
+	Skipped     uint64       `json:"skipped"`
+	Error       string       `json:"error,omitempty"`
+	Bytes       uint64       `json:"bytes"`
+	OpsPerSec   float64      `json:"opsPerSec"`
+	BytesPerSec float64      `json:"bytesPerSec"`
+	Latency     benchLatency `json:"latency"`
+}
+
+// benchMessage is the result of a benchmark run.
+type benchMessage struct {
+	Status     string          `json:"status"`
+	Target     string          `json:"target"`
+	Prefix     string          `json:"prefix"`
+	Duration   time.Duration   `json:"duration"`
+	Concurrent int             `json:"concurrent"`
+	Objects    int             `json:"objects"`
+	KeyDist    string          `json:"keyDist"`
+	Operations []benchOpResult `json:"operations"`
+	Total      benchOpResult   `json:"total"`
+}
+
+func (m benchMessage) JSON() string {
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (m benchMessage) String() string {
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	}
+	row := func(r benchOpResult) []string {
+		throughput := ""
+		if r.Bytes > 0 {
+			throughput = humanize.IBytes(uint64(r.BytesPerSec)) + "/s"
+		}
+		return []string{
+			strings.ToUpper(r.Op), strconv.FormatUint(r.Count, 10), strconv.FormatUint(r.Errors, 10), strconv.FormatUint(r.Skipped, 10),
+			fmt.Sprintf("%.1f", r.OpsPerSec), throughput,
+			ms(r.Latency.P50), ms(r.Latency.P90), ms(r.Latency.P99), ms(r.Latency.P999), ms(r.Latency.Max),
+		}
+	}
+	cellText := [][]string{{"Op", "Count", "Errors", "Skipped", "Ops/s", "Throughput", "p50", "p90", "p99", "p99.9", "Max"}}
+	printColors := []*color.Color{getPrintCol(colGreen)}
+	for _, r := range m.Operations {
+		cellText = append(cellText, row(r))
+		printColors = append(printColors, getPrintCol(colGrey))
+	}
+	if len(m.Operations) > 1 {
+		cellText = append(cellText, row(m.Total))
+		printColors = append(printColors, getPrintCol(colGreen))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Benchmarked %s for %s with %d concurrent operations on %d objects (%s)\n\n",
+		m.Target, m.Duration.Round(time.Millisecond), m.Concurrent, m.Objects, m.KeyDist)
+	tbl := console.NewTable(printColors, make([]bool, len(cellText[0])), 0)
+	fatalIf(probe.NewError(tbl.PopulateTable(&b, cellText)), "Unable to populate the table.")
+	for _, r := range m.Operations {
+		if r.Error != "" {
+			fmt.Fprintln(&b, console.Colorize("BenchError", fmt.Sprintf("%s: %d errors, last: %s", strings.ToUpper(r.Op), r.Errors, r.Error)))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// benchChoice is a weighted choice of a --mix or --size value.
+type benchChoice struct {
+	value  string
+	weight int
+}
+
+// parseBenchChoices parses comma separated NAME[=WEIGHT] pairs.
+func parseBenchChoices(s string) ([]benchChoice, error) {
+	var choices []benchChoice
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, weight, found := strings.Cut(field, "=")
+		choice := benchChoice{value: strings.TrimSpace(name), weight: 1}
+		if found {
+			w, e := strconv.Atoi(strings.TrimSpace(weight))
+			if e != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight in `%s`", field)
+			}
+			choice.weight = w
+		}
+		if choice.weight > 0 {
+			choices = append(choices, choice)
+		}
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no value with a positive weight in `%s`", s)
+	}
+	return choices, nil
+}
+
+// benchWeighted picks values according to their weights.
+type benchWeighted[T any] struct {
+	values []T
+	cumul  []int
+}
+
+func (w benchWeighted[T]) pick(r *mrand.Rand) T {
+	n := r.Intn(w.cumul[len(w.cumul)-1])
+	return w.values[sort.SearchInts(w.cumul, n+1)]
+}
+
+func (w *benchWeighted[T]) add(v T, weight int) {
+	total := weight
+	if len(w.cumul) > 0 {
+		total += w.cumul[len(w.cumul)-1]
+	}
+	w.values = append(w.values, v)
+	w.cumul = append(w.cumul, total)
+}
+
+func parseBenchMix(s string) (mix benchWeighted[string], e error) {
+	choices, e := parseBenchChoices(s)
+	if e != nil {
+		return mix, e
+	}
+	for _, c := range choices {
+		op := strings.ToLower(c.value)
+		found := false
+		for _, known := range benchOps {
+			found = found || op == known
+		}
+		if !found {
+			return mix, fmt.Errorf("unknown operation `%s`, expected one of %s", c.value, strings.Join(benchOps, ", "))
+		}
+		mix.add(op, c.weight)
+	}
+	return mix, nil
+}
+
+func parseBenchSizes(s string) (sizes benchWeighted[int64], e error) {
+	choices, e := parseBenchChoices(s)
+	if e != nil {
+		return sizes, e
+	}
+	for _, c := range choices {
+		size, e := humanize.ParseBytes(c.value)
+		if e != nil {
+			return sizes, fmt.Errorf("invalid object size `%s`: %v", c.value, e)
+		}
+		sizes.add(int64(size), c.weight)
+	}
+	return sizes, nil
+}
+
+// benchKeyPicker returns the index of the next object name to access.
+type benchKeyPicker func() int
+
+// newBenchKeyPicker returns a picker of the object indexes in [0, n) for a worker.
+func newBenchKeyPicker(dist string, n int, r *mrand.Rand, worker, workers int) (benchKeyPicker, error) {
+	switch dist {
+	case "uniform":
+		return func() int { return r.Intn(n) }, nil
+	case "zipf":
+		if n < 2 {
+			return func() int { return 0 }, nil
+		}
+		z := mrand.NewZipf(r, 1.1, 1, uint64(n-1))
+		return func() int { return int(z.Uint64()) }, nil
+	case "sequential":
+		next := worker * n / workers
+		return func() int {
+			i := next % n
+			next++
+			return i
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown key distribution `%s`, expected one of uniform, zipf or sequential", dist)
+}
+
+// Latencies are counted in logarithmic buckets of 1% from 1µs, the
+// last bucket holds everything above about 11 minutes.
+const (
+	benchHistBuckets = 2048
+	benchHistGrowth  = 1.01
+)
+
+// benchHistBucket returns the histogram bucket of a latency.
+func benchHistBucket(d time.Duration) int {
+	if d < time.Microsecond {
+		return 0
+	}
+	i := int(math.Log(float64(d)/float64(time.Microsecond))/math.Log(benchHistGrowth)) + 1
+	if i >= benchHistBuckets {
+		i = benchHistBuckets - 1
+	}
+	return i
+}
+
+// benchHistValue returns the geometric middle of a histogram bucket.
+func benchHistValue(i int) time.Duration {
+	if i == 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Microsecond) * math.Pow(benchHistGrowth, float64(i)-0.5))
+}
+
+// benchStats collects the latencies of an operation.
+type benchStats struct {
+	hist      [benchHistBuckets]uint64
+	count     uint64
+	sum       time.Duration
+	min       time.Duration
+	max       time.Duration
+	errors    uint64
+	skipped   uint64
+	lastError string
+	bytes     uint64
+}
+
+func (s *benchStats) add(latency time.Duration) {
+	if s.count == 0 || latency < s.min {
+		s.min = latency
+	}
+	if latency > s.max {
+		s.max = latency
+	}
+	s.hist[benchHistBucket(latency)]++
+	s.count++
+	s.sum += latency
+}
+
+// result summarizes the latencies of an operation.
+func (s *benchStats) result(op string, elapsed time.Duration) benchOpResult {
+	r := benchOpResult{
+		Op:      op,
+		Count:   s.count,
+		Errors:  s.errors,
+		Skipped: s.skipped,
+		Error:   s.lastError,
+		Bytes:   s.bytes,
+	}
+	if elapsed > 0 {
+		r.OpsPerSec = float64(r.Count) / elapsed.Seconds()
+		r.BytesPerSec = float64(r.Bytes) / elapsed.Seconds()
+	}
+	if s.count == 0 {
+		return r
+	}
+	percentile := func(p float64) time.Duration {
+		rank := uint64(math.Ceil(p * float64(s.count)))
+		var seen uint64
+		for i, n := range s.hist {
+			if seen += n; seen >= rank && n > 0 {
+				// The bucket value is clamped to the exact extremes.
+				v := benchHistValue(i)
+				if v < s.min {
+					v = s.min
+				}
+				if v > s.max {
+					v = s.max
+				}
+				return v
+			}
+		}
+		return s.max
+	}
+	r.Latency = benchLatency{
+		Min:  s.min,
+		Avg:  s.sum / time.Duration(s.count),
+		P50:  percentile(0.5),
+		P90:  percentile(0.9),
+		P99:  percentile(0.99),
+		P999: percentile(0.999),
+		Max:  s.max,
+	}
+	return r
+}
+
+func (s *benchStats) merge(o *benchStats) {
+	if o.count > 0 && (s.count == 0 || o.min < s.min) {
+		s.min = o.min
+	}
+	if o.max > s.max {
+		s.max = o.max
+	}
+	for i, n := range o.hist {
+		s.hist[i] += n
+	}
+	s.count += o.count
+	s.sum += o.sum
+	s.errors += o.errors
+	s.skipped += o.skipped
+	s.bytes += o.bytes
+	if o.lastError != "" {
+		s.lastError = o.lastError
+	}
+}
+
+// benchConfig holds the parameters of a benchmark.
+type benchConfig struct {
+	target     string
+	duration   time.Duration
+	concurrent int
+	mix        benchWeighted[string]
+	sizes      benchWeighted[int64]
+	objects    int
+	keyDist    string
+	prefill    bool
+	keep       bool
+	seed       int64
+}
+
+// benchRun is the state shared by the workers of a benchmark.
+type benchRun struct {
+	benchConfig
+	prefix  string
+	data    []byte
+	present []atomic.Bool
+	// The clients are created before the benchmark, so that only the
+	// S3 calls are timed.
+	clients    []Client
+	listClient Client
+}
+
+func (b *benchRun) objectURL(i int) string {
+	return urlJoinPath(b.target, fmt.Sprintf("%s/obj-%08d", b.prefix, i))
+}
+
+// newClients creates the clients of the objects and of the prefix.
+func (b *benchRun) newClients() *probe.Error {
+	var err *probe.Error
+	b.clients = make([]Client, b.objects)
+	for i := range b.clients {
+		if b.clients[i], err = newClient(b.objectURL(i)); err != nil {
+			return err.Trace(b.objectURL(i))
+		}
+	}
+	b.listClient, err = newClient(urlJoinPath(b.target, b.prefix) + "/")
+	return err
+}
+
+func (b *benchRun) put(ctx context.Context, i int, size int64) (uint64, *probe.Error) {
+	n, err := b.clients[i].Put(ctx, bytes.NewReader(b.data[:size]), size, nil, PutOptions{})
+	if err == nil {
+		b.present[i].Store(true)
+	}
+	return uint64(n), err
+}
+
+// do runs one operation and returns the number of bytes transferred.
+func (b *benchRun) do(ctx context.Context, op string, i int, size int64) (uint64, *probe.Error) {
+	switch op {
+	case "put":
+		return b.put(ctx, i, size)
+	case "list":
+		listCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		count := 0
+		for content := range b.listClient.List(listCtx, ListOptions{ShowDir: DirNone}) {
+			if content.Err != nil {
+				return 0, content.Err
+			}
+			if count++; count == 1000 {
+				break
+			}
+		}
+		return 0, nil
+	}
+
+	clnt := b.clients[i]
+	switch op {
+	case "get":
+		reader, _, err := clnt.Get(ctx, GetOptions{})
+		if err != nil {
+			return 0, err
+		}
+		defer reader.Close()
+		n, e := io.Copy(io.Discard, reader)
+		return uint64(n), probe.NewError(e)
+	case "stat":
+		_, err := clnt.Stat(ctx, StatOptions{})
+		return 0, err
+	case "delete":
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- &ClientContent{URL: clnt.GetURL()}
+		close(contentCh)
+		for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+			if result.Err != nil {
+				return 0, result.Err
+			}
+		}
+		return 0, nil
+	}
+	return 0, errInvalidArgument().Trace(op)
+}
+
+// benchMissBackoff is the wait of a worker which found no existing object.
+const benchMissBackoff = 10 * time.Millisecond
+
+// pickExisting returns the index of an existing object, or -1 when none was found.
+func (b *benchRun) pickExisting(next benchKeyPicker, remove bool) int {
+	for range 16 {
+		i := next()
+		if remove && b.present[i].CompareAndSwap(true, false) {
+			return i
+		}
+		if !remove && b.present[i].Load() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *benchRun) worker(ctx context.Context, worker int) (map[string]*benchStats, error) {
+	r := mrand.New(mrand.NewSource(b.seed + int64(worker)))
+	next, e := newBenchKeyPicker(b.keyDist, b.objects, r, worker, b.concurrent)
+	if e != nil {
+		return nil, e
+	}
+	stats := make(map[string]*benchStats)
+	for ctx.Err() == nil {
+		op := b.mix.pick(r)
+		size := b.sizes.pick(r)
+		var i int
+		switch op {
+		case "get", "stat":
+			i = b.pickExisting(next, false)
+		case "delete":
+			i = b.pickExisting(next, true)
+		default:
+			i = next()
+		}
+		s := stats[op]
+		if s == nil {
+			s = &benchStats{}
+			stats[op] = s
+		}
+		if i < 0 {
+			// No existing object was found, wait for a PUT to create one.
+			s.skipped++
+			select {
+			case <-ctx.Done():
+			case <-time.After(benchMissBackoff):
+			}
+			continue
+		}
+
+		start := time.Now()
+		n, err := b.do(ctx, op, i, size)
+		latency := time.Since(start)
+		if ctx.Err() != nil {
+			// Operations interrupted by the end of the benchmark are not counted.
+			break
+		}
+		if err != nil {
+			s.errors++
+			s.lastError = err.ToGoError().Error()
+			continue
+		}
+		s.add(latency)
+		s.bytes += n
+	}
+	return stats, nil
+}
+
+// parallel runs fn on the object indexes [0, n) with the configured concurrency.
+func (b *benchRun) parallel(ctx context.Context, n int, fn func(i int) *probe.Error) *probe.Error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr *probe.Error
+		next     atomic.Int64
+	)
+	for range b.concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n && ctx.Err() == nil; i = int(next.Add(1) - 1) {
+				if err := fn(i); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// cleanup removes all objects created by the benchmark.
+func (b *benchRun) cleanup(ctx context.Context) *probe.Error {
+	clnt, err := newClient(urlJoinPath(b.target, b.prefix) + "/")
+	if err != nil {
+		return err
+	}
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirLast}) {
+			if content.Err != nil {
+				continue
+			}
+			contentCh <- content
+		}
+	}()
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			err = result.Err
+		}
+	}
+	return err
+}
+
+// runBench runs the benchmark described by cfg.
+func runBench(ctx context.Context, cfg benchConfig) (benchMessage, *probe.Error) {
+	b := &benchRun{
+		benchConfig: cfg,
+		prefix:      fmt.Sprintf("mc-bench-%d", time.Now().UnixNano()),
+		present:     make([]atomic.Bool, cfg.objects),
+	}
+	var maxSize int64
+	for _, size := range cfg.sizes.values {
+		if size > maxSize {
+			maxSize = size
+		}
+	}
+	b.data = make([]byte, maxSize)
+	if _, e := rand.Read(b.data); e != nil {
+		return benchMessage{}, probe.NewError(e)
+	}
+	if err := b.newClients(); err != nil {
+		return benchMessage{}, err
+	}
+
+	if !cfg.keep {
+		defer func() {
+			errorIf(b.cleanup(globalContext).Trace(b.target), "Unable to remove the objects created by the benchmark.")
+		}()
+	}
+
+	if cfg.prefill {
+		r := mrand.New(mrand.NewSource(cfg.seed))
+		sizes := make([]int64, cfg.objects)
+		for i := range sizes {
+			sizes[i] = cfg.sizes.pick(r)
+		}
+		err := b.parallel(ctx, cfg.objects, func(i int) *probe.Error {
+			_, err := b.put(ctx, i, sizes[i])
+			return err
+		})
+		if err != nil {
+			return benchMessage{}, err.Trace(b.objectURL(0))
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		workErr error
+		stats   = make(map[string]*benchStats)
+	)
+	start := time.Now()
+	for w := range cfg.concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws, e := b.worker(runCtx, w)
+			mu.Lock()
+			defer mu.Unlock()
+			if e != nil {
+				workErr = e
+				return
+			}
+			for op, s := range ws {
+				if stats[op] == nil {
+					stats[op] = &benchStats{}
+				}
+				stats[op].merge(s)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if workErr != nil {
+		return benchMessage{}, probe.NewError(workErr)
+	}
+
+	msg := benchMessage{
+		Status:     "success",
+		Target:     cfg.target,
+		Prefix:     b.prefix,
+		Duration:   elapsed,
+		Concurrent: cfg.concurrent,
+		Objects:    cfg.objects,
+		KeyDist:    cfg.keyDist,
+	}
+	total := &benchStats{}
+	for _, op := range benchOps {
+		if s, ok := stats[op]; ok {
+			msg.Operations = append(msg.Operations, s.result(op, elapsed))
+			total.merge(s)
+		}
+	}
+	total.lastError = ""
+	msg.Total = total.result("total", elapsed)
+	return msg, nil
+}
+
+func checkBenchSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+}
+
+// mainBench is the handle for "mc bench" command.
+func mainBench(ctx *cli.Context) error {
+	checkBenchSyntax(ctx)
+
+	console.SetColor("BenchError", color.New(color.FgRed))
+
+	target := strings.TrimSuffix(ctx.Args().Get(0), "/")
+	if alias, path := url2Alias(target); alias != "" && strings.Trim(path, "/") == "" {
+		fatalIf(errInvalidArgument().Trace(target), "Please specify a bucket to run the benchmark in.")
+	}
+
+	cfg := benchConfig{
+		target:     target,
+		concurrent: ctx.Int("concurrent"),
+		objects:    ctx.Int("objects"),
+		keyDist:    strings.ToLower(ctx.String("key-dist")),
+		prefill:    !ctx.Bool("no-prefill"),
+		keep:       ctx.Bool("keep"),
+		seed:       ctx.Int64("seed"),
+	}
+	if !ctx.IsSet("seed") {
+		cfg.seed = time.Now().UnixNano()
+	}
+	var e error
+	cfg.duration, e = time.ParseDuration(ctx.String("duration"))
+	fatalIf(probe.NewError(e), "Unable to parse --duration.")
+	cfg.mix, e = parseBenchMix(ctx.String("mix"))
+	fatalIf(probe.NewError(e), "Unable to parse --mix.")
+	cfg.sizes, e = parseBenchSizes(ctx.String("size"))
+	fatalIf(probe.NewError(e), "Unable to parse --size.")
+	_, e = newBenchKeyPicker(cfg.keyDist, 1, nil, 0, 1)
+	fatalIf(probe.NewError(e), "Unable to parse --key-dist.")
+	if cfg.duration <= 0 || cfg.concurrent <= 0 || cfg.objects <= 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--duration, --concurrent and --objects must be positive.")
+	}
+	hasPut, hasDelete := false, false
+	for _, op := range cfg.mix.values {
+		hasPut = hasPut || op == "put"
+		hasDelete = hasDelete || op == "delete"
+	}
+	if !cfg.prefill && !hasPut {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--no-prefill requires 'put' in --mix to create objects.")
+	}
+	if hasDelete && !hasPut {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "'delete' in --mix requires 'put' to recreate the deleted objects.")
+	}
+
+	if !globalJSON && !globalQuiet {
+		console.Infof("Running the benchmark on %s for %s...\n", target, cfg.duration)
+	}
+	msg, err := runBench(globalContext, cfg)
+	fatalIf(err.Trace(target), "Unable to run the benchmark.")
+	printMsg(msg)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestParseBenchMix(t *testing.T) {
+	mix, e := parseBenchMix("GET=3, put ,stat=0")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(mix.values) != 2 || mix.values[0] != "get" || mix.values[1] != "put" || mix.cumul[1] != 4 {
+		t.Errorf("unexpected mix %+v", mix)
+	}
+	for _, s := range []string{"copy", "get=x", "get=-1", "stat=0"} {
+		if _, e := parseBenchMix(s); e == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+
+	sizes, e := parseBenchSizes("4KiB=70,1MiB=30")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if sizes.values[0] != 4<<10 || sizes.values[1] != 1<<20 || sizes.cumul[1] != 100 {
+		t.Errorf("unexpected sizes %+v", sizes)
+	}
+}
+
+func TestBenchStatsResult(t *testing.T) {
+	var s, other benchStats
+	for i := 100; i >= 1; i-- {
+		if i%2 == 0 {
+			s.add(time.Duration(i) * time.Millisecond)
+		} else {
+			other.add(time.Duration(i) * time.Millisecond)
+		}
+	}
+	s.merge(&other)
+	s.bytes = 1000
+	r := s.result("get", 10*time.Second)
+	if r.Latency.Min != time.Millisecond || r.Latency.Avg != 50500*time.Microsecond || r.Latency.Max != 100*time.Millisecond {
+		t.Errorf("unexpected min, avg or max %+v", r.Latency)
+	}
+	// The percentiles are exact to the 1% of their histogram bucket.
+	for _, p := range []struct {
+		got, want time.Duration
+	}{
+		{r.Latency.P50, 50 * time.Millisecond},
+		{r.Latency.P90, 90 * time.Millisecond},
+		{r.Latency.P99, 99 * time.Millisecond},
+		{r.Latency.P999, 100 * time.Millisecond},
+	} {
+		if diff := p.got - p.want; diff > p.want/100 || -diff > p.want/100 {
+			t.Errorf("expected %v, got %v", p.want, p.got)
+		}
+	}
+	if r.Count != 100 || r.OpsPerSec != 10 || r.BytesPerSec != 100 {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestRunBench(t *testing.T) {
+	defer func(load func() (*configV10, *probe.Error)) { loadMcConfig = load }(loadMcConfig)
+	loadMcConfig = func() (*configV10, *probe.Error) { return newMcConfig(), nil }
+
+	dir := t.TempDir()
+	mix, _ := parseBenchMix("get=2,stat,list")
+	sizes, _ := parseBenchSizes("1KiB,4KiB")
+	msg, err := runBench(context.Background(), benchConfig{
+		target:     dir,
+		duration:   200 * time.Millisecond,
+		concurrent: 2,
+		mix:        mix,
+		sizes:      sizes,
+		objects:    10,
+		keyDist:    "zipf",
+		prefill:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Operations) != 3 || msg.Total.Count == 0 || msg.Total.Errors != 0 {
+		t.Errorf("unexpected result %+v", msg)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the benchmark objects to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestRunBenchSkipped(t *testing.T) {
+	defer func(load func() (*configV10, *probe.Error)) { loadMcConfig = load }(loadMcConfig)
+	loadMcConfig = func() (*configV10, *probe.Error) { return newMcConfig(), nil }
+
+	// Without prefill and PUT, GET never finds an object.
+	mix, _ := parseBenchMix("get")
+	sizes, _ := parseBenchSizes("1KiB")
+	msg, err := runBench(context.Background(), benchConfig{
+		target:     t.TempDir(),
+		duration:   100 * time.Millisecond,
+		concurrent: 2,
+		mix:        mix,
+		sizes:      sizes,
+		objects:    10,
+		keyDist:    "uniform",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The workers wait between misses instead of spinning.
+	if msg.Total.Count != 0 || msg.Total.Skipped == 0 || msg.Total.Skipped > 50 {
+		t.Errorf("unexpected result %+v", msg.Total)
+	}
+}
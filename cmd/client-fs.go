@@ -587,6 +587,12 @@ func (f *fsClient) Get(_ context.Context, opts GetOptions) (io.ReadCloser, *Clie
 		content.Metadata[metadataKey] = fileAttr
 	}
 
+	if opts.RangeEnd != 0 {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(fileData, opts.RangeEnd-opts.RangeStart+1), fileData}, content, nil
+	}
 	return fileData, content, nil
 }
 
@@ -0,0 +1,267 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	clientTransferredBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_client_transferred_bytes_total",
+		Help: "The total number of bytes sent and received in HTTP request and response bodies",
+	}, []string{"direction"})
+	clientTransferredObjects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mc_client_transferred_objects_total",
+		Help: "The total number of objects copied to their target",
+	})
+	clientErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_client_errors_total",
+		Help: "The total number of errors by type",
+	}, []string{"type"})
+	clientRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mc_client_retries_total",
+		Help: "The total number of operations retried",
+	})
+	clientQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mc_client_parallel_queue_depth",
+		Help: "The number of tasks waiting for a parallel worker",
+	})
+	clientWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mc_client_parallel_workers",
+		Help: "The number of parallel workers",
+	})
+	clientInflightBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mc_client_inflight_upload_bytes",
+		Help: "The total size of uploads currently in progress",
+	})
+	clientRequestDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mc_client_request_duration_seconds",
+		Help:    "Histogram of HTTP request latency until response headers per API",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"api"})
+)
+
+var globalMetricsOnce sync.Once
+
+// initClientMetrics serves the client metrics in the Prometheus
+// format at http://address/metrics for the lifetime of the command.
+func initClientMetrics(address string) error {
+	var err error
+	globalMetricsOnce.Do(func() {
+		var l net.Listener
+		l, err = net.Listen("tcp", address)
+		if err != nil {
+			return
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			if e := http.Serve(l, mux); e != nil {
+				errorIf(probe.NewError(e), "Unable to serve client metrics.")
+			}
+		}()
+		globalMetricsEnabled = true
+	})
+	return err
+}
+
+// globalMetricsEnabled is set when client metrics are served.
+var globalMetricsEnabled bool
+
+// countClientError updates the error metrics with the type of an
+// error reported to the user.
+func countClientError(err error) {
+	if err == nil || !globalMetricsEnabled {
+		return
+	}
+	clientErrors.WithLabelValues(clientErrorType(err)).Inc()
+}
+
+// clientErrorType returns a short and bounded classification of err,
+// which is the S3 error code for errors returned by the server.
+func clientErrorType(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "Timeout"
+		}
+		return "NetworkError"
+	}
+	if code := minio.ToErrorResponse(err).Code; code != "" {
+		return code
+	}
+	return "Other"
+}
+
+// metricsTransport records request latencies and transferred bytes.
+type metricsTransport struct {
+	transport http.RoundTripper
+	// host of the endpoint, requests to any other
+	// host use virtual host style bucket lookup.
+	host string
+}
+
+func (m metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		body := &countingBody{ReadCloser: req.Body, direction: "upload"}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	api := s3APIName(req, req.URL.Host != m.host)
+	start := time.Now()
+	resp, err := m.transport.RoundTrip(req)
+	clientRequestDurations.WithLabelValues(api).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return resp, err
+	}
+	if resp.Body != nil && resp.Body != http.NoBody {
+		resp.Body = &countingBody{ReadCloser: resp.Body, direction: "download"}
+	}
+	return resp, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	direction string
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 {
+		clientTransferredBytes.WithLabelValues(c.direction).Add(float64(n))
+	}
+	return n, err
+}
+
+// s3SubResourceAPIs maps sub-resource query parameters to the API
+// names of bucket and object requests, indexed by HTTP method.
+var s3SubResourceAPIs = map[string]map[string]string{
+	"tagging":      {http.MethodGet: "GetTagging", http.MethodPut: "PutTagging", http.MethodDelete: "DeleteTagging"},
+	"retention":    {http.MethodGet: "GetObjectRetention", http.MethodPut: "PutObjectRetention"},
+	"legal-hold":   {http.MethodGet: "GetObjectLegalHold", http.MethodPut: "PutObjectLegalHold"},
+	"acl":          {http.MethodGet: "GetACL", http.MethodPut: "PutACL"},
+	"versioning":   {http.MethodGet: "GetBucketVersioning", http.MethodPut: "PutBucketVersioning"},
+	"lifecycle":    {http.MethodGet: "GetBucketLifecycle", http.MethodPut: "PutBucketLifecycle", http.MethodDelete: "DeleteBucketLifecycle"},
+	"policy":       {http.MethodGet: "GetBucketPolicy", http.MethodPut: "PutBucketPolicy", http.MethodDelete: "DeleteBucketPolicy"},
+	"notification": {http.MethodGet: "GetBucketNotification", http.MethodPut: "PutBucketNotification"},
+	"replication":  {http.MethodGet: "GetBucketReplication", http.MethodPut: "PutBucketReplication", http.MethodDelete: "DeleteBucketReplication"},
+	"encryption":   {http.MethodGet: "GetBucketEncryption", http.MethodPut: "PutBucketEncryption", http.MethodDelete: "DeleteBucketEncryption"},
+	"object-lock":  {http.MethodGet: "GetObjectLockConfig", http.MethodPut: "PutObjectLockConfig"},
+	"cors":         {http.MethodGet: "GetBucketCors", http.MethodPut: "PutBucketCors", http.MethodDelete: "DeleteBucketCors"},
+	"versions":     {http.MethodGet: "ListObjectVersions"},
+	"delete":       {http.MethodPost: "DeleteObjects"},
+	"location":     {http.MethodGet: "GetBucketLocation"},
+	"select":       {http.MethodPost: "SelectObjectContent"},
+	"restore":      {http.MethodPost: "RestoreObject"},
+	"attributes":   {http.MethodGet: "GetObjectAttributes"},
+	"events":       {http.MethodGet: "ListenBucketNotification"},
+	"uploads":      {http.MethodGet: "ListMultipartUploads", http.MethodPost: "CreateMultipartUpload"},
+	"uploadId": {
+		http.MethodGet:    "ListParts",
+		http.MethodPut:    "UploadPart",
+		http.MethodPost:   "CompleteMultipartUpload",
+		http.MethodDelete: "AbortMultipartUpload",
+	},
+}
+
+// s3APIName returns the S3 API name of a request, derived from its
+// method, path and query parameters. Admin API calls are reported
+// as 'admin.<name>'.
+func s3APIName(req *http.Request, virtualHost bool) string {
+	if strings.HasPrefix(req.URL.Path, "/minio/admin/") {
+		parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		return "admin." + parts[len(parts)-1]
+	}
+
+	isCopy := req.Header.Get("X-Amz-Copy-Source") != ""
+	query := req.URL.Query()
+	for param, apis := range s3SubResourceAPIs {
+		if _, ok := query[param]; !ok {
+			continue
+		}
+		if api, ok := apis[req.Method]; ok {
+			if api == "UploadPart" && isCopy {
+				return "UploadPartCopy"
+			}
+			return api
+		}
+	}
+
+	p := strings.Trim(req.URL.Path, "/")
+	isBucket := p != "" && !strings.Contains(p, "/")
+	isObject := strings.Contains(p, "/")
+	if virtualHost {
+		isBucket, isObject = p == "", p != ""
+	}
+	switch {
+	case isObject:
+		switch req.Method {
+		case http.MethodGet:
+			return "GetObject"
+		case http.MethodHead:
+			return "HeadObject"
+		case http.MethodPut:
+			if isCopy {
+				return "CopyObject"
+			}
+			return "PutObject"
+		case http.MethodDelete:
+			return "DeleteObject"
+		}
+	case isBucket:
+		switch req.Method {
+		case http.MethodGet:
+			if query.Get("list-type") == "2" {
+				return "ListObjectsV2"
+			}
+			return "ListObjects"
+		case http.MethodHead:
+			return "HeadBucket"
+		case http.MethodPut:
+			return "CreateBucket"
+		case http.MethodDelete:
+			return "DeleteBucket"
+		case http.MethodPost:
+			return "PostObject"
+		}
+	default:
+		if req.Method == http.MethodGet {
+			return "ListBuckets"
+		}
+	}
+	return "Other"
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestS3APIName(t *testing.T) {
+	testCases := []struct {
+		method      string
+		url         string
+		copySource  bool
+		virtualHost bool
+		want        string
+	}{
+		{http.MethodGet, "https://play.min.io/", false, false, "ListBuckets"},
+		{http.MethodGet, "https://play.min.io/bucket/?list-type=2&prefix=a", false, false, "ListObjectsV2"},
+		{http.MethodHead, "https://play.min.io/bucket", false, false, "HeadBucket"},
+		{http.MethodPut, "https://play.min.io/bucket", false, false, "CreateBucket"},
+		{http.MethodGet, "https://play.min.io/bucket/a/b", false, false, "GetObject"},
+		{http.MethodPut, "https://play.min.io/bucket/a", false, false, "PutObject"},
+		{http.MethodPut, "https://play.min.io/bucket/a", true, false, "CopyObject"},
+		{http.MethodPost, "https://play.min.io/bucket/a?uploads=", false, false, "CreateMultipartUpload"},
+		{http.MethodPut, "https://play.min.io/bucket/a?partNumber=1&uploadId=x", false, false, "UploadPart"},
+		{http.MethodPut, "https://play.min.io/bucket/a?partNumber=1&uploadId=x", true, false, "UploadPartCopy"},
+		{http.MethodPost, "https://play.min.io/bucket/a?uploadId=x", false, false, "CompleteMultipartUpload"},
+		{http.MethodPost, "https://play.min.io/bucket?delete=", false, false, "DeleteObjects"},
+		{http.MethodGet, "https://play.min.io/bucket?versioning=", false, false, "GetBucketVersioning"},
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/?list-type=2", false, true, "ListObjectsV2"},
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/object", false, true, "GetObject"},
+		{http.MethodGet, "https://play.min.io/minio/admin/v3/info", false, false, "admin.info"},
+	}
+	for i, tc := range testCases {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.copySource {
+			req.Header.Set("X-Amz-Copy-Source", "/bucket/src")
+		}
+		if got := s3APIName(req, tc.virtualHost); got != tc.want {
+			t.Errorf("Test %d: %s %s: expected %s, got %s", i+1, tc.method, tc.url, tc.want, got)
+		}
+	}
+}
+
+func TestClientErrorType(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want string
+	}{
+		{context.Canceled, "Canceled"},
+		{minio.ErrorResponse{Code: "NoSuchKey"}, "NoSuchKey"},
+		{errors.New("unknown"), "Other"},
+	}
+	for i, tc := range testCases {
+		if got := clientErrorType(tc.err); got != tc.want {
+			t.Errorf("Test %d: expected %s, got %s", i+1, tc.want, got)
+		}
+	}
+}
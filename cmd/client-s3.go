@@ -28,6 +28,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"path"
@@ -104,7 +105,7 @@ func newCustomDialContext(c *Config) dialContext {
 			KeepAlive: 15 * time.Second,
 		}
 
-		if ip, ok := globalResolvers[addr]; ok {
+		if ip, ok := lookupResolver(c.Resolvers, addr); ok {
 			if _, port, err := net.SplitHostPort(addr); err == nil {
 				addr = net.JoinHostPort(ip.String(), port)
 			} else {
@@ -125,8 +126,24 @@ func newCustomDialContext(c *Config) dialContext {
 	}
 }
 
+// lookupResolver returns the IP address addr is mapped to by resolvers,
+// or else by the --resolve global flag.
+func lookupResolver(resolvers map[string]netip.Addr, addr string) (netip.Addr, bool) {
+	if ip, ok := resolvers[addr]; ok {
+		return ip, true
+	}
+	ip, ok := globalResolvers[addr]
+	return ip, ok
+}
+
 // newCustomDialTLSContext setups a custom TLS dialer for any external communication and proxies.
 func newCustomDialTLSContext(tlsConf *tls.Config) dialContext {
+	return newResolverDialTLSContext(tlsConf, nil)
+}
+
+// newResolverDialTLSContext is newCustomDialTLSContext with resolvers
+// taking precedence over the --resolve global flag.
+func newResolverDialTLSContext(tlsConf *tls.Config, resolvers map[string]netip.Addr) dialContext {
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		dialer := &tls.Dialer{
 			NetDialer: &net.Dialer{
@@ -136,7 +153,7 @@ func newCustomDialTLSContext(tlsConf *tls.Config) dialContext {
 			Config: tlsConf,
 		}
 
-		if ip, ok := globalResolvers[addr]; ok {
+		if ip, ok := lookupResolver(resolvers, addr); ok {
 			dialer.Config = dialer.Config.Clone()
 			if host, port, err := net.SplitHostPort(addr); err == nil {
 				dialer.Config.ServerName = host // Set SNI
@@ -891,8 +908,8 @@ func (c *S3Client) Get(ctx context.Context, opts GetOptions) (io.ReadCloser, *Cl
 	if opts.Zip {
 		o.Set("x-minio-extract", "true")
 	}
-	if opts.RangeStart != 0 {
-		err := o.SetRange(opts.RangeStart, 0)
+	if opts.RangeStart != 0 || opts.RangeEnd != 0 {
+		err := o.SetRange(opts.RangeStart, opts.RangeEnd)
 		if err != nil {
 			return nil, nil, probe.NewError(err)
 		}
@@ -1019,6 +1036,28 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 		return 0, probe.NewError(BucketNameEmpty{})
 	}
 
+	opts, err := putObjectOptions(putOpts, progress)
+	if err != nil {
+		return 0, err
+	}
+
+	ui, e := c.api.PutObject(ctx, bucket, object, reader, size, opts)
+	if e != nil {
+		errResponse := minio.ToErrorResponse(e)
+		if errResponse.Code == "UnexpectedEOF" || e == io.EOF {
+			return ui.Size, probe.NewError(UnexpectedEOF{
+				TotalSize:    size,
+				TotalWritten: ui.Size,
+			})
+		}
+		return ui.Size, c.putError(e)
+	}
+	return ui.Size, nil
+}
+
+// putObjectOptions converts the mc put options and metadata into the
+// options of a PutObject call.
+func putObjectOptions(putOpts PutOptions, progress io.Reader) (minio.PutObjectOptions, *probe.Error) {
 	metadata := make(map[string]string, len(putOpts.metadata))
 	for k, v := range putOpts.metadata {
 		metadata[k] = v
@@ -1060,7 +1099,7 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 	if ok {
 		tagsSet, e := tags.Parse(tagsHdr, true)
 		if e != nil {
-			return 0, probe.NewError(e)
+			return minio.PutObjectOptions{}, probe.NewError(e)
 		}
 		tagsMap = tagsSet.ToMap()
 		delete(metadata, "X-Amz-Tagging")
@@ -1120,47 +1159,42 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 		// Only supported in newer MinIO releases.
 		opts.SetMatchETagExcept("*")
 	}
+	return opts, nil
+}
 
-	ui, e := c.api.PutObject(ctx, bucket, object, reader, size, opts)
-	if e != nil {
-		errResponse := minio.ToErrorResponse(e)
-		if errResponse.Code == "UnexpectedEOF" || e == io.EOF {
-			return ui.Size, probe.NewError(UnexpectedEOF{
-				TotalSize:    size,
-				TotalWritten: ui.Size,
-			})
-		}
-		if errResponse.Code == "AccessDenied" {
-			return ui.Size, probe.NewError(PathInsufficientPermission{
-				Path: c.targetURL.String(),
-			})
-		}
-		if errResponse.Code == "MethodNotAllowed" {
-			return ui.Size, probe.NewError(ObjectAlreadyExists{
-				Object: object,
-			})
-		}
-		if errResponse.Code == "XMinioObjectExistsAsDirectory" {
-			return ui.Size, probe.NewError(ObjectAlreadyExistsAsDirectory{
-				Object: object,
-			})
-		}
-		if errResponse.Code == "NoSuchBucket" {
-			return ui.Size, probe.NewError(BucketDoesNotExist{
-				Bucket: bucket,
-			})
-		}
-		if errResponse.Code == "InvalidBucketName" {
-			return ui.Size, probe.NewError(BucketInvalid{
-				Bucket: bucket,
-			})
-		}
-		if errResponse.Code == "NoSuchKey" {
-			return ui.Size, probe.NewError(ObjectMissing{})
-		}
-		return ui.Size, probe.NewError(e)
+// putError converts the error of an upload into an mc error.
+func (c *S3Client) putError(e error) *probe.Error {
+	bucket, object := c.url2BucketAndObject()
+	errResponse := minio.ToErrorResponse(e)
+	if errResponse.Code == "AccessDenied" {
+		return probe.NewError(PathInsufficientPermission{
+			Path: c.targetURL.String(),
+		})
 	}
-	return ui.Size, nil
+	if errResponse.Code == "MethodNotAllowed" {
+		return probe.NewError(ObjectAlreadyExists{
+			Object: object,
+		})
+	}
+	if errResponse.Code == "XMinioObjectExistsAsDirectory" {
+		return probe.NewError(ObjectAlreadyExistsAsDirectory{
+			Object: object,
+		})
+	}
+	if errResponse.Code == "NoSuchBucket" {
+		return probe.NewError(BucketDoesNotExist{
+			Bucket: bucket,
+		})
+	}
+	if errResponse.Code == "InvalidBucketName" {
+		return probe.NewError(BucketInvalid{
+			Bucket: bucket,
+		})
+	}
+	if errResponse.Code == "NoSuchKey" {
+		return probe.NewError(ObjectMissing{})
+	}
+	return probe.NewError(e)
 }
 
 // PutPart - upload an object with custom metadata. (Same as Put)
@@ -1168,6 +1202,78 @@ func (c *S3Client) PutPart(ctx context.Context, reader io.Reader, size int64, pr
 	return c.Put(ctx, reader, size, progress, putOpts)
 }
 
+// newMultipartUpload initiates a multipart upload of the object and
+// returns its upload ID.
+func (c *S3Client) newMultipartUpload(ctx context.Context, putOpts PutOptions) (string, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	if bucket == "" {
+		return "", probe.NewError(BucketNameEmpty{})
+	}
+	opts, err := putObjectOptions(putOpts, nil)
+	if err != nil {
+		return "", err
+	}
+	uploadID, e := minio.Core{Client: c.api}.NewMultipartUpload(ctx, bucket, object, opts)
+	if e != nil {
+		return "", c.putError(e)
+	}
+	return uploadID, nil
+}
+
+// putObjectPart uploads one part of a multipart upload.
+func (c *S3Client) putObjectPart(ctx context.Context, uploadID string, partNumber int, reader io.Reader, size int64, sse encrypt.ServerSide) (minio.ObjectPart, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	var opts minio.PutObjectPartOptions
+	// Only SSE-C keys are sent with every part, other encryption
+	// types are set when initiating the upload.
+	if sse != nil && sse.Type() == encrypt.SSEC {
+		opts.SSE = sse
+	}
+	part, e := minio.Core{Client: c.api}.PutObjectPart(ctx, bucket, object, uploadID, partNumber, reader, size, opts)
+	if e != nil {
+		return part, c.putError(e)
+	}
+	return part, nil
+}
+
+// listObjectParts returns the uploaded parts of a multipart upload.
+func (c *S3Client) listObjectParts(ctx context.Context, uploadID string) ([]minio.ObjectPart, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	core := minio.Core{Client: c.api}
+	var parts []minio.ObjectPart
+	marker := 0
+	for {
+		result, e := core.ListObjectParts(ctx, bucket, object, uploadID, marker, 1000)
+		if e != nil {
+			return nil, c.putError(e)
+		}
+		parts = append(parts, result.ObjectParts...)
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// completeMultipartUpload commits the given parts of a multipart upload.
+func (c *S3Client) completeMultipartUpload(ctx context.Context, uploadID string, parts []minio.CompletePart) (minio.UploadInfo, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	ui, e := minio.Core{Client: c.api}.CompleteMultipartUpload(ctx, bucket, object, uploadID, parts, minio.PutObjectOptions{})
+	if e != nil {
+		return ui, c.putError(e)
+	}
+	return ui, nil
+}
+
+// abortMultipartUpload removes a multipart upload and its parts.
+func (c *S3Client) abortMultipartUpload(ctx context.Context, uploadID string) *probe.Error {
+	bucket, object := c.url2BucketAndObject()
+	if e := (minio.Core{Client: c.api}).AbortMultipartUpload(ctx, bucket, object, uploadID); e != nil {
+		return c.putError(e)
+	}
+	return nil
+}
+
 // Remove incomplete uploads.
 func (c *S3Client) removeIncompleteObjects(ctx context.Context, bucket string, objectsCh <-chan minio.ObjectInfo) <-chan minio.RemoveObjectResult {
 	removeObjectErrorCh := make(chan minio.RemoveObjectResult)
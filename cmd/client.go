@@ -20,10 +20,12 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"strings"
@@ -32,6 +34,7 @@ import (
 	"github.com/klauspost/compress/gzhttp"
 	"github.com/minio/mc/pkg/httptracer"
 	"github.com/minio/mc/pkg/limiter"
+	"github.com/minio/mc/pkg/oteltrace"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/cors"
@@ -60,6 +63,7 @@ type GetOptions struct {
 	VersionID  string
 	Zip        bool
 	RangeStart int64
+	RangeEnd   int64 // inclusive, 0 reads to the end of the object
 	PartNumber int
 	Preserve   bool
 }
@@ -261,6 +265,9 @@ type Config struct {
 	UploadLimit       int64
 	DownloadLimit     int64
 	Transport         http.RoundTripper
+	CredentialSource  *credentialSourceV10
+	RootCAs           *x509.CertPool
+	Resolvers         map[string]netip.Addr
 }
 
 // getCredsChain returns an []credentials.Provider array for the config
@@ -297,6 +304,15 @@ func (config *Config) getCredsChain() ([]credentials.Provider, *probe.Error) {
 		signType = credentials.SignatureV2
 	}
 
+	if config.CredentialSource != nil {
+		credsChain = append(credsChain, &credentialSourceProvider{
+			alias:    config.Alias,
+			source:   config.CredentialSource,
+			signType: signType,
+		})
+		return credsChain, nil
+	}
+
 	// Credentials
 	creds := &credentials.Static{
 		Value: credentials.Value{
@@ -356,11 +372,15 @@ func (config *Config) initTransport(withS3v2 bool) {
 			DisableCompression: true,
 		}
 		if useTLS {
-			tr.DialTLSContext = newCustomDialTLSContext(&tls.Config{
-				RootCAs:            globalRootCAs,
+			rootCAs := globalRootCAs
+			if config.RootCAs != nil {
+				rootCAs = config.RootCAs
+			}
+			tr.DialTLSContext = newResolverDialTLSContext(&tls.Config{
+				RootCAs:            rootCAs,
 				MinVersion:         tls.VersionTLS12,
 				InsecureSkipVerify: config.Insecure,
-			})
+			}, config.Resolvers)
 
 			// Because we create a custom TLSClientConfig, we have to opt-in to HTTP/2.
 			// See https://github.com/golang/go/issues/14275
@@ -388,6 +408,14 @@ func (config *Config) initTransport(withS3v2 bool) {
 		}
 	}
 
+	if globalMetricsEnabled {
+		transport = metricsTransport{transport: transport, host: newClientURL(config.HostURL).Host}
+	}
+
+	if globalTracer != nil {
+		transport = oteltrace.Transport{Tracer: globalTracer, Transport: transport}
+	}
+
 	transport = gzhttp.Transport(transport)
 	config.Transport = transport
 }
@@ -32,6 +32,7 @@ import (
 	"golang.org/x/net/http/httpguts"
 
 	"github.com/dustin/go-humanize"
+	"github.com/minio/mc/pkg/oteltrace"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/encrypt"
@@ -291,7 +292,7 @@ func getAllMetadata(ctx context.Context, sourceAlias, sourceURLStr string, srcSS
 // uploadSourceToTargetURL - uploads to targetURL from source.
 // optionally optimizes copy for object sizes <= 5GiB by using
 // server side copy operation.
-func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTargetURLOpts) URLs {
+func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTargetURLOpts) (urls URLs) {
 	sourceAlias := uploadOpts.urls.SourceAlias
 	sourceURL := uploadOpts.urls.SourceContent.URL
 	sourceVersion := uploadOpts.urls.SourceContent.VersionID
@@ -301,6 +302,18 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 	sourcePath := filepath.ToSlash(filepath.Join(sourceAlias, uploadOpts.urls.SourceContent.URL.Path))
 	targetPath := filepath.ToSlash(filepath.Join(targetAlias, uploadOpts.urls.TargetContent.URL.Path))
 
+	ctx, endSpan := startOTelSpan(ctx, "copy",
+		oteltrace.String("mc.source", sourcePath),
+		oteltrace.String("mc.target", targetPath),
+		oteltrace.Int("mc.size", length),
+	)
+	defer func() {
+		if urls.Error == nil {
+			clientTransferredObjects.Inc()
+		}
+		endSpan(urls.Error.ToGoError())
+	}()
+
 	srcSSE := getSSE(sourcePath, uploadOpts.encKeyDB[sourceAlias])
 	tgtSSE := getSSE(targetPath, uploadOpts.encKeyDB[targetAlias])
 
@@ -47,12 +47,72 @@ type aliasConfigV10 struct {
 	License      string `json:"license,omitempty"`
 	APIKey       string `json:"apiKey,omitempty"`
 	Src          string `json:"src,omitempty"`
+
+	// Credentials references keys kept outside of the config file.
+	Credentials *credentialSourceV10 `json:"credentials,omitempty"`
+
+	// Profile names the profile of default options of the alias,
+	// Defaults overrides some of them.
+	Profile  string            `json:"profile,omitempty"`
+	Defaults *aliasDefaultsV10 `json:"defaults,omitempty"`
+
+	// options are the default options of the alias resolved from its
+	// profile and its defaults.
+	options *aliasDefaultsV10
+}
+
+// aliasDefaultsV10 are options of the commands using an alias which
+// apply unless they are given on the command line.
+type aliasDefaultsV10 struct {
+	Insecure          *bool    `json:"insecure,omitempty"`
+	Resolve           []string `json:"resolve,omitempty"`
+	CACerts           string   `json:"caCerts,omitempty"`
+	LimitUpload       string   `json:"limitUpload,omitempty"`
+	LimitDownload     string   `json:"limitDownload,omitempty"`
+	ConnReadDeadline  string   `json:"connReadDeadline,omitempty"`
+	ConnWriteDeadline string   `json:"connWriteDeadline,omitempty"`
+	EncKMS            string   `json:"encKMS,omitempty"`
+	EncS3             *bool    `json:"encS3,omitempty"`
+	Checksum          string   `json:"checksum,omitempty"`
+	StorageClass      string   `json:"storageClass,omitempty"`
+}
+
+// credentialSourceV10 is where the keys of an alias are retrieved
+// from instead of the config file.
+type credentialSourceV10 struct {
+	Type     string `json:"type"`
+	Command  string `json:"command,omitempty"`
+	Item     string `json:"item,omitempty"`
+	File     string `json:"file,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+
+	STS *stsCredentialSourceV10 `json:"sts,omitempty"`
+}
+
+// stsCredentialSourceV10 is an identity exchanged for temporary
+// credentials with the STS API of an alias.
+type stsCredentialSourceV10 struct {
+	Type            string `json:"type"`
+	Endpoint        string `json:"endpoint"`
+	Username        string `json:"username,omitempty"`
+	Issuer          string `json:"issuer,omitempty"`
+	ClientID        string `json:"clientId,omitempty"`
+	TokenFile       string `json:"tokenFile,omitempty"`
+	Certificate     string `json:"certificate,omitempty"`
+	Key             string `json:"key,omitempty"`
+	RoleARN         string `json:"roleArn,omitempty"`
+	DurationSeconds int    `json:"durationSeconds,omitempty"`
 }
 
 // configV10 config version.
 type configV10 struct {
 	Version string                    `json:"version"`
 	Aliases map[string]aliasConfigV10 `json:"aliases"`
+	// Groups of aliases, referenced as @group on the command line.
+	Groups map[string][]string `json:"groups,omitempty"`
+	// Profiles of default options shared by aliases.
+	Profiles map[string]aliasDefaultsV10 `json:"profiles,omitempty"`
 }
 
 // newConfigV10 - new config version.
@@ -186,6 +186,7 @@ func getAliasConfig(alias string) (*aliasConfigV10, *probe.Error) {
 	if _, ok := mcCfg.Aliases[alias]; ok {
 		hostCfg := mcCfg.Aliases[alias]
 		hostCfg.Src = mustGetMcConfigPath()
+		hostCfg.options = resolveAliasOptions(mcCfg, hostCfg)
 		return &hostCfg, nil
 	}
 
@@ -335,7 +336,10 @@ func expandAlias(aliasedURL string) (alias, urlStr string, aliasCfg *aliasConfig
 
 	// Find the matching alias entry and expand the URL.
 	if aliasCfg = mustGetHostConfig(alias); aliasCfg != nil {
-		return alias, urlJoinPath(aliasCfg.URL, path), aliasCfg, nil
+		// The alias is still returned when its credentials cannot be
+		// retrieved, clients retry with the credential source.
+		err = aliasCfg.resolveCredentials(alias)
+		return alias, urlJoinPath(aliasCfg.URL, path), aliasCfg, err.Trace(aliasedURL)
 	}
 
 	return "", aliasedURL, nil, nil // No matching entry found. Return original URL as is.
@@ -97,6 +97,14 @@ var (
 			Name:  "zip",
 			Usage: "Extract from remote zip file (MinIO server source only)",
 		},
+		cli.BoolFlag{
+			Name:  "fan-out",
+			Usage: "copy the first argument to all other arguments, reading the source once",
+		},
+		cli.BoolFlag{
+			Name:  "allow-partial",
+			Usage: "with --fan-out, continue with the other targets when a target fails",
+		},
 		checksumFlag,
 	}
 )
@@ -190,6 +198,9 @@ EXAMPLES:
   19. Set tags to the uploaded objects
       {{.Prompt}} {{.HelpName}} -r --tags "category=prod&type=backup" ./data/ play/another-bucket/
 
+  20. Copy a folder to a primary and an offsite bucket, reading each file once.
+      {{.Prompt}} {{.HelpName}} --fan-out -r /mnt/nfs/backup/ myminio/backup/ offsite/backup/
+
 `,
 }
 
@@ -540,11 +551,19 @@ loop:
 
 // mainCopy is the entry point for cp command.
 func mainCopy(cliCtx *cli.Context) error {
+	// The defaults of each --fan-out target are applied by teePutOptions.
+	if !cliCtx.Bool("fan-out") {
+		applyAliasFlagDefaults(cliCtx)
+	}
+
 	ctx, cancelCopy := context.WithCancel(globalContext)
 	defer cancelCopy()
 
-	checkCopySyntax(cliCtx)
+	if !cliCtx.Bool("fan-out") {
+		checkCopySyntax(cliCtx)
+	}
 	console.SetColor("Copy", color.New(color.FgGreen, color.Bold))
+	console.SetColor("TeeFailed", color.New(color.FgRed, color.Bold))
 
 	var err *probe.Error
 
@@ -555,6 +574,10 @@ func mainCopy(cliCtx *cli.Context) error {
 	}
 	fatalIf(err, "SSE Error")
 
+	if cliCtx.Bool("fan-out") {
+		checkCopyFanOutSyntax(ctx, cliCtx, encryptionKeyMap)
+		return mainCopyFanOut(ctx, cliCtx, encryptionKeyMap)
+	}
 	return doCopySession(ctx, cancelCopy, cliCtx, encryptionKeyMap, false)
 }
 
@@ -36,8 +36,11 @@ func checkCopySyntax(cliCtx *cli.Context) {
 		fatalIf(errDummy().Trace(cliCtx.Args()...), "Unable to parse source and target arguments.")
 	}
 
-	srcURLs := URLs[:len(URLs)-1]
-	tgtURL := URLs[len(URLs)-1]
+	checkCopyURLsSyntax(cliCtx, URLs[:len(URLs)-1], URLs[len(URLs)-1])
+}
+
+// checkCopyURLsSyntax validates the flags of a copy of srcURLs to tgtURL.
+func checkCopyURLsSyntax(cliCtx *cli.Context, srcURLs []string, tgtURL string) {
 	isZip := cliCtx.Bool("zip")
 	versionID := cliCtx.String("version-id")
 
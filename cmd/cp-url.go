@@ -253,16 +253,21 @@ func prepareCopyURLsTypeC(ctx context.Context, cc copyURLsContent, o prepareCopy
 
 // makeCopyContentTypeC - CopyURLs content for copying.
 func makeCopyContentTypeC(cc copyURLsContent, sourceClientURL ClientURL) URLs {
-	newSourceURL := cc.sourceContent.URL
+	cc.targetURL = urlJoinPath(cc.targetURL, copySourceSuffix(sourceClientURL, cc.sourceContent.URL))
+	return makeCopyContentTypeA(cc)
+}
+
+// copySourceSuffix returns the path of a listed source object relative
+// to the parent of the copied source, so that 'dir' is copied as
+// 'dir/...' and 'dir/' as its content.
+func copySourceSuffix(sourceClientURL, sourceURL ClientURL) string {
 	pathSeparatorIndex := strings.LastIndex(sourceClientURL.Path, string(sourceClientURL.Separator))
-	newSourceSuffix := filepath.ToSlash(newSourceURL.Path)
+	newSourceSuffix := filepath.ToSlash(sourceURL.Path)
 	if pathSeparatorIndex > 1 {
 		sourcePrefix := filepath.ToSlash(sourceClientURL.Path[:pathSeparatorIndex])
 		newSourceSuffix = strings.TrimPrefix(newSourceSuffix, sourcePrefix)
 	}
-	newTargetURL := urlJoinPath(cc.targetURL, newSourceSuffix)
-	cc.targetURL = newTargetURL
-	return makeCopyContentTypeA(cc)
+	return newSourceSuffix
 }
 
 // MULTI-SOURCE - Type D: copy([](f|d...), d) -> []B
@@ -114,6 +114,10 @@ func validateAndCreateEncryptionKeys(ctx *cli.Context) (encMap map[string][]pref
 		sort.Sort(byPrefixLength(encKeys))
 	}
 
+	if err = addAliasSSEDefaults(ctx.Args(), encMap); err != nil {
+		return nil, err
+	}
+
 	return encMap, nil
 }
 
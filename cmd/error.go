@@ -54,6 +54,11 @@ func fatalIf(err *probe.Error, msg string, data ...interface{}) {
 }
 
 func fatal(err *probe.Error, msg string, data ...interface{}) {
+	countClientError(err.ToGoError())
+
+	// Export pending spans, the process exits right after.
+	shutdownOTelTracing(err.ToGoError())
+
 	if globalJSON {
 		errorMsg := errorMessage{
 			Message: msg,
@@ -132,6 +137,7 @@ func errorIf(err *probe.Error, msg string, data ...interface{}) {
 	if err == nil {
 		return
 	}
+	countClientError(err.ToGoError())
 	if globalJSON {
 		errorMsg := errorMessage{
 			Message: fmt.Sprintf(msg, data...),
@@ -84,6 +84,16 @@ var globalFlags = []cli.Flag{
 		Usage:  "limits downloads to a maximum rate in KiB/s, MiB/s, GiB/s. (default: unlimited)",
 		EnvVar: envPrefix + "LIMIT_DOWNLOAD",
 	},
+	cli.StringFlag{
+		Name:   "otel-export",
+		Usage:  "export OpenTelemetry spans to an OTLP/HTTP endpoint URL or a local file",
+		EnvVar: envPrefix + "OTEL_EXPORT",
+	},
+	cli.StringFlag{
+		Name:   "metrics-address",
+		Usage:  "serve client metrics in Prometheus format on this address (eg: localhost:8081)",
+		EnvVar: envPrefix + "METRICS_ADDRESS",
+	},
 	cli.DurationFlag{
 		Name:   "conn-read-deadline",
 		Usage:  "custom connection READ deadline",
@@ -129,8 +139,13 @@ var checksumFlag = cli.StringFlag{
 }
 
 func parseChecksum(ctx *cli.Context) (useMD5 bool, ct minio.ChecksumType) {
-	useMD5 = ctx.Bool("md5")
-	if cs := ctx.String("checksum"); cs != "" {
+	return parseChecksumValue(ctx.String("checksum"), ctx.Bool("md5"))
+}
+
+// parseChecksumValue parses a --checksum value, useMD5 is set by --md5.
+func parseChecksumValue(cs string, useMD5 bool) (bool, minio.ChecksumType) {
+	var ct minio.ChecksumType
+	if cs != "" {
 		switch strings.ToUpper(cs) {
 		case "CRC32":
 			ct = minio.ChecksumCRC32
@@ -154,5 +169,5 @@ func parseChecksum(ctx *cli.Context) (useMD5 bool, ct minio.ChecksumType) {
 			}
 		}
 	}
-	return
+	return useMD5, ct
 }
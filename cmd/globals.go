@@ -22,12 +22,10 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
-	"net"
 	"net/netip"
 	"net/url"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -86,6 +84,15 @@ var (
 	globalConnReadDeadline  time.Duration
 	globalConnWriteDeadline time.Duration
 
+	// Whether the deadlines are given rather than defaults, which
+	// the defaults of an alias take precedence over.
+	globalConnReadDeadlineSet  bool
+	globalConnWriteDeadlineSet bool
+
+	// Whether --insecure is given, even as --insecure=false, which
+	// takes precedence over the defaults of an alias.
+	globalInsecureSet bool
+
 	globalLimitUpload   uint64
 	globalLimitDownload uint64
 
@@ -131,6 +138,7 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 	globalJSON = globalJSON || json
 	globalNoColor = globalNoColor || noColor || globalJSONLine
 	globalInsecure = globalInsecure || insecure
+	globalInsecureSet = globalInsecureSet || ctx.IsSet("insecure") || ctx.GlobalIsSet("insecure")
 	GlobalDevMode = GlobalDevMode || devMode
 	globalAirgapped = globalAirgapped || airgapped
 
@@ -140,6 +148,9 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 		lipgloss.SetColorProfile(termenv.Ascii)
 	}
 
+	globalConnReadDeadlineSet = ctx.IsSet("conn-read-deadline") || ctx.GlobalIsSet("conn-read-deadline")
+	globalConnWriteDeadlineSet = ctx.IsSet("conn-write-deadline") || ctx.GlobalIsSet("conn-write-deadline")
+
 	globalConnReadDeadline = ctx.Duration("conn-read-deadline")
 	if globalConnReadDeadline <= 0 {
 		globalConnReadDeadline = ctx.GlobalDuration("conn-read-deadline")
@@ -181,24 +192,32 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 
 		// Each entry is a HOST[:PORT]=IP pair. This is very similar to cURL's syntax.
 		for _, e := range dnsEntries {
-			i := strings.IndexByte(e, '=')
-			if i < 0 {
-				return fmt.Errorf("invalid DNS resolve entry %s", e)
-			}
-
-			if strings.ContainsRune(e[:i], ':') {
-				if _, _, err := net.SplitHostPort(e[:i]); err != nil {
-					return fmt.Errorf("invalid DNS resolve entry %s: %v", e, err)
-				}
-			}
-
-			host := e[:i]
-			addr, err := netip.ParseAddr(e[i+1:])
+			host, addr, err := parseResolveEntry(e)
 			if err != nil {
-				return fmt.Errorf("invalid DNS resolve entry %s: %v", e, err)
+				return err
 			}
 			globalResolvers[host] = addr
 		}
 	}
+
+	metricsAddress := ctx.String("metrics-address")
+	if metricsAddress == "" {
+		metricsAddress = ctx.GlobalString("metrics-address")
+	}
+	if metricsAddress != "" {
+		if e := initClientMetrics(metricsAddress); e != nil {
+			return fmt.Errorf("unable to serve client metrics on %s: %v", metricsAddress, e)
+		}
+	}
+
+	otelExport := ctx.String("otel-export")
+	if otelExport == "" {
+		otelExport = ctx.GlobalString("otel-export")
+	}
+	if otelExport != "" {
+		if e := initOTelTracing(ctx, otelExport); e != nil {
+			return fmt.Errorf("unable to export OpenTelemetry spans to %s: %v", otelExport, e)
+		}
+	}
 	return nil
 }
@@ -22,14 +22,19 @@ import (
 	"compress/bzip2"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/mc/pkg/s3select"
+	"github.com/minio/pkg/v3/console"
 )
 
 var headFlags = []cli.Flag{
@@ -50,6 +55,10 @@ var headFlags = []cli.Flag{
 		Name:  "zip",
 		Usage: "extract from remote zip file (MinIO server source only)",
 	},
+	cli.BoolFlag{
+		Name:  "parquet",
+		Usage: "display the schema, row groups and first 'n' rows of a parquet object",
+	},
 }
 
 // Display contents of a file.
@@ -85,6 +94,9 @@ EXAMPLES:
 
   4. Display the first lines of a specific object version.
      {{.Prompt}} {{.HelpName}} --version-id "3ddac055-89a7-40fa-8cd3-530a5581b6b8" s3/json-data/population.json
+
+  5. Display the schema, row groups and first 5 rows of a Parquet object.
+     {{.Prompt}} {{.HelpName}} --parquet -n 5 s3/weather/2024.parquet
 `,
 }
 
@@ -119,6 +131,97 @@ func headURL(sourceURL, sourceVersion string, timeRef time.Time, encKeyDB map[st
 	return headOut(reader, nlines).Trace(sourceURL)
 }
 
+// parquetHeadMessage is the schema and first rows of a Parquet object.
+type parquetHeadMessage struct {
+	Status    string             `json:"status"`
+	Key       string             `json:"name"`
+	NumRows   int64              `json:"numRows"`
+	Columns   []parquetColumn    `json:"columns"`
+	RowGroups []parquetRowGroup  `json:"rowGroups"`
+	Rows      []*s3select.Object `json:"rows"`
+}
+
+func (p parquetHeadMessage) String() string {
+	var b strings.Builder
+	b.WriteString(console.Colorize("Name", fmt.Sprintf("%-10s: %s", "Name", p.Key)) + "\n")
+	fmt.Fprintf(&b, "%-10s: %d\n", "Rows", p.NumRows)
+	fmt.Fprintf(&b, "%-10s:\n", "Schema")
+	for _, col := range p.Columns {
+		typ := col.Type
+		if col.LogicalType != "" {
+			typ += " (" + col.LogicalType + ")"
+		}
+		fmt.Fprintf(&b, "  %s: %s %s\n", col.Path, typ, col.Repetition)
+	}
+	fmt.Fprintf(&b, "%-10s:\n", "RowGroups")
+	for i, rg := range p.RowGroups {
+		fmt.Fprintf(&b, "  %d: %d rows, %s (%s compressed)\n", i, rg.Rows,
+			humanize.IBytes(uint64(rg.Size)), humanize.IBytes(uint64(rg.CompressedSize)))
+	}
+	if len(p.Rows) > 0 {
+		fmt.Fprintf(&b, "%-10s:\n", "Data")
+		for _, row := range p.Rows {
+			b.WriteString("  " + s3select.FormatValue(row) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (p parquetHeadMessage) JSON() string {
+	p.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// headParquetURL displays the schema, row groups and first rows of a
+// Parquet object. Only the footer and the pages holding the displayed
+// rows are downloaded.
+func headParquetURL(sourceURL, sourceVersion string, timeRef time.Time, encKeyDB map[string][]prefixSSEPair, nrows int64) *probe.Error {
+	ctx, cancelHead := context.WithCancel(globalContext)
+	defer cancelHead()
+
+	clnt, content, err := url2Stat(ctx, url2StatOptions{urlStr: sourceURL, versionID: sourceVersion, encKeyDB: encKeyDB, timeRef: timeRef})
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	if content.Type.IsDir() {
+		return errInvalidArgument().Trace(sourceURL)
+	}
+	alias, _ := url2Alias(sourceURL)
+	sse := getSSE(sourceURL, encKeyDB[alias])
+
+	footer, err := readParquetFooter(ctx, clnt, sse, content)
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	msg := parquetHeadMessage{
+		Key:       sourceURL,
+		NumRows:   footer.GetNumRows(),
+		Columns:   parquetColumns(footer),
+		RowGroups: parquetRowGroups(footer),
+	}
+	// Negative number of rows means default number of rows.
+	if nrows < 0 {
+		nrows = 10
+	}
+	if nrows > 0 {
+		pr, err := newParquetReaderFromContent(ctx, clnt, sse, content)
+		if err != nil {
+			return err.Trace(sourceURL)
+		}
+		e := readParquetRows(pr, nrows, func(row *s3select.Object) error {
+			msg.Rows = append(msg.Rows, row)
+			return nil
+		})
+		if e != nil {
+			return probe.NewError(e).Trace(sourceURL)
+		}
+	}
+	printMsg(msg)
+	return nil
+}
+
 // headOut reads from reader stream and writes to stdout. Also check the length of the
 // read bytes against size parameter (if not -1) and return the appropriate error
 func headOut(r io.Reader, nlines int64) *probe.Error {
@@ -175,6 +278,10 @@ func parseHeadSyntax(ctx *cli.Context) (args []string, versionID string, timeRef
 		fatalIf(errInvalidArgument().Trace(), "You cannot specify --version-id and --rewind at the same time")
 	}
 
+	if ctx.Bool("parquet") && len(args) == 0 {
+		fatalIf(errInvalidArgument().Trace(), "--parquet cannot be used with standard input")
+	}
+
 	if versionID != "" && len(args) != 1 {
 		fatalIf(errInvalidArgument().Trace(), "You need to pass at least one argument if --version-id is specified")
 	}
@@ -201,6 +308,11 @@ func mainHead(ctx *cli.Context) error {
 
 	// Convert arguments to URLs: expand alias, fix format.
 	for _, url := range ctx.Args() {
+		if ctx.Bool("parquet") {
+			err = headParquetURL(url, versionID, timeRef, encryptionKeys, ctx.Int64("lines"))
+			fatalIf(err.Trace(url), "Unable to read from `"+url+"`.")
+			continue
+		}
 		err = headURL(
 			url,
 			versionID,
@@ -374,6 +374,9 @@ func registerBefore(ctx *cli.Context) error {
 	// Check if config can be read.
 	checkConfig()
 
+	// Run the command against each alias of a group given as @GROUP.
+	mainAliasGroupRun(os.Args[1:])
+
 	return nil
 }
 
@@ -418,6 +421,7 @@ var appCmds = []cli.Command{
 	adminCmd,
 	anonymousCmd,
 	batchCmd,
+	benchCmd,
 	cpCmd,
 	catCmd,
 	configCmd,
@@ -437,6 +441,7 @@ var appCmds = []cli.Command{
 	mbCmd,
 	mvCmd,
 	mirrorCmd,
+	migrateCmd,
 	odCmd,
 	pingCmd,
 	policyCmd,
@@ -509,6 +514,7 @@ func registerApp(name string) *cli.App {
 	app.EnableBashCompletion = true
 	app.OnUsageError = onUsageError
 	app.After = func(*cli.Context) error {
+		shutdownOTelTracing(nil)
 		globalExpiringCerts.Range(func(k, v interface{}) bool {
 			host := k.(string)
 			expires := v.(time.Time)
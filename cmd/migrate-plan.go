@@ -0,0 +1,804 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/google/uuid"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v3/console"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var migratePlanFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "sample",
+		Usage: "only list the first N objects of the source and extrapolate when the total size is known",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "include all object versions and delete markers in the plan",
+	},
+	cli.IntFlag{
+		Name:  "concurrent",
+		Usage: "number of parallel transfers used for the throughput probe and the estimate",
+		Value: 16,
+	},
+	cli.StringFlag{
+		Name:  "throughput",
+		Usage: "skip the throughput probe and assume the given transfer rate per second (e.g. 500MiB)",
+	},
+	cli.DurationFlag{
+		Name:  "probe-duration",
+		Usage: "duration of the throughput probe of each endpoint",
+		Value: 10 * time.Second,
+	},
+	cli.StringFlag{
+		Name:  "batch-yaml",
+		Usage: "write a batch replicate job definition to run the migration on the target to FILE",
+	},
+}
+
+var migratePlanCmd = cli.Command{
+	Name:         "plan",
+	Usage:        "estimate the duration and the requests of a bucket to bucket migration",
+	Action:       mainMigratePlan,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(migratePlanFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] SOURCE TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Lists the source, groups objects by size, storage class and number of versions,
+  and probes the GET throughput of the source and the PUT throughput of the target.
+  The probe writes temporary objects under TARGET, which are removed afterwards.
+  The estimate assumes the probed throughput is sustained for the whole migration.
+
+EXAMPLES:
+  1. Plan the migration of a bucket from Amazon S3 to MinIO.
+     {{.Prompt}} {{.HelpName}} s3/photos myminio/photos
+
+  2. Plan the migration of all versions based on a sample of 100000 objects.
+     {{.Prompt}} {{.HelpName}} --versions --sample 100000 myminio-old/backups myminio/backups
+
+  3. Plan the migration assuming 1GiB/s and write a batch job definition for the target.
+     {{.Prompt}} {{.HelpName}} --throughput 1GiB --batch-yaml migrate.yaml s3/photos myminio/photos
+     {{.Prompt}} mc batch start myminio migrate.yaml
+`,
+}
+
+// migrateVersionClasses are the upper bounds of the number of
+// versions per object used to group objects.
+var migrateVersionClasses = []struct {
+	max  int
+	name string
+}{
+	{1, "1"},
+	{5, "2-5"},
+	{10, "6-10"},
+	{100, "11-100"},
+	{-1, ">100"},
+}
+
+// migrateSizeClasses are the size groups in ascending order,
+// as returned by convertSizeToTag.
+var migrateSizeClasses = []string{
+	"LESS_THAN_1_KiB",
+	"LESS_THAN_1_MiB",
+	"LESS_THAN_10_MiB",
+	"LESS_THAN_100_MiB",
+	"LESS_THAN_1_GiB",
+	"GREATER_THAN_1_GiB",
+}
+
+// migratePartSize is the default part size used by
+// uploads, smaller objects are uploaded in a single PUT.
+const migratePartSize = 16 * humanize.MiByte
+
+type migrateGroup struct {
+	Name    string `json:"name"`
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+}
+
+type migrateRequests struct {
+	List              int64 `json:"list"`
+	Get               int64 `json:"get"`
+	PutObject         int64 `json:"putObject"`
+	CreateMultipart   int64 `json:"createMultipartUpload"`
+	UploadPart        int64 `json:"uploadPart"`
+	CompleteMultipart int64 `json:"completeMultipartUpload"`
+	DeleteMarker      int64 `json:"deleteMarker"`
+}
+
+func (r migrateRequests) total() int64 {
+	return r.List + r.Get + r.PutObject + r.CreateMultipart + r.UploadPart + r.CompleteMultipart + r.DeleteMarker
+}
+
+func (r migrateRequests) scale(f float64) migrateRequests {
+	s := func(n int64) int64 { return int64(float64(n) * f) }
+	return migrateRequests{
+		List:              s(r.List),
+		Get:               s(r.Get),
+		PutObject:         s(r.PutObject),
+		CreateMultipart:   s(r.CreateMultipart),
+		UploadPart:        s(r.UploadPart),
+		CompleteMultipart: s(r.CompleteMultipart),
+		DeleteMarker:      s(r.DeleteMarker),
+	}
+}
+
+type migrateThroughput struct {
+	Source        float64       `json:"sourceBytesPerSec,omitempty"`
+	Target        float64       `json:"targetBytesPerSec,omitempty"`
+	SourceLatency time.Duration `json:"sourceLatency,omitempty"`
+	TargetLatency time.Duration `json:"targetLatency,omitempty"`
+	Assumed       bool          `json:"assumed,omitempty"`
+}
+
+// rate returns the throughput of the migration, which is
+// limited by the slower of both endpoints.
+func (t migrateThroughput) rate() float64 {
+	switch {
+	case t.Source == 0:
+		return t.Target
+	case t.Target == 0:
+		return t.Source
+	}
+	return min(t.Source, t.Target)
+}
+
+// migratePlanner accumulates the listing of the source.
+type migratePlanner struct {
+	objects        int64
+	versions       int64
+	deleteMarkers  int64
+	bytes          int64
+	sizeClasses    map[string]*migrateGroup
+	storageClasses map[string]*migrateGroup
+	versionClasses map[string]*migrateGroup
+	requests       migrateRequests
+
+	// versions of the object currently listed.
+	lastKey          string
+	lastVersions     int
+	lastVersionBytes int64
+
+	// largest objects used for the source throughput probe.
+	samples []*ClientContent
+}
+
+const migrateProbeSamples = 64
+
+func newMigratePlanner() *migratePlanner {
+	return &migratePlanner{
+		sizeClasses:    make(map[string]*migrateGroup),
+		storageClasses: make(map[string]*migrateGroup),
+		versionClasses: make(map[string]*migrateGroup),
+	}
+}
+
+func addMigrateGroup(groups map[string]*migrateGroup, name string, size int64) {
+	g, ok := groups[name]
+	if !ok {
+		g = &migrateGroup{Name: name}
+		groups[name] = g
+	}
+	g.Objects++
+	g.Bytes += size
+}
+
+// add records a listed object or object version, versions of
+// the same object must be added one after another.
+func (p *migratePlanner) add(content *ClientContent) {
+	if content.URL.Path != p.lastKey {
+		p.flushVersions()
+		p.lastKey = content.URL.Path
+		p.objects++
+	}
+	p.lastVersions++
+
+	if content.IsDeleteMarker {
+		p.deleteMarkers++
+		p.requests.DeleteMarker++
+		return
+	}
+	p.versions++
+	p.bytes += content.Size
+	p.lastVersionBytes += content.Size
+
+	addMigrateGroup(p.sizeClasses, convertSizeToTag(content.Size), content.Size)
+	storageClass := content.StorageClass
+	if storageClass == "" {
+		storageClass = "STANDARD"
+	}
+	addMigrateGroup(p.storageClasses, storageClass, content.Size)
+
+	p.requests.Get++
+	if content.Size <= migratePartSize {
+		p.requests.PutObject++
+	} else {
+		parts, _, _, e := minio.OptimalPartInfo(content.Size, migratePartSize)
+		if e != nil {
+			parts = 1
+		}
+		p.requests.CreateMultipart++
+		p.requests.UploadPart += int64(parts)
+		p.requests.CompleteMultipart++
+	}
+
+	if len(p.samples) < migrateProbeSamples {
+		p.samples = append(p.samples, content)
+	} else if smallest := p.samples[len(p.samples)-1]; content.Size > smallest.Size {
+		p.samples[len(p.samples)-1] = content
+	} else {
+		return
+	}
+	sort.Slice(p.samples, func(i, j int) bool { return p.samples[i].Size > p.samples[j].Size })
+}
+
+func (p *migratePlanner) flushVersions() {
+	if p.lastVersions == 0 {
+		return
+	}
+	for _, c := range migrateVersionClasses {
+		if c.max < 0 || p.lastVersions <= c.max {
+			addMigrateGroup(p.versionClasses, c.name, p.lastVersionBytes)
+			break
+		}
+	}
+	p.lastVersions, p.lastVersionBytes = 0, 0
+}
+
+// sortedMigrateGroups returns the groups ordered by names, or by their
+// position in order when it is not empty.
+func sortedMigrateGroups(groups map[string]*migrateGroup, order []string) []migrateGroup {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+	out := make([]migrateGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(order) > 0 {
+			return rank[out[i].Name] < rank[out[j].Name]
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// estimateMigrateDuration estimates the duration of the migration,
+// the transfer of all bytes at the given throughput plus the latency
+// of all requests spread over the parallel transfers.
+func estimateMigrateDuration(bytes int64, requests migrateRequests, t migrateThroughput, concurrent int) time.Duration {
+	rate := t.rate()
+	if rate <= 0 {
+		return 0
+	}
+	transfer := time.Duration(float64(bytes) / rate * float64(time.Second))
+	if concurrent < 1 {
+		concurrent = 1
+	}
+	srcRequests := requests.Get + requests.List
+	tgtRequests := requests.total() - srcRequests
+	overhead := (time.Duration(srcRequests)*t.SourceLatency + time.Duration(tgtRequests)*t.TargetLatency) / time.Duration(concurrent)
+	return transfer + overhead
+}
+
+type migratePlanMessage struct {
+	Status         string            `json:"status"`
+	Source         string            `json:"source"`
+	Target         string            `json:"target"`
+	Sampled        bool              `json:"sampled,omitempty"`
+	Scale          float64           `json:"scale,omitempty"`
+	Objects        int64             `json:"objects"`
+	Versions       int64             `json:"versions"`
+	DeleteMarkers  int64             `json:"deleteMarkers,omitempty"`
+	Bytes          int64             `json:"bytes"`
+	SizeClasses    []migrateGroup    `json:"sizeClasses"`
+	StorageClasses []migrateGroup    `json:"storageClasses"`
+	VersionClasses []migrateGroup    `json:"versionClasses,omitempty"`
+	Requests       migrateRequests   `json:"requests"`
+	Throughput     migrateThroughput `json:"throughput"`
+	Concurrent     int               `json:"concurrent"`
+	Duration       time.Duration     `json:"duration,omitempty"`
+	MirrorCommand  string            `json:"mirrorCommand"`
+	BatchYAML      string            `json:"batchYAML,omitempty"`
+}
+
+// JSON jsonified migrate plan message.
+func (m migratePlanMessage) JSON() string {
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (m migratePlanMessage) String() string {
+	var b strings.Builder
+	scaled := func(n int64) int64 {
+		if m.Scale > 0 {
+			return int64(float64(n) * m.Scale)
+		}
+		return n
+	}
+	count := func(n int64) string {
+		return console.Colorize("Count", humanize.Comma(scaled(n)))
+	}
+	size := func(n int64) string {
+		return console.Colorize("Count", humanize.IBytes(uint64(scaled(n))))
+	}
+	groups := func(title string, groups []migrateGroup) {
+		if len(groups) == 0 {
+			return
+		}
+		fmt.Fprintln(&b, console.Colorize("Title", title))
+		for _, g := range groups {
+			fmt.Fprintf(&b, "  %-20s %14s objects %12s\n", g.Name, count(g.Objects), size(g.Bytes))
+		}
+	}
+
+	fmt.Fprintf(&b, "%s %s -> %s\n", console.Colorize("Title", "Migration plan:"), m.Source, m.Target)
+	switch {
+	case m.Sampled && m.Scale > 0:
+		fmt.Fprintf(&b, "  Extrapolated from a sample, all counts are scaled by %.2f.\n", m.Scale)
+	case m.Sampled:
+		fmt.Fprintln(&b, console.Colorize("Warning", "  The total size of the source is unknown, the plan only covers the sampled objects."))
+	}
+	fmt.Fprintf(&b, "%16s: %s\n", "Objects", count(m.Objects))
+	fmt.Fprintf(&b, "%16s: %s\n", "Versions", count(m.Versions))
+	if m.DeleteMarkers > 0 {
+		fmt.Fprintf(&b, "%16s: %s\n", "Delete markers", count(m.DeleteMarkers))
+	}
+	fmt.Fprintf(&b, "%16s: %s\n", "Total size", size(m.Bytes))
+	fmt.Fprintln(&b)
+
+	groups("Size:", m.SizeClasses)
+	groups("Storage class:", m.StorageClasses)
+	if len(m.VersionClasses) > 1 {
+		groups("Versions per object:", m.VersionClasses)
+	}
+	fmt.Fprintln(&b)
+
+	r := m.Requests
+	fmt.Fprintln(&b, console.Colorize("Title", "Requests:"))
+	fmt.Fprintf(&b, "%16s: %s\n", "GET", count(r.Get))
+	fmt.Fprintf(&b, "%16s: %s\n", "Single PUT", count(r.PutObject))
+	fmt.Fprintf(&b, "%16s: %s (%s parts)\n", "Multipart", count(r.CreateMultipart), count(r.UploadPart))
+	if r.DeleteMarker > 0 {
+		fmt.Fprintf(&b, "%16s: %s\n", "Delete markers", count(r.DeleteMarker))
+	}
+	fmt.Fprintf(&b, "%16s: %s\n", "Total", count(r.total()))
+	fmt.Fprintln(&b)
+
+	t := m.Throughput
+	fmt.Fprintln(&b, console.Colorize("Title", "Throughput:"))
+	if t.Assumed {
+		fmt.Fprintf(&b, "%16s: %s/s\n", "Assumed", console.Colorize("Count", humanize.IBytes(uint64(t.rate()))))
+	} else {
+		if t.Source > 0 {
+			fmt.Fprintf(&b, "%16s: %s/s, %s latency\n", "Source GET", console.Colorize("Count", humanize.IBytes(uint64(t.Source))), t.SourceLatency.Round(time.Millisecond))
+		}
+		if t.Target > 0 {
+			fmt.Fprintf(&b, "%16s: %s/s, %s latency\n", "Target PUT", console.Colorize("Count", humanize.IBytes(uint64(t.Target))), t.TargetLatency.Round(time.Millisecond))
+		}
+	}
+	fmt.Fprintf(&b, "%16s: %d\n", "Concurrent", m.Concurrent)
+	if m.Duration > 0 {
+		fmt.Fprintf(&b, "%16s: %s\n", "Estimated time", console.Colorize("Duration", timeDurationToHumanizedDuration(m.Duration).StringShort()))
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, console.Colorize("Title", "Mirror command:"))
+	fmt.Fprintf(&b, "  %s\n", m.MirrorCommand)
+	if m.BatchYAML != "" {
+		fmt.Fprintf(&b, "%s %s\n", console.Colorize("Title", "Batch job definition written to"), m.BatchYAML)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func checkMigratePlanSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	if ctx.Int("sample") < 0 || ctx.Int("concurrent") < 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--sample must not be negative and --concurrent must be positive.")
+	}
+	for _, arg := range ctx.Args() {
+		if _, _, hostCfg, err := expandAlias(arg); err != nil || hostCfg == nil {
+			fatalIf(errInvalidAliasedURL(arg).Trace(arg), "Source and target must be aliased URLs.")
+		}
+	}
+}
+
+// mainMigratePlan is the handle for "mc migrate plan" command.
+func mainMigratePlan(cliCtx *cli.Context) error {
+	ctx, cancel := context.WithCancel(globalContext)
+	defer cancel()
+
+	checkMigratePlanSyntax(cliCtx)
+	console.SetColor("Title", color.New(color.FgCyan, color.Bold))
+	console.SetColor("Count", color.New(color.FgWhite, color.Bold))
+	console.SetColor("Duration", color.New(color.FgGreen, color.Bold))
+	console.SetColor("Warning", color.New(color.FgYellow))
+
+	sourceURL, targetURL := cliCtx.Args().Get(0), cliCtx.Args().Get(1)
+	sample := cliCtx.Int("sample")
+	concurrent := cliCtx.Int("concurrent")
+	withVersions := cliCtx.Bool("versions")
+
+	srcClnt, err := newClient(sourceURL)
+	fatalIf(err.Trace(sourceURL), "Unable to initialize source `%s`.", sourceURL)
+	_, err = newClient(targetURL)
+	fatalIf(err.Trace(targetURL), "Unable to initialize target `%s`.", targetURL)
+
+	planner := newMigratePlanner()
+	listed := 0
+	sampled := false
+	for content := range srcClnt.List(ctx, ListOptions{
+		Recursive:         true,
+		WithOlderVersions: withVersions,
+		WithDeleteMarkers: withVersions,
+		ShowDir:           DirNone,
+	}) {
+		if content.Err != nil {
+			fatalIf(content.Err.Trace(sourceURL), "Unable to list source `%s`.", sourceURL)
+		}
+		// Stop at an object boundary so that the versions
+		// of the last object are all accounted for.
+		if sample > 0 && listed >= sample && content.URL.Path != planner.lastKey {
+			sampled = true
+			cancel()
+			break
+		}
+		planner.add(content)
+		listed++
+	}
+	planner.flushVersions()
+	planner.requests.List = int64(listed/1000 + 1)
+
+	msg := migratePlanMessage{
+		Status:         "success",
+		Source:         sourceURL,
+		Target:         targetURL,
+		Sampled:        sampled,
+		Objects:        planner.objects,
+		Versions:       planner.versions,
+		DeleteMarkers:  planner.deleteMarkers,
+		Bytes:          planner.bytes,
+		SizeClasses:    sortedMigrateGroups(planner.sizeClasses, migrateSizeClasses),
+		StorageClasses: sortedMigrateGroups(planner.storageClasses, nil),
+		Requests:       planner.requests,
+		Concurrent:     concurrent,
+		MirrorCommand:  migrateMirrorCommand(sourceURL, targetURL),
+	}
+	if withVersions {
+		names := make([]string, 0, len(migrateVersionClasses))
+		for _, c := range migrateVersionClasses {
+			names = append(names, c.name)
+		}
+		msg.VersionClasses = sortedMigrateGroups(planner.versionClasses, names)
+	}
+	if sampled {
+		msg.Scale = migrateSampleScale(globalContext, sourceURL, planner.bytes)
+	}
+
+	if rate := cliCtx.String("throughput"); rate != "" {
+		bytesPerSec, e := humanize.ParseBytes(strings.TrimSuffix(rate, "/s"))
+		fatalIf(probe.NewError(e).Trace(rate), "Unable to parse --throughput.")
+		msg.Throughput = migrateThroughput{Source: float64(bytesPerSec), Target: float64(bytesPerSec), Assumed: true}
+	} else {
+		msg.Throughput = probeMigrateThroughput(globalContext, sourceURL, targetURL, planner.samples, concurrent, cliCtx.Duration("probe-duration"))
+	}
+
+	bytes, requests := planner.bytes, planner.requests
+	if msg.Scale > 0 {
+		bytes, requests = int64(float64(bytes)*msg.Scale), requests.scale(msg.Scale)
+	}
+	msg.Duration = estimateMigrateDuration(bytes, requests, msg.Throughput, concurrent)
+
+	if fileName := cliCtx.String("batch-yaml"); fileName != "" {
+		out, err := migrateBatchYAML(sourceURL, targetURL)
+		fatalIf(err, "Unable to generate the batch job definition.")
+		e := os.WriteFile(fileName, out, 0o600)
+		fatalIf(probe.NewError(e), "Unable to write the batch job definition to `%s`.", fileName)
+		msg.BatchYAML = fileName
+	}
+
+	printMsg(msg)
+	return nil
+}
+
+// migrateSampleScale returns the factor to extrapolate a sample of
+// the source to the whole bucket, or 0 when the bucket usage is not
+// available or the source is a prefix of a bucket.
+func migrateSampleScale(ctx context.Context, sourceURL string, sampledBytes int64) float64 {
+	_, urlStr, _, err := expandAlias(sourceURL)
+	if err != nil || sampledBytes == 0 {
+		return 0
+	}
+	bucket, object := url2BucketAndObject(newClientURL(urlStr))
+	if object != "" {
+		return 0
+	}
+	adminClient, err := newAdminClient(sourceURL)
+	if err != nil {
+		return 0
+	}
+	info, e := adminClient.DataUsageInfo(ctx)
+	if e != nil {
+		return 0
+	}
+	usage, ok := info.BucketsUsage[bucket]
+	if !ok || usage.Size == 0 {
+		return 0
+	}
+	return float64(usage.Size) / float64(sampledBytes)
+}
+
+// probeMigrateThroughput measures the throughput of concurrent GETs of
+// the largest listed objects of the source and of concurrent PUTs of
+// temporary objects to the target, each for the given duration.
+func probeMigrateThroughput(ctx context.Context, sourceURL, targetURL string, samples []*ClientContent, concurrent int, duration time.Duration) (t migrateThroughput) {
+	const probeSize = 16 * humanize.MiByte
+
+	srcAlias, _, _, err := expandAlias(sourceURL)
+	fatalIf(err.Trace(sourceURL), "Unable to initialize source `%s`.", sourceURL)
+	tgtAlias, tgtURLStr, _, err := expandAlias(targetURL)
+	fatalIf(err.Trace(targetURL), "Unable to initialize target `%s`.", targetURL)
+
+	if len(samples) > 0 && samples[0].Size > 0 {
+		var latencies []time.Duration
+		for _, content := range samples[:min(3, len(samples))] {
+			clnt, err := newClientFromAlias(srcAlias, content.URL.String())
+			if err != nil {
+				break
+			}
+			start := time.Now()
+			if _, err = clnt.Stat(ctx, StatOptions{}); err != nil {
+				break
+			}
+			latencies = append(latencies, time.Since(start))
+		}
+		t.SourceLatency = medianDuration(latencies)
+		t.Source = runMigrateProbe(ctx, concurrent, duration, func(ctx context.Context, i int) (int64, *probe.Error) {
+			content := samples[i%len(samples)]
+			clnt, err := newClientFromAlias(srcAlias, content.URL.String())
+			if err != nil {
+				return 0, err
+			}
+			r, _, err := clnt.Get(ctx, GetOptions{})
+			if err != nil {
+				return 0, err
+			}
+			defer r.Close()
+			n, e := io.CopyN(io.Discard, r, probeSize)
+			if e == io.EOF {
+				e = nil
+			}
+			return n, probe.NewError(e)
+		})
+	}
+
+	data := make([]byte, probeSize)
+	rand.Read(data)
+	probeURL := urlJoinPath(tgtURLStr, ".mc-migrate-probe-"+uuid.NewString())
+	var (
+		mu      sync.Mutex
+		created []string
+	)
+	put := func(ctx context.Context, name string, size int) (int64, *probe.Error) {
+		objectURL := urlJoinPath(probeURL, name)
+		clnt, err := newClientFromAlias(tgtAlias, objectURL)
+		if err != nil {
+			return 0, err
+		}
+		mu.Lock()
+		created = append(created, objectURL)
+		mu.Unlock()
+		return clnt.Put(ctx, bytes.NewReader(data[:size]), int64(size), nil, PutOptions{})
+	}
+
+	var latencies []time.Duration
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, err := put(ctx, fmt.Sprintf("latency-%d", i), 1); err != nil {
+			break
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	t.TargetLatency = medianDuration(latencies)
+	if len(latencies) > 0 {
+		t.Target = runMigrateProbe(ctx, concurrent, duration, func(ctx context.Context, i int) (int64, *probe.Error) {
+			return put(ctx, fmt.Sprintf("object-%d", i), len(data))
+		})
+	}
+
+	removeMigrateProbe(tgtAlias, probeURL, created)
+	return t
+}
+
+// runMigrateProbe runs fn in concurrent workers until duration
+// elapsed and returns the achieved throughput in bytes per second.
+// Transfers interrupted at the end of the probe are not counted.
+func runMigrateProbe(ctx context.Context, concurrent int, duration time.Duration, fn func(ctx context.Context, i int) (int64, *probe.Error)) float64 {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var (
+		total, next int64
+		wg          sync.WaitGroup
+		start       = time.Now()
+	)
+	for w := 0; w < concurrent; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				n, err := fn(ctx, int(atomic.AddInt64(&next, 1)))
+				if err != nil {
+					return
+				}
+				atomic.AddInt64(&total, n)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&total)) / elapsed
+}
+
+func removeMigrateProbe(alias, probeURL string, objects []string) {
+	if len(objects) == 0 {
+		return
+	}
+	clnt, err := newClientFromAlias(alias, probeURL)
+	if err != nil {
+		errorIf(err.Trace(probeURL), "Unable to remove the throughput probe objects.")
+		return
+	}
+	contentCh := make(chan *ClientContent, len(objects))
+	for _, objectURL := range objects {
+		contentCh <- &ClientContent{URL: *newClientURL(objectURL)}
+	}
+	close(contentCh)
+	for result := range clnt.Remove(globalContext, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			errorIf(result.Err.Trace(probeURL), "Unable to remove the throughput probe objects.")
+		}
+	}
+}
+
+func medianDuration(d []time.Duration) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d[len(d)/2]
+}
+
+// migrateMirrorCommand returns the mirror command for the migration.
+func migrateMirrorCommand(sourceURL, targetURL string) string {
+	return fmt.Sprintf("mc mirror --preserve --retry %s %s", sourceURL, targetURL)
+}
+
+type migrateBatchEndpoint struct {
+	Type        string                   `yaml:"type,omitempty"`
+	Bucket      string                   `yaml:"bucket"`
+	Prefix      string                   `yaml:"prefix,omitempty"`
+	Endpoint    string                   `yaml:"endpoint,omitempty"`
+	Path        string                   `yaml:"path,omitempty"`
+	Credentials *migrateBatchCredentials `yaml:"credentials,omitempty"`
+}
+
+type migrateBatchCredentials struct {
+	AccessKey    string `yaml:"accessKey"`
+	SecretKey    string `yaml:"secretKey"`
+	SessionToken string `yaml:"sessionToken,omitempty"`
+}
+
+type migrateBatchJob struct {
+	Replicate struct {
+		APIVersion string               `yaml:"apiVersion"`
+		Source     migrateBatchEndpoint `yaml:"source"`
+		Target     migrateBatchEndpoint `yaml:"target"`
+		Flags      struct {
+			Retry struct {
+				Attempts int    `yaml:"attempts"`
+				Delay    string `yaml:"delay"`
+			} `yaml:"retry"`
+		} `yaml:"flags"`
+	} `yaml:"replicate"`
+}
+
+// migrateBatchYAML returns a batch replicate job definition that pulls
+// the source into the target, it must be started on the target.
+func migrateBatchYAML(sourceURL, targetURL string) ([]byte, *probe.Error) {
+	_, srcURLStr, srcCfg, err := expandAlias(sourceURL)
+	if err != nil {
+		return nil, err.Trace(sourceURL)
+	}
+	tgtAlias, tgtURLStr, _, err := expandAlias(targetURL)
+	if err != nil {
+		return nil, err.Trace(targetURL)
+	}
+
+	var job migrateBatchJob
+	job.Replicate.APIVersion = "v1"
+	srcBucket, srcPrefix := url2BucketAndObject(newClientURL(srcURLStr))
+	tgtBucket, tgtPrefix := url2BucketAndObject(newClientURL(tgtURLStr))
+	srcType := "minio"
+	if isAmazon(newClientURL(srcURLStr).Host) {
+		srcType = "s3"
+	}
+	job.Replicate.Source = migrateBatchEndpoint{
+		Type:     srcType,
+		Bucket:   srcBucket,
+		Prefix:   srcPrefix,
+		Endpoint: srcCfg.URL,
+		Path:     srcCfg.Path,
+		Credentials: &migrateBatchCredentials{
+			AccessKey:    srcCfg.AccessKey,
+			SecretKey:    srcCfg.SecretKey,
+			SessionToken: srcCfg.SessionToken,
+		},
+	}
+	job.Replicate.Target = migrateBatchEndpoint{
+		Type:   "minio",
+		Bucket: tgtBucket,
+		Prefix: tgtPrefix,
+	}
+	job.Replicate.Flags.Retry.Attempts = 10
+	job.Replicate.Flags.Retry.Delay = "500ms"
+
+	out, e := yaml.Marshal(job)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	header := fmt.Sprintf("# Generated by 'mc migrate plan %s %s'\n# Start on the target with 'mc batch start %s FILE'\n",
+		sourceURL, targetURL, tgtAlias)
+	return append([]byte(header), out...), nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigratePlanner(t *testing.T) {
+	p := newMigratePlanner()
+	add := func(key string, size int64, deleteMarker bool) {
+		p.add(&ClientContent{URL: ClientURL{Path: key}, Size: size, IsDeleteMarker: deleteMarker})
+	}
+	add("a", 100, false)
+	add("b", 1, true)
+	add("b", 64<<20, false)
+	add("b", 10<<20, false)
+	add("c", 20<<20, false)
+	p.flushVersions()
+
+	if p.objects != 3 || p.versions != 4 || p.deleteMarkers != 1 {
+		t.Fatalf("unexpected counts %d/%d/%d", p.objects, p.versions, p.deleteMarkers)
+	}
+	if p.requests.PutObject != 2 || p.requests.CreateMultipart != 2 || p.requests.UploadPart != 6 {
+		t.Fatalf("unexpected requests %+v", p.requests)
+	}
+	versions := sortedMigrateGroups(p.versionClasses, []string{"1", "2-5"})
+	if len(versions) != 2 || versions[0].Objects != 2 || versions[1].Objects != 1 || versions[1].Bytes != 74<<20 {
+		t.Fatalf("unexpected version classes %+v", versions)
+	}
+	if p.samples[0].Size != 64<<20 || p.samples[len(p.samples)-1].Size != 100 {
+		t.Fatalf("samples are not ordered by size")
+	}
+}
+
+func TestEstimateMigrateDuration(t *testing.T) {
+	throughput := migrateThroughput{Source: 200, Target: 100, SourceLatency: time.Second, TargetLatency: time.Second}
+	requests := migrateRequests{Get: 5, PutObject: 5}
+	if d := estimateMigrateDuration(1000, requests, throughput, 5); d != 12*time.Second {
+		t.Fatalf("expected 12s, got %s", d)
+	}
+	if d := estimateMigrateDuration(1000, requests, migrateThroughput{}, 5); d != 0 {
+		t.Fatalf("expected no estimate without throughput, got %s", d)
+	}
+}
@@ -1120,6 +1120,8 @@ func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, enc
 
 // Main entry point for mirror command.
 func mainMirror(cliCtx *cli.Context) error {
+	applyAliasFlagDefaults(cliCtx)
+
 	// Additional command specific theme customization.
 	console.SetColor("Mirror", color.New(color.FgGreen, color.Bold))
 
@@ -200,6 +200,8 @@ var rmManager = &removeManager{
 
 // mainMove is the entry point for mv command.
 func mainMove(cliCtx *cli.Context) error {
+	applyAliasFlagDefaults(cliCtx)
+
 	ctx, cancelMove := context.WithCancel(globalContext)
 	defer cancelMove()
 
@@ -0,0 +1,118 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/oteltrace"
+	"github.com/minio/pkg/v3/console"
+	"github.com/minio/pkg/v3/env"
+)
+
+var (
+	// globalTracer exports OpenTelemetry spans when --otel-export
+	// is set, it is nil otherwise.
+	globalTracer *oteltrace.Tracer
+
+	// globalTracerRoot is the span of the running command.
+	globalTracerRoot *oteltrace.Span
+
+	globalTracerOnce sync.Once
+)
+
+// initOTelTracing starts exporting spans to export, which is either
+// an OTLP/HTTP collector URL or a local file. The whole command runs
+// under a single root span, which continues the trace handed over by
+// the caller in the TRACEPARENT environment variable.
+func initOTelTracing(ctx *cli.Context, export string) error {
+	// Global flags are handled before the command is known,
+	// name the root span after the most specific command.
+	name := ctx.App.Name
+	if ctx.Command.Name != "" {
+		name += " " + ctx.Command.Name
+	}
+	if globalTracer != nil {
+		globalTracerRoot.SetName(name)
+		return nil
+	}
+
+	var exporter oteltrace.Exporter
+	if strings.HasPrefix(export, "http://") || strings.HasPrefix(export, "https://") {
+		headers, e := oteltrace.ParseHeaders(env.Get("OTEL_EXPORTER_OTLP_HEADERS", ""))
+		if e != nil {
+			return e
+		}
+		exporter, e = oteltrace.NewHTTPExporter(export, headers, &http.Client{Timeout: 10 * time.Second})
+		if e != nil {
+			return e
+		}
+	} else {
+		var e error
+		exporter, e = oteltrace.NewFileExporter(strings.TrimPrefix(export, "file://"))
+		if e != nil {
+			return e
+		}
+	}
+
+	tracerCtx := globalContext
+	if traceParent := env.Get("TRACEPARENT", ""); traceParent != "" {
+		var e error
+		tracerCtx, e = oteltrace.ContextWithRemoteParent(tracerCtx, traceParent)
+		if e != nil {
+			return e
+		}
+	}
+
+	globalTracer = oteltrace.New(exporter, env.Get("OTEL_SERVICE_NAME", "mc"), ReleaseTag)
+	globalContext, globalTracerRoot = globalTracer.Start(tracerCtx, name, oteltrace.KindInternal)
+	return nil
+}
+
+// shutdownOTelTracing ends the root span and exports all pending spans.
+// It is safe to call when tracing is disabled and more than once.
+func shutdownOTelTracing(err error) {
+	if globalTracer == nil {
+		return
+	}
+	globalTracerOnce.Do(func() {
+		globalTracerRoot.SetError(err)
+		globalTracerRoot.End()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if e := globalTracer.Shutdown(ctx); e != nil {
+			console.Errorln("Unable to export OpenTelemetry spans:", e)
+		}
+	})
+}
+
+// startOTelSpan starts a span for a high level operation, the returned
+// function ends it and records err when it is not nil.
+func startOTelSpan(ctx context.Context, name string, attrs ...oteltrace.Attribute) (context.Context, func(err error)) {
+	ctx, span := globalTracer.Start(ctx, name, oteltrace.KindInternal, attrs...)
+	return ctx, func(err error) {
+		span.SetError(err)
+		span.End()
+	}
+}
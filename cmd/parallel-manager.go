@@ -88,6 +88,7 @@ func (p *ParallelManager) addWorker() {
 
 	// Update number of threads
 	atomic.AddUint32(&p.workersNum, 1)
+	clientWorkers.Inc()
 
 	// Start a new worker
 	p.wg.Add(1)
@@ -97,12 +98,17 @@ func (p *ParallelManager) addWorker() {
 			t, ok := <-p.queueCh
 			if !ok {
 				// No more tasks, quit
+				clientWorkers.Dec()
 				p.wg.Done()
 				return
 			}
+			clientQueueDepth.Dec()
 
 			// Execute the task and send the result to channel.
-			p.resultCh <- t.fn()
+			clientInflightBytes.Add(float64(t.uploadSize))
+			result := t.fn()
+			clientInflightBytes.Sub(float64(t.uploadSize))
+			p.resultCh <- result
 
 			if t.barrier {
 				p.barrierSync.Unlock()
@@ -205,6 +211,7 @@ func (p *ParallelManager) enoughMemForUpload(uploadSize int64) bool {
 }
 
 func (p *ParallelManager) doQueueTask(t task) {
+	clientQueueDepth.Inc()
 	// Check if we have enough memory to perform next task,
 	// if not, wait to finish all currents tasks to continue
 	if !p.enoughMemForUpload(t.uploadSize) {
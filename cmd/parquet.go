@@ -0,0 +1,607 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/mc/pkg/s3select"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/schema"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	// parquetBatchSize is the number of rows decoded at once.
+	parquetBatchSize = 1024
+	// parquetReadAhead is the minimum length of the range requests.
+	parquetReadAhead = 1 << 20
+)
+
+// clientParquetFile gives the Parquet reader random access to an
+// object. Reads are served by range requests of at least
+// parquetReadAhead bytes, so only the footer and the column chunks
+// which are needed get downloaded.
+type clientParquetFile struct {
+	ctx       context.Context
+	clnt      Client
+	sse       encrypt.ServerSide
+	versionID string
+	size      int64
+
+	offset int64
+	stream io.ReadCloser
+	pos    int64 // offset of the stream
+}
+
+var _ source.ParquetFile = &clientParquetFile{}
+
+func (f *clientParquetFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *clientParquetFile) Read(p []byte) (int, error) {
+	for {
+		if f.offset >= f.size {
+			return 0, io.EOF
+		}
+		fresh := false
+		if f.stream == nil || f.pos != f.offset {
+			f.closeStream()
+			length := int64(parquetReadAhead)
+			if int64(len(p)) > length {
+				length = int64(len(p))
+			}
+			end := min(f.offset+length, f.size) - 1
+			reader, _, err := f.clnt.Get(f.ctx, GetOptions{
+				SSE:        f.sse,
+				VersionID:  f.versionID,
+				RangeStart: f.offset,
+				RangeEnd:   end,
+			})
+			if err != nil {
+				return 0, err.ToGoError()
+			}
+			f.stream, f.pos, fresh = reader, f.offset, true
+		}
+		n, e := f.stream.Read(p)
+		f.offset += int64(n)
+		f.pos += int64(n)
+		if e != io.EOF {
+			return n, e
+		}
+		// The range is exhausted, the next read opens a new one.
+		f.closeStream()
+		if n > 0 {
+			return n, nil
+		}
+		if fresh {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+}
+
+func (f *clientParquetFile) closeStream() {
+	if f.stream != nil {
+		f.stream.Close()
+		f.stream = nil
+	}
+}
+
+func (f *clientParquetFile) Write([]byte) (int, error) {
+	return 0, errors.New("parquet object is read only")
+}
+
+func (f *clientParquetFile) Close() error {
+	f.closeStream()
+	return nil
+}
+
+// Open returns an independent handle on the same object, the reader
+// opens one per column.
+func (f *clientParquetFile) Open(string) (source.ParquetFile, error) {
+	return &clientParquetFile{ctx: f.ctx, clnt: f.clnt, sse: f.sse, versionID: f.versionID, size: f.size}, nil
+}
+
+func (f *clientParquetFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet object is read only")
+}
+
+// newParquetReader opens the Parquet object behind clnt.
+func newParquetReader(ctx context.Context, clnt Client, sse encrypt.ServerSide) (*reader.ParquetReader, *probe.Error) {
+	content, err := clnt.Stat(ctx, StatOptions{sse: sse})
+	if err != nil {
+		return nil, err.Trace(clnt.GetURL().String())
+	}
+	return newParquetReaderFromContent(ctx, clnt, sse, content)
+}
+
+// newParquetReaderFromContent opens the Parquet object version
+// described by content.
+func newParquetReaderFromContent(ctx context.Context, clnt Client, sse encrypt.ServerSide, content *ClientContent) (*reader.ParquetReader, *probe.Error) {
+	pf := &clientParquetFile{ctx: ctx, clnt: clnt, sse: sse, versionID: content.VersionID, size: content.Size}
+	pr, e := reader.NewParquetReader(pf, nil, 4)
+	if e != nil {
+		pf.Close()
+		return nil, probe.NewError(fmt.Errorf("unable to read parquet footer: %w", e))
+	}
+	return pr, nil
+}
+
+// readParquetRows decodes up to maxRows rows of a Parquet object, all
+// of them if maxRows is negative, and calls fn for each of them until
+// fn returns an error. io.EOF returned by fn stops the iteration
+// without error.
+func readParquetRows(pr *reader.ParquetReader, maxRows int64, fn func(*s3select.Object) error) error {
+	defer pr.ReadStop()
+	sh := pr.SchemaHandler
+	root := sh.GetRootInName()
+	remaining := pr.GetNumRows()
+	if maxRows >= 0 {
+		remaining = min(remaining, maxRows)
+	}
+	for remaining > 0 {
+		n := int(min(remaining, parquetBatchSize))
+		rows, e := pr.ReadByNumber(n)
+		if e != nil {
+			return e
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		remaining -= int64(len(rows))
+		for _, row := range rows {
+			obj, ok := parquetValue(sh, root, reflect.ValueOf(row)).(*s3select.Object)
+			if !ok {
+				return errors.New("unexpected parquet row")
+			}
+			if e = fn(obj); e != nil {
+				if e == io.EOF {
+					return nil
+				}
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// parquetValue converts a value decoded by the Parquet reader, whose
+// struct fields carry Go-friendly names, into an engine value using the
+// column names of the file.
+func parquetValue(sh *schema.SchemaHandler, inPath string, v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return parquetValue(sh, inPath, v.Elem())
+	case reflect.Struct:
+		obj := &s3select.Object{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			childPath := inPath + common.PAR_GO_PATH_DELIMITER + t.Field(i).Name
+			name := t.Field(i).Name
+			if exPath, ok := sh.InPathToExPath[childPath]; ok {
+				parts := common.StrToPath(exPath)
+				name = parts[len(parts)-1]
+			}
+			obj.Set(name, parquetValue(sh, childPath, v.Field(i)))
+		}
+		return obj
+	case reflect.Slice:
+		elemPath := inPath
+		if listPath := inPath + common.PAR_GO_PATH_DELIMITER + "List" + common.PAR_GO_PATH_DELIMITER + "Element"; hasParquetPath(sh, listPath) {
+			elemPath = listPath
+		}
+		arr := make([]any, v.Len())
+		for i := range arr {
+			arr[i] = parquetValue(sh, elemPath, v.Index(i))
+		}
+		return arr
+	case reflect.Map:
+		valuePath := inPath + common.PAR_GO_PATH_DELIMITER + "Key_value" + common.PAR_GO_PATH_DELIMITER + "Value"
+		obj := &s3select.Object{}
+		iter := v.MapRange()
+		for iter.Next() {
+			obj.Set(fmt.Sprint(iter.Key().Interface()), parquetValue(sh, valuePath, iter.Value()))
+		}
+		return obj
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return parquetInt(parquetElement(sh, inPath), v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return parquetInt(parquetElement(sh, inPath), int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		if el := parquetElement(sh, inPath); el != nil && el.GetType() == parquet.Type_INT96 {
+			return types.INT96ToTime(v.String()).UTC()
+		}
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func hasParquetPath(sh *schema.SchemaHandler, inPath string) bool {
+	_, ok := sh.MapIndex[inPath]
+	return ok
+}
+
+func parquetElement(sh *schema.SchemaHandler, inPath string) *parquet.SchemaElement {
+	idx, ok := sh.MapIndex[inPath]
+	if !ok || int(idx) >= len(sh.SchemaElements) {
+		return nil
+	}
+	return sh.SchemaElements[idx]
+}
+
+// parquetInt applies the logical type of an integer column, turning
+// dates and timestamps into times and decimals into floats.
+func parquetInt(el *parquet.SchemaElement, n int64) any {
+	if el == nil {
+		return n
+	}
+	if lt := el.GetLogicalType(); lt != nil && lt.IsSetTIMESTAMP() {
+		unit := lt.GetTIMESTAMP().GetUnit()
+		switch {
+		case unit.IsSetMILLIS():
+			return types.TIMESTAMP_MILLISToTime(n, true)
+		case unit.IsSetMICROS():
+			return types.TIMESTAMP_MICROSToTime(n, true)
+		default:
+			return types.TIMESTAMP_NANOSToTime(n, true)
+		}
+	}
+	if !el.IsSetConvertedType() {
+		return n
+	}
+	switch el.GetConvertedType() {
+	case parquet.ConvertedType_TIMESTAMP_MILLIS:
+		return types.TIMESTAMP_MILLISToTime(n, true)
+	case parquet.ConvertedType_TIMESTAMP_MICROS:
+		return types.TIMESTAMP_MICROSToTime(n, true)
+	case parquet.ConvertedType_DATE:
+		return time.Unix(n*24*60*60, 0).UTC()
+	case parquet.ConvertedType_DECIMAL:
+		return float64(n) / math.Pow10(int(el.GetScale()))
+	}
+	return n
+}
+
+// readParquetFooter reads the metadata of the Parquet object version
+// described by content.
+func readParquetFooter(ctx context.Context, clnt Client, sse encrypt.ServerSide, content *ClientContent) (*parquet.FileMetaData, *probe.Error) {
+	pf := &clientParquetFile{ctx: ctx, clnt: clnt, sse: sse, versionID: content.VersionID, size: content.Size}
+	defer pf.Close()
+	pr := &reader.ParquetReader{PFile: pf}
+	if e := pr.ReadFooter(); e != nil {
+		return nil, probe.NewError(fmt.Errorf("unable to read parquet footer: %w", e))
+	}
+	return pr.Footer, nil
+}
+
+// parquetColumn describes a leaf column of a Parquet schema.
+type parquetColumn struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType,omitempty"`
+	Repetition  string `json:"repetition"`
+
+	element *parquet.SchemaElement
+}
+
+// parquetColumns returns the leaf columns of a Parquet schema, with
+// nested column names joined by dots.
+func parquetColumns(footer *parquet.FileMetaData) []parquetColumn {
+	var cols []parquetColumn
+	var walk func(i int, prefix []string) int
+	walk = func(i int, prefix []string) int {
+		el := footer.Schema[i]
+		var path []string
+		if i > 0 {
+			path = append(append(path, prefix...), el.GetName())
+		}
+		next := i + 1
+		if el.GetNumChildren() == 0 {
+			if i > 0 {
+				cols = append(cols, parquetColumn{
+					Path:        strings.Join(path, "."),
+					Type:        el.GetType().String(),
+					LogicalType: parquetLogicalType(el),
+					Repetition:  el.GetRepetitionType().String(),
+					element:     el,
+				})
+			}
+			return next
+		}
+		for c := int32(0); c < el.GetNumChildren() && next < len(footer.Schema); c++ {
+			next = walk(next, path)
+		}
+		return next
+	}
+	if len(footer.Schema) > 0 {
+		walk(0, nil)
+	}
+	return cols
+}
+
+func parquetLogicalType(el *parquet.SchemaElement) string {
+	if lt := el.GetLogicalType(); lt != nil {
+		switch {
+		case lt.IsSetSTRING():
+			return "STRING"
+		case lt.IsSetDATE():
+			return "DATE"
+		case lt.IsSetTIMESTAMP():
+			return "TIMESTAMP"
+		case lt.IsSetTIME():
+			return "TIME"
+		case lt.IsSetDECIMAL():
+			return fmt.Sprintf("DECIMAL(%d,%d)", lt.GetDECIMAL().GetPrecision(), lt.GetDECIMAL().GetScale())
+		case lt.IsSetINTEGER():
+			return fmt.Sprintf("INT(%d)", lt.GetINTEGER().GetBitWidth())
+		case lt.IsSetJSON():
+			return "JSON"
+		case lt.IsSetUUID():
+			return "UUID"
+		case lt.IsSetENUM():
+			return "ENUM"
+		}
+	}
+	if el.IsSetConvertedType() {
+		return el.GetConvertedType().String()
+	}
+	return ""
+}
+
+// parquetRowGroup summarizes a row group of a Parquet object.
+type parquetRowGroup struct {
+	Rows           int64 `json:"rows"`
+	Size           int64 `json:"size"`
+	CompressedSize int64 `json:"compressedSize"`
+}
+
+func parquetRowGroups(footer *parquet.FileMetaData) []parquetRowGroup {
+	groups := make([]parquetRowGroup, 0, len(footer.RowGroups))
+	for _, rg := range footer.RowGroups {
+		g := parquetRowGroup{Rows: rg.GetNumRows(), Size: rg.GetTotalByteSize()}
+		for _, chunk := range rg.GetColumns() {
+			g.CompressedSize += chunk.GetMetaData().GetTotalCompressedSize()
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// parquetColumnStats holds the statistics of a column across all the
+// row groups of a Parquet object.
+type parquetColumnStats struct {
+	parquetColumn
+	Codec          string `json:"codec"`
+	Values         int64  `json:"values"`
+	Nulls          *int64 `json:"nulls,omitempty"`
+	Min            any    `json:"min,omitempty"`
+	Max            any    `json:"max,omitempty"`
+	Size           int64  `json:"size"`
+	CompressedSize int64  `json:"compressedSize"`
+}
+
+// parquetStatistics merges the column chunk statistics of all the row
+// groups. The null count is only reported if every row group has it.
+func parquetStatistics(footer *parquet.FileMetaData) []parquetColumnStats {
+	cols := parquetColumns(footer)
+	stats := make([]parquetColumnStats, len(cols))
+	byPath := make(map[string]*parquetColumnStats, len(cols))
+	for i, col := range cols {
+		var nulls int64
+		stats[i] = parquetColumnStats{parquetColumn: col, Nulls: &nulls}
+		byPath[col.Path] = &stats[i]
+	}
+	for _, rg := range footer.RowGroups {
+		for _, chunk := range rg.GetColumns() {
+			md := chunk.GetMetaData()
+			if md == nil {
+				continue
+			}
+			st, ok := byPath[strings.Join(md.GetPathInSchema(), ".")]
+			if !ok {
+				continue
+			}
+			st.Codec = md.GetCodec().String()
+			st.Values += md.GetNumValues()
+			st.Size += md.GetTotalUncompressedSize()
+			st.CompressedSize += md.GetTotalCompressedSize()
+
+			cs := md.GetStatistics()
+			if cs == nil || !cs.IsSetNullCount() {
+				st.Nulls = nil
+			} else if st.Nulls != nil {
+				*st.Nulls += cs.GetNullCount()
+			}
+			if cs == nil {
+				continue
+			}
+			minB, maxB := cs.GetMinValue(), cs.GetMaxValue()
+			if minB == nil && maxB == nil {
+				minB, maxB = cs.GetMin(), cs.GetMax()
+			}
+			if v := parquetStatValue(minB, st.element); v != nil {
+				if c, ok := s3select.Compare(v, st.Min); st.Min == nil || ok && c < 0 {
+					st.Min = v
+				}
+			}
+			if v := parquetStatValue(maxB, st.element); v != nil {
+				if c, ok := s3select.Compare(v, st.Max); st.Max == nil || ok && c > 0 {
+					st.Max = v
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// parquetStatValue decodes a plain encoded statistics value.
+func parquetStatValue(b []byte, el *parquet.SchemaElement) any {
+	if b == nil {
+		return nil
+	}
+	switch el.GetType() {
+	case parquet.Type_BOOLEAN:
+		if len(b) >= 1 {
+			return b[0] != 0
+		}
+	case parquet.Type_INT32:
+		if len(b) >= 4 {
+			return parquetInt(el, int64(int32(binary.LittleEndian.Uint32(b))))
+		}
+	case parquet.Type_INT64:
+		if len(b) >= 8 {
+			return parquetInt(el, int64(binary.LittleEndian.Uint64(b)))
+		}
+	case parquet.Type_INT96:
+		if len(b) == 12 {
+			return types.INT96ToTime(string(b)).UTC()
+		}
+	case parquet.Type_FLOAT:
+		if len(b) >= 4 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+	case parquet.Type_DOUBLE:
+		if len(b) >= 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+	default:
+		if utf8.Valid(b) {
+			return string(b)
+		}
+		return hex.EncodeToString(b)
+	}
+	return nil
+}
+
+// parquetRowWriter writes result rows as a Parquet file. The schema is
+// taken from the first row and every column is optional. Values which
+// are not numbers, booleans or timestamps are written as strings.
+type parquetRowWriter struct {
+	w      io.Writer
+	pw     *writer.CSVWriter
+	names  []string
+	millis []bool // timestamp columns
+}
+
+func (p *parquetRowWriter) init(row *s3select.Object) error {
+	md := make([]string, 0, len(row.Keys))
+	seen := make(map[string]bool, len(row.Keys))
+	for i, key := range row.Keys {
+		// Commas and equal signs would break the schema metadata.
+		name := strings.NewReplacer(",", "_", "=", "_").Replace(key)
+		for seen[strings.ToLower(name)] {
+			name += "_"
+		}
+		seen[strings.ToLower(name)] = true
+
+		typ := "type=BYTE_ARRAY, convertedtype=UTF8"
+		millis := false
+		switch row.Values[i].(type) {
+		case int64:
+			typ = "type=INT64"
+		case float64:
+			typ = "type=DOUBLE"
+		case bool:
+			typ = "type=BOOLEAN"
+		case time.Time:
+			typ = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+			millis = true
+		}
+		md = append(md, fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", name, typ))
+		p.names = append(p.names, key)
+		p.millis = append(p.millis, millis)
+	}
+	pw, e := writer.NewCSVWriterFromWriter(md, p.w, 1)
+	if e != nil {
+		return e
+	}
+	p.pw = pw
+	return nil
+}
+
+func (p *parquetRowWriter) WriteRow(row *s3select.Object) error {
+	if p.pw == nil {
+		if e := p.init(row); e != nil {
+			return e
+		}
+	}
+	rec := make([]*string, len(p.names))
+	for i, name := range p.names {
+		v, ok := row.Lookup(name, true)
+		if !ok || v == nil || v == s3select.Missing {
+			continue
+		}
+		var s string
+		if t, ok := v.(time.Time); ok && p.millis[i] {
+			s = strconv.FormatInt(t.UnixMilli(), 10)
+		} else {
+			s = s3select.FormatValue(v)
+		}
+		rec[i] = &s
+	}
+	if e := p.pw.WriteString(rec); e != nil {
+		return fmt.Errorf("unable to write parquet column: %w", e)
+	}
+	return nil
+}
+
+// Close writes the footer of the Parquet file.
+func (p *parquetRowWriter) Close() error {
+	if p.pw == nil {
+		return nil
+	}
+	return p.pw.WriteStop()
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/s3select"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rows.parquet")
+	f, e := os.Create(fileName)
+	if e != nil {
+		t.Fatal(e)
+	}
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	pw := &parquetRowWriter{w: f}
+	for i, name := range []string{"alice", "bob", "carol"} {
+		row := &s3select.Object{}
+		row.Set("name", name)
+		row.Set("age", int64(30+i))
+		row.Set("score", float64(i)/2)
+		row.Set("at", day.Add(time.Duration(i)*time.Hour))
+		if i == 1 {
+			row.Values[0] = nil
+		}
+		if e = pw.WriteRow(row); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e = pw.Close(); e != nil {
+		t.Fatal(e)
+	}
+	f.Close()
+
+	ctx := context.Background()
+	clnt, err := fsNew(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := clnt.Stat(ctx, StatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	footer, err := readParquetFooter(ctx, clnt, nil, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if footer.GetNumRows() != 3 {
+		t.Fatalf("expected 3 rows, got %d", footer.GetNumRows())
+	}
+	stats := parquetStatistics(footer)
+	if len(stats) != 4 {
+		t.Fatalf("expected 4 columns, got %d", len(stats))
+	}
+	if stats[0].Path != "name" || stats[0].Nulls == nil || *stats[0].Nulls != 1 {
+		t.Errorf("unexpected name column statistics %+v", stats[0])
+	}
+	if stats[1].Min != int64(30) || stats[1].Max != int64(32) {
+		t.Errorf("unexpected age column range %v-%v", stats[1].Min, stats[1].Max)
+	}
+
+	pr, err := newParquetReaderFromContent(ctx, clnt, nil, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	e = readParquetRows(pr, 2, func(row *s3select.Object) error {
+		rows = append(rows, s3select.FormatValue(row))
+		return nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	want := `{"name":"alice","age":30,"score":0,"at":"2024-03-01T12:00:00Z"}
+{"name":null,"age":31,"score":0.5,"at":"2024-03-01T13:00:00Z"}`
+	if got := strings.Join(rows, "\n"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
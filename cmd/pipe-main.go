@@ -18,15 +18,19 @@
 package cmd
 
 import (
+	"errors"
 	"io"
+	"maps"
 	"os"
 	"runtime/debug"
 	"syscall"
 
 	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v3/console"
 )
 
 func defaultPartSize() string {
@@ -57,6 +61,34 @@ var pipeFlags = []cli.Flag{
 		Value: defaultPartSize(),
 		Usage: "customize chunk size for each concurrent upload",
 	},
+	cli.StringSliceFlag{
+		Name:  "to",
+		Usage: "upload to an additional target, reading stdin once",
+	},
+	cli.BoolFlag{
+		Name:  "allow-partial",
+		Usage: "with multiple targets, continue with the other targets when a target fails",
+	},
+	cli.BoolFlag{
+		Name:  "resumable",
+		Usage: "buffer parts to retry failed parts, and keep failed uploads for --resume",
+	},
+	cli.StringFlag{
+		Name:  "spool-dir",
+		Usage: "buffer parts of resumable uploads in a directory instead of memory",
+	},
+	cli.StringFlag{
+		Name:  "resume",
+		Usage: "resume a resumable upload by ID, skipping the input already uploaded",
+	},
+	cli.StringFlag{
+		Name:  "complete",
+		Usage: "complete a resumable upload by ID with the parts uploaded so far",
+	},
+	cli.StringFlag{
+		Name:  "abort",
+		Usage: "abort a resumable upload by ID",
+	},
 	cli.IntFlag{
 		Name:   "pipe-max-size",
 		Usage:  "increase the pipe buffer size to a custom value",
@@ -87,6 +119,13 @@ ENVIRONMENT VARIABLES:
   MC_ENC_KMS: KMS encryption key in the form of (alias/prefix=key).
   MC_ENC_S3: S3 encryption key in the form of (alias/prefix=key).
 
+RESUMABLE UPLOADS:
+  With --resumable, up to --concurrent parts of --part-size are buffered, in memory or
+  in --spool-dir, and failed parts are retried. The upload ID is printed when the upload
+  starts; an upload which still fails is left in place. It can be resumed by feeding the
+  same input to --resume, which skips the bytes already uploaded, or finished with
+  --complete or removed with --abort.
+
 EXAMPLES:
   1. Write contents of stdin to a file on local filesystem.
      {{.Prompt}} {{.HelpName}} /tmp/hello-world.go
@@ -111,6 +150,15 @@ EXAMPLES:
 
   8. Set tags to the uploaded objects
       {{.Prompt}} tar cvf - . | {{.HelpName}} --tags "category=prod&type=backup" play/mybucket/backup.tar
+
+  9. Stream a backup to a primary and an offsite bucket at the same time.
+      {{.Prompt}} tar cvf - . | {{.HelpName}} --to offsite/backups/backup.tar play/backups/backup.tar
+
+ 10. Stream a database dump with retries, buffering four 64MiB parts on disk.
+      {{.Prompt}} pg_dump accountsdb | {{.HelpName}} --resumable --concurrent 4 --part-size 64MiB --spool-dir /var/tmp play/backups/accountsdb.sql
+
+ 11. Resume the upload of example 10. after a failure.
+      {{.Prompt}} pg_dump accountsdb | {{.HelpName}} --resume "1b2cbe1c-24b3-4a7d-8a43-d5d3c9e2fb2e" --spool-dir /var/tmp play/backups/accountsdb.sql
 `,
 }
 
@@ -144,6 +192,33 @@ func pipe(ctx *cli.Context, targetURL string, encKeyDB map[string][]prefixSSEPai
 		}
 	}
 
+	if isResumablePipe(ctx) {
+		if len(ctx.StringSlice("to")) > 0 {
+			return probe.NewError(errors.New("--to is not supported by resumable uploads"))
+		}
+		if md5 || checksum.IsSet() {
+			return probe.NewError(errors.New("--md5 and --checksum are not supported by resumable uploads"))
+		}
+		partSize := int64(multipartSize)
+		if partSize == 0 {
+			_, partSize, _, _ = minio.OptimalPartInfo(-1, 0)
+		}
+		return resumablePipe(globalContext, targetURL, os.Stdin, resumablePipeOptions{
+			putOpts: PutOptions{
+				sse:          sseKey,
+				storageClass: storageClass,
+				metadata:     meta,
+			},
+			partSize:    partSize,
+			concurrency: max(multipartThreads, 1),
+			spoolDir:    ctx.String("spool-dir"),
+			resumeID:    ctx.String("resume"),
+			completeID:  ctx.String("complete"),
+			abortID:     ctx.String("abort"),
+			quiet:       quiet,
+		}).Trace(targetURL)
+	}
+
 	// Stream from stdin to multiple objects until EOF.
 	// Ignore size, since os.Stat() would not return proper size all the time
 	// for local filesystem for example /proc files.
@@ -166,6 +241,22 @@ func pipe(ctx *cli.Context, targetURL string, encKeyDB map[string][]prefixSSEPai
 		reader = os.Stdin
 	}
 
+	if to := ctx.StringSlice("to"); len(to) > 0 {
+		targets := append([]string{targetURL}, to...)
+		msgs := teeUpload(globalContext, "stdin", reader, targets, teePut(-1, func(target string) PutOptions {
+			tgtOpts := opts
+			tgtAlias, _ := url2Alias(target)
+			tgtOpts.sse = getSSE(target, encKeyDB[tgtAlias])
+			tgtOpts.metadata = maps.Clone(meta)
+			tgtOpts.metadata["Content-Type"] = guessURLContentType(target)
+			return tgtOpts
+		}), ctx.Bool("allow-partial"))
+		if !printTeeMessages(msgs, nil) {
+			return probe.NewError(errors.New("upload to one or more targets failed"))
+		}
+		return nil
+	}
+
 	_, err := putTargetStreamWithURL(targetURL, reader, -1, opts)
 	// TODO: See if this check is necessary.
 	switch e := err.ToGoError().(type) {
@@ -178,21 +269,45 @@ func pipe(ctx *cli.Context, targetURL string, encKeyDB map[string][]prefixSSEPai
 	return err.Trace(targetURL)
 }
 
+// isResumablePipe returns true if the upload goes through the
+// resumable multipart path.
+func isResumablePipe(ctx *cli.Context) bool {
+	for _, flag := range []string{"resumable", "spool-dir", "resume", "complete", "abort"} {
+		if ctx.IsSet(flag) {
+			return true
+		}
+	}
+	return false
+}
+
 // checkPipeSyntax - validate arguments passed by user
 func checkPipeSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 1 {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code.
 	}
+	var ids int
+	for _, flag := range []string{"resume", "complete", "abort"} {
+		if ctx.String(flag) != "" {
+			ids++
+		}
+	}
+	if ids > 1 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Only one of --resume, --complete or --abort can be specified.")
+	}
 }
 
 // mainPipe is the main entry point for pipe command.
 func mainPipe(ctx *cli.Context) error {
 	// validate pipe input arguments.
 	checkPipeSyntax(ctx)
+	applyAliasFlagDefaults(ctx)
 
 	encKeyDB, err := validateAndCreateEncryptionKeys(ctx)
 	fatalIf(err, "Unable to parse encryption keys.")
 
+	console.SetColor("Copy", color.New(color.FgGreen, color.Bold))
+	console.SetColor("TeeFailed", color.New(color.FgRed, color.Bold))
+
 	// globalQuiet is true for no window size to get. We just need --quiet here.
 	quiet := ctx.Bool("quiet")
 
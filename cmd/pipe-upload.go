@@ -0,0 +1,473 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/v3/console"
+)
+
+const (
+	// pipePartRetries is the number of attempts to upload a part.
+	pipePartRetries = 10
+	// pipeMaxRetryDelay caps the delay between two attempts.
+	pipeMaxRetryDelay = time.Minute
+	// pipeMaxParts is the maximum number of parts of an S3 upload.
+	pipeMaxParts = 10000
+)
+
+// pipeRetryDelay is the delay before the first retry of a part.
+var pipeRetryDelay = time.Second
+
+// pipePart is an uploaded part of a resumable pipe.
+type pipePart struct {
+	Number int    `json:"number"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+// pipeUploadMessage reports the state of a resumable pipe upload, so
+// that an interrupted upload can be resumed, completed or aborted.
+type pipeUploadMessage struct {
+	Status   string     `json:"status"`
+	Action   string     `json:"action"`
+	Target   string     `json:"target"`
+	UploadID string     `json:"uploadID"`
+	Offset   int64      `json:"offset,omitempty"`
+	Size     int64      `json:"size,omitempty"`
+	Parts    []pipePart `json:"parts,omitempty"`
+}
+
+func (p pipeUploadMessage) String() string {
+	uploadID := console.Colorize("UploadID", p.UploadID)
+	switch p.Action {
+	case "started":
+		return fmt.Sprintf("Started multipart upload `%s` to `%s`.", uploadID, p.Target)
+	case "resumed":
+		return fmt.Sprintf("Resuming multipart upload `%s` to `%s` after %d parts, skipping %s of input.",
+			uploadID, p.Target, len(p.Parts), humanize.IBytes(uint64(p.Offset)))
+	case "completed":
+		return fmt.Sprintf("Completed multipart upload `%s` to `%s` with %d parts, %s.",
+			uploadID, p.Target, len(p.Parts), humanize.IBytes(uint64(p.Size)))
+	case "aborted":
+		return fmt.Sprintf("Aborted multipart upload `%s` to `%s`.", uploadID, p.Target)
+	}
+	msg := fmt.Sprintf("Multipart upload `%s` to `%s` stopped after %d parts, %s.\n",
+		uploadID, p.Target, len(p.Parts), humanize.IBytes(uint64(p.Size)))
+	msg += fmt.Sprintf("Resume it with `mc pipe --resume %s %s` fed with the same input,\n", p.UploadID, p.Target)
+	msg += fmt.Sprintf("or remove it with `mc pipe --abort %s %s`.", p.UploadID, p.Target)
+	return msg
+}
+
+func (p pipeUploadMessage) JSON() string {
+	p.Status = "success"
+	if p.Action == "failed" {
+		p.Status = "error"
+	}
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// pipeBuffer holds one part read from the input until it is uploaded,
+// so that the part can be sent again when an attempt fails.
+type pipeBuffer interface {
+	// fill reads the next part from r and returns its size, which is
+	// smaller than the part size only at the end of the input.
+	fill(r io.Reader) (int64, error)
+	// reader returns a new reader of the buffered part.
+	reader() io.Reader
+	Close() error
+}
+
+type memPipeBuffer struct {
+	b []byte
+	n int
+}
+
+func (m *memPipeBuffer) fill(r io.Reader) (int64, error) {
+	var e error
+	m.n, e = io.ReadFull(r, m.b)
+	if e == io.EOF || e == io.ErrUnexpectedEOF {
+		e = nil
+	}
+	return int64(m.n), e
+}
+
+func (m *memPipeBuffer) reader() io.Reader {
+	return bytes.NewReader(m.b[:m.n])
+}
+
+func (m *memPipeBuffer) Close() error {
+	m.b = nil
+	return nil
+}
+
+// filePipeBuffer spools a part to a temporary file, for part sizes and
+// concurrency which would not fit in memory.
+type filePipeBuffer struct {
+	f    *os.File
+	size int64
+	n    int64
+}
+
+func (f *filePipeBuffer) fill(r io.Reader) (int64, error) {
+	var e error
+	f.n, e = io.CopyN(io.NewOffsetWriter(f.f, 0), r, f.size)
+	if e == io.EOF {
+		e = nil
+	}
+	return f.n, e
+}
+
+func (f *filePipeBuffer) reader() io.Reader {
+	return io.NewSectionReader(f.f, 0, f.n)
+}
+
+func (f *filePipeBuffer) Close() error {
+	defer os.Remove(f.f.Name())
+	return f.f.Close()
+}
+
+func newPipeBuffer(spoolDir string, partSize int64) (pipeBuffer, error) {
+	if spoolDir == "" {
+		return &memPipeBuffer{b: make([]byte, partSize)}, nil
+	}
+	f, e := os.CreateTemp(spoolDir, ".mc-pipe-*")
+	if e != nil {
+		return nil, e
+	}
+	return &filePipeBuffer{f: f, size: partSize}, nil
+}
+
+// pipeUploader streams an input of unknown size into a multipart upload,
+// keeping a bounded window of parts buffered so that failed parts are
+// retried instead of failing the whole upload.
+type pipeUploader struct {
+	clnt     *S3Client
+	target   string
+	uploadID string
+	sse      encrypt.ServerSide
+	partSize int64
+	spoolDir string
+
+	mu    sync.Mutex
+	parts map[int]minio.ObjectPart
+}
+
+// partList returns the uploaded parts ordered by part number.
+func (u *pipeUploader) partList() []pipePart {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	parts := make([]pipePart, 0, len(u.parts))
+	for _, p := range u.parts {
+		parts = append(parts, pipePart{Number: p.PartNumber, Size: p.Size, ETag: p.ETag})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts
+}
+
+func (u *pipeUploader) message(action string) pipeUploadMessage {
+	msg := pipeUploadMessage{Action: action, Target: u.target, UploadID: u.uploadID, Parts: u.partList()}
+	for _, p := range msg.Parts {
+		msg.Size += p.Size
+	}
+	return msg
+}
+
+// resume loads the parts already uploaded and returns the number of
+// the first part to upload and the input offset it starts at. Only the
+// parts contiguous from the first one are kept, the others are sent
+// again.
+func (u *pipeUploader) resume(ctx context.Context) (int, int64, *probe.Error) {
+	parts, err := u.clnt.listObjectParts(ctx, u.uploadID)
+	if err != nil {
+		return 0, 0, err.Trace(u.uploadID)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	var offset int64
+	next := 1
+	for _, p := range parts {
+		if p.PartNumber != next {
+			break
+		}
+		if next == 1 {
+			// Parts must be of the size the upload was started with.
+			u.partSize = p.Size
+		} else if p.Size > u.partSize {
+			break
+		}
+		u.parts[p.PartNumber] = p
+		offset += p.Size
+		next++
+		if p.Size < u.partSize {
+			// The last part was uploaded, only completing is left.
+			return 0, offset, nil
+		}
+	}
+	return next, offset, nil
+}
+
+// upload reads r until EOF and uploads it as parts, starting with part
+// number first, with at most concurrency parts buffered at a time.
+func (u *pipeUploader) upload(ctx context.Context, r io.Reader, first, concurrency int) *probe.Error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	free := make(chan pipeBuffer, concurrency)
+	for i := 0; i < concurrency; i++ {
+		buf, e := newPipeBuffer(u.spoolDir, u.partSize)
+		if e != nil {
+			close(free)
+			for buf := range free {
+				buf.Close()
+			}
+			return probe.NewError(e)
+		}
+		free <- buf
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr *probe.Error
+	)
+	setErr := func(err *probe.Error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for number := first; ; number++ {
+		var buf pipeBuffer
+		select {
+		case buf = <-free:
+		case <-ctx.Done():
+		}
+		if buf == nil {
+			break
+		}
+		n, e := buf.fill(r)
+		if e != nil {
+			free <- buf
+			setErr(probe.NewError(e))
+			break
+		}
+		// An empty input is uploaded as a single empty part.
+		if n == 0 && number > 1 {
+			free <- buf
+			break
+		}
+		if number > pipeMaxParts {
+			free <- buf
+			setErr(probe.NewError(fmt.Errorf("input exceeds %d parts, use a larger --part-size", pipeMaxParts)))
+			break
+		}
+		wg.Add(1)
+		go func(number int, buf pipeBuffer, n int64) {
+			defer wg.Done()
+			defer func() { free <- buf }()
+			part, err := u.putPart(ctx, number, buf, n)
+			if err != nil {
+				setErr(err)
+				return
+			}
+			u.mu.Lock()
+			u.parts[number] = part
+			u.mu.Unlock()
+		}(number, buf, n)
+		if n < u.partSize {
+			break
+		}
+	}
+	wg.Wait()
+
+	close(free)
+	for buf := range free {
+		buf.Close()
+	}
+	return firstErr
+}
+
+// putPart uploads a buffered part, retrying with an exponential backoff.
+func (u *pipeUploader) putPart(ctx context.Context, number int, buf pipeBuffer, size int64) (minio.ObjectPart, *probe.Error) {
+	delay := pipeRetryDelay
+	for attempt := 1; ; attempt++ {
+		part, err := u.clnt.putObjectPart(ctx, u.uploadID, number, buf.reader(), size, u.sse)
+		if err == nil {
+			return part, nil
+		}
+		if attempt == pipePartRetries || ctx.Err() != nil || !isRetryablePipeError(err) {
+			return part, err.Trace(u.target)
+		}
+		errorIf(err.Trace(u.target), "Unable to upload part %d, retrying in %s.", number, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return part, probe.NewError(ctx.Err())
+		}
+		delay = min(2*delay, pipeMaxRetryDelay)
+	}
+}
+
+// isRetryablePipeError returns false for errors which another attempt
+// cannot fix.
+func isRetryablePipeError(err *probe.Error) bool {
+	switch e := err.ToGoError(); e.(type) {
+	case PathInsufficientPermission, BucketDoesNotExist, BucketInvalid:
+		return false
+	default:
+		if errors.Is(e, context.Canceled) {
+			return false
+		}
+		switch minio.ToErrorResponse(e).Code {
+		case "NoSuchUpload", "InvalidAccessKeyId", "SignatureDoesNotMatch", "EntityTooLarge":
+			return false
+		}
+	}
+	return true
+}
+
+// complete commits all the uploaded parts.
+func (u *pipeUploader) complete(ctx context.Context) *probe.Error {
+	parts := u.partList()
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, err := u.clnt.completeMultipartUpload(ctx, u.uploadID, completeParts)
+	return err.Trace(u.target)
+}
+
+// resumablePipeOptions are the options of a resumable pipe.
+type resumablePipeOptions struct {
+	putOpts     PutOptions
+	partSize    int64
+	concurrency int
+	spoolDir    string
+	// resumeID, completeID and abortID select an existing upload.
+	resumeID, completeID, abortID string
+	quiet                         bool
+}
+
+// resumablePipe streams r to targetURL with the multipart API directly.
+// The upload is left in place when it fails, and its ID is printed so
+// that it can be resumed, completed or aborted by a later invocation.
+func resumablePipe(ctx context.Context, targetURL string, r io.Reader, opts resumablePipeOptions) *probe.Error {
+	clnt, err := newClient(targetURL)
+	if err != nil {
+		return err.Trace(targetURL)
+	}
+	s3Clnt, ok := clnt.(*S3Client)
+	if !ok {
+		return probe.NewError(errors.New("resumable uploads are only supported for S3 targets"))
+	}
+	u := &pipeUploader{
+		clnt:     s3Clnt,
+		target:   targetURL,
+		sse:      opts.putOpts.sse,
+		partSize: opts.partSize,
+		spoolDir: opts.spoolDir,
+		parts:    make(map[int]minio.ObjectPart),
+	}
+
+	switch {
+	case opts.abortID != "":
+		u.uploadID = opts.abortID
+		if err = s3Clnt.abortMultipartUpload(ctx, u.uploadID); err != nil {
+			return err.Trace(targetURL, u.uploadID)
+		}
+		printMsg(u.message("aborted"))
+		return nil
+	case opts.completeID != "":
+		u.uploadID = opts.completeID
+		if _, _, err = u.resume(ctx); err != nil {
+			return err
+		}
+		if len(u.parts) == 0 {
+			return probe.NewError(fmt.Errorf("upload %s has no parts to complete", u.uploadID))
+		}
+		if err = u.complete(ctx); err != nil {
+			return err
+		}
+		printMsg(u.message("completed"))
+		return nil
+	}
+
+	first := 1
+	if opts.resumeID != "" {
+		u.uploadID = opts.resumeID
+		var offset int64
+		if first, offset, err = u.resume(ctx); err != nil {
+			return err
+		}
+		msg := u.message("resumed")
+		msg.Offset = offset
+		printMsg(msg)
+		// When all the input was uploaded before, only completing is left.
+		if first > 0 {
+			if _, e := io.CopyN(io.Discard, r, offset); e != nil {
+				if e == io.EOF {
+					e = errors.New("input is shorter than the parts already uploaded")
+				}
+				return probe.NewError(e)
+			}
+		}
+	} else {
+		if u.uploadID, err = s3Clnt.newMultipartUpload(ctx, opts.putOpts); err != nil {
+			return err.Trace(targetURL)
+		}
+		printMsg(u.message("started"))
+	}
+
+	var pg *progressBar
+	if !opts.quiet {
+		pg = newProgressBar(0)
+		r = io.TeeReader(r, pg)
+	}
+	if first > 0 {
+		err = u.upload(ctx, r, first, opts.concurrency)
+	}
+	if err == nil {
+		err = u.complete(ctx)
+	}
+	if pg != nil {
+		pg.Finish()
+	}
+	if err != nil {
+		printMsg(u.message("failed"))
+		return err
+	}
+	printMsg(u.message("completed"))
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// multipartHandler is a minimal S3 multipart upload server, failing the
+// first attempt to upload failPart.
+type multipartHandler struct {
+	mu       sync.Mutex
+	parts    map[int][]byte
+	failPart int
+	object   []byte
+}
+
+func (h *multipartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && q.Has("location"):
+		fmt.Fprint(w, `<LocationConstraint></LocationConstraint>`)
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>object</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && q.Has("partNumber"):
+		number, _ := strconv.Atoi(q.Get("partNumber"))
+		data, e := io.ReadAll(r.Body)
+		if e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if number == h.failPart {
+			h.failPart = 0
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<Error><Code>IncompleteBody</Code><Message>incomplete body</Message></Error>`)
+			return
+		}
+		h.parts[number] = data
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case r.Method == http.MethodGet && q.Has("uploadId"):
+		var b bytes.Buffer
+		b.WriteString(`<ListPartsResult><Bucket>bucket</Bucket><Key>object</Key><UploadId>upload-1</UploadId><IsTruncated>false</IsTruncated>`)
+		for number := 1; number <= len(h.parts)+1; number++ {
+			if data, ok := h.parts[number]; ok {
+				sum := md5.Sum(data)
+				fmt.Fprintf(&b, `<Part><PartNumber>%d</PartNumber><ETag>"%x"</ETag><Size>%d</Size></Part>`, number, sum, len(data))
+			}
+		}
+		b.WriteString(`</ListPartsResult>`)
+		w.Write(b.Bytes())
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+		if e := xml.NewDecoder(r.Body).Decode(&complete); e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.object = nil
+		for _, p := range complete.Parts {
+			h.object = append(h.object, h.parts[p.PartNumber]...)
+		}
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>object</Key><ETag>"etag-2"</ETag></CompleteMultipartUploadResult>`)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestPipeUploader(t *testing.T) {
+	pipeRetryDelay = time.Millisecond
+	handler := &multipartHandler{parts: make(map[int][]byte), failPart: 2}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conf := new(Config)
+	conf.HostURL = server.URL + "/bucket/object"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	// Signature V2 sends the parts unchunked.
+	conf.Signature = "S3v2"
+	clnt, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := bytes.Repeat([]byte("0123456789"), 25)
+	ctx := context.Background()
+	for _, spoolDir := range []string{"", t.TempDir()} {
+		u := &pipeUploader{
+			clnt:     clnt.(*S3Client),
+			partSize: 64,
+			spoolDir: spoolDir,
+			parts:    make(map[int]minio.ObjectPart),
+		}
+		if u.uploadID, err = u.clnt.newMultipartUpload(ctx, PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err = u.upload(ctx, bytes.NewReader(input), 1, 3); err != nil {
+			t.Fatal(err)
+		}
+		if err = u.complete(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(handler.object, input) {
+			t.Fatalf("uploaded object differs from the input: %q", handler.object)
+		}
+	}
+
+	// Drop the last two parts and resume from the same input.
+	delete(handler.parts, 4)
+	delete(handler.parts, 3)
+	u := &pipeUploader{
+		clnt:     clnt.(*S3Client),
+		uploadID: "upload-1",
+		partSize: 1 << 20,
+		parts:    make(map[int]minio.ObjectPart),
+	}
+	first, offset, err := u.resume(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != 3 || offset != 128 || u.partSize != 64 {
+		t.Fatalf("unexpected resume state: part %d, offset %d, part size %d", first, offset, u.partSize)
+	}
+	r := bytes.NewReader(input)
+	r.Seek(offset, io.SeekStart)
+	if err = u.upload(ctx, r, first, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = u.complete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(handler.object, input) {
+		t.Fatalf("resumed object differs from the input: %q", handler.object)
+	}
+}
@@ -96,6 +96,8 @@ EXAMPLES:
 
 // mainPut is the entry point for put command.
 func mainPut(cliCtx *cli.Context) (e error) {
+	applyAliasFlagDefaults(cliCtx)
+
 	args := cliCtx.Args()
 	if len(args) < 2 {
 		showCommandHelpAndExit(cliCtx, 1) // last argument is exit code.
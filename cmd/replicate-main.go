@@ -29,6 +29,7 @@ var replicateSubcommands = []cli.Command{
 	replicateImportCmd,
 	replicateRemoveCmd,
 	replicateBacklogCmd,
+	replicateVerifyCmd,
 }
 
 var replicateCmd = cli.Command{
@@ -0,0 +1,531 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/replication"
+	"github.com/minio/pkg/v3/console"
+)
+
+var replicateVerifyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "remote-bucket",
+		Usage: "only verify the replication target with this ARN",
+	},
+	cli.StringFlag{
+		Name:  "target",
+		Usage: "ALIAS/BUCKET of the replication target, when it can not be found from the configured aliases",
+	},
+	cli.BoolFlag{
+		Name:  "resync",
+		Usage: "start a replication reset of the targets having missing, stale or divergent objects",
+	},
+}
+
+var replicateVerifyCmd = cli.Command{
+	Name:         "verify",
+	Usage:        "verify that the objects of a bucket match its replication targets",
+	Action:       mainReplicateVerify,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(globalFlags, replicateVerifyFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Lists the versions of the bucket and of each target of its replication rules
+  and compares their version IDs, ETags, metadata, tags and delete markers.
+  The target bucket is accessed with the alias configured for its endpoint.
+
+  Versions are reported as:
+    missing    the object does not exist on the target
+    stale      the object exists on the target, but not this version
+    divergent  the version exists on both sides with a different content
+    pending    the version is not replicated yet
+    extra      the version only exists on the target
+
+  The command exits with an error when missing, stale or divergent versions are found.
+
+EXAMPLES:
+  1. Verify the replication of the bucket "mybucket" of the alias "myminio".
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Verify the objects under "path/to/prefix" against the target "backup/mybucket".
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/path/to/prefix --target backup/mybucket
+
+  3. Verify the replication and resync the target if objects differ.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket --resync
+`,
+}
+
+// Types of the versions reported by replicate verify.
+const (
+	replicaMissing   = "missing"
+	replicaStale     = "stale"
+	replicaDivergent = "divergent"
+	replicaPending   = "pending"
+	replicaExtra     = "extra"
+)
+
+type replicateVerifyMessage struct {
+	Status    string `json:"status"`
+	Type      string `json:"type"`
+	Arn       string `json:"arn"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+func (m replicateVerifyMessage) JSON() string {
+	buf, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (m replicateVerifyMessage) String() string {
+	msg := console.Colorize("ReplicaType"+m.Type, fmt.Sprintf("%-10s", m.Type)) + m.Key
+	if m.VersionID != "" {
+		msg += " (" + m.VersionID + ")"
+	}
+	if m.Detail != "" {
+		msg += ": " + m.Detail
+	}
+	return msg
+}
+
+type replicateVerifySummary struct {
+	Status    string `json:"status"`
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Arn       string `json:"arn"`
+	Checked   int    `json:"checked"`
+	Missing   int    `json:"missing"`
+	Stale     int    `json:"stale"`
+	Divergent int    `json:"divergent"`
+	Pending   int    `json:"pending"`
+	Extra     int    `json:"extra"`
+	ResetID   string `json:"resetId,omitempty"`
+}
+
+func (s replicateVerifySummary) offenders() int {
+	return s.Missing + s.Stale + s.Divergent
+}
+
+func (s *replicateVerifySummary) add(m replicateVerifyMessage) {
+	switch m.Type {
+	case replicaMissing:
+		s.Missing++
+	case replicaStale:
+		s.Stale++
+	case replicaDivergent:
+		s.Divergent++
+	case replicaPending:
+		s.Pending++
+	case replicaExtra:
+		s.Extra++
+	}
+}
+
+func (s replicateVerifySummary) JSON() string {
+	buf, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (s replicateVerifySummary) String() string {
+	msg := fmt.Sprintf("Verified %d versions of %s against %s: %d missing, %d stale, %d divergent, %d pending, %d extra.",
+		s.Checked, s.Source, s.Target, s.Missing, s.Stale, s.Divergent, s.Pending, s.Extra)
+	if s.offenders() == 0 {
+		msg = console.Colorize("ReplicaOK", msg)
+	} else {
+		msg = console.Colorize("ReplicaFailed", msg)
+	}
+	if s.ResetID != "" {
+		msg += "\nReplication reset started with ID " + s.ResetID + "."
+	}
+	return msg
+}
+
+// replicaVersions holds the versions of a key, newest first.
+type replicaVersions struct {
+	key      string
+	versions []*ClientContent
+}
+
+// groupReplicaVersions groups the listed versions by key. The listing
+// returns the keys in lexical order.
+func groupReplicaVersions(ctx context.Context, bucket string, contentCh <-chan *ClientContent) (<-chan replicaVersions, <-chan *probe.Error) {
+	groupCh := make(chan replicaVersions)
+	errCh := make(chan *probe.Error, 1)
+	go func() {
+		defer close(groupCh)
+		defer close(errCh)
+		var group replicaVersions
+		send := func() bool {
+			if len(group.versions) == 0 {
+				return true
+			}
+			select {
+			case groupCh <- group:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for content := range contentCh {
+			if content.Err != nil {
+				errCh <- content.Err
+				return
+			}
+			if content.Type.IsDir() {
+				continue
+			}
+			key := strings.TrimPrefix(content.URL.Path, "/"+bucket+"/")
+			if key != group.key {
+				if !send() {
+					return
+				}
+				group = replicaVersions{key: key}
+			}
+			group.versions = append(group.versions, content)
+		}
+		send()
+	}()
+	return groupCh, errCh
+}
+
+// replicaMetadata returns the user metadata and content type of a version.
+func replicaMetadata(c *ClientContent) map[string]string {
+	md := map[string]string{}
+	for k, v := range c.UserMetadata {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-amz-meta-") || k == "content-type" {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+func diffReplicaMaps(kind string, src, tgt map[string]string) []string {
+	var diffs []string
+	for k, v := range src {
+		if tv, ok := tgt[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s %s is missing", kind, k))
+		} else if tv != v {
+			diffs = append(diffs, fmt.Sprintf("%s %s is %q instead of %q", kind, k, tv, v))
+		}
+	}
+	for k := range tgt {
+		if _, ok := src[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s %s is unexpected", kind, k))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// diffReplicaVersion returns how the target version differs from the source.
+func diffReplicaVersion(src, tgt *ClientContent) []string {
+	if src.IsDeleteMarker != tgt.IsDeleteMarker {
+		if src.IsDeleteMarker {
+			return []string{"delete marker is not a delete marker on the target"}
+		}
+		return []string{"version is a delete marker on the target"}
+	}
+	if src.IsDeleteMarker {
+		return nil
+	}
+	var diffs []string
+	if s, t := strings.Trim(src.ETag, "\""), strings.Trim(tgt.ETag, "\""); s != t {
+		diffs = append(diffs, fmt.Sprintf("etag is %s instead of %s", t, s))
+	}
+	diffs = append(diffs, diffReplicaMaps("metadata", replicaMetadata(src), replicaMetadata(tgt))...)
+	return append(diffs, diffReplicaMaps("tag", src.Tags, tgt.Tags)...)
+}
+
+// replicaRulesMatch reports whether a rule replicates the version of key.
+func replicaRulesMatch(rules []replication.Rule, key string, c *ClientContent) bool {
+	for _, rule := range rules {
+		if !strings.HasPrefix(key, rule.Prefix()) {
+			continue
+		}
+		if c.IsDeleteMarker && rule.DeleteMarkerReplication.Status != replication.Enabled {
+			continue
+		}
+		tagsMatch := true
+		if tags := rule.Tags(); tags != "" {
+			for _, tag := range strings.Split(tags, "&") {
+				k, v, _ := strings.Cut(tag, "=")
+				if c.Tags[k] != v {
+					tagsMatch = false
+				}
+			}
+		}
+		if tagsMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyReplicaKey compares the versions of a key on the source and
+// on the target, either may be empty.
+func verifyReplicaKey(key string, rules []replication.Rule, src, tgt []*ClientContent) (checked int, msgs []replicateVerifyMessage) {
+	tgtVersions := make(map[string]*ClientContent, len(tgt))
+	for _, c := range tgt {
+		tgtVersions[c.VersionID] = c
+	}
+	for _, c := range src {
+		t, ok := tgtVersions[c.VersionID]
+		delete(tgtVersions, c.VersionID)
+		if !replicaRulesMatch(rules, key, c) {
+			continue
+		}
+		checked++
+		msg := replicateVerifyMessage{Status: "success", Key: key, VersionID: c.VersionID}
+		switch {
+		case ok:
+			diffs := diffReplicaVersion(c, t)
+			if len(diffs) == 0 {
+				continue
+			}
+			msg.Type, msg.Detail = replicaDivergent, strings.Join(diffs, ", ")
+		case c.ReplicationStatus == string(minio.ReplicationStatusPending):
+			msg.Type = replicaPending
+		case len(tgt) == 0:
+			msg.Type = replicaMissing
+		default:
+			msg.Type = replicaStale
+		}
+		if c.ReplicationStatus == string(minio.ReplicationStatusFailed) && msg.Detail == "" {
+			msg.Detail = "replication failed"
+		}
+		msgs = append(msgs, msg)
+	}
+	for _, t := range tgt {
+		if _, ok := tgtVersions[t.VersionID]; ok {
+			msgs = append(msgs, replicateVerifyMessage{Status: "success", Type: replicaExtra, Key: key, VersionID: t.VersionID})
+		}
+	}
+	return checked, msgs
+}
+
+// verifyReplica walks the source and the target concurrently and
+// reports every version which differs.
+func verifyReplica(ctx context.Context, src, tgt Client, srcBucket, tgtBucket string, rules []replication.Rule, report func(replicateVerifyMessage)) (int, *probe.Error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := ListOptions{Recursive: true, WithOlderVersions: true, WithDeleteMarkers: true, WithMetadata: true}
+	srcCh, srcErrCh := groupReplicaVersions(ctx, srcBucket, src.List(ctx, opts))
+	tgtCh, tgtErrCh := groupReplicaVersions(ctx, tgtBucket, tgt.List(ctx, opts))
+	// Returning cancels the listings when one of them failed.
+	return mergeReplicaVersions(srcCh, srcErrCh, tgtCh, tgtErrCh, rules, report)
+}
+
+// mergeReplicaVersions walks the versions of the source and the target
+// in key order and reports their differences. It stops at the first
+// listing error, since the keys of the other side which were not yet
+// compared would wrongly be reported as missing or extra.
+func mergeReplicaVersions(srcCh <-chan replicaVersions, srcErrCh <-chan *probe.Error, tgtCh <-chan replicaVersions, tgtErrCh <-chan *probe.Error, rules []replication.Rule, report func(replicateVerifyMessage)) (int, *probe.Error) {
+	var listErr *probe.Error
+	// recv returns the next group of versions, the listing error is
+	// available once the group channel is closed.
+	recv := func(ch <-chan replicaVersions, errCh <-chan *probe.Error) (replicaVersions, bool) {
+		g, ok := <-ch
+		if !ok {
+			if err := <-errCh; err != nil && listErr == nil {
+				listErr = err
+			}
+		}
+		return g, ok
+	}
+
+	var checked int
+	s, sok := recv(srcCh, srcErrCh)
+	t, tok := recv(tgtCh, tgtErrCh)
+	for (sok || tok) && listErr == nil {
+		var sv, tv []*ClientContent
+		key := s.key
+		switch {
+		case sok && (!tok || s.key < t.key):
+			sv = s.versions
+			s, sok = recv(srcCh, srcErrCh)
+		case tok && (!sok || t.key < s.key):
+			key, tv = t.key, t.versions
+			t, tok = recv(tgtCh, tgtErrCh)
+		default:
+			sv, tv = s.versions, t.versions
+			s, sok = recv(srcCh, srcErrCh)
+			t, tok = recv(tgtCh, tgtErrCh)
+		}
+		if listErr != nil {
+			break
+		}
+		n, msgs := verifyReplicaKey(key, rules, sv, tv)
+		checked += n
+		for _, msg := range msgs {
+			report(msg)
+		}
+	}
+	return checked, listErr
+}
+
+// replicaTargetAlias returns the alias configured for the endpoint of a
+// remote target, i.e. with the same host.
+func replicaTargetAlias(target madmin.BucketTarget) string {
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(), "Unable to load the mc configuration.")
+	var aliases []string
+	for alias, aliasCfg := range conf.Aliases {
+		if u, e := url.Parse(aliasCfg.URL); e == nil && u.Host == target.Endpoint && (u.Scheme == "https") == target.Secure {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return ""
+	}
+	sort.Strings(aliases)
+	return aliases[0]
+}
+
+// mainReplicateVerify is the handle for "mc replicate verify" command.
+func mainReplicateVerify(cliCtx *cli.Context) error {
+	ctx, cancelReplicateVerify := context.WithCancel(globalContext)
+	defer cancelReplicateVerify()
+
+	if len(cliCtx.Args()) != 1 {
+		showCommandHelpAndExit(cliCtx, 1) // last argument is exit code
+	}
+	console.SetColor("ReplicaType"+replicaMissing, color.New(color.FgRed, color.Bold))
+	console.SetColor("ReplicaType"+replicaStale, color.New(color.FgRed))
+	console.SetColor("ReplicaType"+replicaDivergent, color.New(color.FgYellow, color.Bold))
+	console.SetColor("ReplicaType"+replicaPending, color.New(color.FgCyan))
+	console.SetColor("ReplicaType"+replicaExtra, color.New(color.FgBlue))
+	console.SetColor("ReplicaOK", color.New(color.FgGreen, color.Bold))
+	console.SetColor("ReplicaFailed", color.New(color.FgRed, color.Bold))
+
+	aliasedURL := cliCtx.Args().Get(0)
+	_, urlPath := url2Alias(aliasedURL)
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+	if bucket == "" {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "A bucket is required.")
+	}
+
+	client, err := newClient(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to initialize connection.")
+	cfg, err := client.GetReplication(ctx)
+	fatalIf(err.Trace(aliasedURL), "Unable to get the replication configuration.")
+
+	// The enabled rules of each target.
+	rules := map[string][]replication.Rule{}
+	for _, rule := range cfg.Rules {
+		arn := rule.Destination.Bucket
+		if rule.Status != replication.Enabled || (cliCtx.IsSet("remote-bucket") && arn != cliCtx.String("remote-bucket")) {
+			continue
+		}
+		rules[arn] = append(rules[arn], rule)
+	}
+	if len(rules) == 0 {
+		fatalIf(errDummy().Trace(aliasedURL), "No enabled replication rule found.")
+	}
+	if cliCtx.IsSet("target") && len(rules) > 1 {
+		fatalIf(errInvalidArgument().Trace(aliasedURL), "--target needs --remote-bucket, the bucket has several replication targets.")
+	}
+
+	var targets []madmin.BucketTarget
+	if !cliCtx.IsSet("target") {
+		admClient, err := newAdminClient(aliasedURL)
+		fatalIf(err.Trace(aliasedURL), "Unable to initialize admin connection.")
+		var e error
+		targets, e = admClient.ListRemoteTargets(ctx, bucket, string(madmin.ReplicationService))
+		fatalIf(probe.NewError(e).Trace(aliasedURL), "Unable to list the replication targets.")
+	}
+
+	arns := make([]string, 0, len(rules))
+	for arn := range rules {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+
+	var offenders int
+	for _, arn := range arns {
+		targetURL := cliCtx.String("target")
+		if targetURL == "" {
+			for _, target := range targets {
+				if target.Arn != arn {
+					continue
+				}
+				targetAlias := replicaTargetAlias(target)
+				if targetAlias == "" {
+					fatalIf(errDummy().Trace(arn), "No alias found for the replication target `"+target.Endpoint+"`, use --target.")
+				}
+				targetURL = targetAlias + "/" + target.TargetBucket
+			}
+			if targetURL == "" {
+				fatalIf(errDummy().Trace(arn), "No replication target found for `"+arn+"`.")
+			}
+		}
+		_, targetPath := url2Alias(targetURL)
+		targetBucket, _, _ := strings.Cut(strings.TrimPrefix(targetPath, "/"), "/")
+		tgtClient, err := newClient(path.Join(targetURL, prefix))
+		fatalIf(err.Trace(targetURL), "Unable to initialize connection.")
+
+		summary := replicateVerifySummary{Status: "success", Source: aliasedURL, Target: targetURL, Arn: arn}
+		summary.Checked, err = verifyReplica(ctx, client, tgtClient, bucket, targetBucket, rules[arn], func(msg replicateVerifyMessage) {
+			msg.Arn = arn
+			summary.add(msg)
+			printMsg(msg)
+		})
+		fatalIf(err.Trace(aliasedURL, targetURL), "Unable to list the object versions.")
+
+		if summary.offenders() > 0 {
+			offenders += summary.offenders()
+			if cliCtx.Bool("resync") {
+				rinfo, err := client.ResetReplication(ctx, 0, arn)
+				fatalIf(err.Trace(aliasedURL), "Unable to reset replication.")
+				if len(rinfo.Targets) > 0 {
+					summary.ResetID = rinfo.Targets[0].ResetID
+				}
+			}
+		}
+		printMsg(summary)
+	}
+	if offenders > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/replication"
+)
+
+func TestVerifyReplicaKey(t *testing.T) {
+	rules := []replication.Rule{{
+		Status:                  replication.Enabled,
+		Filter:                  replication.Filter{Prefix: "docs/"},
+		DeleteMarkerReplication: replication.DeleteMarkerReplication{Status: replication.Enabled},
+	}}
+	version := func(id, etag string, md map[string]string) *ClientContent {
+		return &ClientContent{VersionID: id, ETag: etag, UserMetadata: md, Tags: map[string]string{}}
+	}
+
+	src := []*ClientContent{
+		version("v3", "c", nil),
+		version("v2", "b", map[string]string{"X-Amz-Meta-Owner": "alice", "X-Minio-Internal": "x"}),
+		version("v1", "a", nil),
+		version("v0", "z", nil),
+	}
+	src[0].ReplicationStatus = "PENDING"
+	src[2].IsDeleteMarker = true
+	tgt := []*ClientContent{
+		version("v2", "\"b\"", map[string]string{"x-amz-meta-owner": "bob"}),
+		version("v1", "a", nil),
+		version("v0", "z", nil),
+		version("v9", "y", nil),
+	}
+
+	checked, msgs := verifyReplicaKey("docs/a.txt", rules, src, tgt)
+	if checked != 4 {
+		t.Errorf("expected 4 checked versions, got %d", checked)
+	}
+	got := map[string]string{}
+	for _, msg := range msgs {
+		got[msg.VersionID] = msg.Type + " " + msg.Detail
+	}
+	want := map[string]string{
+		"v3": "pending ",
+		"v2": `divergent metadata x-amz-meta-owner is "bob" instead of "alice"`,
+		"v1": "divergent delete marker is not a delete marker on the target",
+		"v9": "extra ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, msgs = verifyReplicaKey("docs/b.txt", rules, src[1:2], nil); len(msgs) != 1 || msgs[0].Type != replicaMissing {
+		t.Errorf("expected a missing version, got %+v", msgs)
+	}
+	if _, msgs = verifyReplicaKey("docs/b.txt", rules, src[1:2], tgt[1:2]); len(msgs) != 2 || msgs[0].Type != replicaStale {
+		t.Errorf("expected a stale and an extra version, got %+v", msgs)
+	}
+	if checked, msgs = verifyReplicaKey("logs/b.txt", rules, src[1:2], nil); checked != 0 || len(msgs) != 0 {
+		t.Errorf("a key outside of the rules was verified: %+v", msgs)
+	}
+}
+
+func TestGroupReplicaVersions(t *testing.T) {
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		for _, p := range []string{"/bucket/a", "/bucket/a", "/bucket/b/c", "/bucket/d"} {
+			content := &ClientContent{}
+			content.URL.Path = p
+			contentCh <- content
+		}
+	}()
+	groupCh, errCh := groupReplicaVersions(context.Background(), "bucket", contentCh)
+	var keys []string
+	var counts []int
+	for group := range groupCh {
+		keys = append(keys, group.key)
+		counts = append(counts, len(group.versions))
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b/c", "d"}) || !reflect.DeepEqual(counts, []int{2, 1, 1}) {
+		t.Errorf("unexpected groups %v %v", keys, counts)
+	}
+}
+
+func TestMergeReplicaVersionsListError(t *testing.T) {
+	rules := []replication.Rule{{Status: replication.Enabled}}
+	groups := func(keys ...string) (chan replicaVersions, chan *probe.Error) {
+		groupCh := make(chan replicaVersions, len(keys))
+		errCh := make(chan *probe.Error, 1)
+		for _, key := range keys {
+			groupCh <- replicaVersions{key: key, versions: []*ClientContent{{VersionID: "v1", ETag: key}}}
+		}
+		return groupCh, errCh
+	}
+	srcCh, srcErrCh := groups("a", "b", "c")
+	close(srcCh)
+	close(srcErrCh)
+	// The target listing fails after "a".
+	tgtCh, tgtErrCh := groups("a")
+	tgtErrCh <- probe.NewError(errors.New("connection reset"))
+	close(tgtCh)
+	close(tgtErrCh)
+
+	var reported []replicateVerifyMessage
+	checked, err := mergeReplicaVersions(srcCh, srcErrCh, tgtCh, tgtErrCh, rules, func(msg replicateVerifyMessage) {
+		reported = append(reported, msg)
+	})
+	if err == nil {
+		t.Fatal("expected the listing error")
+	}
+	if checked != 0 || len(reported) != 0 {
+		t.Errorf("expected no findings after a listing error, got %d checked and %+v", checked, reported)
+	}
+}
@@ -62,6 +62,7 @@ func (r *retryManager) retry(action func(rm *retryManager) *probe.Error) {
 			return
 		case <-time.After(r.retryInterval/2 + time.Duration(rand.Int63n(int64(r.retryInterval)))):
 			r.retries++
+			clientRetries.Inc()
 		}
 
 	}
@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"errors"
 	"os"
 	"os/signal"
 )
@@ -42,6 +43,9 @@ func trapSignals(sig ...os.Signal) {
 	// global context to check for any unusual cpu/mem/goroutines usage
 	stopProfiling()
 
+	// Export pending spans, the process exits right after.
+	shutdownOTelTracing(errors.New(s.String()))
+
 	// Cancel the global context
 	globalCancel()
 
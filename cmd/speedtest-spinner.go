@@ -80,6 +80,9 @@ type PerfTestResult struct {
 	DriveResult           []madmin.DriveSpeedTestResult `json:"drive,omitempty"`
 	Err                   string                        `json:"err,omitempty"`
 	Final                 bool                          `json:"final,omitempty"`
+
+	// autotune is set when the object test tuned its concurrency.
+	autotune bool
 }
 
 func initSpeedTestUI() *speedTestUI {
@@ -0,0 +1,417 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/mc/pkg/s3select"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/pierrec/lz4/v4"
+)
+
+// localSQL runs sql queries on the client, reading objects through
+// Client.Get. It serves backends which do not implement S3 Select,
+// such as the local filesystem. A single executor is shared by all
+// objects so that aggregates are computed across all of them.
+type localSQL struct {
+	expression string
+	// force skips S3 Select and always runs queries locally.
+	force bool
+	// parquet writes all result rows as a single Parquet file.
+	parquet bool
+
+	exec *s3select.Executor
+	out  *bufio.Writer
+	rows s3select.Writer
+}
+
+func newLocalSQL(expression string, force, parquet bool) *localSQL {
+	l := &localSQL{
+		expression: expression,
+		force:      force,
+		parquet:    parquet,
+		out:        bufio.NewWriter(os.Stdout),
+	}
+	if parquet {
+		l.rows = &parquetRowWriter{w: l.out}
+	}
+	return l
+}
+
+// isSelectNotImplemented returns true if the Select error means that
+// the backend does not support S3 Select at all.
+func isSelectNotImplemented(err *probe.Error) bool {
+	e := err.ToGoError()
+	if _, ok := e.(APINotImplemented); ok {
+		return true
+	}
+	errResp := minio.ToErrorResponse(e)
+	switch errResp.Code {
+	case "NotImplemented", "XNotImplemented", "MethodNotAllowed":
+		return true
+	}
+	return errResp.StatusCode == http.StatusNotImplemented
+}
+
+// WriteRow writes one result row with the output serialization of the
+// first queried object.
+func (l *localSQL) WriteRow(row *s3select.Object) error {
+	return l.rows.WriteRow(row)
+}
+
+// run queries the object behind clnt.
+func (l *localSQL) run(ctx context.Context, clnt Client, sse encrypt.ServerSide, selOpts SelectObjectOpts) *probe.Error {
+	if l.exec == nil {
+		q, e := s3select.Parse(l.expression)
+		if e != nil {
+			return probe.NewError(fmt.Errorf("invalid sql expression: %w", e))
+		}
+		l.exec = q.NewExecutor(l)
+	}
+	if l.exec.Done() {
+		return nil
+	}
+
+	object := clnt.GetURL().Path
+	in := selectObjectInputOpts(selOpts, object)
+	if l.rows == nil {
+		out := selectObjectOutputOpts(selOpts, in)
+		if out.JSON != nil {
+			l.rows = &sqlJSONWriter{w: l.out, recordDelimiter: out.JSON.RecordDelimiter}
+		} else {
+			l.rows = &sqlCSVWriter{w: l.out, opts: *out.CSV}
+		}
+	}
+	defer l.out.Flush()
+
+	if in.Parquet != nil {
+		pr, err := newParquetReader(ctx, clnt, sse)
+		if err != nil {
+			return err
+		}
+		return probe.NewError(readParquetRows(pr, -1, func(row *s3select.Object) error {
+			return l.exec.Process(row)
+		}))
+	}
+	if in.CSV == nil && in.JSON == nil {
+		return probe.NewError(errors.New("unable to detect the input format, use --csv-input or --json-input"))
+	}
+
+	reader, _, err := clnt.Get(ctx, GetOptions{SSE: sse})
+	if err != nil {
+		return err.Trace(object)
+	}
+	defer reader.Close()
+	r, e := sqlDecompressReader(reader, in.CompressionType)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if in.CSV != nil {
+		e = l.processCSV(r, in.CSV)
+	} else {
+		e = l.processJSON(r)
+	}
+	if e == io.EOF {
+		e = nil
+	}
+	return probe.NewError(e)
+}
+
+// copyJSON writes the JSON rows returned by S3 Select with the
+// output serialization of localSQL, which is only needed for formats
+// S3 Select cannot produce.
+func (l *localSQL) copyJSON(r io.Reader) *probe.Error {
+	defer l.out.Flush()
+	dec := json.NewDecoder(r)
+	for {
+		v, e := s3select.DecodeJSON(dec)
+		if e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return probe.NewError(e)
+		}
+		row, ok := v.(*s3select.Object)
+		if !ok {
+			row = &s3select.Object{Keys: []string{"_1"}, Values: []any{v}}
+		}
+		if e = l.rows.WriteRow(row); e != nil {
+			return probe.NewError(e)
+		}
+	}
+}
+
+// finish writes the result of an aggregate query and completes the
+// Parquet output.
+func (l *localSQL) finish() *probe.Error {
+	defer l.out.Flush()
+	if l.exec != nil {
+		if e := l.exec.Finish(); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	if pw, ok := l.rows.(*parquetRowWriter); ok {
+		return probe.NewError(pw.Close())
+	}
+	return nil
+}
+
+// isAggregate reports whether an aggregate query runs on the client,
+// its result then depends on all the queried objects.
+func (l *localSQL) isAggregate() bool {
+	if l.exec == nil && !l.force {
+		return false
+	}
+	q, e := s3select.Parse(l.expression)
+	return e == nil && q.IsAggregate()
+}
+
+// abort writes the rows selected so far without completing an
+// aggregate query, when the query failed on some objects.
+func (l *localSQL) abort() {
+	l.out.Flush()
+}
+
+func (l *localSQL) processCSV(r io.Reader, opts *minio.CSVInputOptions) error {
+	if rd := opts.RecordDelimiter; rd != "" && rd != "\n" && rd != "\r\n" {
+		r = newRecordDelimiterReader(r, rd)
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	var e error
+	if cr.Comma, e = sqlCSVRune(opts.FieldDelimiter, ',', "field delimiter"); e != nil {
+		return e
+	}
+	if cr.Comment, e = sqlCSVRune(opts.Comments, 0, "comment character"); e != nil {
+		return e
+	}
+	if q := opts.QuoteCharacter; q != "" && q != `"` {
+		return fmt.Errorf("quote character %q is not supported by the local sql engine", q)
+	}
+	if q := opts.QuoteEscapeCharacter; q != "" && q != `"` {
+		return fmt.Errorf("quote escape character %q is not supported by the local sql engine", q)
+	}
+
+	var names []string
+	switch strings.ToUpper(string(opts.FileHeaderInfo)) {
+	case string(minio.CSVFileHeaderInfoUse):
+		header, e := cr.Read()
+		if e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return e
+		}
+		names = append(names, header...)
+	case string(minio.CSVFileHeaderInfoIgnore):
+		if _, e := cr.Read(); e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return e
+		}
+	}
+
+	for {
+		record, e := cr.Read()
+		if e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return e
+		}
+		row := &s3select.Object{
+			Keys:   make([]string, len(record)),
+			Values: make([]any, len(record)),
+		}
+		for i, v := range record {
+			if i < len(names) {
+				row.Keys[i] = names[i]
+			} else {
+				row.Keys[i] = "_" + strconv.Itoa(i+1)
+			}
+			row.Values[i] = v
+		}
+		if e = l.exec.Process(row); e != nil {
+			return e
+		}
+	}
+}
+
+func (l *localSQL) processJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		v, e := s3select.DecodeJSON(dec)
+		if e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return e
+		}
+		if e = l.exec.Process(v); e != nil {
+			return e
+		}
+	}
+}
+
+// sqlCSVRune returns the single character of a CSV option.
+func sqlCSVRune(s string, def rune, what string) (rune, error) {
+	if s == "" {
+		return def, nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) {
+		return 0, fmt.Errorf("%s %q must be a single character", what, s)
+	}
+	return r, nil
+}
+
+// sqlDecompressReader wraps r according to the compression type.
+func sqlDecompressReader(r io.Reader, compression minio.SelectCompressionType) (io.Reader, error) {
+	switch minio.SelectCompressionType(strings.ToUpper(string(compression))) {
+	case "", minio.SelectCompressionNONE:
+		return r, nil
+	case minio.SelectCompressionGZIP:
+		return gzip.NewReader(r)
+	case minio.SelectCompressionBZIP:
+		return bzip2.NewReader(r), nil
+	case minio.SelectCompressionZSTD:
+		dec, e := zstd.NewReader(r)
+		if e != nil {
+			return nil, e
+		}
+		return dec.IOReadCloser(), nil
+	case minio.SelectCompressionS2, minio.SelectCompressionSNAPPY:
+		return s2.NewReader(r), nil
+	case minio.SelectCompressionLZ4:
+		return lz4.NewReader(r), nil
+	}
+	return nil, fmt.Errorf("unsupported compression type %s", compression)
+}
+
+// recordDelimiterReader rewrites a custom CSV record delimiter to the
+// newline expected by encoding/csv.
+type recordDelimiterReader struct {
+	sc  *bufio.Scanner
+	buf []byte
+}
+
+func newRecordDelimiterReader(r io.Reader, delimiter string) io.Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, []byte(delimiter)); i >= 0 {
+			return i + len(delimiter), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	return &recordDelimiterReader{sc: sc}
+}
+
+func (r *recordDelimiterReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if !r.sc.Scan() {
+			if e := r.sc.Err(); e != nil {
+				return 0, e
+			}
+			return 0, io.EOF
+		}
+		r.buf = append(append(r.buf[:0], r.sc.Bytes()...), '\n')
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// sqlCSVWriter writes result rows as CSV, honoring the --csv-output
+// serialization options.
+type sqlCSVWriter struct {
+	w    *bufio.Writer
+	opts minio.CSVOutputOptions
+}
+
+func (c *sqlCSVWriter) WriteRow(row *s3select.Object) error {
+	fieldDelimiter := c.opts.FieldDelimiter
+	if fieldDelimiter == "" {
+		fieldDelimiter = defaultFieldDelimiter
+	}
+	recordDelimiter := c.opts.RecordDelimiter
+	if recordDelimiter == "" {
+		recordDelimiter = defaultRecordDelimiter
+	}
+	quote := c.opts.QuoteCharacter
+	if quote == "" {
+		quote = `"`
+	}
+	quoteEscape := c.opts.QuoteEscapeCharacter
+	if quoteEscape == "" {
+		quoteEscape = quote
+	}
+	always := strings.EqualFold(string(c.opts.QuoteFields), string(minio.CSVQuoteFieldsAlways))
+	for i, v := range row.Values {
+		if i > 0 {
+			c.w.WriteString(fieldDelimiter)
+		}
+		s := s3select.FormatValue(v)
+		if always || strings.Contains(s, fieldDelimiter) || strings.Contains(s, quote) || strings.ContainsAny(s, "\r\n") {
+			s = quote + strings.ReplaceAll(s, quote, quoteEscape+quote) + quote
+		}
+		c.w.WriteString(s)
+	}
+	_, e := c.w.WriteString(recordDelimiter)
+	return e
+}
+
+// sqlJSONWriter writes result rows as JSON documents.
+type sqlJSONWriter struct {
+	w               *bufio.Writer
+	recordDelimiter string
+}
+
+func (j *sqlJSONWriter) WriteRow(row *s3select.Object) error {
+	b, e := row.MarshalJSON()
+	if e != nil {
+		return e
+	}
+	j.w.Write(b)
+	_, e = j.w.WriteString(j.recordDelimiter)
+	return e
+}
@@ -71,6 +71,14 @@ var sqlFlags = []cli.Flag{
 		Name:  "json-output",
 		Usage: "json output serialization option",
 	},
+	cli.BoolFlag{
+		Name:  "parquet-output",
+		Usage: "write the query result as a parquet file",
+	},
+	cli.BoolFlag{
+		Name:  "local",
+		Usage: "run the query on the client instead of using S3 Select",
+	},
 }
 
 // Display contents of a file.
@@ -94,6 +102,11 @@ FLAGS:
 SERIALIZATION OPTIONS:
   For query serialization options, refer to https://min.io/docs/minio/linux/reference/minio-mc/mc-sql.html#command-mc.sql
 
+LOCAL QUERIES:
+  Local files and servers without S3 Select support are queried on the client, which
+  downloads the objects and supports CSV, JSON and Parquet input. Use --local to always
+  query on the client. Aggregates of local queries are computed across all objects.
+
 EXAMPLES:
   1. Run a query on a set of objects recursively on AWS S3.
      {{.Prompt}} {{.HelpName}} --recursive --query "select * from S3Object" s3/personalbucket/my-large-csvs/
@@ -120,6 +133,16 @@ EXAMPLES:
      {{.Prompt}} {{.HelpName}} --compression GZIP --csv-input "rd=\n,fh=USE,fd=;" \
          --csv-output "rd=\n" --csv-output-header "device_id,uptime,lat,lon" \
          --query "select * from S3Object" myminio/iot-devices/data.csv
+
+  7. Count the matching rows across all local CSV files of a directory.
+     {{.Prompt}} {{.HelpName}} --recursive --query "select count(*) from S3Object s where s.status = 'failed'" ./logs/
+
+  8. Query Parquet objects on a server without S3 Select support.
+     {{.Prompt}} {{.HelpName}} --local --query "select s.city, s.temp from S3Object s limit 10" s3/weather/2024.parquet
+
+  9. Save the result of a query on CSV objects as a Parquet file.
+     {{.Prompt}} {{.HelpName}} --parquet-output --csv-input "fh=USE" \
+         --query "select s.city, cast(s.temp as float) as temp from S3Object s" s3/weather/2024.csv > 2024.parquet
 `,
 }
 
@@ -361,7 +384,13 @@ func getCSVOutputHeaders(ctx *cli.Context, url string, encKeyDB map[string][]pre
 // get the Select options for sql select API
 func getSQLOpts(ctx *cli.Context, csvHdrs []string) (s SelectObjectOpts) {
 	is := getInputSerializationOpts(ctx)
-	os := getOutputSerializationOpts(ctx, csvHdrs)
+	var os map[string]map[string]string
+	if ctx.Bool("parquet-output") {
+		// Rows are requested as JSON and converted to parquet on the client.
+		os = map[string]map[string]string{"json": {}}
+	} else {
+		os = getOutputSerializationOpts(ctx, csvHdrs)
+	}
 
 	return SelectObjectOpts{
 		InputSerOpts:    is,
@@ -380,7 +409,7 @@ func isCSVOrJSON(inOpts map[string]map[string]string) bool {
 	return false
 }
 
-func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr bool) *probe.Error {
+func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr bool, local *localSQL) *probe.Error {
 	ctx, cancelSelect := context.WithCancel(globalContext)
 	defer cancelSelect()
 
@@ -395,18 +424,31 @@ func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair
 	}
 
 	sseKey := getSSE(targetURL, encKeyDB[alias])
-	outputer, err := targetClnt.Select(ctx, expression, sseKey, selOpts)
-	if err != nil {
-		return err.Trace(targetURL, expression)
+	if !local.force {
+		outputer, err := targetClnt.Select(ctx, expression, sseKey, selOpts)
+		if err == nil {
+			defer outputer.Close()
+			if local.parquet {
+				return local.copyJSON(outputer).Trace(targetURL, expression)
+			}
+
+			// write csv header to stdout
+			if len(csvHdrs) > 0 && writeHdr {
+				fmt.Println(strings.Join(csvHdrs, ","))
+			}
+			_, e := io.Copy(os.Stdout, outputer)
+			return probe.NewError(e)
+		}
+		if !isSelectNotImplemented(err) {
+			return err.Trace(targetURL, expression)
+		}
 	}
-	defer outputer.Close()
 
 	// write csv header to stdout
 	if len(csvHdrs) > 0 && writeHdr {
 		fmt.Println(strings.Join(csvHdrs, ","))
 	}
-	_, e := io.Copy(os.Stdout, outputer)
-	return probe.NewError(e)
+	return local.run(ctx, targetClnt, sseKey, selOpts).Trace(targetURL, expression)
 }
 
 func validateOpts(selOpts SelectObjectOpts, url string) {
@@ -430,6 +472,16 @@ func checkSQLSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) == 0 {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code.
 	}
+	if ctx.Bool("parquet-output") {
+		for _, flag := range []string{"csv-output", "csv-output-header", "json-output"} {
+			if ctx.IsSet(flag) {
+				fatalIf(errInvalidArgument(), "--parquet-output cannot be used with --"+flag)
+			}
+		}
+		if isTerminal() {
+			fatalIf(errInvalidArgument(), "--parquet-output writes binary data, redirect the output to a file")
+		}
+	}
 }
 
 // mainSQL is the main entry point for sql command.
@@ -451,15 +503,23 @@ func mainSQL(cliCtx *cli.Context) error {
 	// extract URLs.
 	URLs := cliCtx.Args()
 	writeHdr := true
+	// failed is set when the query could not run on all objects, which
+	// makes the result of a local aggregate query incomplete.
+	failed := false
+	local := newLocalSQL(cliCtx.String("query"), cliCtx.Bool("local"), cliCtx.Bool("parquet-output"))
 	for _, url := range URLs {
 		if _, targetContent, err := url2Stat(ctx, url2StatOptions{urlStr: url, versionID: "", fileAttr: false, encKeyDB: encKeyDB, timeRef: time.Time{}, isZip: false, ignoreBucketExistsCheck: false}); err != nil {
 			errorIf(err.Trace(url), "Unable to run sql for %s.", url)
+			failed = true
 			continue
 		} else if !targetContent.Type.IsDir() {
 			if writeHdr {
 				query, csvHdrs, selOpts = getAndValidateArgs(cliCtx, encKeyDB, url)
 			}
-			errorIf(sqlSelect(url, query, encKeyDB, selOpts, csvHdrs, writeHdr).Trace(url), "Unable to run sql")
+			if err := sqlSelect(url, query, encKeyDB, selOpts, csvHdrs, writeHdr, local); err != nil {
+				errorIf(err.Trace(url), "Unable to run sql")
+				failed = true
+			}
 			writeHdr = false
 			continue
 		}
@@ -467,12 +527,14 @@ func mainSQL(cliCtx *cli.Context) error {
 		clnt, err := newClientFromAlias(targetAlias, targetURL)
 		if err != nil {
 			errorIf(err.Trace(url), "Unable to initialize target `%s`.", url)
+			failed = true
 			continue
 		}
 
 		for content := range clnt.List(ctx, ListOptions{Recursive: cliCtx.Bool("recursive"), WithMetadata: true, ShowDir: DirNone}) {
 			if content.Err != nil {
 				errorIf(content.Err.Trace(url), "Unable to list on target `%s`.", url)
+				failed = true
 				continue
 			}
 			if writeHdr {
@@ -484,13 +546,24 @@ func mainSQL(cliCtx *cli.Context) error {
 			}
 			for _, cTypeSuffix := range supportedContentTypes {
 				if strings.Contains(contentType, cTypeSuffix) {
-					errorIf(sqlSelect(targetAlias+content.URL.Path, query,
-						encKeyDB, selOpts, csvHdrs, writeHdr).Trace(content.URL.String()), "Unable to run sql")
+					if err := sqlSelect(targetAlias+content.URL.Path, query,
+						encKeyDB, selOpts, csvHdrs, writeHdr, local); err != nil {
+						errorIf(err.Trace(content.URL.String()), "Unable to run sql")
+						failed = true
+					}
 				}
 				writeHdr = false
 			}
 		}
 	}
+	if failed && local.isAggregate() {
+		// An aggregate computed on part of the objects would be
+		// wrong, only the rows selected so far are written.
+		local.abort()
+		errorIf(errDummy().Trace(), "The sql query did not run on all objects, its result is incomplete.")
+		return exitStatus(globalErrorExitStatus)
+	}
+	errorIf(local.finish().Trace(), "Unable to run sql")
 
 	// Done.
 	return nil
@@ -147,3 +147,21 @@ func TestParseSerializationOpts(t *testing.T) {
 		}
 	}
 }
+
+func TestLocalSQLIsAggregate(t *testing.T) {
+	testCases := []struct {
+		query     string
+		force     bool
+		aggregate bool
+	}{
+		{"select count(*) from S3Object", true, true},
+		{"select * from S3Object", true, false},
+		// Server side queries are aggregated per object.
+		{"select count(*) from S3Object", false, false},
+	}
+	for i, tc := range testCases {
+		if got := newLocalSQL(tc.query, tc.force, false).isAggregate(); got != tc.aggregate {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.aggregate, got)
+		}
+	}
+}
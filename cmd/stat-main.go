@@ -55,6 +55,10 @@ var (
 			Name:  "no-list",
 			Usage: "disable all LIST operations for stat",
 		},
+		cli.BoolFlag{
+			Name:  "parquet",
+			Usage: "show row groups and column statistics of parquet objects",
+		},
 	}
 )
 
@@ -98,6 +102,9 @@ EXAMPLES:
 
   7. Stat all objects versions recursively created before 1st January 2020.
      {{.Prompt}} {{.HelpName}} --versions --rewind 2020.01.01T00:00 s3/personal-docs/
+
+  8. Show the column statistics of a Parquet object.
+     {{.Prompt}} {{.HelpName}} --parquet s3/weather/2024.parquet
 `,
 }
 
@@ -135,6 +142,10 @@ func parseAndCheckStatSyntax(ctx context.Context, cliCtx *cli.Context) ([]string
 		fatalIf(errInvalidArgument().Trace(args...), "You cannot specify --no-list with either --versions or --recursive.")
 	}
 
+	if (recursive || withVersions) && cliCtx.Bool("parquet") {
+		fatalIf(errInvalidArgument().Trace(args...), "You cannot specify --parquet with either --versions or --recursive.")
+	}
+
 	var targetUrls []string
 	for _, url := range URLs {
 		_, path := url2Alias(url)
@@ -187,6 +198,13 @@ func mainStat(cliCtx *cli.Context) error {
 		args = []string{"."}
 	}
 
+	if cliCtx.Bool("parquet") {
+		for _, targetURL := range args {
+			fatalIf(statParquetURL(ctx, targetURL, versionID, rewind, encKeyDB), "Unable to stat `"+targetURL+"`.")
+		}
+		return nil
+	}
+
 	headOnly := cliCtx.Bool("no-list")
 	for _, targetURL := range args {
 		fatalIf(statURL(ctx, targetURL, versionID, rewind, withVersions, false, isRecursive, headOnly, encKeyDB), "Unable to stat `"+targetURL+"`.")
@@ -31,6 +31,7 @@ import (
 	json "github.com/minio/colorjson"
 	"github.com/minio/madmin-go/v3"
 	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/mc/pkg/s3select"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/lifecycle"
 	"github.com/minio/minio-go/v7/pkg/notification"
@@ -171,6 +172,81 @@ func (stat statMessage) JSON() string {
 	return string(jsonMessageBytes)
 }
 
+// parquetStatMessage holds the row groups and column statistics of a
+// Parquet object.
+type parquetStatMessage struct {
+	Status    string               `json:"status"`
+	Key       string               `json:"name"`
+	NumRows   int64                `json:"numRows"`
+	CreatedBy string               `json:"createdBy,omitempty"`
+	RowGroups []parquetRowGroup    `json:"rowGroups"`
+	Columns   []parquetColumnStats `json:"columns"`
+}
+
+func (p parquetStatMessage) String() string {
+	var b strings.Builder
+	b.WriteString(console.Colorize("Name", fmt.Sprintf("%-10s: %s", "Name", p.Key)) + "\n")
+	fmt.Fprintf(&b, "%-10s: %d\n", "Rows", p.NumRows)
+	fmt.Fprintf(&b, "%-10s: %d\n", "RowGroups", len(p.RowGroups))
+	if p.CreatedBy != "" {
+		fmt.Fprintf(&b, "%-10s: %s\n", "CreatedBy", p.CreatedBy)
+	}
+	fmt.Fprintf(&b, "%-10s:\n", "Columns")
+	for _, col := range p.Columns {
+		typ := col.Type
+		if col.LogicalType != "" {
+			typ += " (" + col.LogicalType + ")"
+		}
+		b.WriteString("  " + console.Colorize("Key", col.Path) + ": " + typ + "\n")
+		fmt.Fprintf(&b, "    %-10s: %s\n", "Codec", col.Codec)
+		fmt.Fprintf(&b, "    %-10s: %d\n", "Values", col.Values)
+		if col.Nulls != nil {
+			fmt.Fprintf(&b, "    %-10s: %d\n", "Nulls", *col.Nulls)
+		}
+		if col.Min != nil {
+			fmt.Fprintf(&b, "    %-10s: %s\n", "Min", s3select.FormatValue(col.Min))
+		}
+		if col.Max != nil {
+			fmt.Fprintf(&b, "    %-10s: %s\n", "Max", s3select.FormatValue(col.Max))
+		}
+		fmt.Fprintf(&b, "    %-10s: %s (%s compressed)\n", "Size",
+			humanize.IBytes(uint64(col.Size)), humanize.IBytes(uint64(col.CompressedSize)))
+	}
+	return b.String()
+}
+
+func (p parquetStatMessage) JSON() string {
+	p.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// statParquetURL prints the statistics stored in the footer of a
+// Parquet object, without downloading its data.
+func statParquetURL(ctx context.Context, targetURL, versionID string, timeRef time.Time, encKeyDB map[string][]prefixSSEPair) *probe.Error {
+	clnt, content, err := url2Stat(ctx, url2StatOptions{urlStr: targetURL, versionID: versionID, encKeyDB: encKeyDB, timeRef: timeRef})
+	if err != nil {
+		return err.Trace(targetURL)
+	}
+	if content.Type.IsDir() {
+		return errInvalidArgument().Trace(targetURL)
+	}
+	alias, _ := url2Alias(targetURL)
+	footer, err := readParquetFooter(ctx, clnt, getSSE(targetURL, encKeyDB[alias]), content)
+	if err != nil {
+		return err.Trace(targetURL)
+	}
+	printMsg(parquetStatMessage{
+		Key:       targetURL,
+		NumRows:   footer.GetNumRows(),
+		CreatedBy: footer.GetCreatedBy(),
+		RowGroups: parquetRowGroups(footer),
+		Columns:   parquetStatistics(footer),
+	})
+	return nil
+}
+
 // parseStat parses client Content container into statMessage struct.
 func parseStat(c *ClientContent) statMessage {
 	content := statMessage{}
@@ -0,0 +1,481 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	gojson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/klauspost/compress/gzip"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+// Severities of the findings, the most severe first.
+const (
+	diagCritical = "critical"
+	diagWarning  = "warning"
+	diagInfo     = "info"
+)
+
+var diagSeverityOrder = map[string]int{diagCritical: 0, diagWarning: 1, diagInfo: 2}
+
+const (
+	diagClockSkewWarning  = 5 * time.Second
+	diagClockSkewCritical = 15 * time.Minute
+	diagLowInodesRatio    = 0.1
+	diagMinOpenFiles      = 65536
+)
+
+// supportDiagFinding is an issue found in a diagnostics report.
+type supportDiagFinding struct {
+	Status   string `json:"status"`
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Node     string `json:"node,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f supportDiagFinding) JSON() string {
+	buf, e := json.MarshalIndent(f, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (f supportDiagFinding) String() string {
+	msg := console.Colorize("DiagSeverity"+f.Severity, fmt.Sprintf("%-9s", strings.ToUpper(f.Severity)))
+	msg += console.Colorize("DiagCheck", fmt.Sprintf("%-14s", f.Check))
+	if f.Node != "" {
+		msg += f.Node + ": "
+	}
+	return msg + f.Message
+}
+
+type supportDiagSummary struct {
+	Status     string `json:"status"`
+	Deployment string `json:"deploymentID,omitempty"`
+	Time       string `json:"timestamp"`
+	Servers    int    `json:"servers"`
+	Critical   int    `json:"critical"`
+	Warnings   int    `json:"warnings"`
+	Info       int    `json:"info"`
+}
+
+func (s supportDiagSummary) JSON() string {
+	buf, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (s supportDiagSummary) String() string {
+	msg := fmt.Sprintf("Report of %d servers collected %s: %d critical, %d warnings, %d info.", s.Servers, s.Time, s.Critical, s.Warnings, s.Info)
+	if s.Critical > 0 {
+		return console.Colorize("DiagSeverity"+diagCritical, msg)
+	}
+	return console.Colorize("DiagOK", msg)
+}
+
+// readDiagReport reads a report saved by support diag, either the gzipped
+// file, which starts with a version header, or the JSON output.
+func readDiagReport(r io.Reader) (madmin.HealthInfo, error) {
+	var info madmin.HealthInfo
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, e := gzip.NewReader(br)
+		if e != nil {
+			return info, e
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+	decoder := gojson.NewDecoder(br)
+	var doc map[string]gojson.RawMessage
+	if e := decoder.Decode(&doc); e != nil {
+		return info, e
+	}
+	if len(doc) == 1 && doc["version"] != nil {
+		// Skip the header of the saved file.
+		doc = nil
+		if e := decoder.Decode(&doc); e != nil {
+			return info, e
+		}
+	}
+	buf, e := gojson.Marshal(doc)
+	if e != nil {
+		return info, e
+	}
+	if e = gojson.Unmarshal(buf, &info); e != nil {
+		return info, e
+	}
+	if info.Version != madmin.HealthInfoVersion {
+		return info, fmt.Errorf("unsupported report version %q, only version %s reports can be analyzed", info.Version, madmin.HealthInfoVersion)
+	}
+	return info, nil
+}
+
+// diagCheck returns the findings of a check of a report.
+type diagCheck func(info madmin.HealthInfo) []supportDiagFinding
+
+var supportDiagChecks = map[string]diagCheck{
+	"servers":      diagCheckServers,
+	"erasure-sets": diagCheckErasureSets,
+	"clock":        diagCheckClock,
+	"versions":     diagCheckVersions,
+	"drives":       diagCheckDrives,
+	"network":      diagCheckNetwork,
+	"inodes":       diagCheckInodes,
+	"kernel":       diagCheckKernel,
+	"errors":       diagCheckErrors,
+}
+
+// analyzeDiagReport runs all the checks and returns their findings, the
+// most severe first.
+func analyzeDiagReport(info madmin.HealthInfo) []supportDiagFinding {
+	var findings []supportDiagFinding
+	for name, check := range supportDiagChecks {
+		for _, f := range check(info) {
+			f.Status = "success"
+			f.Check = name
+			findings = append(findings, f)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return diagSeverityOrder[a.Severity] < diagSeverityOrder[b.Severity]
+		}
+		if a.Check != b.Check {
+			return a.Check < b.Check
+		}
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		return a.Message < b.Message
+	})
+	return findings
+}
+
+// diagMajority returns the most common value and the nodes having
+// another value, when the nodes do not all have the same value.
+func diagMajority(values map[string]string) (majority string, others []string) {
+	counts := map[string]int{}
+	for _, v := range values {
+		counts[v]++
+	}
+	if len(counts) < 2 {
+		return "", nil
+	}
+	for v, n := range counts {
+		if n > counts[majority] || (n == counts[majority] && v < majority) {
+			majority = v
+		}
+	}
+	for node, v := range values {
+		if v != majority {
+			others = append(others, node)
+		}
+	}
+	sort.Strings(others)
+	return majority, others
+}
+
+func diagCheckServers(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	for _, srv := range info.Minio.Info.Servers {
+		if srv.State != "" && srv.State != string(madmin.ItemOnline) {
+			findings = append(findings, supportDiagFinding{Severity: diagCritical, Node: srv.Endpoint, Message: "server is " + srv.State})
+		}
+	}
+	return findings
+}
+
+func diagCheckErasureSets(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	var backend madmin.ErasureBackend
+	if buf, e := gojson.Marshal(info.Minio.Info.Backend); e == nil {
+		gojson.Unmarshal(buf, &backend)
+	}
+	type erasureSet struct{ total, offline, healing int }
+	sets := map[[2]int]*erasureSet{}
+	for _, srv := range info.Minio.Info.Servers {
+		for _, drive := range srv.Drives {
+			if drive.PoolIndex < 0 || drive.SetIndex < 0 {
+				continue
+			}
+			id := [2]int{drive.PoolIndex, drive.SetIndex}
+			if sets[id] == nil {
+				sets[id] = &erasureSet{}
+			}
+			sets[id].total++
+			if drive.State != madmin.DriveStateOk {
+				sets[id].offline++
+			}
+			if drive.Healing {
+				sets[id].healing++
+			}
+		}
+	}
+	for id, set := range sets {
+		name := fmt.Sprintf("pool %d set %d", id[0]+1, id[1]+1)
+		parity := backend.StandardSCParity
+		if parity <= 0 {
+			parity = set.total / 2
+		}
+		readQuorum := set.total - parity
+		writeQuorum := readQuorum
+		if readQuorum == parity {
+			writeQuorum++
+		}
+		online := set.total - set.offline
+		switch {
+		case online < readQuorum:
+			findings = append(findings, supportDiagFinding{Severity: diagCritical, Message: fmt.Sprintf("%s has %d/%d drives online, below its read quorum of %d", name, online, set.total, readQuorum)})
+		case online < writeQuorum:
+			findings = append(findings, supportDiagFinding{Severity: diagCritical, Message: fmt.Sprintf("%s has %d/%d drives online, below its write quorum of %d", name, online, set.total, writeQuorum)})
+		case set.offline > 0:
+			findings = append(findings, supportDiagFinding{Severity: diagWarning, Message: fmt.Sprintf("%s is degraded, %d/%d drives offline", name, set.offline, set.total)})
+		}
+		if set.healing > 0 {
+			findings = append(findings, supportDiagFinding{Severity: diagInfo, Message: fmt.Sprintf("%s is healing %d drives", name, set.healing)})
+		}
+	}
+	return findings
+}
+
+func diagCheckClock(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	var minNode, maxNode string
+	var minTime, maxTime time.Time
+	for _, sc := range info.Sys.SysConfig {
+		var ti madmin.TimeInfo
+		buf, e := gojson.Marshal(sc.Config["time-info"])
+		if e != nil || gojson.Unmarshal(buf, &ti) != nil || ti.CurrentTime.IsZero() {
+			continue
+		}
+		if minTime.IsZero() || ti.CurrentTime.Before(minTime) {
+			minTime, minNode = ti.CurrentTime, sc.Addr
+		}
+		if maxTime.IsZero() || ti.CurrentTime.After(maxTime) {
+			maxTime, maxNode = ti.CurrentTime, sc.Addr
+		}
+	}
+	skew := maxTime.Sub(minTime)
+	severity := ""
+	switch {
+	case skew >= diagClockSkewCritical:
+		severity = diagCritical
+	case skew >= diagClockSkewWarning:
+		severity = diagWarning
+	default:
+		return nil
+	}
+	return []supportDiagFinding{{Severity: severity, Message: fmt.Sprintf("clock of %s is %s ahead of %s, synchronize the servers with NTP", maxNode, skew.Round(time.Second), minNode)}}
+}
+
+func diagCheckVersions(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	versions := map[string]string{}
+	for _, srv := range info.Minio.Info.Servers {
+		if srv.Version != "" {
+			versions[srv.Endpoint] = srv.Version
+		}
+	}
+	majority, others := diagMajority(versions)
+	for _, node := range others {
+		findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: node, Message: fmt.Sprintf("runs MinIO %s while most servers run %s", versions[node], majority)})
+	}
+	return findings
+}
+
+func diagCheckDrives(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	// Firmware revisions by model.
+	revisions := map[string]map[string]string{}
+	models := map[string]bool{}
+	for _, parts := range info.Sys.Partitions {
+		for _, p := range parts.Partitions {
+			if p.Model == "" {
+				continue
+			}
+			models[p.Model] = true
+			if revisions[p.Model] == nil {
+				revisions[p.Model] = map[string]string{}
+			}
+			revisions[p.Model][parts.Addr+" "+p.Device] = p.Revision
+		}
+	}
+	for model, drives := range revisions {
+		majority, others := diagMajority(drives)
+		for _, drive := range others {
+			node, device, _ := strings.Cut(drive, " ")
+			findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: node, Message: fmt.Sprintf("drive %s (%s) has firmware %s while most drives of this model have %s", device, model, drives[drive], majority)})
+		}
+	}
+	if len(models) > 1 {
+		names := make([]string, 0, len(models))
+		for model := range models {
+			names = append(names, model)
+		}
+		sort.Strings(names)
+		findings = append(findings, supportDiagFinding{Severity: diagInfo, Message: "drives of different models are used: " + strings.Join(names, ", ")})
+	}
+	for _, srv := range info.Minio.Info.Servers {
+		for _, drive := range srv.Drives {
+			if drive.RootDisk {
+				findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: srv.Endpoint, Message: "drive " + drive.DrivePath + " is on the root drive"})
+			}
+		}
+	}
+	return findings
+}
+
+// diagCheckNetwork reports network interfaces whose driver or firmware
+// differs from most others. The report has no MTU of the interfaces,
+// so MTU mismatches can not be detected.
+func diagCheckNetwork(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	drivers := map[string]string{}
+	for _, ni := range info.Sys.NetInfo {
+		if ni.Driver != "" {
+			drivers[ni.Addr+" "+ni.Interface] = ni.Driver + " " + ni.FirmwareVersion
+		}
+	}
+	majority, others := diagMajority(drivers)
+	for _, nic := range others {
+		node, iface, _ := strings.Cut(nic, " ")
+		findings = append(findings, supportDiagFinding{Severity: diagInfo, Node: node, Message: fmt.Sprintf("interface %s uses driver and firmware %s while most interfaces use %s", iface, drivers[nic], majority)})
+	}
+	return findings
+}
+
+func diagCheckInodes(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	for _, srv := range info.Minio.Info.Servers {
+		for _, drive := range srv.Drives {
+			total := drive.FreeInodes + drive.UsedInodes
+			if total == 0 || float64(drive.FreeInodes) >= diagLowInodesRatio*float64(total) {
+				continue
+			}
+			severity := diagWarning
+			if drive.FreeInodes == 0 {
+				severity = diagCritical
+			}
+			findings = append(findings, supportDiagFinding{Severity: severity, Node: srv.Endpoint, Message: fmt.Sprintf("drive %s has %s free inodes (%.1f%%)", drive.DrivePath, humanize.Comma(int64(drive.FreeInodes)), 100*float64(drive.FreeInodes)/float64(total))})
+		}
+	}
+	return findings
+}
+
+func diagCheckKernel(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	kernels := map[string]string{}
+	for _, osInfo := range info.Sys.OSInfo {
+		if osInfo.Info.KernelVersion != "" {
+			kernels[osInfo.Addr] = osInfo.Info.KernelVersion
+		}
+	}
+	majority, others := diagMajority(kernels)
+	for _, node := range others {
+		findings = append(findings, supportDiagFinding{Severity: diagInfo, Node: node, Message: fmt.Sprintf("runs kernel %s while most servers run %s", kernels[node], majority)})
+	}
+
+	for _, sc := range info.Sys.SysConfig {
+		if thp, ok := sc.Config["thp-config"].(map[string]interface{}); ok {
+			if enabled, _ := thp["enabled"].(string); strings.Contains(enabled, "[always]") {
+				findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: sc.Addr, Message: "transparent huge pages are always enabled, set them to madvise"})
+			}
+		}
+		var xfs madmin.XFSErrorConfigs
+		if buf, e := gojson.Marshal(sc.Config["xfs-error-config"]); e == nil && gojson.Unmarshal(buf, &xfs) == nil {
+			for _, cfg := range xfs.Configs {
+				if cfg.MaxRetries != 0 {
+					findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: sc.Addr, Message: fmt.Sprintf("%s is %d, set it to 0 so that failing drives are not retried forever", cfg.ConfigFile, cfg.MaxRetries)})
+				}
+			}
+		}
+		if limit, ok := sc.Config["rlimit-max"].(float64); ok && limit > 0 && limit < diagMinOpenFiles {
+			findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: sc.Addr, Message: fmt.Sprintf("open files limit is %d, raise it to at least %d", uint64(limit), diagMinOpenFiles)})
+		}
+	}
+	return findings
+}
+
+func diagCheckErrors(info madmin.HealthInfo) (findings []supportDiagFinding) {
+	for _, se := range info.Sys.SysErrs {
+		for _, e := range se.Errors {
+			findings = append(findings, supportDiagFinding{Severity: diagWarning, Node: se.Addr, Message: e})
+		}
+	}
+	if info.Error != "" {
+		findings = append(findings, supportDiagFinding{Severity: diagWarning, Message: "report is incomplete: " + info.Error})
+	}
+	if info.Minio.Error != "" {
+		findings = append(findings, supportDiagFinding{Severity: diagWarning, Message: "MinIO information is incomplete: " + info.Minio.Error})
+	}
+	return findings
+}
+
+// mainSupportDiagAnalyze is the handle for "mc support diag analyze FILE".
+func mainSupportDiagAnalyze(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, 1) // last argument is exit code
+	}
+	console.SetColor("DiagSeverity"+diagCritical, color.New(color.FgRed, color.Bold))
+	console.SetColor("DiagSeverity"+diagWarning, color.New(color.FgYellow, color.Bold))
+	console.SetColor("DiagSeverity"+diagInfo, color.New(color.FgCyan))
+	console.SetColor("DiagCheck", color.New(color.FgWhite))
+	console.SetColor("DiagOK", color.New(color.FgGreen, color.Bold))
+
+	filename := ctx.Args().Get(1)
+	f, e := os.Open(filename)
+	fatalIf(probe.NewError(e), "Unable to open the diagnostics report.")
+	defer f.Close()
+	info, e := readDiagReport(f)
+	if errors.Is(e, io.EOF) {
+		e = errors.New("the report is empty")
+	}
+	fatalIf(probe.NewError(e).Trace(filename), "Unable to read the diagnostics report.")
+
+	summary := supportDiagSummary{
+		Status:     "success",
+		Deployment: info.Minio.Info.DeploymentID,
+		Time:       info.TimeStamp.Format(time.RFC3339),
+		Servers:    len(info.Minio.Info.Servers),
+	}
+	for _, finding := range analyzeDiagReport(info) {
+		switch finding.Severity {
+		case diagCritical:
+			summary.Critical++
+		case diagWarning:
+			summary.Warnings++
+		default:
+			summary.Info++
+		}
+		printMsg(finding)
+	}
+	printMsg(summary)
+	if summary.Critical > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+func TestAnalyzeDiagReport(t *testing.T) {
+	now := time.Now().UTC()
+	drive := func(set int, state string) madmin.Disk {
+		return madmin.Disk{DrivePath: "/data", State: state, PoolIndex: 0, SetIndex: set, FreeInodes: 1000, UsedInodes: 1000}
+	}
+	info := madmin.HealthInfo{Version: madmin.HealthInfoVersion, TimeStamp: now}
+	info.Minio.Info.Backend = madmin.ErasureBackend{Type: madmin.ErasureType, StandardSCParity: 2, DrivesPerSet: []int{4}}
+	info.Minio.Info.Servers = []madmin.ServerInfo{
+		{Endpoint: "node1:9000", State: "online", Version: "2024-01-01", Drives: []madmin.Disk{drive(0, madmin.DriveStateOk), drive(0, madmin.DriveStateOffline), drive(1, madmin.DriveStateOk), drive(1, madmin.DriveStateOk)}},
+		{Endpoint: "node2:9000", State: "online", Version: "2024-01-01", Drives: []madmin.Disk{drive(0, madmin.DriveStateOffline), drive(0, madmin.DriveStateOffline), drive(1, madmin.DriveStateOk), drive(1, madmin.DriveStateOk)}},
+		{Endpoint: "node3:9000", State: "offline", Version: "2023-06-01"},
+	}
+	info.Minio.Info.Servers[0].Drives[2].FreeInodes = 10
+	info.Sys.SysConfig = []madmin.SysConfig{
+		{NodeCommon: madmin.NodeCommon{Addr: "node1"}, Config: map[string]interface{}{"time-info": madmin.TimeInfo{CurrentTime: now}}},
+		{NodeCommon: madmin.NodeCommon{Addr: "node2"}, Config: map[string]interface{}{
+			"time-info":  madmin.TimeInfo{CurrentTime: now.Add(time.Minute)},
+			"thp-config": map[string]string{"enabled": "[always] madvise never"},
+			"rlimit-max": 1024,
+		}},
+	}
+	info.Sys.Partitions = []madmin.Partitions{
+		{NodeCommon: madmin.NodeCommon{Addr: "node1"}, Partitions: []madmin.Partition{{Device: "sda", Model: "SSD1", Revision: "A1"}, {Device: "sdb", Model: "SSD1", Revision: "A1"}}},
+		{NodeCommon: madmin.NodeCommon{Addr: "node2"}, Partitions: []madmin.Partition{{Device: "sda", Model: "SSD1", Revision: "B2"}}},
+	}
+
+	data, e := TarGZHealthInfo(info, madmin.HealthInfoVersion)
+	if e != nil {
+		t.Fatal(e)
+	}
+	report, e := readDiagReport(bytes.NewReader(data))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	got := map[string]bool{}
+	for _, f := range analyzeDiagReport(report) {
+		got[f.Severity+" "+f.Check+" "+f.Node+" "+f.Message] = true
+	}
+	for _, want := range []string{
+		"critical servers node3:9000 server is offline",
+		"critical erasure-sets  pool 1 set 1 has 1/4 drives online, below its read quorum of 2",
+		"warning clock  clock of node2 is 1m0s ahead of node1, synchronize the servers with NTP",
+		"warning versions node3:9000 runs MinIO 2023-06-01 while most servers run 2024-01-01",
+		"warning drives node2 drive sda (SSD1) has firmware B2 while most drives of this model have A1",
+		"warning inodes node1:9000 drive /data has 10 free inodes (1.0%)",
+		"warning kernel node2 transparent huge pages are always enabled, set them to madvise",
+		"warning kernel node2 open files limit is 1024, raise it to at least 65536",
+	} {
+		if !got[want] {
+			t.Errorf("missing finding %q in %v", want, got)
+		}
+	}
+	if findings := analyzeDiagReport(report); findings[0].Severity != diagCritical || findings[len(findings)-1].Severity == diagCritical {
+		t.Errorf("findings are not sorted by severity: %+v", findings)
+	}
+
+	report.Version = madmin.HealthInfoVersion2
+	data, _ = TarGZHealthInfo(report, report.Version)
+	if _, e = readDiagReport(bytes.NewReader(data)); e == nil {
+		t.Error("a version 2 report was accepted")
+	}
+}
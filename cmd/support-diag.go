@@ -79,10 +79,25 @@ var supportDiagCmd = cli.Command{
 
 USAGE:
   {{.HelpName}} TARGET
+  {{.HelpName}} analyze FILE
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+DESCRIPTION:
+  'analyze FILE' checks a report saved with --airgap locally, without uploading it
+  to SUBNET. It reports, most severe first:
+    - offline servers and erasure sets which lost their read or write quorum
+    - clock skew between the servers
+    - servers running different MinIO or kernel versions
+    - drives of the same model with different firmware and mixed drive models
+    - network interfaces with different drivers or firmware. MTU mismatches are
+      not checked, the report does not include the MTU of the interfaces
+    - drives with few free inodes and MinIO drives on the root drive
+    - transparent huge pages, XFS error retries and open file limits
+    - errors reported by the servers
+  It exits with an error when critical issues are found.
+
 EXAMPLES:
   1. Upload MinIO diagnostics report for cluster with alias 'myminio' to SUBNET
      {{.Prompt}} {{.HelpName}} myminio
@@ -92,6 +107,9 @@ EXAMPLES:
 
   3. Upload MinIO diagnostics report for cluster with alias 'myminio' to SUBNET, with strict anonymization
      {{.Prompt}} {{.HelpName}} myminio --anonymize=strict
+
+  4. Check a saved MinIO diagnostics report for known issues, without uploading it
+     {{.Prompt}} {{.HelpName}} analyze myminio-health_20240102150405.json.gz
 `,
 }
 
@@ -191,6 +209,9 @@ func warnText(s string) string {
 }
 
 func mainSupportDiag(ctx *cli.Context) error {
+	if ctx.Args().First() == "analyze" && len(ctx.Args()) > 1 {
+		return mainSupportDiagAnalyze(ctx)
+	}
 	checkSupportDiagSyntax(ctx)
 
 	// Get the alias parameter from cli
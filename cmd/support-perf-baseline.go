@@ -0,0 +1,380 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	gojson "encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var supportPerfBaselineFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "save",
+		Usage: "save the results as a baseline in this file",
+	},
+	cli.StringFlag{
+		Name:  "compare",
+		Usage: "compare the results with the baseline saved in this file",
+	},
+	cli.Float64Flag{
+		Name:  "threshold",
+		Usage: "percentage by which a result can be slower than the baseline before it is a regression",
+		Value: 10,
+	},
+	cli.StringFlag{
+		Name:  "sweep-size",
+		Usage: "run the object test for each of these comma separated object sizes, requires --save or --compare",
+	},
+	cli.StringFlag{
+		Name:  "sweep-concurrent",
+		Usage: "run the object test for each of these comma separated numbers of concurrent requests per server, requires --save or --compare",
+	},
+}
+
+// isSupportPerfBaselineRun reports whether the results are saved or
+// compared locally instead of being uploaded to SUBNET. Sweeps are only
+// allowed with --save or --compare.
+func isSupportPerfBaselineRun(ctx *cli.Context) bool {
+	if ctx.IsSet("save") || ctx.IsSet("compare") {
+		return true
+	}
+	// The report uploaded to SUBNET holds a single object result.
+	if ctx.IsSet("sweep-size") || ctx.IsSet("sweep-concurrent") {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--sweep-size and --sweep-concurrent require --save or --compare.")
+	}
+	return false
+}
+
+// speedTestObjectSweep returns the object speedtests to run, one for each
+// combination of --sweep-size and --sweep-concurrent.
+func speedTestObjectSweep(ctx *cli.Context) []madmin.SpeedtestOpts {
+	base := speedTestObjectOpts(ctx)
+	sizes := []int{base.Size}
+	if ctx.IsSet("sweep-size") {
+		sizes = nil
+		for _, s := range strings.Split(ctx.String("sweep-size"), ",") {
+			size, e := humanize.ParseBytes(strings.TrimSpace(s))
+			fatalIf(probe.NewError(e).Trace(s), "Unable to parse --sweep-size.")
+			if size == 0 {
+				fatalIf(errInvalidArgument().Trace(s), "size is expected to be more than 0 bytes")
+			}
+			sizes = append(sizes, int(size))
+		}
+	}
+	concurrents := []int{base.Concurrency}
+	if ctx.IsSet("sweep-concurrent") {
+		concurrents = nil
+		for _, s := range strings.Split(ctx.String("sweep-concurrent"), ",") {
+			concurrent, e := strconv.Atoi(strings.TrimSpace(s))
+			fatalIf(probe.NewError(e).Trace(s), "Unable to parse --sweep-concurrent.")
+			if concurrent <= 0 {
+				fatalIf(errInvalidArgument().Trace(s), "concurrency cannot be '0' or negative")
+			}
+			concurrents = append(concurrents, concurrent)
+		}
+	}
+
+	var sweep []madmin.SpeedtestOpts
+	for _, size := range sizes {
+		for _, concurrent := range concurrents {
+			opts := base
+			opts.Size, opts.Concurrency = size, concurrent
+			if ctx.IsSet("sweep-concurrent") {
+				opts.Autotune = false
+			}
+			sweep = append(sweep, opts)
+		}
+	}
+	return sweep
+}
+
+// perfMetric is a throughput measured by a perf test, for the cluster or
+// for a node or a drive.
+type perfMetric struct {
+	Test  string  `json:"test"`
+	Name  string  `json:"name"`
+	Node  string  `json:"node,omitempty"`
+	Drive string  `json:"drive,omitempty"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+func (m perfMetric) key() string {
+	return m.Test + "\x00" + m.Name + "\x00" + m.Node + "\x00" + m.Drive
+}
+
+// perfBaseline is the file saved by support perf --save.
+type perfBaseline struct {
+	Version int          `json:"version"`
+	Alias   string       `json:"alias"`
+	Time    time.Time    `json:"time"`
+	Metrics []perfMetric `json:"metrics"`
+}
+
+const perfBaselineVersion = 1
+
+// perfTestMetrics flattens the results of the perf tests into metrics.
+func perfTestMetrics(results []PerfTestResult) (metrics []perfMetric) {
+	add := func(test, name, node, drive string, value uint64, unit string) {
+		metrics = append(metrics, perfMetric{Test: test, Name: name, Node: node, Drive: drive, Value: float64(value), Unit: unit})
+	}
+	for _, r := range results {
+		if r.Err != "" {
+			continue
+		}
+		switch r.Type {
+		case ObjectPerfTest:
+			if r.ObjectResult == nil {
+				continue
+			}
+			o := r.ObjectResult
+			concurrent := "auto"
+			if !r.autotune {
+				concurrent = strconv.Itoa(o.Concurrent)
+			}
+			run := fmt.Sprintf("%s x%s", humanize.IBytes(uint64(o.Size)), concurrent)
+			for op, stats := range map[string]madmin.SpeedTestStats{"PUT": o.PUTStats, "GET": o.GETStats} {
+				add("object", op+" "+run, "", "", stats.ThroughputPerSec, "B/s")
+				add("object", op+" objects "+run, "", "", stats.ObjectsPerSec, "obj/s")
+				for _, srv := range stats.Servers {
+					if srv.Err == "" {
+						add("object", op+" "+run, srv.Endpoint, "", srv.ThroughputPerSec, "B/s")
+					}
+				}
+			}
+		case DrivePerfTest:
+			for _, srv := range r.DriveResult {
+				for _, drive := range srv.DrivePerf {
+					if drive.Error == "" {
+						add("drive", "read", srv.Endpoint, drive.Path, drive.ReadThroughput, "B/s")
+						add("drive", "write", srv.Endpoint, drive.Path, drive.WriteThroughput, "B/s")
+					}
+				}
+			}
+		case NetPerfTest:
+			if r.NetResult == nil {
+				continue
+			}
+			for _, node := range r.NetResult.NodeResults {
+				if node.Error == "" {
+					add("net", "tx", node.Endpoint, "", node.TX, "B/s")
+					add("net", "rx", node.Endpoint, "", node.RX, "B/s")
+				}
+			}
+		case SiteReplicationPerfTest:
+			if r.SiteReplicationResult == nil {
+				continue
+			}
+			for _, node := range r.SiteReplicationResult.NodeResults {
+				if node.Error == "" {
+					add("site-replication", "tx", node.Endpoint, "", node.TX, "B/s")
+					add("site-replication", "rx", node.Endpoint, "", node.RX, "B/s")
+				}
+			}
+		case ClientPerfTest:
+			if c := r.ClientResult; c != nil && c.Error == "" && c.TimeSpent > 0 {
+				add("client", "upload", c.Endpoint, "", uint64(float64(c.BytesSend)/time.Duration(c.TimeSpent).Seconds()), "B/s")
+			}
+		}
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].key() < metrics[j].key() })
+	return metrics
+}
+
+// perfCompareMessage is a metric compared with its baseline.
+type perfCompareMessage struct {
+	Status     string  `json:"status"`
+	Test       string  `json:"test"`
+	Name       string  `json:"name"`
+	Node       string  `json:"node,omitempty"`
+	Drive      string  `json:"drive,omitempty"`
+	Unit       string  `json:"unit"`
+	Baseline   float64 `json:"baseline"`
+	Current    float64 `json:"current"`
+	Delta      float64 `json:"deltaPercent"`
+	Regression bool    `json:"regression"`
+}
+
+func (m perfCompareMessage) JSON() string {
+	buf, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func perfValueString(value float64, unit string) string {
+	if unit == "B/s" {
+		return humanize.IBytes(uint64(value)) + "/s"
+	}
+	return humanize.Comma(int64(value)) + " " + unit
+}
+
+func (m perfCompareMessage) String() string {
+	where := m.Node
+	if m.Drive != "" {
+		where += " " + m.Drive
+	}
+	if where == "" {
+		where = "cluster"
+	}
+	delta := fmt.Sprintf("%+.1f%%", m.Delta)
+	switch {
+	case m.Regression:
+		delta = console.Colorize("PerfRegression", delta+" REGRESSION")
+	case m.Delta > 0:
+		delta = console.Colorize("PerfImproved", delta)
+	}
+	return fmt.Sprintf("%-16s %-28s %-36s %14s -> %-14s %s", m.Test, m.Name, where,
+		perfValueString(m.Baseline, m.Unit), perfValueString(m.Current, m.Unit), delta)
+}
+
+// comparePerfMetrics compares the metrics measured in both runs, a metric
+// more than threshold percent slower than its baseline is a regression.
+func comparePerfMetrics(baseline, current []perfMetric, threshold float64) []perfCompareMessage {
+	previous := make(map[string]perfMetric, len(baseline))
+	for _, m := range baseline {
+		previous[m.key()] = m
+	}
+	var msgs []perfCompareMessage
+	for _, m := range current {
+		b, ok := previous[m.key()]
+		if !ok || b.Value == 0 {
+			continue
+		}
+		delta := 100 * (m.Value - b.Value) / b.Value
+		msgs = append(msgs, perfCompareMessage{
+			Status:     "success",
+			Test:       m.Test,
+			Name:       m.Name,
+			Node:       m.Node,
+			Drive:      m.Drive,
+			Unit:       m.Unit,
+			Baseline:   b.Value,
+			Current:    m.Value,
+			Delta:      delta,
+			Regression: delta < -threshold,
+		})
+	}
+	return msgs
+}
+
+type perfBaselineMessage struct {
+	Status      string  `json:"status"`
+	Compared    int     `json:"compared,omitempty"`
+	Regressions int     `json:"regressions"`
+	Saved       string  `json:"saved,omitempty"`
+	Baseline    string  `json:"baseline,omitempty"`
+	Threshold   float64 `json:"threshold,omitempty"`
+}
+
+func (m perfBaselineMessage) JSON() string {
+	buf, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (m perfBaselineMessage) String() string {
+	var lines []string
+	if m.Baseline != "" {
+		msg := fmt.Sprintf("Compared %d results with %s: %d regressions beyond %.1f%%.", m.Compared, m.Baseline, m.Regressions, m.Threshold)
+		if m.Regressions > 0 {
+			msg = console.Colorize("PerfRegression", msg)
+		} else {
+			msg = console.Colorize("PerfImproved", msg)
+		}
+		lines = append(lines, msg)
+	}
+	if m.Saved != "" {
+		lines = append(lines, "Baseline saved at "+m.Saved+".")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func loadPerfBaseline(filename string) (perfBaseline, *probe.Error) {
+	var baseline perfBaseline
+	buf, e := os.ReadFile(filename)
+	if e != nil {
+		return baseline, probe.NewError(e)
+	}
+	if e = gojson.Unmarshal(buf, &baseline); e != nil {
+		return baseline, probe.NewError(e)
+	}
+	if baseline.Version != perfBaselineVersion {
+		return baseline, probe.NewError(fmt.Errorf("unsupported baseline version %d", baseline.Version))
+	}
+	return baseline, nil
+}
+
+// execSupportPerfBaseline runs the perf tests and saves or compares their
+// results locally.
+func execSupportPerfBaseline(ctx *cli.Context, aliasedURL, perfType string) error {
+	console.SetColor("PerfRegression", color.New(color.FgRed, color.Bold))
+	console.SetColor("PerfImproved", color.New(color.FgGreen))
+
+	var baseline perfBaseline
+	if filename := ctx.String("compare"); filename != "" {
+		var err *probe.Error
+		baseline, err = loadPerfBaseline(filename)
+		fatalIf(err.Trace(filename), "Unable to load the baseline.")
+	}
+
+	metrics := perfTestMetrics(runPerfTests(ctx, aliasedURL, perfType))
+	if len(metrics) == 0 {
+		fatalIf(errDummy().Trace(aliasedURL), "No performance results were captured.")
+	}
+
+	msg := perfBaselineMessage{Status: "success"}
+	if filename := ctx.String("compare"); filename != "" {
+		msg.Baseline, msg.Threshold = filename, ctx.Float64("threshold")
+		for _, cmp := range comparePerfMetrics(baseline.Metrics, metrics, msg.Threshold) {
+			msg.Compared++
+			if cmp.Regression {
+				msg.Regressions++
+			}
+			printMsg(cmp)
+		}
+	}
+	if filename := ctx.String("save"); filename != "" {
+		alias, _ := url2Alias(aliasedURL)
+		buf, e := gojson.MarshalIndent(perfBaseline{Version: perfBaselineVersion, Alias: alias, Time: UTCNow(), Metrics: metrics}, "", "  ")
+		fatalIf(probe.NewError(e), "Unable to marshal the baseline.")
+		fatalIf(probe.NewError(os.WriteFile(filename, buf, 0o644)).Trace(filename), "Unable to save the baseline.")
+		msg.Saved = filename
+	}
+	printMsg(msg)
+	if msg.Baseline != "" && msg.Compared == 0 {
+		errorIf(errDummy().Trace(msg.Baseline), "No result matches the baseline `%s`, run the same tests with the same sweep to compare them.", msg.Baseline)
+		return exitStatus(globalErrorExitStatus)
+	}
+	if msg.Regressions > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+func TestComparePerfMetrics(t *testing.T) {
+	run := func(put, drive uint64, concurrent int) []PerfTestResult {
+		return []PerfTestResult{
+			{
+				Type: ObjectPerfTest,
+				ObjectResult: &madmin.SpeedTestResult{
+					Size:       64 << 20,
+					Concurrent: concurrent,
+					PUTStats: madmin.SpeedTestStats{ThroughputPerSec: put, Servers: []madmin.SpeedTestStatServer{
+						{Endpoint: "node1:9000", ThroughputPerSec: put / 2},
+						{Endpoint: "node2:9000", Err: "timeout"},
+					}},
+					GETStats: madmin.SpeedTestStats{ThroughputPerSec: 2 * put},
+				},
+				autotune: true,
+			},
+			{
+				Type: DrivePerfTest,
+				DriveResult: []madmin.DriveSpeedTestResult{{
+					Endpoint:  "node1:9000",
+					DrivePerf: []madmin.DrivePerf{{Path: "/data1", ReadThroughput: drive, WriteThroughput: drive}},
+				}},
+			},
+			{Type: NetPerfTest, Err: "failed"},
+		}
+	}
+
+	baseline := perfTestMetrics(run(1000, 500, 32))
+	// PUT and GET throughput and objects/s of the cluster, PUT of node1, read and write of a drive.
+	if len(baseline) != 7 {
+		t.Fatalf("expected 7 metrics, got %+v", baseline)
+	}
+
+	// The autotuned concurrency differs, the metrics still match.
+	current := perfTestMetrics(run(850, 560, 48))
+	msgs := comparePerfMetrics(baseline, current, 10)
+	regressions := map[string]bool{}
+	for _, msg := range msgs {
+		if msg.Regression {
+			regressions[msg.Test+" "+msg.Name+" "+msg.Node+" "+msg.Drive] = true
+		}
+	}
+	want := map[string]bool{
+		"object PUT 64 MiB xauto  ":           true,
+		"object PUT 64 MiB xauto node1:9000 ": true,
+		"object GET 64 MiB xauto  ":           true,
+	}
+	if len(msgs) != 5 || len(regressions) != len(want) {
+		t.Fatalf("unexpected comparison %+v", msgs)
+	}
+	for k := range want {
+		if !regressions[k] {
+			t.Errorf("missing regression %q in %v", k, regressions)
+		}
+	}
+
+	if msgs := comparePerfMetrics(baseline, current, 20); len(msgs) != 5 {
+		t.Errorf("expected 5 compared metrics, got %d", len(msgs))
+	} else {
+		for _, msg := range msgs {
+			if msg.Regression {
+				t.Errorf("unexpected regression beyond 20%%: %+v", msg)
+			}
+		}
+	}
+}
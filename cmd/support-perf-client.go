@@ -60,22 +60,23 @@ func mainAdminSpeedTestClientPerf(ctx *cli.Context, aliasedURL string, outCh cha
 		resultCh <- result
 	}()
 	if globalJSON {
-		var rsl PerfTestOutput
+		var r PerfTestResult
 		select {
 		case e := <-errorCh:
-			rsl = convertPerfResult(PerfTestResult{
+			r = PerfTestResult{
 				Type:  ClientPerfTest,
 				Err:   e.Error(),
 				Final: true,
-			})
+			}
 		case result := <-resultCh:
-			rsl = convertPerfResult(PerfTestResult{
+			r = PerfTestResult{
 				Type:         ClientPerfTest,
 				ClientResult: &result,
 				Final:        true,
-			})
+			}
 		}
-		printMsg(rsl)
+		printMsg(convertPerfResult(r))
+		sendPerfTestResult(outCh, r)
 		return nil
 	}
 
@@ -45,61 +45,57 @@ func mainAdminSpeedtest(_ *cli.Context) error {
 	return nil
 }
 
-func mainAdminSpeedTestObject(ctx *cli.Context, aliasedURL string, outCh chan<- PerfTestResult) error {
-	client, perr := newAdminClient(aliasedURL)
-	if perr != nil {
-		fatalIf(perr.Trace(aliasedURL), "Unable to initialize admin client.")
-		return nil
-	}
-
-	ctxt, cancel := context.WithCancel(globalContext)
-	defer cancel()
-
+// speedTestObjectOpts returns the options of the object speedtest.
+func speedTestObjectOpts(ctx *cli.Context) madmin.SpeedtestOpts {
 	duration, e := time.ParseDuration(ctx.String("duration"))
-	if e != nil {
-		fatalIf(probe.NewError(e), "Unable to parse duration")
-		return nil
-	}
+	fatalIf(probe.NewError(e), "Unable to parse duration")
 	if duration <= 0 {
 		fatalIf(errInvalidArgument(), "duration cannot be 0 or negative")
-		return nil
 	}
 	size, e := humanize.ParseBytes(ctx.String("size"))
-	if e != nil {
-		fatalIf(probe.NewError(e), "Unable to parse object size")
-		return nil
-	}
+	fatalIf(probe.NewError(e), "Unable to parse object size")
 	if size <= 0 {
 		fatalIf(errInvalidArgument(), "size is expected to be more than 0 bytes")
-		return nil
 	}
 	concurrent := ctx.Int("concurrent")
 	if concurrent <= 0 {
 		fatalIf(errInvalidArgument(), "concurrency cannot be '0' or negative")
-		return nil
 	}
-	globalPerfTestVerbose = ctx.Bool("verbose")
-
-	// Turn-off autotuning only when "concurrent" is specified
-	// in all other scenarios keep auto-tuning on.
-	autotune := !ctx.IsSet("concurrent")
 
-	resultCh, e := client.Speedtest(ctxt, madmin.SpeedtestOpts{
+	return madmin.SpeedtestOpts{
 		Size:        int(size),
 		Duration:    duration,
 		Concurrency: concurrent,
-		Autotune:    autotune,
-		Bucket:      ctx.String("bucket"), // This is a hidden flag.
-		NoClear:     ctx.Bool("noclear"),
-	})
+		// Turn-off autotuning only when "concurrent" is specified
+		// in all other scenarios keep auto-tuning on.
+		Autotune: !ctx.IsSet("concurrent"),
+		Bucket:   ctx.String("bucket"), // This is a hidden flag.
+		NoClear:  ctx.Bool("noclear"),
+	}
+}
+
+// runSpeedTestObject runs an object speedtest and sends its final result to outCh.
+func runSpeedTestObject(aliasedURL string, opts madmin.SpeedtestOpts, outCh chan<- PerfTestResult) error {
+	client, perr := newAdminClient(aliasedURL)
+	if perr != nil {
+		fatalIf(perr.Trace(aliasedURL), "Unable to initialize admin client.")
+		return nil
+	}
+
+	ctxt, cancel := context.WithCancel(globalContext)
+	defer cancel()
+
+	resultCh, e := client.Speedtest(ctxt, opts)
 
 	if globalJSON {
 		if e != nil {
-			printMsg(convertPerfResult(PerfTestResult{
+			r := PerfTestResult{
 				Type:  ObjectPerfTest,
 				Err:   e.Error(),
 				Final: true,
-			}))
+			}
+			printMsg(convertPerfResult(r))
+			sendPerfTestResult(outCh, r)
 			return nil
 		}
 
@@ -110,12 +106,13 @@ func mainAdminSpeedTestObject(ctx *cli.Context, aliasedURL string, outCh chan<-
 			}
 		}
 
-		printMsg(convertPerfResult(PerfTestResult{
+		r := PerfTestResult{
 			Type:         ObjectPerfTest,
 			ObjectResult: &result,
 			Final:        true,
-		}))
-
+		}
+		printMsg(convertPerfResult(r))
+		sendPerfTestResult(outCh, r)
 		return nil
 	}
 
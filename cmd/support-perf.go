@@ -83,7 +83,7 @@ var supportPerfFlags = append([]cli.Flag{
 		Usage:  "run tests on drive(s) one-by-one",
 		Hidden: true,
 	},
-}, subnetCommonFlags...)
+}, append(supportPerfBaselineFlags, subnetCommonFlags...)...)
 
 var supportPerfCmd = cli.Command{
 	Name:            "perf",
@@ -108,6 +108,15 @@ EXAMPLES:
 
   2. Run object storage, network, and drive performance tests on cluster with alias 'myminio', save and upload to SUBNET manually
      {{.Prompt}} {{.HelpName}} myminio --airgap
+
+  3. Save the results of the tests on cluster with alias 'myminio' as a baseline, without uploading them to SUBNET
+     {{.Prompt}} {{.HelpName}} myminio --save baseline.json
+
+  4. Compare the drive performance with the baseline, exit with an error if a drive is more than 15% slower
+     {{.Prompt}} {{.HelpName}} drive myminio --compare baseline.json --threshold 15
+
+  5. Run the object test for each object size and concurrency, and compare them with the baseline
+     {{.Prompt}} {{.HelpName}} object myminio --sweep-size 4MiB,64MiB --sweep-concurrent 16,64 --compare baseline.json
 `,
 }
 
@@ -291,6 +300,10 @@ func mainSupportPerf(ctx *cli.Context) error {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code
 	}
 
+	if isSupportPerfBaselineRun(ctx) {
+		return execSupportPerfBaseline(ctx, aliasedURL, perfType)
+	}
+
 	// Main execution
 	execSupportPerf(ctx, aliasedURL, perfType)
 
@@ -517,8 +530,16 @@ func savePerfResultFile(tmpFileName, resultFileNamePfx string) {
 	console.Infof("MinIO performance report saved at %s, please upload to SUBNET portal manually\n", zipFileName)
 }
 
+// sendPerfTestResult sends the final result of a test, if asked for.
+func sendPerfTestResult(outCh chan<- PerfTestResult, r PerfTestResult) {
+	if outCh != nil {
+		outCh <- r
+	}
+}
+
 func runPerfTests(ctx *cli.Context, aliasedURL, perfType string) []PerfTestResult {
-	resultCh := make(chan PerfTestResult)
+	// Buffered as the results are sent before returning with --json.
+	resultCh := make(chan PerfTestResult, 1)
 	results := []PerfTestResult{}
 	defer close(resultCh)
 
@@ -533,7 +554,14 @@ func runPerfTests(ctx *cli.Context, aliasedURL, perfType string) []PerfTestResul
 		case "drive":
 			mainAdminSpeedTestDrive(ctx, aliasedURL, resultCh)
 		case "object":
-			mainAdminSpeedTestObject(ctx, aliasedURL, resultCh)
+			globalPerfTestVerbose = ctx.Bool("verbose")
+			for _, opts := range speedTestObjectSweep(ctx) {
+				runSpeedTestObject(aliasedURL, opts, resultCh)
+				r := <-resultCh
+				r.autotune = opts.Autotune
+				results = append(results, r)
+			}
+			continue
 		case "net":
 			mainAdminSpeedTestNetperf(ctx, aliasedURL, resultCh)
 		case "site-replication":
@@ -544,9 +572,7 @@ func runPerfTests(ctx *cli.Context, aliasedURL, perfType string) []PerfTestResul
 			showCommandHelpAndExit(ctx, 1) // last argument is exit code
 		}
 
-		if !globalJSON {
-			results = append(results, <-resultCh)
-		}
+		results = append(results, <-resultCh)
 	}
 
 	return results
@@ -0,0 +1,490 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+var supportTopLocksSampleFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "duration",
+		Usage: "sample the locks during this duration and report aggregated hold times",
+	},
+	cli.StringFlag{
+		Name:  "interval",
+		Usage: "interval between two lock samples",
+		Value: "1s",
+	},
+	cli.IntFlag{
+		Name:  "prefix-depth",
+		Usage: "number of path components of the resource prefixes locks are aggregated by",
+		Value: 1,
+	},
+	cli.IntFlag{
+		Name:  "top",
+		Usage: "number of most contended paths to report",
+		Value: 10,
+	},
+	cli.BoolFlag{
+		Name:  "trace",
+		Usage: "trace the S3 calls during the sampling and report the calls on the most contended paths",
+	},
+}
+
+// supportTopLocksSampleCount is the number of locks requested by each sample.
+const supportTopLocksSampleCount = 10000
+
+// lockHoldBuckets are the upper bounds of the lock hold time histograms.
+var lockHoldBuckets = []time.Duration{
+	100 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+}
+
+// lockHoldStats aggregates the hold times of a group of locks.
+type lockHoldStats struct {
+	Name  string        `json:"name"`
+	Locks int           `json:"locks"`
+	Write int           `json:"write"`
+	Read  int           `json:"read"`
+	Stale int           `json:"stale"`
+	Total time.Duration `json:"totalHold"`
+	Max   time.Duration `json:"maxHold"`
+}
+
+func (s *lockHoldStats) add(l *sampledLock) {
+	s.Locks++
+	if strings.EqualFold(l.Type, "write") {
+		s.Write++
+	} else {
+		s.Read++
+	}
+	if l.stale {
+		s.Stale++
+	}
+	s.Total += l.hold
+	if l.hold > s.Max {
+		s.Max = l.hold
+	}
+}
+
+// lockHistogramBucket counts the locks held for at most Le, or longer
+// than the previous bucket when Le is empty.
+type lockHistogramBucket struct {
+	Le    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+// lockHistogram is the hold time histogram of one lock type.
+type lockHistogram struct {
+	Type    string                `json:"type"`
+	Buckets []lockHistogramBucket `json:"buckets"`
+}
+
+// lockTraceCall aggregates the traced calls of one API on a path.
+type lockTraceCall struct {
+	API    string        `json:"api"`
+	Count  int           `json:"count"`
+	Errors int           `json:"errors"`
+	Total  time.Duration `json:"totalDuration"`
+	Max    time.Duration `json:"maxDuration"`
+}
+
+// contendedPath reports the locks of a resource.
+type contendedPath struct {
+	lockHoldStats
+	MaxConcurrent int             `json:"maxConcurrent"`
+	Contended     int             `json:"contendedSamples"`
+	Calls         []lockTraceCall `json:"calls,omitempty"`
+}
+
+// supportTopLocksSampleMessage is the report of a lock sampling.
+type supportTopLocksSampleMessage struct {
+	Status     string          `json:"status"`
+	Samples    int             `json:"samples"`
+	Duration   time.Duration   `json:"duration"`
+	Locks      int             `json:"locks"`
+	Traced     int             `json:"tracedCalls,omitempty"`
+	ByType     []lockHoldStats `json:"byType"`
+	ByOwner    []lockHoldStats `json:"byOwner"`
+	ByPrefix   []lockHoldStats `json:"byPrefix"`
+	Histograms []lockHistogram `json:"histograms"`
+	TopPaths   []contendedPath `json:"topPaths"`
+}
+
+func (m supportTopLocksSampleMessage) JSON() string {
+	buf, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(buf)
+}
+
+func (m supportTopLocksSampleMessage) String() string {
+	hold := func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	}
+	statsTable := func(b *strings.Builder, title string, stats []lockHoldStats) {
+		fmt.Fprintln(b, console.Colorize("LockSampleTitle", title))
+		cellText := [][]string{{"Name", "Locks", "Write", "Read", "Stale", "Total hold", "Max hold"}}
+		printColors := []*color.Color{getPrintCol(colGreen)}
+		for _, s := range stats {
+			cellText = append(cellText, []string{
+				s.Name, strconv.Itoa(s.Locks), strconv.Itoa(s.Write), strconv.Itoa(s.Read),
+				strconv.Itoa(s.Stale), hold(s.Total), hold(s.Max),
+			})
+			printColors = append(printColors, getPrintCol(colGrey))
+		}
+		tbl := console.NewTable(printColors, make([]bool, len(cellText[0])), 0)
+		fatalIf(probe.NewError(tbl.PopulateTable(b, cellText)), "Unable to populate the table.")
+		fmt.Fprintln(b)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sampled %d distinct locks in %d samples over %s.", m.Locks, m.Samples, m.Duration.Round(time.Second))
+	fmt.Fprintln(&b, " Hold times are the longest observed, locks are held at least that long.")
+	fmt.Fprintln(&b)
+	if m.Locks == 0 {
+		return strings.TrimSuffix(b.String(), "\n\n")
+	}
+	statsTable(&b, "Locks by type", m.ByType)
+	statsTable(&b, "Locks by owner", m.ByOwner)
+	statsTable(&b, "Locks by resource prefix", m.ByPrefix)
+
+	for _, h := range m.Histograms {
+		fmt.Fprintln(&b, console.Colorize("LockSampleTitle", "Hold time of "+h.Type+" locks"))
+		most := 0
+		for _, bucket := range h.Buckets {
+			most = max(most, bucket.Count)
+		}
+		for i, bucket := range h.Buckets {
+			label := "<= " + bucket.Le
+			if bucket.Le == "" {
+				label = " > " + h.Buckets[i-1].Le
+			}
+			bar := ""
+			if most > 0 {
+				bar = strings.Repeat("█", (bucket.Count*40+most-1)/most)
+			}
+			fmt.Fprintf(&b, "  %-9s %6d %s\n", label, bucket.Count, console.Colorize("LockSampleBar", bar))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintln(&b, console.Colorize("LockSampleTitle", "Most contended paths"))
+	cellText := [][]string{{"Resource", "Locks", "Write", "Max concurrent", "Contended samples", "Total hold", "Max hold"}}
+	printColors := []*color.Color{getPrintCol(colGreen)}
+	for _, p := range m.TopPaths {
+		cellText = append(cellText, []string{
+			p.Name, strconv.Itoa(p.Locks), strconv.Itoa(p.Write), strconv.Itoa(p.MaxConcurrent),
+			strconv.Itoa(p.Contended), hold(p.Total), hold(p.Max),
+		})
+		printColors = append(printColors, getPrintCol(colGrey))
+	}
+	tbl := console.NewTable(printColors, make([]bool, len(cellText[0])), 0)
+	fatalIf(probe.NewError(tbl.PopulateTable(&b, cellText)), "Unable to populate the table.")
+
+	if m.Traced > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, console.Colorize("LockSampleTitle", fmt.Sprintf("S3 calls on the most contended paths (%d calls traced)", m.Traced)))
+		for _, p := range m.TopPaths {
+			if len(p.Calls) == 0 {
+				continue
+			}
+			fmt.Fprintln(&b, "  "+p.Name)
+			for _, c := range p.Calls {
+				fmt.Fprintf(&b, "    %-28s %6d calls %4d errors  avg %-10s max %s\n",
+					c.API, c.Count, c.Errors, hold(c.Total/time.Duration(c.Count)), hold(c.Max))
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sampledLock is a lock seen in one or more samples.
+type sampledLock struct {
+	Resource string
+	Type     string
+	Owner    string
+	hold     time.Duration
+	stale    bool
+}
+
+// lockSampler aggregates the locks of successive samples.
+type lockSampler struct {
+	mu            sync.Mutex
+	samples       int
+	locks         map[string]*sampledLock
+	maxConcurrent map[string]int
+	contended     map[string]int
+	// calls holds the traced calls by path and API.
+	calls  map[string]map[string]*lockTraceCall
+	traced int
+}
+
+func newLockSampler() *lockSampler {
+	return &lockSampler{
+		locks:         make(map[string]*sampledLock),
+		maxConcurrent: make(map[string]int),
+		contended:     make(map[string]int),
+		calls:         make(map[string]map[string]*lockTraceCall),
+	}
+}
+
+// addSample records the locks held at time now.
+func (s *lockSampler) addSample(entries madmin.LockEntries, now time.Time) {
+	s.samples++
+	concurrent := make(map[string]int)
+	for _, entry := range entries {
+		elapsed := entry.Elapsed
+		// elapsed can be zero with older MinIO versions.
+		if elapsed == 0 {
+			elapsed = now.Sub(entry.Timestamp)
+		}
+		key := strings.Join([]string{entry.ID, entry.Resource, entry.Owner, entry.Timestamp.String()}, "|")
+		l, ok := s.locks[key]
+		if !ok {
+			l = &sampledLock{Resource: entry.Resource, Type: entry.Type, Owner: entry.Owner}
+			s.locks[key] = l
+		}
+		if elapsed > l.hold {
+			l.hold = elapsed
+		}
+		l.stale = l.stale || entry.Quorum > len(entry.ServerList)
+		concurrent[entry.Resource]++
+	}
+	for resource, n := range concurrent {
+		s.maxConcurrent[resource] = max(s.maxConcurrent[resource], n)
+		if n > 1 {
+			s.contended[resource]++
+		}
+	}
+}
+
+// addTrace records a traced call.
+func (s *lockSampler) addTrace(t madmin.TraceInfo) {
+	path := strings.TrimPrefix(t.Path, "/")
+	if path == "" {
+		return
+	}
+	s.traced++
+	apis := s.calls[path]
+	if apis == nil {
+		apis = make(map[string]*lockTraceCall)
+		s.calls[path] = apis
+	}
+	c := apis[t.FuncName]
+	if c == nil {
+		c = &lockTraceCall{API: t.FuncName}
+		apis[t.FuncName] = c
+	}
+	c.Count++
+	if t.Error != "" || (t.HTTP != nil && t.HTTP.RespInfo.StatusCode >= 500) {
+		c.Errors++
+	}
+	c.Total += t.Duration
+	if t.Duration > c.Max {
+		c.Max = t.Duration
+	}
+}
+
+// lockPrefix returns the first depth components of a lock resource.
+func lockPrefix(resource string, depth int) string {
+	parts := strings.SplitN(strings.TrimPrefix(resource, "/"), "/", depth+1)
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// sortedLockStats returns the groups by decreasing total hold time.
+func sortedLockStats(groups map[string]*lockHoldStats) []lockHoldStats {
+	stats := make([]lockHoldStats, 0, len(groups))
+	for _, s := range groups {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// report aggregates the sampled locks.
+func (s *lockSampler) report(duration time.Duration, depth, top int) supportTopLocksSampleMessage {
+	m := supportTopLocksSampleMessage{
+		Status:   "success",
+		Samples:  s.samples,
+		Duration: duration,
+		Locks:    len(s.locks),
+		Traced:   s.traced,
+	}
+	byType := make(map[string]*lockHoldStats)
+	byOwner := make(map[string]*lockHoldStats)
+	byPrefix := make(map[string]*lockHoldStats)
+	byResource := make(map[string]*lockHoldStats)
+	histograms := make(map[string][]int)
+	group := func(groups map[string]*lockHoldStats, name string, l *sampledLock) {
+		g := groups[name]
+		if g == nil {
+			g = &lockHoldStats{Name: name}
+			groups[name] = g
+		}
+		g.add(l)
+	}
+	for _, l := range s.locks {
+		group(byType, l.Type, l)
+		group(byOwner, l.Owner, l)
+		group(byPrefix, lockPrefix(l.Resource, depth), l)
+		group(byResource, l.Resource, l)
+		if histograms[l.Type] == nil {
+			histograms[l.Type] = make([]int, len(lockHoldBuckets)+1)
+		}
+		histograms[l.Type][sort.Search(len(lockHoldBuckets), func(i int) bool { return l.hold <= lockHoldBuckets[i] })]++
+	}
+	m.ByType = sortedLockStats(byType)
+	m.ByOwner = sortedLockStats(byOwner)
+	m.ByPrefix = sortedLockStats(byPrefix)
+
+	for _, t := range m.ByType {
+		h := lockHistogram{Type: t.Name}
+		for i, count := range histograms[t.Name] {
+			bucket := lockHistogramBucket{Count: count}
+			if i < len(lockHoldBuckets) {
+				bucket.Le = lockHoldBuckets[i].String()
+			}
+			h.Buckets = append(h.Buckets, bucket)
+		}
+		m.Histograms = append(m.Histograms, h)
+	}
+
+	// The most contended paths are the ones concurrently locked the most
+	// often, then the ones held the longest.
+	for _, r := range sortedLockStats(byResource) {
+		m.TopPaths = append(m.TopPaths, contendedPath{
+			lockHoldStats: r,
+			MaxConcurrent: s.maxConcurrent[r.Name],
+			Contended:     s.contended[r.Name],
+		})
+	}
+	sort.SliceStable(m.TopPaths, func(i, j int) bool {
+		return m.TopPaths[i].Contended > m.TopPaths[j].Contended
+	})
+	if len(m.TopPaths) > top {
+		m.TopPaths = m.TopPaths[:top]
+	}
+	for i, p := range m.TopPaths {
+		for _, c := range s.calls[strings.TrimPrefix(p.Name, "/")] {
+			m.TopPaths[i].Calls = append(m.TopPaths[i].Calls, *c)
+		}
+		sort.Slice(m.TopPaths[i].Calls, func(a, b int) bool {
+			return m.TopPaths[i].Calls[a].Count > m.TopPaths[i].Calls[b].Count
+		})
+	}
+	return m
+}
+
+// sampleSupportTopLocks samples the locks of the cluster every interval
+// until duration is elapsed and reports the aggregated locks.
+func sampleSupportTopLocks(ctx *cli.Context, client *madmin.AdminClient) supportTopLocksSampleMessage {
+	duration, e := time.ParseDuration(ctx.String("duration"))
+	fatalIf(probe.NewError(e), "Unable to parse --duration.")
+	interval, e := time.ParseDuration(ctx.String("interval"))
+	fatalIf(probe.NewError(e), "Unable to parse --interval.")
+	depth, top := ctx.Int("prefix-depth"), ctx.Int("top")
+	if duration <= 0 || interval <= 0 || depth <= 0 || top <= 0 {
+		fatalIf(errInvalidArgument(), "--duration, --interval, --prefix-depth and --top must be positive.")
+	}
+
+	console.SetColor("LockSampleTitle", color.New(color.FgGreen, color.Bold))
+	console.SetColor("LockSampleBar", color.New(color.FgBlue))
+
+	sampleCtx, cancel := context.WithTimeout(globalContext, duration)
+	defer cancel()
+
+	sampler := newLockSampler()
+	traceDone := make(chan struct{})
+	traceCh := make(chan madmin.TraceInfo, 1000)
+	if ctx.Bool("trace") {
+		go func() {
+			defer close(traceCh)
+			for t := range client.ServiceTrace(sampleCtx, madmin.ServiceTraceOpts{S3: true}) {
+				if t.Err != nil {
+					errorIf(probe.NewError(t.Err), "Unable to trace the S3 calls.")
+					return
+				}
+				traceCh <- t.Trace
+			}
+		}()
+	} else {
+		close(traceCh)
+	}
+	go func() {
+		defer close(traceDone)
+		for t := range traceCh {
+			sampler.mu.Lock()
+			sampler.addTrace(t)
+			sampler.mu.Unlock()
+		}
+	}()
+
+	if !globalJSON && !globalQuiet {
+		console.Infof("Sampling the locks every %s for %s...\n", interval, duration)
+	}
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		entries, e := client.TopLocksWithOpts(sampleCtx, madmin.TopLockOpts{Count: supportTopLocksSampleCount})
+		if sampleCtx.Err() != nil {
+			break
+		}
+		fatalIf(probe.NewError(e), "Unable to get server locks list.")
+		sampler.mu.Lock()
+		sampler.addSample(entries, time.Now().UTC())
+		sampler.mu.Unlock()
+
+		select {
+		case <-sampleCtx.Done():
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+	cancel()
+	<-traceDone
+
+	return sampler.report(time.Since(start), depth, top)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+func TestLockSamplerReport(t *testing.T) {
+	start := time.Now()
+	now := start
+	lock := func(id, resource, typ, owner string, elapsed time.Duration) madmin.LockEntry {
+		return madmin.LockEntry{
+			ID: id, Resource: resource, Type: typ, Owner: owner,
+			Timestamp: start, Elapsed: elapsed,
+			ServerList: []string{"a", "b"}, Quorum: 2,
+		}
+	}
+
+	s := newLockSampler()
+	s.addSample(madmin.LockEntries{
+		lock("1", "hot/a/obj", "WRITE", "node1", 50*time.Millisecond),
+		lock("2", "hot/a/obj", "READ", "node2", 20*time.Millisecond),
+		lock("3", "cold/obj", "READ", "node1", 10*time.Millisecond),
+	}, now)
+	now = now.Add(time.Second)
+	s.addSample(madmin.LockEntries{
+		lock("1", "hot/a/obj", "WRITE", "node1", 2*time.Second),
+		lock("4", "hot/b/obj", "WRITE", "node2", time.Millisecond),
+	}, now)
+	s.addTrace(madmin.TraceInfo{FuncName: "s3.PutObject", Path: "/hot/a/obj", Duration: 3 * time.Second})
+	s.addTrace(madmin.TraceInfo{FuncName: "s3.PutObject", Path: "/hot/a/obj", Duration: time.Second, Error: "timeout"})
+	s.addTrace(madmin.TraceInfo{FuncName: "s3.GetObject", Path: "/cold/obj", Duration: time.Millisecond})
+
+	m := s.report(2*time.Second, 1, 2)
+	if m.Samples != 2 || m.Locks != 4 || m.Traced != 3 {
+		t.Fatalf("unexpected report %+v", m)
+	}
+	if len(m.ByPrefix) != 2 || m.ByPrefix[0].Name != "hot" || m.ByPrefix[0].Locks != 3 || m.ByPrefix[0].Max != 2*time.Second {
+		t.Errorf("unexpected prefixes %+v", m.ByPrefix)
+	}
+	if len(m.ByType) != 2 || m.ByType[0].Name != "WRITE" || m.ByType[0].Write != 2 || m.ByType[1].Read != 2 {
+		t.Errorf("unexpected types %+v", m.ByType)
+	}
+	if len(m.ByOwner) != 2 || m.ByOwner[0].Name != "node1" || m.ByOwner[0].Total != 2010*time.Millisecond {
+		t.Errorf("unexpected owners %+v", m.ByOwner)
+	}
+	// The write lock held for 2s is counted in the 5s bucket.
+	if m.Histograms[0].Type != "WRITE" || m.Histograms[0].Buckets[0].Count != 1 || m.Histograms[0].Buckets[2].Count != 1 {
+		t.Errorf("unexpected histogram %+v", m.Histograms[0])
+	}
+
+	if len(m.TopPaths) != 2 {
+		t.Fatalf("expected the 2 top paths, got %+v", m.TopPaths)
+	}
+	top := m.TopPaths[0]
+	if top.Name != "hot/a/obj" || top.Contended != 1 || top.MaxConcurrent != 2 || top.Locks != 2 {
+		t.Errorf("unexpected top path %+v", top)
+	}
+	if len(top.Calls) != 1 || top.Calls[0].API != "s3.PutObject" || top.Calls[0].Count != 2 || top.Calls[0].Errors != 1 || top.Calls[0].Max != 3*time.Second {
+		t.Errorf("unexpected calls %+v", top.Calls)
+	}
+	if m.String() == "" || m.JSON() == "" {
+		t.Error("expected a rendered report")
+	}
+}
+
+func TestLockPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		resource string
+		depth    int
+		want     string
+	}{
+		{"bucket/a/b/obj", 1, "bucket"},
+		{"bucket/a/b/obj", 2, "bucket/a"},
+		{"bucket/obj", 3, "bucket/obj"},
+		{"/bucket", 1, "bucket"},
+	} {
+		if got := lockPrefix(tc.resource, tc.depth); got != tc.want {
+			t.Errorf("lockPrefix(%q, %d) = %q, want %q", tc.resource, tc.depth, got, tc.want)
+		}
+	}
+}
@@ -49,7 +49,7 @@ var supportTopLocksCmd = cli.Command{
 	Before:       setGlobalsFromContext,
 	Action:       mainSupportTopLocks,
 	OnUsageError: onUsageError,
-	Flags:        append(supportTopLocksFlag, supportGlobalFlags...),
+	Flags:        append(append(supportTopLocksFlag, supportTopLocksSampleFlags...), supportGlobalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -59,9 +59,25 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+DESCRIPTION:
+  Without --duration, lists the oldest locks held at the moment.
+
+  With --duration, the locks are sampled every --interval and the longest
+  observed hold time of each lock is aggregated by lock type, owner node and
+  resource prefix. Hold time histograms and the paths most often locked
+  concurrently are reported. With --trace, the S3 calls made on those paths
+  during the sampling are reported as well.
+
 EXAMPLES:
   1. List oldest locks on a MinIO cluster.
      {{.Prompt}} {{.HelpName}} myminio/
+
+  2. Sample the locks for 5 minutes and report the hold times by bucket.
+     {{.Prompt}} {{.HelpName}} --duration 5m myminio/
+
+  3. Sample the locks every 200ms for 1 minute, aggregate by bucket and first
+     level prefix and report the S3 calls on the 20 most contended paths.
+     {{.Prompt}} {{.HelpName}} --duration 1m --interval 200ms --prefix-depth 2 --top 20 --trace myminio/
 `,
 }
 
@@ -150,6 +166,11 @@ func mainSupportTopLocks(ctx *cli.Context) error {
 	client, err := newAdminClient(aliasedURL)
 	fatalIf(err, "Unable to initialize admin connection.")
 
+	if ctx.IsSet("duration") {
+		printMsg(sampleSupportTopLocks(ctx, client))
+		return nil
+	}
+
 	// Call top locks API
 	entries, e := client.TopLocksWithOpts(globalContext, madmin.TopLockOpts{
 		Count: ctx.Int("count"),
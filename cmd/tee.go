@@ -0,0 +1,424 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/hookreader"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v3/console"
+)
+
+// teeMessage is the status of the upload of a source to one of the
+// targets of a fan-out copy.
+type teeMessage struct {
+	Status string `json:"status"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Size   int64  `json:"size"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (t teeMessage) String() string {
+	if t.Error != "" {
+		return console.Colorize("TeeFailed", fmt.Sprintf("`%s` -> `%s`: %s", t.Source, t.Target, t.Error))
+	}
+	return console.Colorize("Copy", fmt.Sprintf("`%s` -> `%s` (%s)", t.Source, t.Target, humanize.IBytes(uint64(t.Size))))
+}
+
+func (t teeMessage) JSON() string {
+	t.Status = "success"
+	if t.Error != "" {
+		t.Status = "error"
+	}
+	jsonMessageBytes, e := json.MarshalIndent(t, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// teeWriter writes to the pipes of all the targets which have not
+// failed yet. Unless partial is set, the first failed target stops the
+// fan-out for all of them.
+type teeWriter struct {
+	pipes   []*io.PipeWriter
+	errs    []error
+	failed  int
+	partial bool
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	alive := 0
+	for i, pw := range t.pipes {
+		if t.errs[i] != nil {
+			continue
+		}
+		if _, e := pw.Write(p); e != nil {
+			t.errs[i] = e
+			if t.failed < 0 {
+				t.failed = i
+			}
+			if !t.partial {
+				return 0, e
+			}
+			continue
+		}
+		alive++
+	}
+	if alive == 0 {
+		return 0, errors.New("uploads to all targets failed")
+	}
+	return len(p), nil
+}
+
+// teePutFunc uploads a stream to one target.
+type teePutFunc func(ctx context.Context, target string, r io.Reader) (int64, *probe.Error)
+
+// teeUpload reads r once and uploads it concurrently to all targets
+// with put. The slowest target sets the pace of the others. It returns
+// one status message per target, the failure of a target is reported
+// on that target only.
+func teeUpload(ctx context.Context, source string, r io.Reader, targets []string, put teePutFunc, partial bool) []teeMessage {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make([]teeMessage, len(targets))
+	tw := &teeWriter{
+		pipes:   make([]*io.PipeWriter, len(targets)),
+		errs:    make([]error, len(targets)),
+		failed:  -1,
+		partial: partial,
+	}
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		pr, pw := io.Pipe()
+		tw.pipes[i] = pw
+		msgs[i] = teeMessage{Source: source, Target: target}
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			n, err := put(ctx, target, pr)
+			if err != nil {
+				// Unblock the writer, which reports the failure.
+				pr.CloseWithError(fmt.Errorf("upload to `%s` failed: %w", target, err.ToGoError()))
+				msgs[i].Error = err.ToGoError().Error()
+				return
+			}
+			// A target which stops reading early must not block the
+			// others, drain what it left.
+			if left, _ := io.Copy(io.Discard, pr); left > 0 {
+				msgs[i].Error = fmt.Sprintf("the upload stopped %d bytes before the end of the source", left)
+				return
+			}
+			msgs[i].Size = n
+		}(i, target)
+	}
+
+	_, e := io.Copy(tw, r)
+	if e != nil && !partial {
+		// Stop the uploads which are still running.
+		cancel()
+	}
+	for _, pw := range tw.pipes {
+		pw.CloseWithError(e)
+	}
+	wg.Wait()
+	if e != nil && !partial && tw.failed >= 0 {
+		for i := range msgs {
+			if i != tw.failed && msgs[i].Error != "" {
+				msgs[i].Error = fmt.Sprintf("canceled, the upload to `%s` failed", targets[tw.failed])
+			}
+		}
+	}
+	return msgs
+}
+
+// teePut returns a teePutFunc uploading size bytes with the options
+// returned by opts for each target.
+func teePut(size int64, opts func(target string) PutOptions) teePutFunc {
+	return func(ctx context.Context, target string, r io.Reader) (int64, *probe.Error) {
+		alias, urlStrFull, _, err := expandAlias(target)
+		if err != nil {
+			return 0, err.Trace(target)
+		}
+		return putTargetStream(ctx, alias, urlStrFull, "", "", "", r, size, nil, opts(target))
+	}
+}
+
+// teePutOptions returns the options of uploads to target.
+func teePutOptions(cliCtx *cli.Context, target, contentType string, encKeyDB map[string][]prefixSSEPair, meta map[string]string) PutOptions {
+	alias, _ := url2Alias(target)
+	md5, checksum := parseChecksum(cliCtx)
+	storageClass := cliCtx.String("storage-class")
+	aliasChecksum, aliasStorageClass := aliasUploadDefaults(cliCtx, target)
+	if aliasChecksum != "" {
+		md5, checksum = parseChecksumValue(aliasChecksum, false)
+	}
+	if aliasStorageClass != "" {
+		storageClass = aliasStorageClass
+	}
+	metadata := maps.Clone(meta)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	if contentType == "" {
+		contentType = guessURLContentType(target)
+	}
+	metadata["Content-Type"] = contentType
+	return PutOptions{
+		sse:              getSSE(target, encKeyDB[alias]),
+		storageClass:     storageClass,
+		metadata:         metadata,
+		md5:              md5,
+		checksum:         checksum,
+		disableMultipart: cliCtx.Bool("disable-multipart"),
+	}
+}
+
+// teeMetadata parses the --attr and --tags flags.
+func teeMetadata(cliCtx *cli.Context) map[string]string {
+	meta := map[string]string{}
+	if attr := cliCtx.String("attr"); attr != "" {
+		var err *probe.Error
+		meta, err = getMetaDataEntry(attr)
+		fatalIf(err.Trace(attr), "Unable to parse --attr value")
+	}
+	if tags := cliCtx.String("tags"); tags != "" {
+		meta["X-Amz-Tagging"] = tags
+	}
+	return meta
+}
+
+// printTeeMessages prints the status of all targets and returns false
+// if any of them failed. Only failures are printed over a progress bar.
+func printTeeMessages(msgs []teeMessage, pg ProgressReader) bool {
+	ok := true
+	_, isProgressBar := pg.(*progressBar)
+	for _, msg := range msgs {
+		if msg.Error == "" && isProgressBar {
+			continue
+		}
+		if msg.Error != "" {
+			ok = false
+			if isProgressBar {
+				console.Eraseline()
+			}
+		}
+		printMsg(msg)
+	}
+	return ok
+}
+
+// checkCopyFanOutSyntax validates a copy of the first argument to all
+// the others, with the checks of a copy to each of them.
+func checkCopyFanOutSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) {
+	args := cliCtx.Args()
+	if len(args) < 2 {
+		showCommandHelpAndExit(cliCtx, 1) // last argument is exit code.
+	}
+	for _, flag := range []string{"preserve", rmFlag, rdFlag, lhFlag, "older-than", "newer-than"} {
+		if cliCtx.IsSet(flag) {
+			fatalIf(errInvalidArgument().Trace(args...), "--fan-out cannot be used with --"+flag+".")
+		}
+	}
+	parseChecksum(cliCtx)
+
+	source, targets := args[0], args[1:]
+	isRecursive := cliCtx.Bool("recursive")
+	if isRecursive && cliCtx.String("version-id") != "" {
+		fatalIf(errInvalidArgument().Trace(args...), "--version-id cannot be used with --recursive.")
+	}
+	timeRef := parseRewindFlag(cliCtx.String("rewind"))
+
+	var (
+		content *ClientContent
+		err     *probe.Error
+	)
+	if isRecursive {
+		_, content, err = firstURL2Stat(ctx, source, timeRef, cliCtx.Bool("zip"))
+	} else {
+		_, content, err = url2Stat(ctx, url2StatOptions{urlStr: source, versionID: cliCtx.String("version-id"), encKeyDB: encKeyDB, timeRef: timeRef, isZip: cliCtx.Bool("zip")})
+	}
+	fatalIf(err.Trace(source), "Unable to read from `%s`.", source)
+	if !isRecursive && content.Type.IsDir() {
+		fatalIf(errRequiresRecursive(source).Trace(source), "Unable to copy `%s`.", source)
+	}
+
+	sourceAlias, sourceURL, _ := mustExpandAlias(source)
+	sourceURL = sourceAlias + ":" + sourceURL
+	for _, target := range targets {
+		checkCopyURLsSyntax(cliCtx, []string{source}, target)
+		if !isRecursive {
+			if isDir, _ := isAliasURLDir(ctx, target, encKeyDB, time.Time{}, false); isDir {
+				target = urlJoinPath(target, filepath.Base(source))
+			}
+		}
+		targetAlias, targetURL, _ := mustExpandAlias(target)
+		targetURL = targetAlias + ":" + targetURL
+		if isRecursive && isURLContains(sourceURL, targetURL, "/") {
+			fatalIf(errCopyIntoSelf(source).Trace(target), "Unable to copy `%s` to `%s`.", source, target)
+		}
+		if !isRecursive && strings.TrimSuffix(sourceURL, "/") == strings.TrimSuffix(targetURL, "/") {
+			fatalIf(errInvalidArgument().Trace(source, target), "Source and target `%s` are the same.", target)
+		}
+	}
+}
+
+// mainCopyFanOut copies the first argument to all the others, reading
+// each source object once. Objects are copied in parallel like cp.
+func mainCopyFanOut(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) error {
+	args := cliCtx.Args()
+	source, targets := args[0], args[1:]
+	partial := cliCtx.Bool("allow-partial")
+	versionID := cliCtx.String("version-id")
+	timeRef := parseRewindFlag(cliCtx.String("rewind"))
+	isZip := cliCtx.Bool("zip")
+	meta := teeMetadata(cliCtx)
+
+	ctx, cancelFanOut := context.WithCancel(ctx)
+	defer cancelFanOut()
+
+	var pg ProgressReader
+	if !globalQuiet && !globalJSON {
+		pg = newProgressBar(0)
+	} else {
+		pg = newAccounter(0)
+	}
+
+	copyObject := func(sourceURL string, targets []string) URLs {
+		if ctx.Err() != nil {
+			// The fan-out was stopped by a previous failure.
+			return URLs{}
+		}
+		reader, content, err := getSourceStreamMetadataFromURL(ctx, sourceURL, versionID, timeRef, encKeyDB, isZip)
+		if err != nil {
+			if _, ok := pg.(*progressBar); ok {
+				console.Eraseline()
+			}
+			errorIf(err.Trace(sourceURL), "Unable to read from `%s`.", sourceURL)
+			return URLs{Error: err}
+		}
+		defer reader.Close()
+		if progressReader, ok := pg.(*progressBar); ok {
+			progressReader.SetCaption(sourceURL + ":")
+		}
+		contentType := content.Metadata["Content-Type"]
+		msgs := teeUpload(ctx, sourceURL, hookreader.NewHook(reader, pg), targets, teePut(content.Size, func(target string) PutOptions {
+			return teePutOptions(cliCtx, target, contentType, encKeyDB, meta)
+		}), partial)
+		if !printTeeMessages(msgs, pg) {
+			return URLs{Error: errDummy().Trace(sourceURL)}
+		}
+		return URLs{}
+	}
+
+	statusCh := make(chan URLs)
+	parallel := newParallelManager(statusCh)
+	go func() {
+		defer func() {
+			parallel.stopAndWait()
+			close(statusCh)
+		}()
+
+		var totalBytes int64
+		queue := func(sourceURL string, size int64, targetURLs []string) {
+			totalBytes += size
+			pg.SetTotal(totalBytes)
+			parallel.queueTask(func() URLs {
+				return copyObject(sourceURL, targetURLs)
+			}, size)
+		}
+
+		if !cliCtx.Bool("recursive") {
+			_, content, err := url2Stat(ctx, url2StatOptions{urlStr: source, versionID: versionID, encKeyDB: encKeyDB, timeRef: timeRef, isZip: isZip})
+			if err != nil {
+				errorIf(err.Trace(source), "Unable to read from `%s`.", source)
+				statusCh <- URLs{Error: err}
+				return
+			}
+			targetURLs := make([]string, len(targets))
+			for i, target := range targets {
+				targetURLs[i] = target
+				if isDir, _ := isAliasURLDir(ctx, target, encKeyDB, time.Time{}, false); isDir {
+					targetURLs[i] = urlJoinPath(target, filepath.Base(source))
+				}
+			}
+			queue(source, content.Size, targetURLs)
+			return
+		}
+
+		alias, _, _ := mustExpandAlias(source)
+		clnt, err := newClient(source)
+		if err != nil {
+			errorIf(err.Trace(source), "Unable to initialize `%s`.", source)
+			statusCh <- URLs{Error: err}
+			return
+		}
+		for content := range clnt.List(ctx, ListOptions{Recursive: true, TimeRef: timeRef, ShowDir: DirNone, ListZip: isZip}) {
+			if content.Err != nil {
+				errorIf(content.Err.Trace(source), "Unable to list `%s`.", source)
+				statusCh <- URLs{Error: content.Err}
+				continue
+			}
+			if !content.Type.IsRegular() {
+				continue
+			}
+			// Keep the last element of the source like cp does.
+			suffix := copySourceSuffix(clnt.GetURL(), content.URL)
+			targetURLs := make([]string, len(targets))
+			for i, target := range targets {
+				targetURLs[i] = urlJoinPath(target, suffix)
+			}
+			queue(alias+content.URL.Path, content.Size, targetURLs)
+		}
+	}()
+
+	ok := true
+	for urls := range statusCh {
+		if urls.Error != nil {
+			ok = false
+			if !partial {
+				// Stop listing and skip the objects already queued.
+				cancelFanOut()
+			}
+		}
+	}
+
+	if progressReader, isProgressBar := pg.(*progressBar); isProgressBar {
+		if ok && progressReader.ProgressBar.Get() > 0 {
+			progressReader.Finish()
+		} else {
+			console.Eraseline()
+		}
+	}
+	if !ok || globalContext.Err() != nil {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestTeeUpload(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	if e := os.WriteFile(blocker, nil, 0o644); e != nil {
+		t.Fatal(e)
+	}
+	data := bytes.Repeat([]byte("fan-out"), 100000)
+	put := func(ctx context.Context, target string, r io.Reader) (int64, *probe.Error) {
+		clnt, err := fsNew(target)
+		if err != nil {
+			return 0, err
+		}
+		return clnt.Put(ctx, r, -1, nil, PutOptions{metadata: map[string]string{}})
+	}
+
+	testCases := []struct {
+		targets []string
+		partial bool
+		written []bool
+	}{
+		{[]string{filepath.Join(dir, "a", "1"), filepath.Join(dir, "b", "1")}, false, []bool{true, true}},
+		{[]string{filepath.Join(dir, "a", "2"), filepath.Join(blocker, "2")}, true, []bool{true, false}},
+		{[]string{filepath.Join(dir, "a", "3"), filepath.Join(blocker, "3")}, false, []bool{false, false}},
+	}
+	for i, tc := range testCases {
+		msgs := teeUpload(context.Background(), "stdin", bytes.NewReader(data), tc.targets, put, tc.partial)
+		for j, msg := range msgs {
+			if ok := msg.Error == ""; ok != tc.written[j] {
+				t.Errorf("case %d: target %s: unexpected status %+v", i, msg.Target, msg)
+				continue
+			}
+			if !tc.written[j] {
+				continue
+			}
+			got, e := os.ReadFile(tc.targets[j])
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("case %d: target %s has %d bytes, want %d", i, msg.Target, len(got), len(data))
+			}
+		}
+	}
+}
+
+func TestTeeUploadFailureTarget(t *testing.T) {
+	data := bytes.Repeat([]byte("fan-out"), 100000)
+	put := func(_ context.Context, target string, r io.Reader) (int64, *probe.Error) {
+		switch target {
+		case "short":
+			// Stops reading before the end of the source.
+			n, e := io.CopyN(io.Discard, r, 10)
+			return n, probe.NewError(e)
+		case "fail":
+			return 0, probe.NewError(errors.New("disk full"))
+		}
+		n, e := io.Copy(io.Discard, r)
+		return n, probe.NewError(e)
+	}
+
+	// A target which stops early does not break the others.
+	msgs := teeUpload(context.Background(), "stdin", bytes.NewReader(data), []string{"short", "ok"}, put, false)
+	if msgs[0].Error == "" || msgs[1].Error != "" || msgs[1].Size != int64(len(data)) {
+		t.Errorf("unexpected status %+v", msgs)
+	}
+
+	// The others are reported as canceled by the failed target.
+	msgs = teeUpload(context.Background(), "stdin", bytes.NewReader(data), []string{"ok", "fail"}, put, false)
+	if msgs[1].Error != "disk full" || msgs[0].Error != "canceled, the upload to `fail` failed" {
+		t.Errorf("unexpected status %+v", msgs)
+	}
+}
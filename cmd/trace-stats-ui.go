@@ -44,6 +44,7 @@ type traceStatsUI struct {
 	quitting   bool
 	maxEntries int
 	allFlag    bool
+	offline    bool
 }
 
 func (m *traceStatsUI) Init() tea.Cmd {
@@ -74,8 +75,13 @@ func (m *traceStatsUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *traceStatsUI) View() string {
 	var s strings.Builder
 
-	s.WriteString(fmt.Sprintf("%s %s\n",
-		console.Colorize("metrics-top-title", "Duration: "+time.Since(m.current.Started).Round(time.Second).String()), m.meter.View()))
+	dur := m.current.duration()
+	if m.offline {
+		s.WriteString(console.Colorize("metrics-top-title", "Duration: "+dur.Round(time.Second).String()) + "\n")
+	} else {
+		s.WriteString(fmt.Sprintf("%s %s\n",
+			console.Colorize("metrics-top-title", "Duration: "+dur.Round(time.Second).String()), m.meter.View()))
+	}
 
 	// Set table header - akin to k8s style
 	// https://github.com/olekukonko/tablewriter#example-10---set-nowhitespace-and-tablepadding-option
@@ -99,7 +105,6 @@ func (m *traceStatsUI) View() string {
 		totalRX  = 0
 		totalTX  = 0
 	)
-	dur := time.Since(m.current.Started)
 	for _, v := range m.current.Calls {
 		totalCnt += v.Count
 		totalRX += v.CallStats.Rx
@@ -276,12 +281,7 @@ func roundDur(d time.Duration) time.Duration {
 	return d.Round(time.Microsecond)
 }
 
-func initTraceStatsUI(allFlag bool, maxEntries int, traces <-chan madmin.ServiceTraceInfo) *traceStatsUI {
-	meter := spinner.New()
-	meter.Spinner = spinner.Meter
-	meter.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	// Use half the default fps to reduce flickering
-	meter.Spinner.FPS = time.Second / 3
+func initTraceStatsUIColors() {
 	console.SetColor("metrics-duration", color.New(color.FgWhite))
 	console.SetColor("metrics-size", color.New(color.FgGreen))
 	console.SetColor("metrics-dur", color.New(color.FgGreen))
@@ -293,6 +293,15 @@ func initTraceStatsUI(allFlag bool, maxEntries int, traces <-chan madmin.Service
 	console.SetColor("metrics-number", color.New(color.FgWhite))
 	console.SetColor("metrics-number-secondary", color.New(color.FgBlue))
 	console.SetColor("metrics-zero", color.New(color.FgWhite))
+}
+
+func initTraceStatsUI(allFlag bool, maxEntries int, traces <-chan madmin.ServiceTraceInfo) *traceStatsUI {
+	meter := spinner.New()
+	meter.Spinner = spinner.Meter
+	meter.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	// Use half the default fps to reduce flickering
+	meter.Spinner.FPS = time.Second / 3
+	initTraceStatsUIColors()
 	stats := &statTrace{Calls: make(map[string]statItem, 20), Started: time.Now()}
 	go func() {
 		for t := range traces {
@@ -157,6 +157,8 @@ func NewS3Config(alias, urlStr string, aliasCfg *aliasConfigV10) *Config {
 		s3Config.SessionToken = aliasCfg.SessionToken
 		s3Config.Signature = aliasCfg.API
 		s3Config.Lookup = getLookupType(aliasCfg.Path)
+		s3Config.CredentialSource = aliasCfg.Credentials
+		aliasCfg.options.apply(s3Config)
 	}
 	return s3Config
 }
@@ -0,0 +1,447 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// watchSpoolRecord is a line of the spool file. An event is
+// appended before it is dispatched and a record with Done set
+// is appended once it was delivered.
+type watchSpoolRecord struct {
+	ID    int64      `json:"id"`
+	Done  bool       `json:"done,omitempty"`
+	Event *EventInfo `json:"event,omitempty"`
+}
+
+// watchSpool persists the events which are not delivered yet,
+// so that they are dispatched again after a restart.
+type watchSpool struct {
+	mu       sync.Mutex
+	fileName string
+	f        *os.File
+	nextID   int64
+	// pending holds the events of the spool which are not delivered.
+	pending map[int64]watchSpoolRecord
+	// delivered is the number of events delivered since the
+	// spool was last compacted.
+	delivered int
+}
+
+// watchSpoolCompactDone is the number of delivered events after which
+// the spool is rewritten with the pending events only.
+const watchSpoolCompactDone = 1000
+
+// openWatchSpool opens the spool file and returns the events which
+// were not delivered, in the order they were received. The file is
+// compacted to these events.
+func openWatchSpool(fileName string) (*watchSpool, []watchSpoolRecord, *probe.Error) {
+	var (
+		pending []watchSpoolRecord
+		nextID  int64
+	)
+	if f, e := os.Open(fileName); e == nil {
+		done := make(map[int64]bool)
+		var records []watchSpoolRecord
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			var rec watchSpoolRecord
+			// The last record is incomplete when mc was
+			// killed while appending it, skip it.
+			if gojson.Unmarshal(scanner.Bytes(), &rec) != nil {
+				continue
+			}
+			if rec.Done {
+				done[rec.ID] = true
+				continue
+			}
+			records = append(records, rec)
+			if rec.ID > nextID {
+				nextID = rec.ID
+			}
+		}
+		f.Close()
+		if e = scanner.Err(); e != nil {
+			return nil, nil, probe.NewError(e)
+		}
+		for _, rec := range records {
+			if !done[rec.ID] && rec.Event != nil {
+				pending = append(pending, rec)
+			}
+		}
+	} else if !os.IsNotExist(e) {
+		return nil, nil, probe.NewError(e)
+	}
+
+	if e := os.MkdirAll(filepath.Dir(fileName), 0o700); e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	f, err := rewriteWatchSpool(fileName, pending)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &watchSpool{
+		fileName: fileName,
+		f:        f,
+		nextID:   nextID,
+		pending:  make(map[int64]watchSpoolRecord, len(pending)),
+	}
+	for _, rec := range pending {
+		s.pending[rec.ID] = rec
+	}
+	return s, pending, nil
+}
+
+// rewriteWatchSpool atomically replaces the spool file with records
+// and returns it opened for appending.
+func rewriteWatchSpool(fileName string, records []watchSpoolRecord) (*os.File, *probe.Error) {
+	var buf bytes.Buffer
+	enc := gojson.NewEncoder(&buf)
+	for _, rec := range records {
+		if e := enc.Encode(rec); e != nil {
+			return nil, probe.NewError(e)
+		}
+	}
+	tmp := fileName + ".tmp"
+	if e := os.WriteFile(tmp, buf.Bytes(), 0o600); e != nil {
+		return nil, probe.NewError(e)
+	}
+	if e := os.Rename(tmp, fileName); e != nil {
+		return nil, probe.NewError(e)
+	}
+	f, e := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return f, nil
+}
+
+func (s *watchSpool) write(rec watchSpoolRecord, sync bool) *probe.Error {
+	buf, e := gojson.Marshal(rec)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e = s.f.Write(append(buf, '\n')); e != nil {
+		return probe.NewError(e)
+	}
+	if sync {
+		return probe.NewError(s.f.Sync())
+	}
+	return nil
+}
+
+// compact rewrites the spool with the pending events only.
+func (s *watchSpool) compact() *probe.Error {
+	records := make([]watchSpoolRecord, 0, len(s.pending))
+	for _, rec := range s.pending {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	if e := s.f.Close(); e != nil {
+		return probe.NewError(e)
+	}
+	f, err := rewriteWatchSpool(s.fileName, records)
+	if err != nil {
+		// Keep appending to the uncompacted spool.
+		var e error
+		if s.f, e = os.OpenFile(s.fileName, os.O_WRONLY|os.O_APPEND, 0o600); e != nil {
+			return probe.NewError(e)
+		}
+		return err
+	}
+	s.f = f
+	s.delivered = 0
+	return nil
+}
+
+// add persists a received event before it is dispatched.
+func (s *watchSpool) add(event EventInfo) (watchSpoolRecord, *probe.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	rec := watchSpoolRecord{ID: s.nextID, Event: &event}
+	if err := s.write(rec, true); err != nil {
+		return rec, err
+	}
+	s.pending[rec.ID] = rec
+	return rec, nil
+}
+
+// done records the delivery of an event. It is not synced, a
+// lost record only causes the event to be delivered again. The
+// spool is compacted once enough events were delivered.
+func (s *watchSpool) done(id int64) *probe.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+	if s.delivered++; s.delivered >= watchSpoolCompactDone {
+		return s.compact()
+	}
+	return s.write(watchSpoolRecord{ID: id, Done: true}, false)
+}
+
+func (s *watchSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// watchSpoolFile returns the default spool file of a watch, which is
+// derived from the watched URL and the dispatch target.
+func watchSpoolFile(watchURL, dispatchTarget string) string {
+	name := strconv.FormatUint(uint64(hashWatchKey(watchURL+"\x00"+dispatchTarget)), 16)
+	return filepath.Join(mustGetMcConfigDir(), "watch", name+".spool")
+}
+
+// hashWatchKey is the 32-bit FNV-1a hash of s.
+func hashWatchKey(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
+
+// watchDispatcher delivers events with a bounded number of workers.
+// Events are queued in memory without limit, so that a slow consumer
+// never blocks reading the events, and persisted in the spool.
+type watchDispatcher struct {
+	spool   *watchSpool
+	deliver func(ctx context.Context, event EventInfo) error
+	retries int
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []watchSpoolRecord
+	quit  bool
+	wg    sync.WaitGroup
+}
+
+const (
+	watchRetryMinDelay = time.Second
+	watchRetryMaxDelay = time.Minute
+)
+
+func newWatchDispatcher(spool *watchSpool, retries int, deliver func(ctx context.Context, event EventInfo) error) *watchDispatcher {
+	d := &watchDispatcher{spool: spool, retries: retries, deliver: deliver}
+	d.cond = sync.NewCond(&d.mu)
+	return d
+}
+
+// start starts the workers, which stop when ctx is canceled.
+func (d *watchDispatcher) start(ctx context.Context, workers int) {
+	go func() {
+		<-ctx.Done()
+		d.mu.Lock()
+		d.quit = true
+		d.mu.Unlock()
+		d.cond.Broadcast()
+	}()
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				d.mu.Lock()
+				for len(d.queue) == 0 && !d.quit {
+					d.cond.Wait()
+				}
+				if d.quit {
+					d.mu.Unlock()
+					return
+				}
+				rec := d.queue[0]
+				d.queue = d.queue[1:]
+				d.mu.Unlock()
+				d.dispatch(ctx, rec)
+			}
+		}()
+	}
+}
+
+// enqueue queues an event which is already in the spool.
+func (d *watchDispatcher) enqueue(rec watchSpoolRecord) {
+	d.mu.Lock()
+	d.queue = append(d.queue, rec)
+	d.mu.Unlock()
+	d.cond.Signal()
+}
+
+// add persists and queues a new event.
+func (d *watchDispatcher) add(event EventInfo) *probe.Error {
+	rec, err := d.spool.add(event)
+	if err != nil {
+		return err
+	}
+	d.enqueue(rec)
+	return nil
+}
+
+// wait waits for the workers to stop.
+func (d *watchDispatcher) wait() {
+	d.wg.Wait()
+}
+
+// dispatch delivers an event, retrying with exponential backoff. An
+// event that can not be delivered stays in the spool and is retried
+// the next time the watch starts.
+func (d *watchDispatcher) dispatch(ctx context.Context, rec watchSpoolRecord) {
+	delay := watchRetryMinDelay
+	var e error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		if attempt > 0 {
+			clientRetries.Inc()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(2*delay, watchRetryMaxDelay)
+		}
+		if e = d.deliver(ctx, *rec.Event); e == nil {
+			errorIf(d.spool.done(rec.ID), "Unable to update the event spool.")
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	errorIf(probe.NewError(e).Trace(rec.Event.Path), "Unable to deliver the `%s` event for `%s`, it stays in the spool.", rec.Event.Type, rec.Event.Path)
+}
+
+// watchEventPayload returns the message printed for an event,
+// its JSON form is the payload posted to a webhook.
+func watchEventPayload(event EventInfo) watchMessage {
+	msg := watchMessage{Status: "success"}
+	msg.Event.Path = event.Path
+	msg.Event.Size = event.Size
+	msg.Event.Time = event.Time
+	msg.Event.Type = event.Type
+	msg.Event.Synthetic = event.Synthetic
+	msg.Source.Host = event.Host
+	msg.Source.Port = event.Port
+	msg.Source.UserAgent = event.UserAgent
+	return msg
+}
+
+// newWatchWebhook returns a function posting events to url.
+func newWatchWebhook(url string, client *http.Client) func(ctx context.Context, event EventInfo) error {
+	return func(ctx context.Context, event EventInfo) error {
+		buf, e := gojson.Marshal(watchEventPayload(event))
+		if e != nil {
+			return e
+		}
+		req, e := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+		if e != nil {
+			return e
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, e := client.Do(req)
+		if e != nil {
+			return e
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// watchExecEvent holds the shell quoted event fields which
+// can be used in an --exec command template.
+type watchExecEvent struct {
+	Path, Type, Size, Time, Host string
+}
+
+// watchExecQuote quotes s as a single argument for the shell used by --exec,
+// unlike shellQuote it also protects globbing and escape characters.
+func watchExecQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// newWatchExec returns a function running the command template for
+// an event. The event is also passed in MC_EVENT_* environment variables.
+func newWatchExec(command string) (func(ctx context.Context, event EventInfo) error, *probe.Error) {
+	tmpl, e := template.New("exec").Option("missingkey=error").Parse(command)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return func(ctx context.Context, event EventInfo) error {
+		size := strconv.FormatInt(event.Size, 10)
+		var cmdLine strings.Builder
+		if e := tmpl.Execute(&cmdLine, watchExecEvent{
+			Path: watchExecQuote(event.Path),
+			Type: watchExecQuote(string(event.Type)),
+			Size: size,
+			Time: watchExecQuote(event.Time),
+			Host: watchExecQuote(event.Host),
+		}); e != nil {
+			return e
+		}
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", cmdLine.String())
+		} else {
+			cmd = exec.CommandContext(ctx, "/bin/sh", "-c", cmdLine.String())
+		}
+		cmd.Env = append(os.Environ(),
+			"MC_EVENT_PATH="+event.Path,
+			"MC_EVENT_TYPE="+string(event.Type),
+			"MC_EVENT_SIZE="+size,
+			"MC_EVENT_TIME="+event.Time,
+			"MC_EVENT_HOST="+event.Host,
+		)
+		var stderr bytes.Buffer
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = &stderr
+		if e := cmd.Run(); e != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return errors.New(msg)
+			}
+			return e
+		}
+		return nil
+	}, nil
+}
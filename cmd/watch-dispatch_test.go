@@ -0,0 +1,173 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWatchSpool(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "watch.spool")
+	spool, pending, err := openWatchSpool(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected an empty spool, got %d events", len(pending))
+	}
+	for _, p := range []string{"a", "b", "c"} {
+		if _, err = spool.add(EventInfo{Path: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = spool.done(2); err != nil {
+		t.Fatal(err)
+	}
+	spool.Close()
+
+	// Simulate a record cut short by a crash.
+	f, e := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		t.Fatal(e)
+	}
+	f.WriteString(`{"id":4,"event":{"Pa`)
+	f.Close()
+
+	spool, pending, err = openWatchSpool(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	if len(pending) != 2 || pending[0].Event.Path != "a" || pending[1].Event.Path != "c" {
+		t.Fatalf("unexpected pending events %+v", pending)
+	}
+	rec, err := spool.add(EventInfo{Path: "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ID != 4 {
+		t.Fatalf("expected id 4, got %d", rec.ID)
+	}
+}
+
+func TestWatchSpoolCompact(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "watch.spool")
+	spool, _, err := openWatchSpool(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	for i := 0; i < watchSpoolCompactDone+2; i++ {
+		rec, err := spool.add(EventInfo{Path: strconv.Itoa(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < watchSpoolCompactDone {
+			if err = spool.done(rec.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The spool was compacted when the last event was delivered.
+	buf, e := os.ReadFile(fileName)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if lines := strings.Count(string(buf), "\n"); lines != 2 {
+		t.Fatalf("expected 2 records after compaction, got %d", lines)
+	}
+	_, pending, err := openWatchSpool(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Event.Path != strconv.Itoa(watchSpoolCompactDone) {
+		t.Fatalf("unexpected pending events %+v", pending)
+	}
+}
+
+func TestWatchDispatcher(t *testing.T) {
+	spool, _, err := openWatchSpool(filepath.Join(t.TempDir(), "watch.spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+		wg        sync.WaitGroup
+	)
+	d := newWatchDispatcher(spool, 0, func(_ context.Context, event EventInfo) error {
+		defer wg.Done()
+		if event.Path == "fail" {
+			return errors.New("failed")
+		}
+		mu.Lock()
+		delivered = append(delivered, event.Path)
+		mu.Unlock()
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	d.start(ctx, 2)
+	wg.Add(3)
+	for _, p := range []string{"a", "fail", "b"} {
+		if err := d.add(EventInfo{Path: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	cancel()
+	d.wait()
+	spool.Close()
+
+	if len(delivered) != 2 {
+		t.Fatalf("expected 2 delivered events, got %v", delivered)
+	}
+	_, pending, err := openWatchSpool(spool.f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Event.Path != "fail" {
+		t.Fatalf("expected the failed event in the spool, got %+v", pending)
+	}
+}
+
+func TestWatchExecQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell only")
+	}
+	for _, s := range []string{"a b", "it's", "$(rm -rf /)", "*.txt", `back\slash`} {
+		out, e := exec.Command("/bin/sh", "-c", "printf %s "+watchExecQuote(s)).Output()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(out) != s {
+			t.Errorf("expected %q, got %q", s, out)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
@@ -50,6 +51,32 @@ var watchFlags = []cli.Flag{
 		Name:  "recursive",
 		Usage: "recursively watch for events",
 	},
+	cli.StringFlag{
+		Name:  "exec",
+		Usage: "run a command template for each event, e.g. 'convert {{.Path}}', its output is written to stderr",
+	},
+	cli.StringFlag{
+		Name:  "webhook",
+		Usage: "POST each event as JSON to a webhook URL",
+	},
+	cli.IntFlag{
+		Name:  "concurrency",
+		Value: 4,
+		Usage: "number of events dispatched in parallel with --exec or --webhook",
+	},
+	cli.IntFlag{
+		Name:  "retries",
+		Value: 5,
+		Usage: "number of retries with exponential backoff of a failed dispatch",
+	},
+	cli.StringFlag{
+		Name:  "state",
+		Usage: "file recording the last processed event, objects created while not watching are emitted on restart",
+	},
+	cli.StringFlag{
+		Name:  "spool",
+		Usage: "file of the events not yet dispatched, defaults to a file in the config folder",
+	},
 }
 
 var watchCmd = cli.Command{
@@ -86,6 +113,19 @@ EXAMPLES:
 
   6. Watch for events on local directory.
      {{.Prompt}} {{.HelpName}} /usr/share
+
+  7. Run a command for each new ".csv" object, the event is also passed in MC_EVENT_PATH, MC_EVENT_TYPE,
+     MC_EVENT_SIZE, MC_EVENT_TIME and MC_EVENT_HOST environment variables. The standard output of the
+     command is written to the standard error of mc, so that it does not mix with the --json events.
+     {{.Prompt}} {{.HelpName}} --events put --suffix ".csv" --exec "./ingest.sh {{"{{"}}.Path{{"}}"}} {{"{{"}}.Size{{"}}"}}" play/testbucket
+
+  8. POST events of a local ingest folder to a webhook, events not yet delivered are kept in the spool
+     and delivered when the watch is restarted.
+     {{.Prompt}} {{.HelpName}} --recursive --webhook https://hooks.example.com/ingest /mnt/ingest
+
+  9. Watch new objects without missing any across restarts and reconnects. Objects created in the meantime
+     are emitted as events flagged as synthetic before the live events.
+     {{.Prompt}} {{.HelpName}} --events put --state ~/.mc/indexer.state play/testbucket
 `,
 }
 
@@ -94,16 +134,23 @@ func checkWatchSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 1 {
 		showCommandHelpAndExit(ctx, 1) // last argument is exit code
 	}
+	if ctx.String("exec") != "" && ctx.String("webhook") != "" {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--exec and --webhook cannot be used together.")
+	}
+	if ctx.Int("concurrency") < 1 || ctx.Int("retries") < 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "--concurrency must be positive and --retries must not be negative.")
+	}
 }
 
 // watchMessage container to hold one event notification
 type watchMessage struct {
 	Status string `json:"status"`
 	Event  struct {
-		Time string                 `json:"time"`
-		Size int64                  `json:"size"`
-		Path string                 `json:"path"`
-		Type notification.EventType `json:"type"`
+		Time      string                 `json:"time"`
+		Size      int64                  `json:"size"`
+		Path      string                 `json:"path"`
+		Type      notification.EventType `json:"type"`
+		Synthetic bool                   `json:"synthetic,omitempty"`
 	} `json:"events"`
 	Source struct {
 		Host      string `json:"host,omitempty"`
@@ -128,6 +175,9 @@ func (u watchMessage) String() string {
 	}
 	msg += console.Colorize("EventType", fmt.Sprintf("%s ", u.Event.Type))
 	msg += console.Colorize("ObjectName", u.Event.Path)
+	if u.Event.Synthetic {
+		msg += console.Colorize("Synthetic", " (synthetic)")
+	}
 	return msg
 }
 
@@ -136,6 +186,7 @@ func mainWatch(cliCtx *cli.Context) error {
 	console.SetColor("Size", color.New(color.FgYellow))
 	console.SetColor("EventType", color.New(color.FgCyan, color.Bold))
 	console.SetColor("ObjectName", color.New(color.Bold))
+	console.SetColor("Synthetic", color.New(color.FgYellow))
 
 	checkWatchSyntax(cliCtx)
 
@@ -162,56 +213,138 @@ func mainWatch(cliCtx *cli.Context) error {
 	ctx, cancelWatch := context.WithCancel(globalContext)
 	defer cancelWatch()
 
-	// Start watching on events
-	wo, err := s3Client.Watch(ctx, options)
-	fatalIf(err, "Unable to watch on the specified bucket.")
+	dispatcher := newWatchDispatcherFromContext(ctx, cliCtx, path)
 
-	// Initialize.. waitgroup to track the go-routine.
-	var wg sync.WaitGroup
+	state := loadWatchStateFromContext(cliCtx)
+	handleEvent := func(event EventInfo) {
+		printMsg(watchEventPayload(event))
+		if dispatcher != nil {
+			fatalIf(dispatcher.add(event), "Unable to add the event to the spool.")
+		}
+		errorIf(state.observe(event), "Unable to save the watch state.")
+	}
 
-	// Increment wait group to wait subsequent routine.
-	wg.Add(1)
+watch:
+	for {
+		// Start watching on events
+		wo, err := s3Client.Watch(ctx, options)
+		if err != nil && state != nil && ctx.Err() == nil {
+			// Keep trying, the gap is reconciled once connected.
+			errorIf(err, "Unable to watch on the specified bucket.")
+			select {
+			case <-ctx.Done():
+				break watch
+			case <-time.After(watchReconnectDelay):
+			}
+			continue
+		}
+		fatalIf(err, "Unable to watch on the specified bucket.")
 
-	// Start routine to watching on events.
-	go func() {
-		defer wg.Done()
+		// Emit the objects created since the last processed event
+		// once the live stream is established, so that no events
+		// are missed in between.
+		if state != nil {
+			err = reconcileWatch(ctx, s3Client, state.since(), options, handleEvent)
+			errorIf(err, "Unable to list the objects created since the last event.")
+		}
 
-		// Wait for all events.
-		for {
-			select {
-			case <-globalContext.Done():
-				// Signal received we are done.
-				close(wo.DoneChan)
-				return
-			case events, ok := <-wo.Events():
-				if !ok {
-					return
-				}
-				for _, event := range events {
-					msg := watchMessage{}
-					msg.Event.Path = event.Path
-					msg.Event.Size = event.Size
-					msg.Event.Time = event.Time
-					msg.Event.Type = event.Type
-					msg.Source.Host = event.Host
-					msg.Source.Port = event.Port
-					msg.Source.UserAgent = event.UserAgent
-					printMsg(msg)
-				}
-			case err, ok := <-wo.Errors():
-				if !ok {
-					return
-				}
-				if err != nil {
-					errorIf(err, "Unable to watch for events.")
+		// Initialize.. waitgroup to track the go-routine.
+		var wg sync.WaitGroup
+
+		// Increment wait group to wait subsequent routine.
+		wg.Add(1)
+
+		watchFailed := false
+
+		// Start routine to watching on events.
+		go func() {
+			defer wg.Done()
+
+			// Wait for all events.
+			for {
+				select {
+				case <-globalContext.Done():
+					// Signal received we are done.
+					close(wo.DoneChan)
 					return
+				case events, ok := <-wo.Events():
+					if !ok {
+						return
+					}
+					for _, event := range events {
+						handleEvent(event)
+					}
+				case err, ok := <-wo.Errors():
+					if !ok {
+						return
+					}
+					if err != nil {
+						errorIf(err, "Unable to watch for events.")
+						close(wo.DoneChan)
+						watchFailed = true
+						return
+					}
 				}
 			}
-		}
-	}()
+		}()
 
-	// Wait on the routine to be finished or exit.
-	wg.Wait()
+		// Wait on the routine to be finished or exit.
+		wg.Wait()
 
+		if state == nil || !watchFailed || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break watch
+		case <-time.After(watchReconnectDelay):
+		}
+	}
+	errorIf(state.save(), "Unable to save the watch state.")
+
+	if dispatcher != nil {
+		cancelWatch()
+		dispatcher.wait()
+		errorIf(probe.NewError(dispatcher.spool.Close()), "Unable to close the event spool.")
+	}
 	return nil
 }
+
+// newWatchDispatcherFromContext starts dispatching events with --exec or
+// --webhook, beginning with the events left in the spool. It returns nil
+// when events are only printed.
+func newWatchDispatcherFromContext(ctx context.Context, cliCtx *cli.Context, path string) *watchDispatcher {
+	var (
+		deliver func(ctx context.Context, event EventInfo) error
+		target  string
+	)
+	switch {
+	case cliCtx.String("exec") != "":
+		target = cliCtx.String("exec")
+		var err *probe.Error
+		deliver, err = newWatchExec(target)
+		fatalIf(err.Trace(target), "Unable to parse the --exec command template.")
+	case cliCtx.String("webhook") != "":
+		target = cliCtx.String("webhook")
+		deliver = newWatchWebhook(target, httpClient(30*time.Second))
+	default:
+		return nil
+	}
+
+	spoolFile := cliCtx.String("spool")
+	if spoolFile == "" {
+		spoolFile = watchSpoolFile(path, target)
+	}
+	spool, pending, err := openWatchSpool(spoolFile)
+	fatalIf(err.Trace(spoolFile), "Unable to open the event spool.")
+	if len(pending) > 0 && !globalJSON && !globalQuiet {
+		console.Infof("Dispatching %d events left in the spool `%s`.\n", len(pending), spoolFile)
+	}
+
+	dispatcher := newWatchDispatcher(spool, cliCtx.Int("retries"), deliver)
+	for _, rec := range pending {
+		dispatcher.enqueue(rec)
+	}
+	dispatcher.start(ctx, cliCtx.Int("concurrency"))
+	return dispatcher
+}
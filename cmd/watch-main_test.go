@@ -0,0 +1,38 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/minio/cli"
+)
+
+func TestWatchHelp(t *testing.T) {
+	var buf bytes.Buffer
+	cli.HelpPrinter(&buf, watchCmd.CustomHelpTemplate, watchCmd)
+	help := buf.String()
+	if !strings.Contains(help, `--exec "./ingest.sh {{.Path}} {{.Size}}"`) {
+		t.Errorf("expected the --exec template in example 7, got:\n%s", help)
+	}
+	if !strings.Contains(help, "--state ~/.mc/indexer.state") {
+		t.Errorf("expected the last example, got:\n%s", help)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	gojson "encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const (
+	// watchStateSaveInterval limits how often the state is saved, a
+	// state older than the last event only causes duplicate events.
+	watchStateSaveInterval = time.Second
+
+	// watchReconnectDelay is the delay before watching again
+	// after the live stream failed.
+	watchReconnectDelay = 5 * time.Second
+)
+
+// watchState records the time of the last processed event.
+type watchState struct {
+	LastEventTime time.Time `json:"lastEventTime"`
+
+	mu       sync.Mutex
+	fileName string
+	saved    time.Time
+}
+
+// loadWatchStateFromContext loads the --state file, it returns nil
+// when --state is not set. A new state starts at the current time.
+func loadWatchStateFromContext(cliCtx *cli.Context) *watchState {
+	fileName := cliCtx.String("state")
+	if fileName == "" {
+		return nil
+	}
+	state, err := loadWatchState(fileName)
+	fatalIf(err.Trace(fileName), "Unable to load the watch state.")
+	return state
+}
+
+func loadWatchState(fileName string) (*watchState, *probe.Error) {
+	state := &watchState{fileName: fileName}
+	buf, e := os.ReadFile(fileName)
+	switch {
+	case os.IsNotExist(e):
+		state.LastEventTime = time.Now().UTC()
+		return state, state.save()
+	case e != nil:
+		return nil, probe.NewError(e)
+	}
+	if e = gojson.Unmarshal(buf, state); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return state, nil
+}
+
+// since returns the time from which missed objects must be listed.
+func (s *watchState) since() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LastEventTime
+}
+
+// observe advances the state to the time of a processed event.
+// Synthetic events are emitted in listing order, not in time order,
+// they do not advance the state so that an interrupted reconciliation
+// starts over.
+func (s *watchState) observe(event EventInfo) *probe.Error {
+	if s == nil || event.Synthetic {
+		return nil
+	}
+	t, e := time.Parse(time.RFC3339Nano, event.Time)
+	if e != nil {
+		return nil
+	}
+	s.mu.Lock()
+	if t.After(s.LastEventTime) {
+		s.LastEventTime = t.UTC()
+	}
+	due := time.Since(s.saved) >= watchStateSaveInterval
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return s.save()
+}
+
+// save writes the state, it is a no-op for a nil state.
+func (s *watchState) save() *probe.Error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, e := gojson.Marshal(s)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if dir := filepath.Dir(s.fileName); dir != "" {
+		if e = os.MkdirAll(dir, 0o700); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	tmp := s.fileName + ".tmp"
+	if e = os.WriteFile(tmp, buf, 0o600); e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.Rename(tmp, s.fileName); e != nil {
+		return probe.NewError(e)
+	}
+	s.saved = time.Now()
+	return nil
+}
+
+// reconcileWatch emits synthetic 'put' events for the objects matching
+// the watch options which were created or modified since the given time.
+// Deleted objects can not be found by listing and are not reconciled.
+func reconcileWatch(ctx context.Context, clnt Client, since time.Time, options WatchOptions, emit func(EventInfo)) *probe.Error {
+	if since.IsZero() || !watchesEvent(options.Events, "put") {
+		return nil
+	}
+	_, isS3 := clnt.(*S3Client)
+	root := strings.TrimSuffix(filepath.ToSlash(clnt.GetURL().Path), "/") + "/"
+	for content := range clnt.List(ctx, ListOptions{Recursive: isS3 || options.Recursive, ShowDir: DirNone}) {
+		if content.Err != nil {
+			return content.Err.Trace(clnt.GetURL().String())
+		}
+		if content.Time.Before(since) {
+			continue
+		}
+		var name string
+		if isS3 {
+			_, name = url2BucketAndObject(&content.URL)
+		} else {
+			// Listing a directory without a trailing separator
+			// also lists its siblings sharing the name as prefix.
+			p := filepath.ToSlash(content.URL.Path)
+			if !strings.HasPrefix(p, root) {
+				continue
+			}
+			name = strings.TrimPrefix(p, root)
+		}
+		if !strings.HasPrefix(name, options.Prefix) || !strings.HasSuffix(name, options.Suffix) {
+			continue
+		}
+		emit(EventInfo{
+			Time:      content.Time.UTC().Format(time.RFC3339Nano),
+			Size:      content.Size,
+			Path:      content.URL.String(),
+			Type:      notification.ObjectCreatedPut,
+			Synthetic: true,
+		})
+	}
+	return nil
+}
+
+func watchesEvent(events []string, event string) bool {
+	for _, e := range events {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchState(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "watch.state")
+	state, err := loadWatchState(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.since().IsZero() {
+		t.Fatal("expected a new state to start now")
+	}
+
+	eventTime := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+	state.saved = time.Time{}
+	if err = state.observe(EventInfo{Time: eventTime.Format(time.RFC3339Nano)}); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.observe(EventInfo{Time: eventTime.Add(time.Hour).Format(time.RFC3339Nano), Synthetic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = loadWatchState(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.since().Equal(eventTime) {
+		t.Fatalf("expected %s, got %s", eventTime, state.since())
+	}
+}
+
+func TestReconcileWatch(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "watched")
+	for _, name := range []string{"old.txt", "new.txt", "new.jpg", "sub/new.txt"} {
+		fileName := filepath.Join(root, name)
+		if e := os.MkdirAll(filepath.Dir(fileName), 0o700); e != nil {
+			t.Fatal(e)
+		}
+		if e := os.WriteFile(fileName, []byte("data"), 0o600); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := os.WriteFile(root+".state", []byte("{}"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	since := time.Now().Add(-time.Minute)
+	if e := os.Chtimes(filepath.Join(root, "old.txt"), since.Add(-time.Hour), since.Add(-time.Hour)); e != nil {
+		t.Fatal(e)
+	}
+
+	clnt, err := fsNew(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	err = reconcileWatch(context.Background(), clnt, since, WatchOptions{Events: []string{"put"}, Suffix: ".txt", Recursive: true}, func(event EventInfo) {
+		if !event.Synthetic {
+			t.Errorf("expected a synthetic event for %s", event.Path)
+		}
+		rel, _ := filepath.Rel(root, event.Path)
+		got = append(got, filepath.ToSlash(rel))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "new.txt" || got[1] != "sub/new.txt" {
+		t.Fatalf("unexpected reconciled events %v", got)
+	}
+}
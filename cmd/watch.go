@@ -37,6 +37,9 @@ type EventInfo struct {
 	Port         string
 	UserAgent    string
 	Type         notification.EventType
+	// Synthetic is set for events of objects created
+	// while not watching, found by listing.
+	Synthetic bool
 }
 
 // WatchOptions contains watch configuration options
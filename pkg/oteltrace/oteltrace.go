@@ -0,0 +1,335 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package oteltrace implements a minimal OpenTelemetry tracer which
+// exports spans in the OTLP/JSON encoding, either to an OTLP/HTTP
+// collector or to a local file.
+//
+// All methods are safe to call on a nil *Tracer and a nil *Span,
+// so callers do not need to check whether tracing is enabled.
+package oteltrace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship of a span to its parent.
+type SpanKind int
+
+// Span kinds as defined by OTLP.
+const (
+	KindInternal SpanKind = 1
+	KindClient   SpanKind = 3
+)
+
+// statusError is the OTLP status code of failed spans.
+const statusError = 2
+
+// Attribute is a key value pair attached to a span.
+// Value must be one of string, int64, float64 or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+type (
+	traceID [16]byte
+	spanID  [8]byte
+)
+
+// Span is a single timed operation.
+type Span struct {
+	tracer   *Tracer
+	traceID  traceID
+	spanID   spanID
+	parentID spanID
+	name     string
+	kind     SpanKind
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attrs      []Attribute
+	statusCode int
+	statusMsg  string
+	ended      bool
+	resends    map[string]int64
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetName replaces the name of the span.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetError marks the span as failed, a nil error is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = statusError
+	s.statusMsg = err.Error()
+}
+
+// End completes the span and queues it for export.
+// Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.queue(s)
+}
+
+// TraceParent returns the W3C trace context header value of the span.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+// resend returns how many times a request identified
+// by key was sent before as a child of this span.
+func (s *Span) resend(key string) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resends == nil {
+		s.resends = make(map[string]int64)
+	}
+	n := s.resends[key]
+	s.resends[key] = n + 1
+	return n
+}
+
+type spanCtxKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span as the current span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanCtxKey{}, span)
+}
+
+// SpanFromContext returns the current span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanCtxKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns a copy of ctx whose next span continues
+// the trace described by a W3C 'traceparent' header value, such as one
+// handed over by a calling process in the TRACEPARENT environment variable.
+func ContextWithRemoteParent(ctx context.Context, traceParent string) (context.Context, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx, errors.New("invalid traceparent " + traceParent)
+	}
+	parent := &Span{ended: true}
+	if _, err := hex.Decode(parent.traceID[:], []byte(parts[1])); err != nil {
+		return ctx, err
+	}
+	if _, err := hex.Decode(parent.spanID[:], []byte(parts[2])); err != nil {
+		return ctx, err
+	}
+	if parent.traceID == (traceID{}) || parent.spanID == (spanID{}) {
+		return ctx, errors.New("invalid traceparent " + traceParent)
+	}
+	return ContextWithSpan(ctx, parent), nil
+}
+
+const (
+	maxQueuedSpans = 512
+	flushInterval  = 5 * time.Second
+)
+
+// Tracer creates spans and exports them in batches.
+type Tracer struct {
+	exporter Exporter
+	resource []Attribute
+	scope    string
+	version  string
+
+	mu      sync.Mutex
+	pending []*Span
+	flushCh chan struct{}
+	doneCh  chan struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// New returns a tracer which exports its spans with exporter
+// on behalf of serviceName at the given version.
+func New(exporter Exporter, serviceName, version string) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		resource: []Attribute{
+			String("service.name", serviceName),
+			String("service.version", version),
+		},
+		scope:   "github.com/minio/mc",
+		version: version,
+		flushCh: make(chan struct{}, 1),
+		doneCh:  make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t
+}
+
+// Start creates a new span as a child of the current span of ctx
+// and returns a copy of ctx carrying the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) queue(s *Span) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.pending = append(t.pending, s)
+	if len(t.pending) >= maxQueuedSpans {
+		select {
+		case t.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.doneCh:
+			return
+		case <-ticker.C:
+		case <-t.flushCh:
+		}
+		t.flush(context.Background())
+	}
+}
+
+func (t *Tracer) flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	payload, err := t.encode(spans)
+	if err != nil {
+		return err
+	}
+	return t.exporter.Export(ctx, payload)
+}
+
+// Shutdown exports all pending spans and closes the exporter.
+// Spans ending after Shutdown are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	close(t.doneCh)
+	t.wg.Wait()
+
+	err := t.flush(ctx)
+	if cerr := t.exporter.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package oteltrace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type memExporter struct {
+	mu       sync.Mutex
+	requests []otlpTraceRequest
+}
+
+func (m *memExporter) Export(_ context.Context, payload []byte) error {
+	var req otlpTraceRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	return nil
+}
+
+func (m *memExporter) Close() error { return nil }
+
+func (m *memExporter) spans() []otlpSpan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var spans []otlpSpan
+	for _, req := range m.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func TestTransport(t *testing.T) {
+	var gotParent string
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotParent = r.Header.Get("traceparent")
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	exp := &memExporter{}
+	tracer := New(exp, "mc", "test")
+	ctx, root := tracer.Start(context.Background(), "cp", KindInternal)
+	client := &http.Client{Transport: Transport{Tracer: tracer, Transport: http.DefaultTransport}}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/bucket/object?partNumber=2&uploadId=abc", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	root.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exp.spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	rootSpan := spans[2]
+	if rootSpan.Name != "cp" || rootSpan.ParentSpanID != "" {
+		t.Fatalf("unexpected root span %+v", rootSpan)
+	}
+	for i, s := range spans[:2] {
+		if s.TraceID != rootSpan.TraceID || s.ParentSpanID != rootSpan.SpanID {
+			t.Errorf("span %d is not a child of the root span", i)
+		}
+		if s.Kind != KindClient {
+			t.Errorf("span %d: expected client kind, got %d", i, s.Kind)
+		}
+		attrs := map[string]otlpAnyValue{}
+		for _, kv := range s.Attributes {
+			attrs[kv.Key] = kv.Value
+		}
+		if v := attrs["aws.s3.part_number"].IntValue; v == nil || *v != "2" {
+			t.Errorf("span %d: missing part number", i)
+		}
+		_, resent := attrs["http.request.resend_count"]
+		if resent != (i == 1) {
+			t.Errorf("span %d: unexpected resend count", i)
+		}
+	}
+	if spans[0].Status.Code != statusError || spans[1].Status.Code != 0 {
+		t.Errorf("unexpected span status %+v %+v", spans[0].Status, spans[1].Status)
+	}
+	if want := "00-" + spans[1].TraceID + "-" + spans[1].SpanID + "-01"; gotParent != want {
+		t.Errorf("expected traceparent %s, got %s", want, gotParent)
+	}
+}
+
+func TestRemoteParent(t *testing.T) {
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := ContextWithRemoteParent(context.Background(), parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &memExporter{}
+	tracer := New(exp, "mc", "test")
+	_, span := tracer.Start(ctx, "mirror", KindInternal)
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	spans := exp.spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spans[0].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("span does not continue remote trace: %+v", spans[0])
+	}
+
+	for _, invalid := range []string{"", "00-abc-def-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		if _, err := ContextWithRemoteParent(context.Background(), invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "noop", KindInternal)
+	span.SetAttributes(String("k", "v"))
+	span.End()
+	if SpanFromContext(ctx) != nil {
+		t.Fatal("expected no span in context")
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
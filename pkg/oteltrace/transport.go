@@ -0,0 +1,108 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package oteltrace
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Transport creates a client span for every HTTP round trip, as a child
+// of the span carried by the request context. The W3C trace context is
+// propagated to the server with the 'traceparent' header.
+type Transport struct {
+	Tracer    *Tracer
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Transport == nil {
+		return nil, errors.New("Invalid Argument")
+	}
+	if t.Tracer == nil {
+		return t.Transport.RoundTrip(req)
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+	attrs := []Attribute{
+		String("http.request.method", req.Method),
+		String("url.full", u.String()),
+		String("server.address", req.URL.Hostname()),
+	}
+	query := req.URL.Query()
+	if part := query.Get("partNumber"); part != "" {
+		if n, err := strconv.ParseInt(part, 10, 64); err == nil {
+			attrs = append(attrs, Int("aws.s3.part_number", n))
+		}
+	}
+	if uploadID := query.Get("uploadId"); uploadID != "" {
+		attrs = append(attrs, String("aws.s3.upload_id", uploadID))
+	}
+	if n := SpanFromContext(req.Context()).resend(req.Method + " " + req.URL.String()); n > 0 {
+		attrs = append(attrs, Int("http.request.resend_count", n))
+	}
+
+	ctx, span := t.Tracer.Start(req.Context(), req.Method, KindClient, attrs...)
+	req = req.Clone(ctx)
+	req.Header.Set("traceparent", span.TraceParent())
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		span.End()
+		return resp, err
+	}
+	span.SetAttributes(Int("http.response.status_code", int64(resp.StatusCode)))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetError(errors.New(resp.Status))
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		span.End()
+		return resp, nil
+	}
+	// End the span once the response body is consumed,
+	// so that downloads are accounted for entirely.
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+type spanBody struct {
+	io.ReadCloser
+	span *Span
+	once sync.Once
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.span.End)
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.span.End)
+	return err
+}
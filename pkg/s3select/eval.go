@@ -0,0 +1,649 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// env is the evaluation context of an expression.
+type env struct {
+	rec   any
+	alias string
+	// aggs holds the results of the aggregate functions once all
+	// records have been processed.
+	aggs []any
+}
+
+type expr interface {
+	eval(e *env) (any, error)
+}
+
+type literal struct {
+	v any
+}
+
+func (l *literal) eval(*env) (any, error) {
+	return l.v, nil
+}
+
+// pathElem is one step of a path into a record: a field name, an
+// array index or the [*] wildcard (FROM clause only).
+type pathElem struct {
+	name     string
+	quoted   bool
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (p pathElem) apply(v any) any {
+	if p.isIndex {
+		if arr, ok := v.([]any); ok && p.index < len(arr) {
+			return arr[p.index]
+		}
+		return Missing
+	}
+	if obj, ok := v.(*Object); ok {
+		v, _ := obj.Lookup(p.name, p.quoted)
+		return v
+	}
+	return Missing
+}
+
+type columnRef struct {
+	path []pathElem
+}
+
+func (c *columnRef) eval(e *env) (any, error) {
+	path := c.path
+	if len(path) > 1 && e.alias != "" && strings.EqualFold(path[0].name, e.alias) {
+		path = path[1:]
+	}
+	v := e.rec
+	for _, p := range path {
+		v = p.apply(v)
+	}
+	return v, nil
+}
+
+// evalBool evaluates x as a condition. A nil result means unknown.
+func evalBool(x expr, e *env) (any, error) {
+	v, err := x.eval(e)
+	if err != nil || isNull(v) {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, e := strconv.ParseBool(v); e == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a boolean value", FormatValue(v))
+}
+
+type logicalExpr struct {
+	or   bool
+	l, r expr
+}
+
+func (x *logicalExpr) eval(e *env) (any, error) {
+	l, err := evalBool(x.l, e)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit when the left side decides the result.
+	if l == x.or {
+		return l, nil
+	}
+	r, err := evalBool(x.r, e)
+	if err != nil {
+		return nil, err
+	}
+	if r == x.or {
+		return r, nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	return !x.or, nil
+}
+
+type notExpr struct {
+	x expr
+}
+
+func (x *notExpr) eval(e *env) (any, error) {
+	v, err := evalBool(x.x, e)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return !v.(bool), nil
+}
+
+type compareExpr struct {
+	op   string
+	l, r expr
+}
+
+func (x *compareExpr) eval(e *env) (any, error) {
+	l, err := x.l.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	r, err := x.r.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if isNull(l) || isNull(r) {
+		return nil, nil
+	}
+	c, ok := compareValues(l, r)
+	if !ok {
+		// Values of different types are never equal.
+		switch x.op {
+		case "=":
+			return false, nil
+		case "!=", "<>":
+			return true, nil
+		}
+		return nil, nil
+	}
+	switch x.op {
+	case "=":
+		return c == 0, nil
+	case "!=", "<>":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+type isExpr struct {
+	x       expr
+	not     bool
+	missing bool
+}
+
+func (x *isExpr) eval(e *env) (any, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	res := isNull(v)
+	if x.missing {
+		res = v == Missing
+	}
+	return res != x.not, nil
+}
+
+type likeExpr struct {
+	x, pattern, escape expr
+	not                bool
+
+	// cache of the last compiled pattern, which is usually a literal.
+	lastPattern string
+	lastEscape  string
+	re          *regexp.Regexp
+}
+
+func (x *likeExpr) eval(e *env) (any, error) {
+	v, err := x.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	pv, err := x.pattern.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	var esc any = ""
+	if x.escape != nil {
+		if esc, err = x.escape.eval(e); err != nil {
+			return nil, err
+		}
+	}
+	if isNull(v) || isNull(pv) || isNull(esc) {
+		return nil, nil
+	}
+	pattern, escape := FormatValue(pv), FormatValue(esc)
+	if x.re == nil || pattern != x.lastPattern || escape != x.lastEscape {
+		re, err := likeToRegexp(pattern, escape)
+		if err != nil {
+			return nil, err
+		}
+		x.re, x.lastPattern, x.lastEscape = re, pattern, escape
+	}
+	return x.re.MatchString(FormatValue(v)) != x.not, nil
+}
+
+// likeToRegexp translates a LIKE pattern, where % matches any sequence
+// of characters and _ any single character.
+func likeToRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	if utf8.RuneCountInString(escape) > 1 {
+		return nil, errors.New("LIKE escape must be a single character")
+	}
+	esc, _ := utf8.DecodeRuneInString(escape)
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case escape != "" && c == esc:
+			escaped = true
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		return nil, errors.New("LIKE pattern ends with the escape character")
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+type betweenExpr struct {
+	x, lo, hi expr
+	not       bool
+}
+
+func (x *betweenExpr) eval(e *env) (any, error) {
+	ge, err := (&compareExpr{op: ">=", l: x.x, r: x.lo}).eval(e)
+	if err != nil {
+		return nil, err
+	}
+	le, err := (&compareExpr{op: "<=", l: x.x, r: x.hi}).eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if ge == false || le == false {
+		return x.not, nil
+	}
+	if ge == nil || le == nil {
+		return nil, nil
+	}
+	return !x.not, nil
+}
+
+type inExpr struct {
+	x    expr
+	list []expr
+	not  bool
+}
+
+func (x *inExpr) eval(e *env) (any, error) {
+	var unknown bool
+	for _, item := range x.list {
+		eq, err := (&compareExpr{op: "=", l: x.x, r: item}).eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if eq == true {
+			return !x.not, nil
+		}
+		if eq == nil {
+			unknown = true
+		}
+	}
+	if unknown {
+		return nil, nil
+	}
+	return x.not, nil
+}
+
+type arithExpr struct {
+	op   string
+	l, r expr
+}
+
+func (x *arithExpr) eval(e *env) (any, error) {
+	l, err := x.l.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	r, err := x.r.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if isNull(l) || isNull(r) {
+		return nil, nil
+	}
+	if x.op == "||" {
+		return FormatValue(l) + FormatValue(r), nil
+	}
+	a, oka := toNumber(l)
+	b, okb := toNumber(r)
+	if !oka || !okb {
+		return nil, fmt.Errorf("invalid operands for '%s': %s, %s", x.op, FormatValue(l), FormatValue(r))
+	}
+	return arith(x.op, a, b)
+}
+
+func arith(op string, a, b any) (any, error) {
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch op {
+		case "+":
+			return ai + bi, nil
+		case "-":
+			return ai - bi, nil
+		case "*":
+			return ai * bi, nil
+		case "/", "%":
+			if bi == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if op == "%" {
+				return ai % bi, nil
+			}
+			return ai / bi, nil
+		}
+	}
+	af, bf := toFloat(a), toFloat(b)
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return af / bf, nil
+	}
+	if bf == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return math.Mod(af, bf), nil
+}
+
+// castTypes maps the accepted CAST type names to their canonical name.
+var castTypes = map[string]string{
+	"INT": "INT", "INTEGER": "INT", "BIGINT": "INT", "SMALLINT": "INT",
+	"FLOAT": "FLOAT", "REAL": "FLOAT", "DOUBLE": "FLOAT", "DECIMAL": "FLOAT", "NUMERIC": "FLOAT",
+	"STRING": "STRING", "VARCHAR": "STRING", "CHAR": "STRING",
+	"BOOL": "BOOL", "BOOLEAN": "BOOL",
+	"TIMESTAMP": "TIMESTAMP",
+}
+
+type castExpr struct {
+	x   expr
+	typ string
+}
+
+func (x *castExpr) eval(e *env) (any, error) {
+	v, err := x.x.eval(e)
+	if err != nil || isNull(v) {
+		return v, err
+	}
+	return castValue(v, x.typ)
+}
+
+func castValue(v any, typ string) (any, error) {
+	switch typ {
+	case "INT":
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case time.Time:
+			return v.Unix(), nil
+		}
+		n, ok := toNumber(v)
+		if !ok {
+			break
+		}
+		if f, ok := n.(float64); ok {
+			return int64(f), nil
+		}
+		return n, nil
+	case "FLOAT":
+		if b, ok := v.(bool); ok {
+			if b {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		}
+		if n, ok := toNumber(v); ok {
+			return toFloat(n), nil
+		}
+	case "STRING":
+		return FormatValue(v), nil
+	case "BOOL":
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case float64:
+			return v != 0, nil
+		case string:
+			if b, e := strconv.ParseBool(strings.TrimSpace(v)); e == nil {
+				return b, nil
+			}
+		}
+	case "TIMESTAMP":
+		switch v := v.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return parseTimestamp(v)
+		}
+	}
+	return nil, fmt.Errorf("can not cast %s to %s", FormatValue(v), typ)
+}
+
+type caseWhen struct {
+	cond, then expr
+}
+
+type caseExpr struct {
+	operand expr
+	whens   []caseWhen
+	els     expr
+}
+
+func (x *caseExpr) eval(e *env) (any, error) {
+	for _, w := range x.whens {
+		var match any
+		var err error
+		if x.operand != nil {
+			match, err = (&compareExpr{op: "=", l: x.operand, r: w.cond}).eval(e)
+		} else {
+			match, err = evalBool(w.cond, e)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if match == true {
+			return w.then.eval(e)
+		}
+	}
+	if x.els != nil {
+		return x.els.eval(e)
+	}
+	return nil, nil
+}
+
+type funcExpr struct {
+	name string
+	args []expr
+	// option holds the TRIM direction or the EXTRACT part.
+	option string
+	// chars holds the characters removed by TRIM.
+	chars expr
+}
+
+func (x *funcExpr) eval(e *env) (any, error) {
+	args := make([]any, len(x.args))
+	for i, a := range x.args {
+		v, err := a.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch x.name {
+	case "COALESCE":
+		for _, v := range args {
+			if !isNull(v) {
+				return v, nil
+			}
+		}
+		return nil, nil
+	case "NULLIF":
+		if c, ok := compareValues(args[0], args[1]); ok && c == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	case "UTCNOW":
+		return time.Now().UTC(), nil
+	}
+	for _, v := range args {
+		if isNull(v) {
+			return nil, nil
+		}
+	}
+	switch x.name {
+	case "LOWER":
+		return strings.ToLower(FormatValue(args[0])), nil
+	case "UPPER":
+		return strings.ToUpper(FormatValue(args[0])), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(utf8.RuneCountInString(FormatValue(args[0]))), nil
+	case "TRIM":
+		return x.trim(e, FormatValue(args[0]))
+	case "SUBSTRING":
+		return substring(args)
+	case "TO_TIMESTAMP":
+		return castValue(args[0], "TIMESTAMP")
+	case "EXTRACT":
+		return extract(x.option, args[0])
+	}
+	return nil, fmt.Errorf("unsupported function %s", x.name)
+}
+
+func (x *funcExpr) trim(e *env, s string) (any, error) {
+	chars := " "
+	if x.chars != nil {
+		v, err := x.chars.eval(e)
+		if err != nil || isNull(v) {
+			return nil, err
+		}
+		chars = FormatValue(v)
+	}
+	switch x.option {
+	case "LEADING":
+		return strings.TrimLeft(s, chars), nil
+	case "TRAILING":
+		return strings.TrimRight(s, chars), nil
+	}
+	return strings.Trim(s, chars), nil
+}
+
+// substring implements SUBSTRING with SQL semantics: positions start
+// at 1, and a start before the first character shortens the length.
+func substring(args []any) (any, error) {
+	s := []rune(FormatValue(args[0]))
+	start, ok := toNumber(args[1])
+	if !ok {
+		return nil, errors.New("SUBSTRING start must be a number")
+	}
+	from := int64(toFloat(start))
+	to := int64(len(s)) + 1
+	if len(args) == 3 {
+		length, ok := toNumber(args[2])
+		if !ok || toFloat(length) < 0 {
+			return nil, errors.New("SUBSTRING length must be a non-negative number")
+		}
+		to = from + int64(toFloat(length))
+	}
+	from = max(from, 1)
+	to = min(to, int64(len(s))+1)
+	if from >= to {
+		return "", nil
+	}
+	return string(s[from-1 : to-1]), nil
+}
+
+func extract(part string, v any) (any, error) {
+	t, err := castValue(v, "TIMESTAMP")
+	if err != nil {
+		return nil, err
+	}
+	ts := t.(time.Time)
+	switch part {
+	case "YEAR":
+		return int64(ts.Year()), nil
+	case "MONTH":
+		return int64(ts.Month()), nil
+	case "DAY":
+		return int64(ts.Day()), nil
+	case "HOUR":
+		return int64(ts.Hour()), nil
+	case "MINUTE":
+		return int64(ts.Minute()), nil
+	case "SECOND":
+		return int64(ts.Second()), nil
+	}
+	_, offset := ts.Zone()
+	if part == "TIMEZONE_HOUR" {
+		return int64(offset / 3600), nil
+	}
+	return int64(offset % 3600 / 60), nil
+}
+
+// aggregateExpr evaluates to the result of its aggregate function,
+// which is only known after all records have been processed.
+type aggregateExpr struct {
+	fn  string
+	arg expr // nil for COUNT(*)
+	idx int
+}
+
+func (x *aggregateExpr) eval(e *env) (any, error) {
+	if x.idx >= len(e.aggs) {
+		return nil, errors.New("aggregate function used outside of an aggregate query")
+	}
+	return e.aggs[x.idx], nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// twoCharOps are the operators made of two characters, checked before
+// the single character ones.
+var twoCharOps = []string{"<=", ">=", "<>", "!=", "||"}
+
+const singleCharOps = "=<>+-*/%(),.[]"
+
+// lex splits a query into tokens.
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'':
+			str, n, e := lexQuoted(s, i, '\'')
+			if e != nil {
+				return nil, e
+			}
+			toks = append(toks, token{kind: tokString, text: str, pos: i})
+			i += n
+		case c == '"':
+			str, n, e := lexQuoted(s, i, '"')
+			if e != nil {
+				return nil, e
+			}
+			toks = append(toks, token{kind: tokQuotedIdent, text: str, pos: i})
+			i += n
+		case c >= '0' && c <= '9', c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			start := i
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+				j := i + 1
+				if j < len(s) && (s[j] == '+' || s[j] == '-') {
+					j++
+				}
+				if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+					i = j
+					for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+						i++
+					}
+				}
+			}
+			toks = append(toks, token{kind: tokNumber, text: s[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(s) && (s[i] == '_' || unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i]))) {
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, text: s[start:i], pos: start})
+		default:
+			matched := false
+			for _, op := range twoCharOps {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune(singleCharOps, c) {
+				toks = append(toks, token{kind: tokOp, text: string(c), pos: i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, pos: len(s)})
+	return toks, nil
+}
+
+// lexQuoted reads a string quoted with q starting at s[start], where a
+// doubled quote stands for the quote itself. It returns the unquoted
+// string and the number of bytes consumed.
+func lexQuoted(s string, start int, q byte) (string, int, error) {
+	var sb strings.Builder
+	i := start + 1
+	for i < len(s) {
+		if s[i] == q {
+			if i+1 < len(s) && s[i+1] == q {
+				sb.WriteByte(q)
+				i += 2
+				continue
+			}
+			return sb.String(), i + 1 - start, nil
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string at position %d", start)
+}
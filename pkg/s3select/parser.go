@@ -0,0 +1,763 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keywords can not be used as unquoted aliases.
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "ESCAPE": true,
+	"BETWEEN": true, "IN": true, "IS": true, "NULL": true, "MISSING": true,
+	"TRUE": true, "FALSE": true, "CAST": true, "CASE": true, "WHEN": true,
+	"THEN": true, "ELSE": true, "END": true,
+}
+
+// aggregateFuncs are the supported aggregate functions.
+var aggregateFuncs = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+}
+
+// scalarFuncs maps the supported scalar functions to their minimum and
+// maximum number of arguments, -1 meaning unbounded.
+var scalarFuncs = map[string][2]int{
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"TRIM":             {1, 1},
+	"SUBSTRING":        {2, 3},
+	"COALESCE":         {1, -1},
+	"NULLIF":           {2, 2},
+	"UTCNOW":           {0, 0},
+	"TO_TIMESTAMP":     {1, 1},
+	"EXTRACT":          {1, 1},
+}
+
+type parser struct {
+	toks []token
+	pos  int
+	q    *Query
+
+	// aggDepth is non-zero while parsing the argument of an aggregate.
+	aggDepth int
+	// refs counts the column references outside of aggregates in the
+	// select item being parsed.
+	refs int
+}
+
+// Parse parses a query written in the S3 Select SQL dialect.
+func Parse(query string) (*Query, error) {
+	toks, e := lex(query)
+	if e != nil {
+		return nil, e
+	}
+	p := &parser{toks: toks, q: &Query{limit: -1}}
+	if e = p.parseQuery(); e != nil {
+		return nil, e
+	}
+	return p.q, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func isKeyword(t token, kw string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if isKeyword(p.peek(), kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.errorf("expected %s", kw)
+	}
+	return nil
+}
+
+func (p *parser) acceptOp(op string) bool {
+	t := p.peek()
+	if t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected '%s'", op)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	found := "end of query"
+	if t.kind != tokEOF {
+		found = fmt.Sprintf("'%s'", t.text)
+	}
+	return fmt.Errorf("%s at position %d, found %s", fmt.Sprintf(format, args...), t.pos, found)
+}
+
+// acceptAlias parses an optional `[AS] name`.
+func (p *parser) acceptAlias() (string, error) {
+	if p.acceptKeyword("AS") {
+		t := p.next()
+		if t.kind != tokIdent && t.kind != tokQuotedIdent {
+			p.pos--
+			return "", p.errorf("expected alias")
+		}
+		return t.text, nil
+	}
+	t := p.peek()
+	if t.kind == tokQuotedIdent || t.kind == tokIdent && !keywords[strings.ToUpper(t.text)] {
+		p.pos++
+		return t.text, nil
+	}
+	return "", nil
+}
+
+func (p *parser) parseQuery() error {
+	if e := p.expectKeyword("SELECT"); e != nil {
+		return e
+	}
+	if e := p.parseSelectList(); e != nil {
+		return e
+	}
+	if e := p.expectKeyword("FROM"); e != nil {
+		return e
+	}
+	if e := p.parseFrom(); e != nil {
+		return e
+	}
+	if p.acceptKeyword("WHERE") {
+		naggs := len(p.q.aggs)
+		where, e := p.parseExpr()
+		if e != nil {
+			return e
+		}
+		if len(p.q.aggs) != naggs {
+			return errors.New("aggregate functions are not allowed in the WHERE clause")
+		}
+		p.q.where = where
+	}
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		n, e := strconv.ParseInt(t.text, 10, 64)
+		if t.kind != tokNumber || e != nil || n < 0 {
+			p.pos--
+			return p.errorf("expected a non-negative integer LIMIT")
+		}
+		p.q.limit = n
+	}
+	if p.peek().kind != tokEOF {
+		return p.errorf("unexpected input")
+	}
+	return nil
+}
+
+func (p *parser) parseSelectList() error {
+	if p.acceptOp("*") {
+		p.q.all = true
+		return nil
+	}
+	var plainItems int
+	for {
+		p.refs = 0
+		e, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		name, err := p.acceptAlias()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = "_" + strconv.Itoa(len(p.q.items)+1)
+			if c, ok := e.(*columnRef); ok {
+				name = c.path[len(c.path)-1].name
+			}
+		}
+		if p.refs > 0 {
+			plainItems++
+		}
+		p.q.items = append(p.q.items, selectItem{expr: e, name: name})
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if len(p.q.aggs) > 0 && plainItems > 0 {
+		return errors.New("aggregate and non-aggregate columns can not be mixed in the SELECT list")
+	}
+	return nil
+}
+
+func (p *parser) parseFrom() error {
+	t := p.next()
+	if t.kind != tokIdent || !strings.EqualFold(t.text, "S3Object") {
+		p.pos--
+		return p.errorf("expected S3Object")
+	}
+	for {
+		switch {
+		case p.acceptOp("["):
+			if p.acceptOp("*") {
+				p.q.from = append(p.q.from, pathElem{wildcard: true})
+			} else {
+				n, e := p.parseIndex()
+				if e != nil {
+					return e
+				}
+				p.q.from = append(p.q.from, pathElem{index: n, isIndex: true})
+			}
+			if e := p.expectOp("]"); e != nil {
+				return e
+			}
+			continue
+		case p.peek().kind == tokOp && p.peek().text == "." && p.peekAt(1).kind != tokEOF:
+			p.pos++
+			t := p.next()
+			if t.kind != tokIdent && t.kind != tokQuotedIdent {
+				p.pos--
+				return p.errorf("expected a path element")
+			}
+			p.q.from = append(p.q.from, pathElem{name: t.text, quoted: t.kind == tokQuotedIdent})
+			continue
+		}
+		break
+	}
+	alias, e := p.acceptAlias()
+	if e != nil {
+		return e
+	}
+	p.q.alias = alias
+	return nil
+}
+
+func (p *parser) parseIndex() (int, error) {
+	t := p.next()
+	n, e := strconv.Atoi(t.text)
+	if t.kind != tokNumber || e != nil || n < 0 {
+		p.pos--
+		return 0, p.errorf("expected an array index")
+	}
+	return n, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr, error) {
+	l, e := p.parseAnd()
+	if e != nil {
+		return nil, e
+	}
+	for p.acceptKeyword("OR") {
+		r, e := p.parseAnd()
+		if e != nil {
+			return nil, e
+		}
+		l = &logicalExpr{or: true, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	l, e := p.parseNot()
+	if e != nil {
+		return nil, e
+	}
+	for p.acceptKeyword("AND") {
+		r, e := p.parseNot()
+		if e != nil {
+			return nil, e
+		}
+		l = &logicalExpr{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		x, e := p.parseNot()
+		if e != nil {
+			return nil, e
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	l, e := p.parseAdditive()
+	if e != nil {
+		return nil, e
+	}
+	t := p.peek()
+	if t.kind == tokOp {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			r, e := p.parseAdditive()
+			if e != nil {
+				return nil, e
+			}
+			return &compareExpr{op: t.text, l: l, r: r}, nil
+		}
+	}
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		switch {
+		case p.acceptKeyword("NULL"):
+			return &isExpr{x: l, not: not}, nil
+		case p.acceptKeyword("MISSING"):
+			return &isExpr{x: l, not: not, missing: true}, nil
+		}
+		return nil, p.errorf("expected NULL or MISSING")
+	}
+	not := false
+	if isKeyword(t, "NOT") {
+		n := p.peekAt(1)
+		if isKeyword(n, "LIKE") || isKeyword(n, "BETWEEN") || isKeyword(n, "IN") {
+			p.pos++
+			not = true
+		}
+	}
+	switch {
+	case p.acceptKeyword("LIKE"):
+		pattern, e := p.parseAdditive()
+		if e != nil {
+			return nil, e
+		}
+		var escape expr
+		if p.acceptKeyword("ESCAPE") {
+			if escape, e = p.parseAdditive(); e != nil {
+				return nil, e
+			}
+		}
+		return &likeExpr{x: l, pattern: pattern, escape: escape, not: not}, nil
+	case p.acceptKeyword("BETWEEN"):
+		lo, e := p.parseAdditive()
+		if e != nil {
+			return nil, e
+		}
+		if e = p.expectKeyword("AND"); e != nil {
+			return nil, e
+		}
+		hi, e := p.parseAdditive()
+		if e != nil {
+			return nil, e
+		}
+		return &betweenExpr{x: l, lo: lo, hi: hi, not: not}, nil
+	case p.acceptKeyword("IN"):
+		if e := p.expectOp("("); e != nil {
+			return nil, e
+		}
+		list, e := p.parseExprList(")")
+		if e != nil {
+			return nil, e
+		}
+		return &inExpr{x: l, list: list, not: not}, nil
+	}
+	return l, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	l, e := p.parseMultiplicative()
+	if e != nil {
+		return nil, e
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || t.text != "+" && t.text != "-" && t.text != "||" {
+			return l, nil
+		}
+		p.pos++
+		r, e := p.parseMultiplicative()
+		if e != nil {
+			return nil, e
+		}
+		l = &arithExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	l, e := p.parseUnary()
+	if e != nil {
+		return nil, e
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || t.text != "*" && t.text != "/" && t.text != "%" {
+			return l, nil
+		}
+		p.pos++
+		r, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		l = &arithExpr{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.acceptOp("-") {
+		x, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		return &arithExpr{op: "-", l: &literal{v: int64(0)}, r: x}, nil
+	}
+	if p.acceptOp("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+// parseExprList parses a comma separated list of expressions followed
+// by the closing operator end.
+func (p *parser) parseExprList(end string) ([]expr, error) {
+	var list []expr
+	if p.acceptOp(end) {
+		return list, nil
+	}
+	for {
+		x, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		list = append(list, x)
+		if p.acceptOp(end) {
+			return list, nil
+		}
+		if e = p.expectOp(","); e != nil {
+			return nil, e
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if n, e := strconv.ParseInt(t.text, 10, 64); e == nil {
+			return &literal{v: n}, nil
+		}
+		f, e := strconv.ParseFloat(t.text, 64)
+		if e != nil {
+			p.pos--
+			return nil, p.errorf("invalid number")
+		}
+		return &literal{v: f}, nil
+	case tokString:
+		return &literal{v: t.text}, nil
+	case tokQuotedIdent:
+		return p.parseColumnRef(t)
+	case tokOp:
+		if t.text == "(" {
+			x, e := p.parseExpr()
+			if e != nil {
+				return nil, e
+			}
+			return x, p.expectOp(")")
+		}
+	case tokIdent:
+		name := strings.ToUpper(t.text)
+		switch name {
+		case "TRUE":
+			return &literal{v: true}, nil
+		case "FALSE":
+			return &literal{v: false}, nil
+		case "NULL":
+			return &literal{v: nil}, nil
+		case "MISSING":
+			return &literal{v: Missing}, nil
+		case "CAST":
+			return p.parseCast()
+		case "CASE":
+			return p.parseCase()
+		}
+		if keywords[name] {
+			break
+		}
+		if p.acceptOp("(") {
+			return p.parseCall(name)
+		}
+		return p.parseColumnRef(t)
+	}
+	p.pos--
+	return nil, p.errorf("unexpected token")
+}
+
+func (p *parser) parseColumnRef(first token) (expr, error) {
+	if p.aggDepth == 0 {
+		p.refs++
+	}
+	c := &columnRef{path: []pathElem{{name: first.text, quoted: first.kind == tokQuotedIdent}}}
+	for {
+		switch {
+		case p.acceptOp("."):
+			t := p.next()
+			if t.kind != tokIdent && t.kind != tokQuotedIdent {
+				p.pos--
+				return nil, p.errorf("expected a column name")
+			}
+			c.path = append(c.path, pathElem{name: t.text, quoted: t.kind == tokQuotedIdent})
+		case p.acceptOp("["):
+			n, e := p.parseIndex()
+			if e != nil {
+				return nil, e
+			}
+			if e = p.expectOp("]"); e != nil {
+				return nil, e
+			}
+			c.path = append(c.path, pathElem{index: n, isIndex: true})
+		default:
+			return c, nil
+		}
+	}
+}
+
+func (p *parser) parseCast() (expr, error) {
+	if e := p.expectOp("("); e != nil {
+		return nil, e
+	}
+	x, e := p.parseExpr()
+	if e != nil {
+		return nil, e
+	}
+	if e = p.expectKeyword("AS"); e != nil {
+		return nil, e
+	}
+	t := p.next()
+	typ := strings.ToUpper(t.text)
+	if t.kind != tokIdent || castTypes[typ] == "" {
+		p.pos--
+		return nil, p.errorf("unsupported CAST type")
+	}
+	// Accept and ignore precision arguments, e.g. DECIMAL(10, 2).
+	if p.acceptOp("(") {
+		if _, e = p.parseExprList(")"); e != nil {
+			return nil, e
+		}
+	}
+	if e = p.expectOp(")"); e != nil {
+		return nil, e
+	}
+	return &castExpr{x: x, typ: castTypes[typ]}, nil
+}
+
+func (p *parser) parseCase() (expr, error) {
+	c := &caseExpr{}
+	if !isKeyword(p.peek(), "WHEN") {
+		operand, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		c.operand = operand
+	}
+	for p.acceptKeyword("WHEN") {
+		cond, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		if e = p.expectKeyword("THEN"); e != nil {
+			return nil, e
+		}
+		then, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		c.whens = append(c.whens, caseWhen{cond: cond, then: then})
+	}
+	if len(c.whens) == 0 {
+		return nil, p.errorf("expected WHEN")
+	}
+	if p.acceptKeyword("ELSE") {
+		els, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		c.els = els
+	}
+	return c, p.expectKeyword("END")
+}
+
+func (p *parser) parseCall(name string) (expr, error) {
+	if aggregateFuncs[name] {
+		if p.aggDepth > 0 {
+			return nil, p.errorf("aggregate functions can not be nested")
+		}
+		agg := &aggregateExpr{fn: name, idx: len(p.q.aggs)}
+		if name == "COUNT" && p.acceptOp("*") {
+			if e := p.expectOp(")"); e != nil {
+				return nil, e
+			}
+		} else {
+			p.aggDepth++
+			arg, e := p.parseExpr()
+			p.aggDepth--
+			if e != nil {
+				return nil, e
+			}
+			if e = p.expectOp(")"); e != nil {
+				return nil, e
+			}
+			agg.arg = arg
+		}
+		p.q.aggs = append(p.q.aggs, agg)
+		return agg, nil
+	}
+
+	arity, ok := scalarFuncs[name]
+	if !ok {
+		p.pos -= 2
+		return nil, p.errorf("unsupported function %s", name)
+	}
+	f := &funcExpr{name: name}
+	var args []expr
+	var e error
+	switch name {
+	case "SUBSTRING":
+		args, e = p.parseSubstringArgs()
+	case "TRIM":
+		args, e = p.parseTrimArgs(f)
+	case "EXTRACT":
+		args, e = p.parseExtractArgs(f)
+	default:
+		args, e = p.parseExprList(")")
+	}
+	if e != nil {
+		return nil, e
+	}
+	if len(args) < arity[0] || arity[1] >= 0 && len(args) > arity[1] {
+		return nil, fmt.Errorf("wrong number of arguments to %s", name)
+	}
+	f.args = args
+	return f, nil
+}
+
+// parseSubstringArgs parses both SUBSTRING(s, start[, length]) and
+// SUBSTRING(s FROM start [FOR length]).
+func (p *parser) parseSubstringArgs() ([]expr, error) {
+	s, e := p.parseExpr()
+	if e != nil {
+		return nil, e
+	}
+	if p.acceptOp(",") {
+		rest, e := p.parseExprList(")")
+		if e != nil {
+			return nil, e
+		}
+		return append([]expr{s}, rest...), nil
+	}
+	if e = p.expectKeyword("FROM"); e != nil {
+		return nil, e
+	}
+	start, e := p.parseExpr()
+	if e != nil {
+		return nil, e
+	}
+	args := []expr{s, start}
+	if p.acceptKeyword("FOR") {
+		length, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		args = append(args, length)
+	}
+	return args, p.expectOp(")")
+}
+
+// parseTrimArgs parses TRIM([[LEADING|TRAILING|BOTH] [chars] FROM] s).
+func (p *parser) parseTrimArgs(f *funcExpr) ([]expr, error) {
+	for _, where := range []string{"LEADING", "TRAILING", "BOTH"} {
+		if p.acceptKeyword(where) {
+			f.option = where
+			break
+		}
+	}
+	if f.option != "" && p.acceptKeyword("FROM") {
+		s, e := p.parseExpr()
+		if e != nil {
+			return nil, e
+		}
+		return []expr{s}, p.expectOp(")")
+	}
+	x, e := p.parseExpr()
+	if e != nil {
+		return nil, e
+	}
+	if p.acceptKeyword("FROM") {
+		f.chars = x
+		if x, e = p.parseExpr(); e != nil {
+			return nil, e
+		}
+	}
+	return []expr{x}, p.expectOp(")")
+}
+
+// parseExtractArgs parses EXTRACT(part FROM timestamp).
+func (p *parser) parseExtractArgs(f *funcExpr) ([]expr, error) {
+	t := p.next()
+	part := strings.ToUpper(t.text)
+	switch part {
+	case "YEAR", "MONTH", "DAY", "HOUR", "MINUTE", "SECOND", "TIMEZONE_HOUR", "TIMEZONE_MINUTE":
+	default:
+		p.pos--
+		return nil, p.errorf("unsupported EXTRACT part")
+	}
+	f.option = part
+	if e := p.expectKeyword("FROM"); e != nil {
+		return nil, e
+	}
+	x, e := p.parseExpr()
+	if e != nil {
+		return nil, e
+	}
+	return []expr{x}, p.expectOp(")")
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package s3select implements a client side engine for the S3 Select
+// SQL dialect, so that queries can run against backends which do not
+// implement SelectObjectContent, and against local files.
+//
+// The input records are supplied by the caller, which is responsible
+// for decoding CSV, JSON or Parquet data into Object values.
+package s3select
+
+import (
+	"fmt"
+	"io"
+)
+
+// Query is a parsed S3 Select query.
+type Query struct {
+	all   bool
+	items []selectItem
+	from  []pathElem
+	alias string
+	where expr
+	limit int64
+	aggs  []*aggregateExpr
+}
+
+type selectItem struct {
+	expr expr
+	name string
+}
+
+// IsAggregate reports whether the query computes aggregates, in which
+// case it produces a single row once all records are processed.
+func (q *Query) IsAggregate() bool {
+	return len(q.aggs) > 0
+}
+
+// records applies the FROM clause path to an input record, which can
+// expand into several records with the [*] wildcard.
+func (q *Query) records(rec any) []any {
+	recs := []any{rec}
+	for _, p := range q.from {
+		var next []any
+		for _, r := range recs {
+			if !p.wildcard {
+				if v := p.apply(r); v != Missing {
+					next = append(next, v)
+				}
+				continue
+			}
+			if arr, ok := r.([]any); ok {
+				next = append(next, arr...)
+			} else {
+				next = append(next, r)
+			}
+		}
+		recs = next
+	}
+	return recs
+}
+
+// Writer receives the rows produced by a query.
+type Writer interface {
+	WriteRow(row *Object) error
+}
+
+// Executor runs a query over a stream of records.
+type Executor struct {
+	q    *Query
+	w    Writer
+	env  env
+	aggs []aggState
+	rows int64
+}
+
+// NewExecutor returns an executor writing the result of the query to w.
+// Several inputs can be fed to the same executor, aggregates are then
+// computed across all of them.
+func (q *Query) NewExecutor(w Writer) *Executor {
+	return &Executor{
+		q:    q,
+		w:    w,
+		env:  env{alias: q.alias},
+		aggs: make([]aggState, len(q.aggs)),
+	}
+}
+
+// Done reports whether the LIMIT clause has been satisfied, after which
+// no more records need to be processed.
+func (x *Executor) Done() bool {
+	return x.q.limit >= 0 && x.rows >= x.q.limit
+}
+
+// Process evaluates the query on one input record. It returns io.EOF
+// once the LIMIT clause has been satisfied.
+func (x *Executor) Process(rec any) error {
+	for _, r := range x.q.records(rec) {
+		if x.Done() {
+			return io.EOF
+		}
+		x.env.rec = r
+		if x.q.where != nil {
+			ok, err := evalBool(x.q.where, &x.env)
+			if err != nil {
+				return err
+			}
+			if ok != true {
+				continue
+			}
+		}
+		if x.q.IsAggregate() {
+			for i, agg := range x.q.aggs {
+				if err := x.aggs[i].add(agg, &x.env); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		row, err := x.project()
+		if err != nil {
+			return err
+		}
+		if err = x.w.WriteRow(row); err != nil {
+			return err
+		}
+		x.rows++
+	}
+	if x.Done() {
+		return io.EOF
+	}
+	return nil
+}
+
+func (x *Executor) project() (*Object, error) {
+	if x.q.all {
+		if obj, ok := x.env.rec.(*Object); ok {
+			return obj, nil
+		}
+		return &Object{Keys: []string{"_1"}, Values: []any{x.env.rec}}, nil
+	}
+	row := &Object{
+		Keys:   make([]string, 0, len(x.q.items)),
+		Values: make([]any, 0, len(x.q.items)),
+	}
+	for _, item := range x.q.items {
+		v, err := item.expr.eval(&x.env)
+		if err != nil {
+			return nil, err
+		}
+		row.Keys = append(row.Keys, item.name)
+		row.Values = append(row.Values, v)
+	}
+	return row, nil
+}
+
+// Finish writes the result of an aggregate query. It does nothing for
+// other queries.
+func (x *Executor) Finish() error {
+	if !x.q.IsAggregate() || x.q.limit == 0 {
+		return nil
+	}
+	x.env.rec = nil
+	x.env.aggs = make([]any, len(x.aggs))
+	for i, agg := range x.q.aggs {
+		x.env.aggs[i] = x.aggs[i].result(agg.fn)
+	}
+	row, err := x.project()
+	if err != nil {
+		return err
+	}
+	return x.w.WriteRow(row)
+}
+
+type aggState struct {
+	count   int64
+	sumInt  int64
+	sumFlt  float64
+	isFloat bool
+	value   any // MIN or MAX
+}
+
+func (s *aggState) add(agg *aggregateExpr, e *env) error {
+	if agg.arg == nil {
+		s.count++
+		return nil
+	}
+	v, err := agg.arg.eval(e)
+	if err != nil {
+		return err
+	}
+	if isNull(v) {
+		return nil
+	}
+	switch agg.fn {
+	case "COUNT":
+	case "SUM", "AVG":
+		n, ok := toNumber(v)
+		if !ok {
+			return fmt.Errorf("%s of non-numeric value %s", agg.fn, FormatValue(v))
+		}
+		if i, ok := n.(int64); ok && !s.isFloat {
+			s.sumInt += i
+		} else {
+			if !s.isFloat {
+				s.isFloat = true
+				s.sumFlt = float64(s.sumInt)
+			}
+			s.sumFlt += toFloat(n)
+		}
+	case "MIN", "MAX":
+		// CSV values are strings, compare them as numbers when possible.
+		if n, ok := toNumber(v); ok {
+			v = n
+		}
+		if s.value == nil {
+			s.value = v
+			break
+		}
+		c, ok := compareValues(v, s.value)
+		if !ok {
+			return fmt.Errorf("%s of values of different types", agg.fn)
+		}
+		if agg.fn == "MIN" && c < 0 || agg.fn == "MAX" && c > 0 {
+			s.value = v
+		}
+	}
+	s.count++
+	return nil
+}
+
+func (s *aggState) result(fn string) any {
+	switch fn {
+	case "COUNT":
+		return s.count
+	case "MIN", "MAX":
+		return s.value
+	}
+	if s.count == 0 {
+		return nil
+	}
+	if fn == "AVG" {
+		if s.isFloat {
+			return s.sumFlt / float64(s.count)
+		}
+		return float64(s.sumInt) / float64(s.count)
+	}
+	if s.isFloat {
+		return s.sumFlt
+	}
+	return s.sumInt
+}
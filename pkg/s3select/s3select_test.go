@@ -0,0 +1,150 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+type rowCollector struct {
+	rows []string
+}
+
+func (c *rowCollector) WriteRow(row *Object) error {
+	b, e := row.MarshalJSON()
+	if e != nil {
+		return e
+	}
+	c.rows = append(c.rows, string(b))
+	return nil
+}
+
+func csvRow(header []string, values ...string) *Object {
+	row := &Object{Keys: header}
+	for _, v := range values {
+		row.Values = append(row.Values, v)
+	}
+	return row
+}
+
+func runQuery(t *testing.T, query string, records []any) []string {
+	t.Helper()
+	q, e := Parse(query)
+	if e != nil {
+		t.Fatalf("%s: %v", query, e)
+	}
+	c := &rowCollector{}
+	x := q.NewExecutor(c)
+	for _, rec := range records {
+		if e = x.Process(rec); e == io.EOF {
+			break
+		}
+		if e != nil {
+			t.Fatalf("%s: %v", query, e)
+		}
+	}
+	if e = x.Finish(); e != nil {
+		t.Fatalf("%s: %v", query, e)
+	}
+	return c.rows
+}
+
+func TestQueryCSV(t *testing.T) {
+	header := []string{"name", "age", "city"}
+	records := []any{
+		csvRow(header, "alice", "30", "Paris"),
+		csvRow(header, "bob", "25", "New York"),
+		csvRow(header, "carol", "41", "Berlin"),
+		csvRow(header, "dave", "", "Rome"),
+	}
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{"select * from S3Object limit 1", []string{`{"name":"alice","age":"30","city":"Paris"}`}},
+		{"SELECT s.name FROM S3Object s WHERE s.age > 26", []string{`{"name":"alice"}`, `{"name":"carol"}`}},
+		{"select _1, _3 from s3object where city like '%i%'", []string{`{"_1":"alice","_3":"Paris"}`, `{"_1":"carol","_3":"Berlin"}`}},
+		{"select s.name as n from S3Object s where s.age between 25 and 30 and not s.name = 'bob'", []string{`{"n":"alice"}`}},
+		{"select upper(name) || '-' || char_length(city) from S3Object where name in ('bob', 'dave')", []string{`{"_1":"BOB-8"}`, `{"_1":"DAVE-4"}`}},
+		{"select count(*), count(s.age), sum(s.age), avg(s.age), min(s.age), max(s.city) from S3Object s where s.age <> ''", []string{`{"_1":3,"_2":3,"_3":96,"_4":32,"_5":25,"_6":"Paris"}`}},
+		{"select cast(age as int) * 2 as double_age from S3Object where name = 'alice'", []string{`{"double_age":60}`}},
+		{"select case when cast(age as int) >= 40 then 'senior' else 'junior' end as grp from S3Object limit 3", []string{`{"grp":"junior"}`, `{"grp":"junior"}`, `{"grp":"senior"}`}},
+		{"select substring(city from 2 for 3), trim(leading 'P' from city) from S3Object limit 1", []string{`{"_1":"ari","_2":"aris"}`}},
+		{`select "name" from S3Object where "NAME" = 'alice'`, nil},
+		{"select count(*) from S3Object where nosuchcolumn is missing", []string{`{"_1":4}`}},
+	}
+	for _, tc := range testCases {
+		got := runQuery(t, tc.query, records)
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	input := `{"id":1,"user":{"name":"x","roles":["admin","dev"]}}
+{"id":2,"user":{"name":"y","roles":[]},"extra":null}
+{"items":[{"n":1},{"n":2},{"n":3}]}`
+	dec := json.NewDecoder(strings.NewReader(input))
+	var records []any
+	for {
+		v, e := DecodeJSON(dec)
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			t.Fatal(e)
+		}
+		records = append(records, v)
+	}
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{"select * from S3Object[*] s where s.id = 2", []string{`{"id":2,"user":{"name":"y","roles":[]},"extra":null}`}},
+		{"select s.user.name, s.user.roles[1] as role from S3Object s where s.id = 1", []string{`{"name":"x","role":"dev"}`}},
+		{"select s.id from S3Object s where s.extra is null", []string{`{"id":1}`, `{"id":2}`, `{}`}},
+		{"select sum(s.n) from S3Object[*].items[*] s", []string{`{"_1":6}`}},
+	}
+	for _, tc := range testCases {
+		got := runQuery(t, tc.query, records)
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		"select from S3Object",
+		"select * from table",
+		"select s.name, count(*) from S3Object s",
+		"select * from S3Object where count(*) > 1",
+		"select nosuchfunc(a) from S3Object",
+		"select * from S3Object limit -1",
+		"select 'unterminated from S3Object",
+		"select count(sum(a)) from S3Object",
+	} {
+		if _, e := Parse(query); e == nil {
+			t.Errorf("%s: expected a parse error", query)
+		}
+	}
+}
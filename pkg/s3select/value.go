@@ -0,0 +1,376 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package s3select
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values handled by the engine are plain Go values: nil (NULL), bool,
+// int64, float64, string, time.Time, []any, *Object and Missing.
+
+// Missing is the value of a path which does not exist in a record. It
+// differs from NULL in that it is left out of JSON output.
+var Missing = missingValue{}
+
+type missingValue struct{}
+
+// Object is an ordered collection of named values. CSV rows and JSON
+// objects are both represented as an Object, so that SELECT * keeps
+// the column order of the input.
+type Object struct {
+	Keys   []string
+	Values []any
+}
+
+// Set sets key to v, appending key if it does not exist yet.
+func (o *Object) Set(key string, v any) {
+	for i, k := range o.Keys {
+		if k == key {
+			o.Values[i] = v
+			return
+		}
+	}
+	o.Keys = append(o.Keys, key)
+	o.Values = append(o.Values, v)
+}
+
+// Lookup returns the value of key. Unquoted identifiers are matched
+// case-insensitively, like S3 Select does. Positional names such as
+// _1 and _2 are resolved against the column order when no column
+// with that name exists.
+func (o *Object) Lookup(key string, caseSensitive bool) (any, bool) {
+	for i, k := range o.Keys {
+		if k == key {
+			return o.Values[i], true
+		}
+	}
+	if !caseSensitive {
+		for i, k := range o.Keys {
+			if strings.EqualFold(k, key) {
+				return o.Values[i], true
+			}
+		}
+	}
+	if strings.HasPrefix(key, "_") {
+		if n, e := strconv.Atoi(key[1:]); e == nil && n >= 1 && n <= len(o.Values) {
+			return o.Values[n-1], true
+		}
+	}
+	return Missing, false
+}
+
+// MarshalJSON encodes the object keeping its key order, leaving out
+// missing values.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for i, k := range o.Keys {
+		v := o.Values[i]
+		if v == Missing {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, e := json.Marshal(k)
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, e := marshalValue(v)
+		if e != nil {
+			return nil, e
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func marshalValue(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case missingValue:
+		return []byte("null"), nil
+	case time.Time:
+		return json.Marshal(v.Format(time.RFC3339Nano))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return json.Marshal(FormatValue(v))
+		}
+	case []any:
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, err := marshalValue(e)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(v)
+}
+
+// FormatValue returns the textual form of a value, as written to CSV
+// output. Nested values are rendered as JSON.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case nil, missingValue:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *Object, []any:
+		b, e := marshalValue(v)
+		if e != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// DecodeJSON reads the next JSON value from dec. Objects are decoded
+// into *Object to keep their key order, integral numbers into int64 and
+// all other numbers into float64. It returns io.EOF at the end of input.
+func DecodeJSON(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+	tok, e := dec.Token()
+	if e != nil {
+		return nil, e
+	}
+	return decodeJSONToken(dec, tok)
+}
+
+func decodeJSONToken(dec *json.Decoder, tok json.Token) (any, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &Object{}
+			for dec.More() {
+				kt, e := dec.Token()
+				if e != nil {
+					return nil, e
+				}
+				key, ok := kt.(string)
+				if !ok {
+					return nil, errors.New("invalid JSON object key")
+				}
+				vt, e := dec.Token()
+				if e != nil {
+					return nil, e
+				}
+				v, e := decodeJSONToken(dec, vt)
+				if e != nil {
+					return nil, e
+				}
+				obj.Set(key, v)
+			}
+			if _, e := dec.Token(); e != nil {
+				return nil, e
+			}
+			return obj, nil
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				vt, e := dec.Token()
+				if e != nil {
+					return nil, e
+				}
+				v, e := decodeJSONToken(dec, vt)
+				if e != nil {
+					return nil, e
+				}
+				arr = append(arr, v)
+			}
+			if _, e := dec.Token(); e != nil {
+				return nil, e
+			}
+			return arr, nil
+		}
+		return nil, fmt.Errorf("unexpected JSON delimiter %q", t)
+	case json.Number:
+		if n, e := t.Int64(); e == nil {
+			return n, nil
+		}
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
+
+// toNumber converts v to int64 or float64. Strings are parsed, since
+// every CSV value is a string.
+func toNumber(v any) (any, bool) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if n, e := strconv.ParseInt(s, 10, 64); e == nil {
+			return n, true
+		}
+		if f, e := strconv.ParseFloat(s, 64); e == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(v any) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func isNull(v any) bool {
+	return v == nil || v == Missing
+}
+
+// timestampLayouts are the layouts accepted when converting strings
+// to timestamps.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, e := time.Parse(layout, s); e == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// compareValues compares two non-null values. It reports false when
+// the values are of incompatible types.
+func compareValues(a, b any) (int, bool) {
+	if isNumber(a) || isNumber(b) {
+		x, okx := toNumber(a)
+		y, oky := toNumber(b)
+		if !okx || !oky {
+			return 0, false
+		}
+		if xi, ok := x.(int64); ok {
+			if yi, ok := y.(int64); ok {
+				switch {
+				case xi < yi:
+					return -1, true
+				case xi > yi:
+					return 1, true
+				}
+				return 0, true
+			}
+		}
+		xf, yf := toFloat(x), toFloat(y)
+		switch {
+		case xf < yf:
+			return -1, true
+		case xf > yf:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch x := a.(type) {
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), true
+		case time.Time:
+			t, e := parseTimestamp(x)
+			if e != nil {
+				return 0, false
+			}
+			return t.Compare(y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			}
+			return 1, true
+		}
+	case time.Time:
+		switch y := b.(type) {
+		case time.Time:
+			return x.Compare(y), true
+		case string:
+			t, e := parseTimestamp(y)
+			if e != nil {
+				return 0, false
+			}
+			return x.Compare(t), true
+		}
+	}
+	return 0, false
+}
+
+// Compare compares two values with the engine's comparison rules. It
+// reports false when the values are null or of incompatible types.
+func Compare(a, b any) (int, bool) {
+	if isNull(a) || isNull(b) {
+		return 0, false
+	}
+	return compareValues(a, b)
+}